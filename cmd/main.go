@@ -1,30 +1,224 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"smkdevid/echocommercehub/internal/app"
+	"smkdevid/echocommercehub/internal/app/handlers"
+	"smkdevid/echocommercehub/internal/app/middlewares"
 	"smkdevid/echocommercehub/internal/configs"
+	"smkdevid/echocommercehub/internal/databases/migrations"
 	postgresql "smkdevid/echocommercehub/internal/databases/postgresql"
+	"smkdevid/echocommercehub/internal/services/products"
 	"smkdevid/echocommercehub/internal/services/promotions"
 	"smkdevid/echocommercehub/internal/transports/delivery"
+	"smkdevid/echocommercehub/utils/requests"
+	"smkdevid/echocommercehub/utils/responses"
 
 	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/shopspring/decimal"
 )
 
 func main() {
 
-	// Initialize Environment Variables
-	configs.LoadViperEnv()
+	configPath := flag.String("config", configs.DefaultConfigFile, "path of the config file, empty to read the environment only")
+	migrationsDir := flag.String("migrations", migrations.Dir, "directory where `migrate create` writes new migrations")
+	flag.Parse()
+
+	// `migrate create <name>` only writes files, it needs neither the config nor the database
+	if flag.Arg(0) == "migrate" && flag.Arg(1) == "create" {
+		up, down, err := migrations.Create(*migrationsDir, flag.Arg(2))
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("created %s and %s", up, down)
+		return
+	}
+
+	// Load and validate the config once, every missing or invalid key is reported at boot
+	config, err := configs.Load(*configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Stop on SIGTERM or Ctrl+C, including while waiting for the database
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
 
 	// Initialize PostgreSQL Conn
-	db := configs.InitDatabase()
+	db, err := configs.InitDatabase(ctx, config.Database)
+	if err != nil {
+		log.Fatalf("database unavailable: %v", err)
+	}
+
+	schema, err := migrations.Load(migrations.Files)
+	if err != nil {
+		log.Fatal(err)
+	}
+	migrator := migrations.NewMigrator(db, schema)
+
+	// `migrate up|down|status` manages the schema and exits
+	if flag.Arg(0) == "migrate" {
+		if err := migrations.Run(migrator, flag.Args()[1:], os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// Refuse to serve with a schema older than the code expects
+	if err := migrator.CheckCurrent(); err != nil {
+		log.Fatal(err)
+	}
 
 	e := echo.New()
 
+	// Every response carries a request ID and errors are written in the response envelope
+	e.Use(middleware.RequestID())
+	e.HTTPErrorHandler = responses.HTTPErrorHandler
+
+	// Validate request payloads with struct tags and the domain rules
+	validator := requests.NewValidator()
+	promotions.RegisterValidationRules(validator)
+	products.RegisterValidationRules(validator)
+	e.Validator = validator
+
 	// Apps Architect
 	PromotionRepo := postgresql.NewPromotionRepository(db)
 
+	CouponRepo := postgresql.NewCouponRepository(db)
+
+	IdempotencyRepo := postgresql.NewIdempotencyRepository(db)
+
+	ProductRepo := postgresql.NewProductRepository(db)
+	VariantRepo := postgresql.NewProductVariantRepository(db)
+	MediaRepo := postgresql.NewProductMediaRepository(db)
+	CartRepo := postgresql.NewCartRepository(db)
+	OrderRepo := postgresql.NewOrderRepository(db)
+	PaymentRepo := postgresql.NewPaymentRepository(db)
+	WebhookEventRepo := postgresql.NewWebhookEventRepository(db)
+
+	// Uploaded product media, served by this server when kept on the local filesystem
+	MediaStorage, err := configs.InitStorage(config.Media)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if config.Media.Storage == configs.MediaStorageLocal && strings.HasPrefix(config.Media.BaseURL, "/") {
+		e.Static(config.Media.BaseURL, config.Media.LocalDir)
+	}
+
+	// Gateway the orders are paid through, the fake provider keeps its charges in memory
+	PaymentProvider, err := configs.InitPaymentProvider(config.Payment)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	PromoService := promotions.NewPromotionService(PromotionRepo)
+	CouponService := promotions.NewCouponService(CouponRepo, PromotionRepo)
+	ProductService := products.NewProductService(ProductRepo)
+	VariantService := products.NewVariantService(ProductRepo, VariantRepo)
+	MediaService := products.NewMediaService(ProductRepo, VariantRepo, MediaRepo, MediaStorage, products.MediaLimits{
+		MaxImageSize: config.Media.MaxImageSize,
+		MaxVideoSize: config.Media.MaxVideoSize,
+	})
+	CartService := products.NewCartService(CartRepo, ProductRepo, VariantRepo, PromotionRepo, CouponRepo, OrderRepo, products.CartSettings{
+		GuestTTL:     config.Cart.GuestTTL,
+		CustomerTTL:  config.Cart.CustomerTTL,
+		TaxRate:      decimal.NewFromFloat(config.Cart.TaxRate),
+		ShippingCost: decimal.NewFromInt(config.Cart.ShippingCost),
+		StackingMode: config.Cart.StackingMode,
+	})
+	OrderService := products.NewOrderService(OrderRepo, CartService)
+	PaymentService := products.NewPaymentService(PaymentRepo, OrderService, PaymentProvider, config.Payment.Expiry)
+
+	// Retried create requests with the same Idempotency-Key are answered from the first response
+	Idempotency := middlewares.Idempotency(IdempotencyRepo, 24*time.Hour)
+
+	// Back office routes require the admin token, every request to them is refused when none is configured
+	AdminOnly := middlewares.AdminToken(config.Server.AdminToken)
+	if config.Server.AdminToken == "" {
+		log.Println("server: SERVER.ADMIN_TOKEN is not set, the admin routes refuse every request")
+	}
+
+	// Customers are identified by their signed access token, the X-Customer-ID header only behind a trusted gateway
+	Customer := middlewares.CustomerSession(config.Supabase.JWT, config.Server.TrustCustomerHeader)
+	if config.Supabase.JWT == "" && !config.Server.TrustCustomerHeader {
+		log.Println("server: SUPABASE.JWT is not set, customers cannot log in")
+	}
+
+	// The largest upload plus some room for the rest of the multipart form
+	UploadLimit := middleware.BodyLimit(fmt.Sprintf("%dB", max(config.Media.MaxImageSize, config.Media.MaxVideoSize)+1<<20))
+
+	// Liveness and readiness probes of the orchestrator
+	delivery.HealthRoute(e, map[string]handlers.HealthCheck{
+		"database": postgresql.PingDatabase(db),
+	}, 2*time.Second)
+
+	// Resource routes of the current API version
+	api := delivery.APIRoute(e)
+	delivery.PromotionRoute(api, PromoService, Idempotency, AdminOnly)
+	delivery.CouponRoute(api, CouponService, AdminOnly)
+	delivery.ProductRoute(api, ProductService, Idempotency)
+	delivery.VariantRoute(api, VariantService)
+	delivery.MediaRoute(api, MediaService, UploadLimit)
+	delivery.CartRoute(api, CartService, Customer)
+	delivery.OrderRoute(api, OrderService, PaymentService, Idempotency, Customer)
+	delivery.AdminOrderRoute(api, OrderService, AdminOnly)
+	delivery.PaymentRoute(api, PaymentService, Idempotency, Customer)
+	delivery.AdminPaymentRoute(api, PaymentService, AdminOnly)
+
+	// Settling fake payments by hand is only served when asked for, on development setups
+	if Fake, ok := PaymentProvider.(*products.FakeProvider); ok && config.Payment.DevSimulate {
+		delivery.FakePaymentRoute(api, PaymentService, Fake)
+	}
+
+	// Outcomes notified by the payment provider are stored when received and applied in the background
+	var WebhookProcessor *products.WebhookProcessor
+	if WebhookProvider, ok := PaymentProvider.(products.WebhookProvider); ok {
+		WebhookService := products.NewWebhookService(WebhookEventRepo, PaymentService, WebhookProvider, products.WebhookSettings{
+			MaxAttempts:  config.Webhook.MaxAttempts,
+			RetryBackoff: config.Webhook.RetryBackoff,
+			MaxBackoff:   config.Webhook.MaxBackoff,
+		})
+		delivery.WebhookRoute(api, WebhookService)
+		delivery.AdminWebhookRoute(api, WebhookService, AdminOnly)
+		WebhookProcessor = products.NewWebhookProcessor(WebhookService, WebhookService.Received(), config.Webhook.PollInterval)
+	}
+
+	// Unversioned paths stay available for existing clients until the sunset date
+	delivery.LegacyPromotionRoute(e, PromoService, Idempotency, AdminOnly, config.Server.LegacySunset)
+	delivery.LegacyCouponRoute(e, CouponService, AdminOnly, config.Server.LegacySunset)
+
+	server := app.NewServer(e, config.Server)
+
+	// Move promotions between lifecycle statuses in the background
+	PromoScheduler := promotions.NewPromotionScheduler(PromotionRepo, promotions.LogEventPublisher{}, time.Minute)
+	server.AddWorker(PromoScheduler.Run)
+
+	// Remove the files of deleted media and of the media of deleted products
+	MediaJanitor := products.NewMediaJanitor(MediaRepo, MediaStorage, config.Media.CleanupInterval)
+	server.AddWorker(MediaJanitor.Run)
+
+	// Close the carts nobody touched before their expiry
+	CartJanitor := products.NewCartJanitor(CartRepo, config.Cart.CleanupInterval)
+	server.AddWorker(CartJanitor.Run)
 
-	delivery.PromotionRoute(e, PromoService)
+	// Apply the received payment webhooks, retrying the ones that fail
+	if WebhookProcessor != nil {
+		server.AddWorker(WebhookProcessor.Run)
+	}
 
-	e.Logger.Fatal(e.Start(":8080"))
+	// Serve until SIGTERM or Ctrl+C, then drain in-flight requests and workers
+	if err := server.Run(ctx); err != nil {
+		log.Fatalf("server: %v", err)
+	}
+	log.Println("server: stopped")
 }
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"smkdevid/echocommercehub/internal/app/middlewares"
+	"smkdevid/echocommercehub/internal/services/products"
+	"smkdevid/echocommercehub/utils/requests"
+	"smkdevid/echocommercehub/utils/responses"
+
+	"github.com/labstack/echo/v4"
+)
+
+// CartCookie keeps the token of the guest cart
+const CartCookie = "cart_token"
+
+// cartOwnerFromRequest reads the customer verified by the session middleware, or the guest cart cookie
+// when not logged in
+func cartOwnerFromRequest(c echo.Context) products.CartOwner {
+	owner := products.CartOwner{CustomerID: middlewares.CustomerID(c)}
+	if owner.CustomerID != "" {
+		owner.Segment = middlewares.CustomerSegment(c)
+		return owner
+	}
+	if cookie, err := c.Cookie(CartCookie); err == nil {
+		owner.Token = cookie.Value
+	}
+	return owner
+}
+
+// writeCart sends the cart, a guest cart refreshes its cookie until the new expiry of the cart
+func writeCart(c echo.Context, status int, cart products.CartView) error {
+	if cart.Token != "" && cart.ExpiresAt != nil {
+		c.SetCookie(&http.Cookie{
+			Name:     CartCookie,
+			Value:    cart.Token,
+			Path:     "/",
+			Expires:  *cart.ExpiresAt,
+			HttpOnly: true,
+			Secure:   c.Scheme() == "https",
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+	return responses.JSON(c, status, cart)
+}
+
+func PSQLGetCart(CartService products.CartService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		cart, err := CartService.GetCart(cartOwnerFromRequest(c))
+		if err != nil {
+			return err
+		}
+		return writeCart(c, http.StatusOK, cart)
+	}
+}
+
+func PSQLAddCartItem(CartService products.CartService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var req products.AddCartItemRequest
+		if err := requests.BindAndValidate(c, &req); err != nil {
+			return err
+		}
+
+		cart, err := CartService.AddItem(cartOwnerFromRequest(c), req)
+		if err != nil {
+			return err
+		}
+		return writeCart(c, http.StatusOK, cart)
+	}
+}
+
+func PSQLUpdateCartItem(CartService products.CartService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		variantID := c.Param("variant_id")
+
+		var req products.UpdateCartItemRequest
+		if err := requests.BindAndValidate(c, &req); err != nil {
+			return err
+		}
+
+		cart, err := CartService.UpdateItem(cartOwnerFromRequest(c), variantID, req)
+		if err != nil {
+			return err
+		}
+		return writeCart(c, http.StatusOK, cart)
+	}
+}
+
+func PSQLRemoveCartItem(CartService products.CartService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		variantID := c.Param("variant_id")
+
+		cart, err := CartService.RemoveItem(cartOwnerFromRequest(c), variantID)
+		if err != nil {
+			return err
+		}
+		return writeCart(c, http.StatusOK, cart)
+	}
+}
+
+func PSQLApplyCartCoupon(CartService products.CartService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var req products.ApplyCouponRequest
+		if err := requests.BindAndValidate(c, &req); err != nil {
+			return err
+		}
+
+		cart, err := CartService.ApplyCoupon(cartOwnerFromRequest(c), req)
+		if err != nil {
+			return err
+		}
+		return writeCart(c, http.StatusOK, cart)
+	}
+}
+
+func PSQLRemoveCartCoupon(CartService products.CartService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		cart, err := CartService.RemoveCoupon(cartOwnerFromRequest(c))
+		if err != nil {
+			return err
+		}
+		return writeCart(c, http.StatusOK, cart)
+	}
+}
+
+// PSQLMergeCarts moves the guest cart of the cookie into the cart of the customer after login,
+// then removes the cookie
+func PSQLMergeCarts(CartService products.CartService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		customerID := middlewares.CustomerID(c)
+		if customerID == "" {
+			return echo.NewHTTPError(http.StatusUnauthorized, "Log in to merge the guest cart")
+		}
+
+		var token string
+		if cookie, err := c.Cookie(CartCookie); err == nil {
+			token = cookie.Value
+		}
+
+		customer := products.CartOwner{CustomerID: customerID, Segment: middlewares.CustomerSegment(c)}
+		cart, err := CartService.MergeCarts(customer, token)
+		if err != nil {
+			return err
+		}
+
+		c.SetCookie(&http.Cookie{
+			Name:     CartCookie,
+			Path:     "/",
+			Expires:  time.Unix(0, 0),
+			MaxAge:   -1,
+			HttpOnly: true,
+		})
+		return writeCart(c, http.StatusOK, cart)
+	}
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http"
+
+	"smkdevid/echocommercehub/internal/services/promotions"
+	"smkdevid/echocommercehub/utils/requests"
+	"smkdevid/echocommercehub/utils/responses"
+
+	"github.com/labstack/echo/v4"
+)
+
+func PSQLGenerateCoupons(CouponService promotions.CouponService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		promotionID := c.Param("promotion_id")
+
+		var req promotions.GenerateCouponRequest
+		if err := requests.BindAndValidate(c, &req); err != nil {
+			return err
+		}
+
+		coupons, err := CouponService.GenerateCoupons(promotionID, req)
+		if err != nil {
+			return err
+		}
+
+		return responses.JSON(c, http.StatusCreated, coupons)
+	}
+}
+
+func PSQLGetCouponsbyPromotionID(CouponService promotions.CouponService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		promotionID := c.Param("promotion_id")
+
+		coupons, err := CouponService.GetCouponsbyPromotionID(promotionID)
+		if err != nil {
+			return err
+		}
+		return responses.JSON(c, http.StatusOK, coupons)
+	}
+}
+
+func PSQLValidateCoupon(CouponService promotions.CouponService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		couponCode := c.Param("coupon_code")
+
+		var cart promotions.Cart
+		if err := requests.BindAndValidate(c, &cart); err != nil {
+			return err
+		}
+
+		validation, err := CouponService.ValidateCoupon(couponCode, cart)
+		if err != nil {
+			return err
+		}
+
+		return responses.JSON(c, http.StatusOK, validation)
+	}
+}
+
+func PSQLRedeemCoupon(CouponService promotions.CouponService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		couponCode := c.Param("coupon_code")
+
+		var req promotions.RedeemCouponRequest
+		if err := requests.BindAndValidate(c, &req); err != nil {
+			return err
+		}
+
+		redemption, err := CouponService.RedeemCoupon(couponCode, req)
+		if err != nil {
+			return err
+		}
+
+		return responses.JSON(c, http.StatusOK, redemption)
+	}
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"smkdevid/echocommercehub/utils/responses"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Status of a health check
+const (
+	HealthStatusOK   = "ok"
+	HealthStatusFail = "fail"
+)
+
+// HealthCheck reports whether a dependency can serve requests
+type HealthCheck func(ctx context.Context) error
+
+// HealthReport is the body of the health endpoints, Checks holds the result of every dependency
+type HealthReport struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// CheckResult is the result of a single HealthCheck
+type CheckResult struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Liveness answers as long as the process serves HTTP, it does not look at the dependencies
+// so a database outage does not restart every instance
+func Liveness() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return responses.JSON(c, http.StatusOK, HealthReport{Status: HealthStatusOK})
+	}
+}
+
+// Readiness runs every check and answers 503 when one of them fails, so no traffic is routed to the instance
+func Readiness(checks map[string]HealthCheck, timeout time.Duration) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx, cancel := context.WithTimeout(c.Request().Context(), timeout)
+		defer cancel()
+
+		report := HealthReport{Status: HealthStatusOK, Checks: make(map[string]CheckResult, len(checks))}
+		for name, check := range checks {
+			start := time.Now()
+			result := CheckResult{Status: HealthStatusOK}
+			if err := check(ctx); err != nil {
+				result.Status = HealthStatusFail
+				result.Error = err.Error()
+				report.Status = HealthStatusFail
+			}
+			result.Duration = time.Since(start).String()
+			report.Checks[name] = result
+		}
+
+		status := http.StatusOK
+		if report.Status != HealthStatusOK {
+			status = http.StatusServiceUnavailable
+		}
+		return responses.JSON(c, status, report)
+	}
+}
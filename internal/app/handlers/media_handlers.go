@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"net/http"
+
+	"smkdevid/echocommercehub/internal/services/products"
+	"smkdevid/echocommercehub/utils/requests"
+	"smkdevid/echocommercehub/utils/responses"
+
+	"github.com/labstack/echo/v4"
+)
+
+// PSQLUploadMedia reads a multipart form with the file in `file` and optional `alt_text` and `variant_id`
+func PSQLUploadMedia(MediaService products.MediaService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		productID := c.Param("product_id")
+
+		header, err := c.FormFile("file")
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "A multipart form with a file field is required")
+		}
+		file, err := header.Open()
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Uploaded file cannot be read")
+		}
+		defer file.Close()
+
+		media, err := MediaService.UploadMedia(c.Request().Context(), productID, products.MediaUpload{
+			VariantID: c.FormValue("variant_id"),
+			AltText:   c.FormValue("alt_text"),
+			Size:      header.Size,
+			Body:      file,
+		})
+		if err != nil {
+			return err
+		}
+		return responses.JSON(c, http.StatusCreated, media)
+	}
+}
+
+func PSQLGetMediabyProductID(MediaService products.MediaService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		productID := c.Param("product_id")
+
+		media, err := MediaService.GetMediabyProductID(productID, c.QueryParam("variant_id"))
+		if err != nil {
+			return err
+		}
+		return responses.JSON(c, http.StatusOK, media)
+	}
+}
+
+func PSQLUpdateMedia(MediaService products.MediaService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		productID := c.Param("product_id")
+		mediaID := c.Param("media_id")
+
+		var req products.UpdateMediaRequest
+		if err := requests.BindAndValidate(c, &req); err != nil {
+			return err
+		}
+
+		updatedMedia, err := MediaService.UpdateMedia(productID, mediaID, req)
+		if err != nil {
+			return err
+		}
+		return responses.JSON(c, http.StatusOK, updatedMedia)
+	}
+}
+
+func PSQLReorderMedia(MediaService products.MediaService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		productID := c.Param("product_id")
+
+		var req products.ReorderMediaRequest
+		if err := requests.BindAndValidate(c, &req); err != nil {
+			return err
+		}
+
+		media, err := MediaService.ReorderMedia(productID, req)
+		if err != nil {
+			return err
+		}
+		return responses.JSON(c, http.StatusOK, media)
+	}
+}
+
+func PSQLDeleteMedia(MediaService products.MediaService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		productID := c.Param("product_id")
+		mediaID := c.Param("media_id")
+
+		if err := MediaService.DeleteMedia(productID, mediaID); err != nil {
+			return err
+		}
+		return c.NoContent(http.StatusNoContent) // 204
+	}
+}
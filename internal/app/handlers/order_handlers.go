@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"smkdevid/echocommercehub/internal/app/middlewares"
+	postgresql "smkdevid/echocommercehub/internal/databases/postgresql"
+	"smkdevid/echocommercehub/internal/services/products"
+	"smkdevid/echocommercehub/utils/exception"
+	"smkdevid/echocommercehub/utils/requests"
+	"smkdevid/echocommercehub/utils/responses"
+
+	"github.com/labstack/echo/v4"
+)
+
+// customerFromRequest reads the customer verified by the session middleware, the customer order routes
+// are not open to guests
+func customerFromRequest(c echo.Context) (string, error) {
+	customerID := middlewares.CustomerID(c)
+	if customerID == "" {
+		return "", echo.NewHTTPError(http.StatusUnauthorized, "Log in to see and place orders")
+	}
+	return customerID, nil
+}
+
+func PSQLPlaceOrder(OrderService products.OrderService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		customerID, err := customerFromRequest(c)
+		if err != nil {
+			return err
+		}
+
+		var req products.PlaceOrderRequest
+		if err := requests.BindAndValidate(c, &req); err != nil {
+			return err
+		}
+
+		customer := products.CartOwner{CustomerID: customerID, Segment: middlewares.CustomerSegment(c)}
+		order, err := OrderService.PlaceOrder(customer, req)
+		if err != nil {
+			return err
+		}
+		return responses.JSON(c, http.StatusCreated, order)
+	}
+}
+
+func PSQLListCustomerOrders(OrderService products.OrderService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		customerID, err := customerFromRequest(c)
+		if err != nil {
+			return err
+		}
+
+		query, err := orderQueryFromRequest(c)
+		if err != nil {
+			return err
+		}
+		query.CustomerID = customerID
+		return listOrders(c, OrderService, query)
+	}
+}
+
+func PSQLGetCustomerOrder(OrderService products.OrderService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		customerID, err := customerFromRequest(c)
+		if err != nil {
+			return err
+		}
+
+		order, err := OrderService.GetCustomerOrder(customerID, c.Param("order_id"))
+		if err != nil {
+			return err
+		}
+		return responses.JSON(c, http.StatusOK, order)
+	}
+}
+
+func PSQLGetCustomerOrderHistory(OrderService products.OrderService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		customerID, err := customerFromRequest(c)
+		if err != nil {
+			return err
+		}
+
+		order, err := OrderService.GetCustomerOrder(customerID, c.Param("order_id"))
+		if err != nil {
+			return err
+		}
+		history, err := OrderService.GetOrderHistory(order.OrderID)
+		if err != nil {
+			return err
+		}
+		return responses.JSON(c, http.StatusOK, history)
+	}
+}
+
+// PSQLCancelCustomerOrder cancels the order through PaymentService, which voids its open payments first
+func PSQLCancelCustomerOrder(PaymentService products.PaymentService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		customerID, err := customerFromRequest(c)
+		if err != nil {
+			return err
+		}
+
+		var req products.CancelOrderRequest
+		if err := requests.BindAndValidate(c, &req); err != nil {
+			return err
+		}
+
+		order, err := PaymentService.CancelCustomerOrder(c.Request().Context(), customerID, c.Param("order_id"), req)
+		if err != nil {
+			return err
+		}
+		return responses.JSON(c, http.StatusOK, order)
+	}
+}
+
+func PSQLListOrders(OrderService products.OrderService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		query, err := orderQueryFromRequest(c)
+		if err != nil {
+			return err
+		}
+		query.CustomerID = c.QueryParam("customer_id")
+		return listOrders(c, OrderService, query)
+	}
+}
+
+func PSQLGetOrder(OrderService products.OrderService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		order, err := OrderService.GetOrder(c.Param("order_id"))
+		if err != nil {
+			return err
+		}
+		return responses.JSON(c, http.StatusOK, order)
+	}
+}
+
+func PSQLGetOrderHistory(OrderService products.OrderService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		history, err := OrderService.GetOrderHistory(c.Param("order_id"))
+		if err != nil {
+			return err
+		}
+		return responses.JSON(c, http.StatusOK, history)
+	}
+}
+
+func PSQLTransitionOrder(OrderService products.OrderService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var req products.TransitionOrderRequest
+		if err := requests.BindAndValidate(c, &req); err != nil {
+			return err
+		}
+
+		order, err := OrderService.TransitionOrder(c.Param("order_id"), req, actorFromRequest(c))
+		if err != nil {
+			return err
+		}
+		return responses.JSON(c, http.StatusOK, order)
+	}
+}
+
+func orderQueryFromRequest(c echo.Context) (postgresql.OrderQuery, error) {
+	query := postgresql.OrderQuery{
+		Status: c.QueryParam("status"),
+	}
+
+	err := echo.QueryParamsBinder(c).
+		Int("limit", &query.Limit).
+		Int("offset", &query.Offset).
+		BindError()
+	if err != nil {
+		return query, echo.NewHTTPError(http.StatusBadRequest, "Invalid query parameter: "+err.Error())
+	}
+	return query, nil
+}
+
+func listOrders(c echo.Context, OrderService products.OrderService, query postgresql.OrderQuery) error {
+	page, err := OrderService.ListOrders(query)
+	if err != nil {
+		// Invalid query parameters are a bad request, not an invalid payload
+		var validationErr *exception.ValidationError
+		if errors.As(err, &validationErr) {
+			return echo.NewHTTPError(http.StatusBadRequest, requests.ValidationResponse{
+				Message: validationErr.Message,
+				Errors:  validationErr.Fields,
+			})
+		}
+		return err
+	}
+	return responses.Paginated(c, page.Data, responses.Pagination{
+		Total:  page.Total,
+		Limit:  page.Limit,
+		Offset: page.Offset,
+	})
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"net/http"
+
+	"smkdevid/echocommercehub/internal/services/products"
+	"smkdevid/echocommercehub/utils/requests"
+	"smkdevid/echocommercehub/utils/responses"
+
+	"github.com/labstack/echo/v4"
+)
+
+// SimulatePaymentRequest settles a charge of the fake provider in local development
+type SimulatePaymentRequest struct {
+	Status string `json:"status" validate:"required,oneof=authorized paid failed expired"`
+}
+
+func PSQLCreatePayment(PaymentService products.PaymentService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		customerID, err := customerFromRequest(c)
+		if err != nil {
+			return err
+		}
+
+		var req products.CreatePaymentRequest
+		if err := requests.BindAndValidate(c, &req); err != nil {
+			return err
+		}
+
+		payment, err := PaymentService.CreatePayment(c.Request().Context(), customerID, c.Param("order_id"), req)
+		if err != nil {
+			return err
+		}
+		return responses.JSON(c, http.StatusCreated, payment)
+	}
+}
+
+func PSQLGetCustomerOrderPayments(PaymentService products.PaymentService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		customerID, err := customerFromRequest(c)
+		if err != nil {
+			return err
+		}
+
+		payments, err := PaymentService.GetCustomerOrderPayments(customerID, c.Param("order_id"))
+		if err != nil {
+			return err
+		}
+		return responses.JSON(c, http.StatusOK, payments)
+	}
+}
+
+func PSQLGetOrderPayments(PaymentService products.PaymentService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		payments, err := PaymentService.GetOrderPayments(c.Param("order_id"))
+		if err != nil {
+			return err
+		}
+		return responses.JSON(c, http.StatusOK, payments)
+	}
+}
+
+func PSQLGetPayment(PaymentService products.PaymentService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		payment, err := PaymentService.GetPayment(c.Param("payment_id"))
+		if err != nil {
+			return err
+		}
+		return responses.JSON(c, http.StatusOK, payment)
+	}
+}
+
+func PSQLCapturePayment(PaymentService products.PaymentService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		payment, err := PaymentService.CapturePayment(c.Request().Context(), c.Param("payment_id"), actorFromRequest(c))
+		if err != nil {
+			return err
+		}
+		return responses.JSON(c, http.StatusOK, payment)
+	}
+}
+
+func PSQLVoidPayment(PaymentService products.PaymentService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		payment, err := PaymentService.VoidPayment(c.Request().Context(), c.Param("payment_id"), actorFromRequest(c))
+		if err != nil {
+			return err
+		}
+		return responses.JSON(c, http.StatusOK, payment)
+	}
+}
+
+func PSQLRefundPayment(PaymentService products.PaymentService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var req products.RefundPaymentRequest
+		if err := requests.BindAndValidate(c, &req); err != nil {
+			return err
+		}
+
+		payment, err := PaymentService.RefundPayment(c.Request().Context(), c.Param("payment_id"), req, actorFromRequest(c))
+		if err != nil {
+			return err
+		}
+		return responses.JSON(c, http.StatusOK, payment)
+	}
+}
+
+// PSQLSyncPayment reads the state of the payment from the provider, for when a notification got lost
+func PSQLSyncPayment(PaymentService products.PaymentService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		payment, err := PaymentService.SyncPayment(c.Request().Context(), c.Param("payment_id"))
+		if err != nil {
+			return err
+		}
+		return responses.JSON(c, http.StatusOK, payment)
+	}
+}
+
+// PSQLSimulatePayment settles a charge of the fake provider and records it like a notification would
+func PSQLSimulatePayment(PaymentService products.PaymentService, Fake *products.FakeProvider) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var req SimulatePaymentRequest
+		if err := requests.BindAndValidate(c, &req); err != nil {
+			return err
+		}
+
+		payment, err := PaymentService.GetPayment(c.Param("payment_id"))
+		if err != nil {
+			return err
+		}
+		result, err := Fake.SetStatus(payment.Reference, req.Status)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+
+		payment, err = PaymentService.RecordOutcome(c.Request().Context(), payment.Reference, result)
+		if err != nil {
+			return err
+		}
+		return responses.JSON(c, http.StatusOK, payment)
+	}
+}
@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	postgresql "smkdevid/echocommercehub/internal/databases/postgresql"
+	models "smkdevid/echocommercehub/internal/models/schema"
+	"smkdevid/echocommercehub/internal/services/products"
+	"smkdevid/echocommercehub/utils/exception"
+	"smkdevid/echocommercehub/utils/requests"
+	"smkdevid/echocommercehub/utils/responses"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+)
+
+func PSQLCreateProduct(ProductService products.ProductService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var product models.Product
+		if err := requests.BindAndValidate(c, &product); err != nil {
+			return err
+		}
+
+		createdProduct, err := ProductService.CreateProduct(product)
+		if err != nil {
+			return err
+		}
+		return responses.JSON(c, http.StatusCreated, createdProduct)
+	}
+}
+
+func PSQLListProducts(ProductService products.ProductService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		query, err := productQueryFromRequest(c)
+		if err != nil {
+			return err
+		}
+
+		page, err := ProductService.ListProducts(query)
+		if err != nil {
+			// Invalid query parameters are a bad request, not an invalid payload
+			var validationErr *exception.ValidationError
+			if errors.As(err, &validationErr) {
+				return echo.NewHTTPError(http.StatusBadRequest, requests.ValidationResponse{
+					Message: validationErr.Message,
+					Errors:  validationErr.Fields,
+				})
+			}
+			return err
+		}
+		return responses.Paginated(c, page.Data, responses.Pagination{
+			Total:  page.Total,
+			Limit:  page.Limit,
+			Offset: page.Offset,
+		})
+	}
+}
+
+func PSQLSearchProducts(ProductService products.ProductService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		query, err := searchQueryFromRequest(c)
+		if err != nil {
+			return err
+		}
+
+		page, err := ProductService.SearchProducts(query)
+		if err != nil {
+			var validationErr *exception.ValidationError
+			if errors.As(err, &validationErr) {
+				return echo.NewHTTPError(http.StatusBadRequest, requests.ValidationResponse{
+					Message: validationErr.Message,
+					Errors:  validationErr.Fields,
+				})
+			}
+			return err
+		}
+		return responses.Paginated(c, page, responses.Pagination{
+			Total:  page.Total,
+			Limit:  page.Limit,
+			Offset: page.Offset,
+		})
+	}
+}
+
+// searchQueryFromRequest reads the search text, filters and pagination of the product search:
+// q, category_id, brand, min_price, max_price, availability, limit and offset
+func searchQueryFromRequest(c echo.Context) (postgresql.ProductSearchQuery, error) {
+	query := postgresql.ProductSearchQuery{
+		Text:         c.QueryParam("q"),
+		CategoryID:   c.QueryParam("category_id"),
+		Brand:        c.QueryParam("brand"),
+		Availability: c.QueryParam("availability"),
+	}
+
+	err := echo.QueryParamsBinder(c).
+		Int("limit", &query.Limit).
+		Int("offset", &query.Offset).
+		BindError()
+	if err != nil {
+		return query, echo.NewHTTPError(http.StatusBadRequest, "Invalid query parameter: "+err.Error())
+	}
+
+	if query.MinPrice, err = queryDecimal(c, "min_price"); err != nil {
+		return query, err
+	}
+	if query.MaxPrice, err = queryDecimal(c, "max_price"); err != nil {
+		return query, err
+	}
+	return query, nil
+}
+
+// productQueryFromRequest reads the filters, sort and pagination of the product list:
+// status, category_id, brand, q, min_price, max_price, sort, order, limit and offset
+func productQueryFromRequest(c echo.Context) (postgresql.ProductQuery, error) {
+	query := postgresql.ProductQuery{
+		Status:     c.QueryParam("status"),
+		CategoryID: c.QueryParam("category_id"),
+		Brand:      c.QueryParam("brand"),
+		Name:       c.QueryParam("q"),
+		SortBy:     c.QueryParam("sort"),
+		SortOrder:  c.QueryParam("order"),
+	}
+
+	if query.Status != "" && !products.IsValidProductStatus(query.Status) {
+		return query, echo.NewHTTPError(http.StatusBadRequest, "Invalid product status")
+	}
+
+	err := echo.QueryParamsBinder(c).
+		Int("limit", &query.Limit).
+		Int("offset", &query.Offset).
+		BindError()
+	if err != nil {
+		return query, echo.NewHTTPError(http.StatusBadRequest, "Invalid query parameter: "+err.Error())
+	}
+
+	if query.MinPrice, err = queryDecimal(c, "min_price"); err != nil {
+		return query, err
+	}
+	if query.MaxPrice, err = queryDecimal(c, "max_price"); err != nil {
+		return query, err
+	}
+	return query, nil
+}
+
+// queryDecimal reads an optional decimal number from the query string
+func queryDecimal(c echo.Context, param string) (*decimal.Decimal, error) {
+	value := c.QueryParam(param)
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := decimal.NewFromString(value)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "Invalid "+param+", expected a number")
+	}
+	return &parsed, nil
+}
+
+func PSQLGetProductbyProductID(ProductService products.ProductService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		productID := c.Param("product_id")
+
+		product, err := ProductService.GetProductbyProductID(productID)
+		if err != nil {
+			return err
+		}
+		return responses.JSON(c, http.StatusOK, product)
+	}
+}
+
+func PSQLUpdateProductbyProductID(ProductService products.ProductService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		productID := c.Param("product_id")
+
+		var product models.Product
+		if err := requests.BindAndValidate(c, &product); err != nil {
+			return err
+		}
+		product.ProductID = productID
+
+		updatedProduct, err := ProductService.UpdateProductbyProductID(product)
+		if err != nil {
+			return err
+		}
+		return responses.JSON(c, http.StatusOK, updatedProduct)
+	}
+}
+
+func PSQLDeleteProductbyProductID(ProductService products.ProductService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		productID := c.Param("product_id")
+
+		if err := ProductService.DeleteProductbyProductID(productID); err != nil {
+			return err
+		}
+		return c.NoContent(http.StatusNoContent) // 204
+	}
+}
@@ -1,39 +1,123 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"time"
 
+	"smkdevid/echocommercehub/internal/app/middlewares"
+	postgresql "smkdevid/echocommercehub/internal/databases/postgresql"
 	models "smkdevid/echocommercehub/internal/models/schema"
 	"smkdevid/echocommercehub/internal/services/promotions"
 	"smkdevid/echocommercehub/utils/exception"
+	"smkdevid/echocommercehub/utils/requests"
+	"smkdevid/echocommercehub/utils/responses"
 
 	"github.com/labstack/echo/v4"
 )
 
+// actorFromRequest is the admin authenticated on the back office route, it is recorded in the history
+// of the changes. Anonymous only when a route is served without the admin middleware.
+func actorFromRequest(c echo.Context) string {
+	if admin := middlewares.Admin(c); admin != "" {
+		return admin
+	}
+	return "anonymous"
+}
+
 func PSQLCreatePromotionData(PromoService promotions.PromotionService) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		var promo models.Promotion
-		if err := c.Bind(&promo); err != nil {
-			return echo.NewHTTPError(http.StatusBadRequest, "Invalid promotion data")
+		if err := requests.BindAndValidate(c, &promo); err != nil {
+			return err
 		}
 
-		createdPromo, err := PromoService.CreatePromotion(promo)
+		createdPromo, err := PromoService.CreatePromotion(promo, actorFromRequest(c))
 		if err != nil {
-			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create promotion")
+			return err
 		}
 
-		return c.JSON(http.StatusCreated, createdPromo)
+		c.Response().Header().Set(requests.HeaderETag, requests.VersionETag(createdPromo.Version))
+		return responses.JSON(c, http.StatusCreated, createdPromo)
 	}
 }
 
 func PSQLGetAllPromotionData(PromoService promotions.PromotionService) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		promotions, err := PromoService.GetAllPromotions()
+		query, err := promotionQueryFromRequest(c)
+		if err != nil {
+			return err
+		}
+
+		page, err := PromoService.ListPromotions(query)
 		if err != nil {
-			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to retrieve promotions: "+err.Error())
+			// Invalid query parameters are a bad request, not an invalid payload
+			var validationErr *exception.ValidationError
+			if errors.As(err, &validationErr) {
+				return echo.NewHTTPError(http.StatusBadRequest, requests.ValidationResponse{
+					Message: validationErr.Message,
+					Errors:  validationErr.Fields,
+				})
+			}
+			return err
 		}
-		return c.JSON(http.StatusOK, promotions)
+		return responses.Paginated(c, page.Data, responses.Pagination{
+			Total:      page.Total,
+			Limit:      page.Limit,
+			Offset:     page.Offset,
+			NextCursor: page.NextCursor,
+		})
+	}
+}
+
+// promotionQueryFromRequest reads the filters, sort and pagination of the promotion list:
+// status, discount_type, q, active_from, active_to, include_deleted, sort, order, limit, offset and cursor
+func promotionQueryFromRequest(c echo.Context) (postgresql.PromotionQuery, error) {
+	query := postgresql.PromotionQuery{
+		Status:       c.QueryParam("status"),
+		DiscountType: c.QueryParam("discount_type"),
+		Name:         c.QueryParam("q"),
+		SortBy:       c.QueryParam("sort"),
+		SortOrder:    c.QueryParam("order"),
+		Cursor:       c.QueryParam("cursor"),
+	}
+
+	if query.Status != "" && !promotions.IsValidPromotionStatus(query.Status) {
+		return query, echo.NewHTTPError(http.StatusBadRequest, "Invalid promotion status")
+	}
+	if query.DiscountType != "" && !promotions.IsValidDiscountType(query.DiscountType) {
+		return query, echo.NewHTTPError(http.StatusBadRequest, "Invalid discount type")
+	}
+
+	err := echo.QueryParamsBinder(c).
+		Bool("include_deleted", &query.IncludeDeleted).
+		Int("limit", &query.Limit).
+		Int("offset", &query.Offset).
+		BindError()
+	if err != nil {
+		return query, echo.NewHTTPError(http.StatusBadRequest, "Invalid query parameter: "+err.Error())
+	}
+
+	if query.ActiveFrom, err = queryTime(c, "active_from"); err != nil {
+		return query, err
+	}
+	if query.ActiveTo, err = queryTime(c, "active_to"); err != nil {
+		return query, err
+	}
+	return query, nil
+}
+
+// queryTime reads an optional RFC 3339 date from the query string
+func queryTime(c echo.Context, param string) (*time.Time, error) {
+	value := c.QueryParam(param)
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "Invalid "+param+", expected an RFC 3339 date")
 	}
+	return &parsed, nil
 }
 
 func PSQLGetPromotionbyPromotionID(PromoService promotions.PromotionService) echo.HandlerFunc {
@@ -42,55 +126,239 @@ func PSQLGetPromotionbyPromotionID(PromoService promotions.PromotionService) ech
 
 		promo, err := PromoService.GetPromotionbyPromotionID(promotionID)
 		if err != nil {
-
-			// ! Update the exception with the custom one. For now leave it there.
-			if e, ok := err.(*exception.PromotionIDNotFoundError); ok {
-				return echo.NewHTTPError(http.StatusNotFound, e.Error())
-			}
-			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get promotion")
+			return err
 		}
 
-		return c.JSON(http.StatusOK, promo)
+		c.Response().Header().Set(requests.HeaderETag, requests.VersionETag(promo.Version))
+		return responses.JSON(c, http.StatusOK, promo)
 	}
 }
 
+// PSQLUpdatePromotionbyPromotionID replaces the promotion. Send the ETag of the read promotion in If-Match,
+// a stale one gets a 412 and a request without it a 428.
 func PSQLUpdatePromotionbyPromotionID(PromoService promotions.PromotionService) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		promotionID := c.Param("promotion_id")
 
-		// TODO: check if promotion_id is exist
 		promo, err := PromoService.GetPromotionbyPromotionID(promotionID)
 		if err != nil {
-			if e, ok := err.(*exception.PromotionIDNotFoundError); ok {
-				return echo.NewHTTPError(http.StatusNotFound, e.Error())
-			}
-			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get promotion")
+			return err
+		}
+
+		version, err := expectedVersion(c, promo)
+		if err != nil {
+			return err
 		}
 
-		if err := c.Bind(&promo); err != nil {
-			return echo.NewHTTPError(http.StatusBadRequest, "Invalid promotion data")
+		current := promo
+		if err := requests.BindAndValidate(c, &promo); err != nil {
+			return err
 		}
+		keepServerFields(&promo, current, version)
 
 		// Update promotion
-		updatedPromo, err := PromoService.UpdatePromotionbyPromotionID(promo)
+		updatedPromo, err := PromoService.UpdatePromotionbyPromotionID(promo, actorFromRequest(c))
 		if err != nil {
-			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update promotion")
+			return versionConflict(c, err)
 		}
 
-		return c.JSON(http.StatusOK, updatedPromo)
+		c.Response().Header().Set(requests.HeaderETag, requests.VersionETag(updatedPromo.Version))
+		return responses.JSON(c, http.StatusOK, updatedPromo)
 	}
 }
 
+// PSQLPatchPromotionbyPromotionID applies a JSON Merge Patch to the promotion.
+// Send the ETag of the read promotion in If-Match to get a 412 instead of overwriting a newer change,
+// a request without If-Match gets a 428.
+func PSQLPatchPromotionbyPromotionID(PromoService promotions.PromotionService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		promotionID := c.Param("promotion_id")
+
+		promo, err := PromoService.GetPromotionbyPromotionID(promotionID)
+		if err != nil {
+			return err
+		}
+
+		version, err := expectedVersion(c, promo)
+		if err != nil {
+			return err
+		}
+
+		current := promo
+		if err := requests.BindMergePatchAndValidate(c, &promo); err != nil {
+			return err
+		}
+		keepServerFields(&promo, current, version)
+
+		updatedPromo, err := PromoService.UpdatePromotionbyPromotionID(promo, actorFromRequest(c))
+		if err != nil {
+			return versionConflict(c, err)
+		}
+
+		c.Response().Header().Set(requests.HeaderETag, requests.VersionETag(updatedPromo.Version))
+		return responses.JSON(c, http.StatusOK, updatedPromo)
+	}
+}
+
+// keepServerFields restores the fields owned by the server after a payload was bound over the promotion.
+// The status only changes through the pause and resume endpoints and the scheduler.
+func keepServerFields(promo *models.Promotion, current models.Promotion, version uint) {
+	promo.ID = current.ID
+	promo.PromotionID = current.PromotionID
+	promo.Status = current.Status
+	promo.CreatedAt = current.CreatedAt
+	promo.DeletedAt = current.DeletedAt
+	promo.Version = version
+}
+
+// expectedVersion is the version the update applies on, taken from the required If-Match. "*" applies
+// on the read promotion.
+func expectedVersion(c echo.Context, promo models.Promotion) (uint, error) {
+	if err := requests.RequireIfMatch(c); err != nil {
+		return 0, err
+	}
+
+	version, ok, err := requests.IfMatchVersion(c)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return promo.Version, nil
+	}
+	if version != promo.Version {
+		return 0, versionConflict(c, &exception.PromotionVersionConflictError{
+			Message:        "Promotion Was Modified",
+			PromotionID:    promo.PromotionID,
+			CurrentVersion: promo.Version,
+		})
+	}
+	return version, nil
+}
+
+// versionConflict sends the ETag of the current version along a stale write, so the client can refetch
+func versionConflict(c echo.Context, err error) error {
+	var conflict *exception.PromotionVersionConflictError
+	if errors.As(err, &conflict) {
+		c.Response().Header().Set(requests.HeaderETag, requests.VersionETag(conflict.CurrentVersion))
+	}
+	return err
+}
+
 func PSQLDeletePromotionbyPromotionID(PromoService promotions.PromotionService) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		promotionID := c.Param("promotion_id")
 
-		if err := PromoService.DeletePromotionbyPromotionID(promotionID); err != nil {
-			if e, ok := err.(*exception.NotFoundError); ok {
-				return echo.NewHTTPError(http.StatusNotFound, e.Error())
-			}
-			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to delete promotion")
+		if err := PromoService.DeletePromotionbyPromotionID(promotionID, actorFromRequest(c)); err != nil {
+			return err
+		}
+		return c.NoContent(http.StatusNoContent) // 204
+	}
+}
+
+func PSQLRestorePromotionbyPromotionID(PromoService promotions.PromotionService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		promotionID := c.Param("promotion_id")
+
+		promo, err := PromoService.RestorePromotionbyPromotionID(promotionID, actorFromRequest(c))
+		if err != nil {
+			return err
+		}
+		return responses.JSON(c, http.StatusOK, promo)
+	}
+}
+
+func PSQLGetPromotionHistory(PromoService promotions.PromotionService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		promotionID := c.Param("promotion_id")
+
+		history, err := PromoService.GetPromotionHistory(promotionID)
+		if err != nil {
+			return err
+		}
+		return responses.JSON(c, http.StatusOK, history)
+	}
+}
+
+func PSQLCheckPromotionEligibility(PromoService promotions.PromotionService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		promotionID := c.Param("promotion_id")
+
+		var cart promotions.Cart
+		if err := requests.BindAndValidate(c, &cart); err != nil {
+			return err
+		}
+
+		result, err := PromoService.CheckEligibility(promotionID, cart)
+		if err != nil {
+			return err
+		}
+
+		return responses.JSON(c, http.StatusOK, result)
+	}
+}
+
+func PSQLQuotePromotionDiscount(PromoService promotions.PromotionService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		promotionID := c.Param("promotion_id")
+
+		var cart promotions.Cart
+		if err := requests.BindAndValidate(c, &cart); err != nil {
+			return err
+		}
+
+		quote, err := PromoService.QuoteDiscount(promotionID, cart)
+		if err != nil {
+			return err
+		}
+
+		return responses.JSON(c, http.StatusOK, quote)
+	}
+}
+
+func PSQLResolvePromotions(PromoService promotions.PromotionService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		mode := c.QueryParam("mode")
+		if mode == "" {
+			mode = promotions.StackingModePriority
+		}
+		if !promotions.IsValidStackingMode(mode) {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid stacking mode")
+		}
+
+		var cart promotions.Cart
+		if err := requests.BindAndValidate(c, &cart); err != nil {
+			return err
+		}
+
+		result, err := PromoService.ResolvePromotions(cart, mode)
+		if err != nil {
+			return err
+		}
+
+		return responses.JSON(c, http.StatusOK, result)
+	}
+}
+
+func PSQLPausePromotion(PromoService promotions.PromotionService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		promotionID := c.Param("promotion_id")
+
+		promo, err := PromoService.PausePromotion(promotionID, actorFromRequest(c))
+		if err != nil {
+			return err
+		}
+		return responses.JSON(c, http.StatusOK, promo)
+	}
+}
+
+func PSQLResumePromotion(PromoService promotions.PromotionService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		promotionID := c.Param("promotion_id")
+
+		promo, err := PromoService.ResumePromotion(promotionID, actorFromRequest(c))
+		if err != nil {
+			return err
 		}
-		return c.JSON(http.StatusNoContent, models.Promotion{}) // 204
+		return responses.JSON(c, http.StatusOK, promo)
 	}
 }
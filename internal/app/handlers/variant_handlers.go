@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"net/http"
+
+	models "smkdevid/echocommercehub/internal/models/schema"
+	"smkdevid/echocommercehub/internal/services/products"
+	"smkdevid/echocommercehub/utils/requests"
+	"smkdevid/echocommercehub/utils/responses"
+
+	"github.com/labstack/echo/v4"
+)
+
+func PSQLGetProductOptions(VariantService products.VariantService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		productID := c.Param("product_id")
+
+		options, err := VariantService.GetProductOptions(productID)
+		if err != nil {
+			return err
+		}
+		return responses.JSON(c, http.StatusOK, options)
+	}
+}
+
+func PSQLGenerateVariants(VariantService products.VariantService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		productID := c.Param("product_id")
+
+		var req products.GenerateVariantsRequest
+		if err := requests.BindAndValidate(c, &req); err != nil {
+			return err
+		}
+
+		variants, err := VariantService.GenerateVariants(productID, req)
+		if err != nil {
+			return err
+		}
+		return responses.JSON(c, http.StatusCreated, variants)
+	}
+}
+
+func PSQLCreateVariant(VariantService products.VariantService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		productID := c.Param("product_id")
+
+		var variant models.ProductVariant
+		if err := requests.BindAndValidate(c, &variant); err != nil {
+			return err
+		}
+
+		createdVariant, err := VariantService.CreateVariant(productID, variant)
+		if err != nil {
+			return err
+		}
+		return responses.JSON(c, http.StatusCreated, createdVariant)
+	}
+}
+
+func PSQLGetVariantsbyProductID(VariantService products.VariantService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		productID := c.Param("product_id")
+
+		variants, err := VariantService.GetVariantsbyProductID(productID)
+		if err != nil {
+			return err
+		}
+		return responses.JSON(c, http.StatusOK, variants)
+	}
+}
+
+func PSQLGetVariantbyVariantID(VariantService products.VariantService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		productID := c.Param("product_id")
+		variantID := c.Param("variant_id")
+
+		variant, err := VariantService.GetVariantbyVariantID(productID, variantID)
+		if err != nil {
+			return err
+		}
+		return responses.JSON(c, http.StatusOK, variant)
+	}
+}
+
+func PSQLUpdateVariant(VariantService products.VariantService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		productID := c.Param("product_id")
+		variantID := c.Param("variant_id")
+
+		var variant models.ProductVariant
+		if err := requests.BindAndValidate(c, &variant); err != nil {
+			return err
+		}
+
+		updatedVariant, err := VariantService.UpdateVariant(productID, variantID, variant)
+		if err != nil {
+			return err
+		}
+		return responses.JSON(c, http.StatusOK, updatedVariant)
+	}
+}
+
+func PSQLDeleteVariant(VariantService products.VariantService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		productID := c.Param("product_id")
+		variantID := c.Param("variant_id")
+
+		if err := VariantService.DeleteVariant(productID, variantID); err != nil {
+			return err
+		}
+		return c.NoContent(http.StatusNoContent) // 204
+	}
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	postgresql "smkdevid/echocommercehub/internal/databases/postgresql"
+	"smkdevid/echocommercehub/internal/services/products"
+	"smkdevid/echocommercehub/utils/exception"
+	"smkdevid/echocommercehub/utils/requests"
+	"smkdevid/echocommercehub/utils/responses"
+
+	"github.com/labstack/echo/v4"
+)
+
+// MaxWebhookSize bounds the body of a payment webhook
+const MaxWebhookSize = 1 << 20
+
+// WebhookReceipt acknowledges a webhook, Duplicate tells that the event was received before
+type WebhookReceipt struct {
+	WebhookID string `json:"webhook_id"`
+	EventID   string `json:"event_id"`
+	Status    string `json:"status"`
+	Duplicate bool   `json:"duplicate"`
+}
+
+// PSQLReceivePaymentWebhook stores a signed webhook of the payment provider and acknowledges it right
+// away, the event is applied in the background. A redelivered event is acknowledged again.
+func PSQLReceivePaymentWebhook(WebhookService products.WebhookService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		body, err := io.ReadAll(http.MaxBytesReader(c.Response(), c.Request().Body, MaxWebhookSize))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "Webhook body is too large")
+		}
+
+		event, duplicate, err := WebhookService.ReceiveWebhook(c.Request().Header, body)
+		if errors.Is(err, products.ErrInvalidWebhookSignature) {
+			return echo.NewHTTPError(http.StatusUnauthorized, "Invalid webhook signature")
+		}
+		if err != nil {
+			return err
+		}
+
+		status := http.StatusAccepted
+		if duplicate {
+			status = http.StatusOK
+		}
+		return responses.JSON(c, status, WebhookReceipt{
+			WebhookID: event.WebhookID,
+			EventID:   event.EventID,
+			Status:    event.Status,
+			Duplicate: duplicate,
+		})
+	}
+}
+
+func PSQLListWebhookEvents(WebhookService products.WebhookService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		query := postgresql.WebhookEventQuery{
+			Status: c.QueryParam("status"),
+		}
+		err := echo.QueryParamsBinder(c).
+			Int("limit", &query.Limit).
+			Int("offset", &query.Offset).
+			BindError()
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid query parameter: "+err.Error())
+		}
+
+		page, err := WebhookService.ListWebhookEvents(query)
+		if err != nil {
+			// Invalid query parameters are a bad request, not an invalid payload
+			var validationErr *exception.ValidationError
+			if errors.As(err, &validationErr) {
+				return echo.NewHTTPError(http.StatusBadRequest, requests.ValidationResponse{
+					Message: validationErr.Message,
+					Errors:  validationErr.Fields,
+				})
+			}
+			return err
+		}
+		return responses.Paginated(c, page.Data, responses.Pagination{
+			Total:  page.Total,
+			Limit:  page.Limit,
+			Offset: page.Offset,
+		})
+	}
+}
+
+// PSQLReplayWebhookEvent queues a failed webhook event again
+func PSQLReplayWebhookEvent(WebhookService products.WebhookService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		event, err := WebhookService.ReplayWebhookEvent(c.Param("webhook_id"))
+		if err != nil {
+			return err
+		}
+		return responses.JSON(c, http.StatusAccepted, event)
+	}
+}
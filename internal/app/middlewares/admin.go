@@ -0,0 +1,37 @@
+package middlewares
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// AdminPrincipal is who a request holding the admin token acts as, it is recorded as the actor of its changes
+const AdminPrincipal = "admin"
+
+// adminKey is where AdminToken keeps the authenticated admin on the context
+const adminKey = "admin"
+
+// AdminToken restricts the back office routes to requests sending the admin token as a bearer token,
+// "Authorization: Bearer <token>". The token is compared in constant time.
+func AdminToken(token string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			sent, ok := strings.CutPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+			if !ok || token == "" || subtle.ConstantTimeCompare([]byte(sent), []byte(token)) != 1 {
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer realm="admin"`)
+				return echo.NewHTTPError(http.StatusUnauthorized, "A valid admin token is required")
+			}
+			c.Set(adminKey, AdminPrincipal)
+			return next(c)
+		}
+	}
+}
+
+// Admin is the admin authenticated by AdminToken, empty when the route is not an admin route
+func Admin(c echo.Context) string {
+	admin, _ := c.Get(adminKey).(string)
+	return admin
+}
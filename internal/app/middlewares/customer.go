@@ -0,0 +1,93 @@
+package middlewares
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/labstack/echo/v4"
+)
+
+// HeaderCustomer carries the ID of the logged in customer when a gateway in front of the server
+// verified the session, it is ignored unless the server is configured to trust it
+const HeaderCustomer = "X-Customer-ID"
+
+// HeaderCustomerSegment carries the segment of the customer sent along HeaderCustomer
+const HeaderCustomerSegment = "X-Customer-Segment"
+
+// customerKey and segmentKey are where CustomerSession keeps the verified customer on the context
+const (
+	customerKey = "customer_id"
+	segmentKey  = "customer_segment"
+)
+
+// sessionClaims are the claims of a customer access token, the segment of the customer is kept in
+// the app metadata which only the server can change
+type sessionClaims struct {
+	jwt.StandardClaims
+	AppMetadata struct {
+		Segment string `json:"segment"`
+	} `json:"app_metadata"`
+}
+
+// CustomerSession identifies the logged in customer from the access token sent as a bearer token,
+// "Authorization: Bearer <jwt>". The token must be signed with secret using HS256 and carry a subject
+// and an expiry, the subject is the customer ID and app_metadata.segment the customer segment.
+// Requests without a token go on as a guest. With trustHeader the X-Customer-ID and X-Customer-Segment
+// headers of a verifying gateway are accepted when no token is sent.
+func CustomerSession(secret string, trustHeader bool) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if token, ok := strings.CutPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer "); ok {
+				claims, err := verifySession(secret, token)
+				if err != nil {
+					c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer realm="customer", error="invalid_token"`)
+					return echo.NewHTTPError(http.StatusUnauthorized, "The session is invalid or expired, log in again")
+				}
+				c.Set(customerKey, claims.Subject)
+				c.Set(segmentKey, claims.AppMetadata.Segment)
+			} else if customerID := c.Request().Header.Get(HeaderCustomer); trustHeader && customerID != "" {
+				c.Set(customerKey, customerID)
+				c.Set(segmentKey, c.Request().Header.Get(HeaderCustomerSegment))
+			}
+			return next(c)
+		}
+	}
+}
+
+// CustomerID is the customer identified by CustomerSession, empty for a guest
+func CustomerID(c echo.Context) string {
+	customerID, _ := c.Get(customerKey).(string)
+	return customerID
+}
+
+// CustomerSegment is the segment of the customer identified by CustomerSession, empty when the
+// customer has none or for a guest
+func CustomerSegment(c echo.Context) string {
+	segment, _ := c.Get(segmentKey).(string)
+	return segment
+}
+
+// verifySession checks the signature and expiry of the token and returns its claims
+func verifySession(secret string, token string) (sessionClaims, error) {
+	var claims sessionClaims
+	if secret == "" {
+		return claims, errors.New("no session secret is configured")
+	}
+
+	_, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (interface{}, error) {
+		if t.Method != jwt.SigningMethodHS256 {
+			return nil, fmt.Errorf("unexpected signing method %v", t.Header["alg"])
+		}
+		return []byte(secret), nil
+	})
+	if err != nil {
+		return claims, err
+	}
+	if claims.Subject == "" || claims.ExpiresAt == 0 {
+		return claims, errors.New("session has no subject or expiry")
+	}
+	return claims, nil
+}
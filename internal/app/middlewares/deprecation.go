@@ -0,0 +1,42 @@
+package middlewares
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Headers announcing a deprecated endpoint, see RFC 8594 for Sunset
+const (
+	HeaderDeprecation = "Deprecation"
+	HeaderSunset      = "Sunset"
+	HeaderLink        = "Link"
+)
+
+// Deprecated marks the responses of a route kept for old clients. It tells when the route
+// goes away and links to the route replacing it. Path parameters written as :name in the
+// successor are filled from the request.
+func Deprecated(sunset time.Time, successor string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			header := c.Response().Header()
+			header.Set(HeaderDeprecation, "true")
+			header.Set(HeaderSunset, sunset.UTC().Format(http.TimeFormat))
+			header.Set(HeaderLink, fmt.Sprintf(`<%s>; rel="successor-version"`, successorPath(c, successor)))
+			return next(c)
+		}
+	}
+}
+
+func successorPath(c echo.Context, successor string) string {
+	segments := strings.Split(successor, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = c.Param(strings.TrimPrefix(segment, ":"))
+		}
+	}
+	return strings.Join(segments, "/")
+}
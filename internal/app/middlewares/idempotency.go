@@ -0,0 +1,153 @@
+package middlewares
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	postgresql "smkdevid/echocommercehub/internal/databases/postgresql"
+	models "smkdevid/echocommercehub/internal/models/schema"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// HeaderIdempotencyKey is the request header carrying the client chosen key
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// HeaderIdempotentReplayed is set on responses replayed from a previous request
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+)
+
+// Idempotency makes POST requests safe to retry. A request sent again with the same
+// Idempotency-Key gets the stored response of the first one instead of being processed twice.
+// Keys belong to the caller that sent them, the same key of another customer is a different key.
+// Keys expire after ttl. Requests without the header are processed as usual.
+func Idempotency(IdempotencyRepo postgresql.IdempotencyRepository, ttl time.Duration) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key := c.Request().Header.Get(HeaderIdempotencyKey)
+			if key == "" {
+				return next(c)
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				return echo.NewHTTPError(http.StatusBadRequest, "Idempotency-Key must be at most 255 characters")
+			}
+
+			scope := caller(c)
+			if scope != "" {
+				key = scope + ":" + key
+			}
+
+			requestHash, err := hashRequest(c, scope)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+			}
+
+			record, found, err := IdempotencyRepo.GetIdempotencyKey(key)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, "Failed to check idempotency key")
+			}
+			if found && time.Since(record.CreatedAt) < ttl {
+				return replay(c, record, requestHash)
+			}
+
+			reserved, err := IdempotencyRepo.ReserveIdempotencyKey(models.IdempotencyKey{
+				Key:         key,
+				RequestHash: requestHash,
+			}, ttl)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, "Failed to reserve idempotency key")
+			}
+			if !reserved {
+				return echo.NewHTTPError(http.StatusConflict, "A request with this Idempotency-Key is already in progress")
+			}
+
+			recorder := &responseRecorder{ResponseWriter: c.Response().Writer}
+			c.Response().Writer = recorder
+
+			err = next(c)
+
+			// Only successful responses are kept, a failed request can be retried with the same key
+			status := c.Response().Status
+			if err != nil || !c.Response().Committed || status < 200 || status >= 300 {
+				if err := IdempotencyRepo.DeleteIdempotencyKey(key); err != nil {
+					log.Printf("idempotency: failed to release key %s: %v", key, err)
+				}
+				return err
+			}
+
+			if err := IdempotencyRepo.CompleteIdempotencyKey(models.IdempotencyKey{
+				Key:          key,
+				RequestHash:  requestHash,
+				StatusCode:   status,
+				ContentType:  c.Response().Header().Get(echo.HeaderContentType),
+				ResponseBody: recorder.body.Bytes(),
+			}); err != nil {
+				log.Printf("idempotency: failed to store response of key %s: %v", key, err)
+			}
+			return nil
+		}
+	}
+}
+
+// replay answers a retried request from the stored record
+func replay(c echo.Context, record models.IdempotencyKey, requestHash string) error {
+	if record.RequestHash != requestHash {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, "Idempotency-Key was already used with a different request")
+	}
+	if !record.Completed {
+		return echo.NewHTTPError(http.StatusConflict, "A request with this Idempotency-Key is already in progress")
+	}
+
+	c.Response().Header().Set(HeaderIdempotentReplayed, "true")
+	return c.Blob(record.StatusCode, record.ContentType, record.ResponseBody)
+}
+
+// caller names who sent the request, the admin, the customer identified by CustomerSession or empty for a guest
+func caller(c echo.Context) string {
+	if admin := Admin(c); admin != "" {
+		return admin
+	}
+	if customerID := CustomerID(c); customerID != "" {
+		return "customer:" + customerID
+	}
+	return ""
+}
+
+// hashRequest fingerprints the caller, method, path with the query string and body, then restores the
+// body for the handler
+func hashRequest(c echo.Context, scope string) (string, error) {
+	req := c.Request()
+
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		if err != nil {
+			return "", err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(scope + "\n" + req.Method + " " + req.URL.RequestURI() + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// responseRecorder copies the response body while it is written to the client
+type responseRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
@@ -0,0 +1,112 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+
+	"smkdevid/echocommercehub/internal/configs"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Worker is a background job running until its context is cancelled
+type Worker func(ctx context.Context)
+
+// Server runs the HTTP server together with the background workers and stops them gracefully
+type Server struct {
+	Echo    *echo.Echo
+	Config  configs.ServerConfig
+	workers []Worker
+}
+
+// NewServer creates a new instance of Server
+func NewServer(e *echo.Echo, config configs.ServerConfig) *Server {
+	e.Server.ReadTimeout = config.ReadTimeout
+	e.Server.ReadHeaderTimeout = config.ReadHeaderTimeout
+	e.Server.WriteTimeout = config.WriteTimeout
+	e.Server.IdleTimeout = config.IdleTimeout
+	e.TLSServer.ReadTimeout = config.ReadTimeout
+	e.TLSServer.ReadHeaderTimeout = config.ReadHeaderTimeout
+	e.TLSServer.WriteTimeout = config.WriteTimeout
+	e.TLSServer.IdleTimeout = config.IdleTimeout
+
+	return &Server{
+		Echo:   e,
+		Config: config,
+	}
+}
+
+// AddWorker registers a background job started with the server
+func (s *Server) AddWorker(worker Worker) {
+	s.workers = append(s.workers, worker)
+}
+
+// Run serves until ctx is cancelled, then stops accepting connections, waits for the
+// in-flight requests and the workers, and gives up after the shutdown timeout
+func (s *Server) Run(ctx context.Context) error {
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	var workers sync.WaitGroup
+	for _, worker := range s.workers {
+		workers.Add(1)
+		go func(worker Worker) {
+			defer workers.Done()
+			worker(workerCtx)
+		}(worker)
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- s.start()
+	}()
+
+	select {
+	case err := <-serveErr:
+		// The server could not start, the workers have nothing to serve
+		stopWorkers()
+		workers.Wait()
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Printf("server: shutting down, draining requests for up to %s", s.Config.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.Config.ShutdownTimeout)
+	defer cancel()
+
+	shutdownErr := s.Echo.Shutdown(shutdownCtx)
+	stopWorkers()
+
+	drained := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+	case <-shutdownCtx.Done():
+		return errors.Join(shutdownErr, errors.New("background workers did not stop before the shutdown timeout"))
+	}
+
+	if err := <-serveErr; err != nil {
+		return errors.Join(shutdownErr, err)
+	}
+	return shutdownErr
+}
+
+func (s *Server) start() error {
+	var err error
+	if s.Config.TLSEnabled() {
+		err = s.Echo.StartTLS(s.Config.Address, s.Config.TLSCertFile, s.Config.TLSKeyFile)
+	} else {
+		err = s.Echo.Start(s.Config.Address)
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,39 @@
+package configs
+
+import (
+	"fmt"
+	"time"
+)
+
+// CartConfig controls how long carts live and how their totals are calculated
+type CartConfig struct {
+	GuestTTL        time.Duration `mapstructure:"GUEST_TTL"`
+	CustomerTTL     time.Duration `mapstructure:"CUSTOMER_TTL"`
+	TaxRate         float64       `mapstructure:"TAX_RATE"`
+	ShippingCost    int64         `mapstructure:"SHIPPING_COST"`
+	StackingMode    string        `mapstructure:"STACKING_MODE"`
+	CleanupInterval time.Duration `mapstructure:"CLEANUP_INTERVAL"`
+}
+
+func (c CartConfig) problems() []string {
+	var problems []string
+	if c.GuestTTL <= 0 {
+		problems = append(problems, "CART.GUEST_TTL must be greater than 0")
+	}
+	if c.CustomerTTL <= 0 {
+		problems = append(problems, "CART.CUSTOMER_TTL must be greater than 0")
+	}
+	if c.TaxRate < 0 || c.TaxRate >= 1 {
+		problems = append(problems, "CART.TAX_RATE must be between 0 and 1, e.g. 0.11 for 11%")
+	}
+	if c.ShippingCost < 0 {
+		problems = append(problems, "CART.SHIPPING_COST must be greater than or equal to 0")
+	}
+	if c.StackingMode != "priority" && c.StackingMode != "best_for_customer" {
+		problems = append(problems, fmt.Sprintf("CART.STACKING_MODE must be one of [priority best_for_customer], got %q", c.StackingMode))
+	}
+	if c.CleanupInterval <= 0 {
+		problems = append(problems, "CART.CLEANUP_INTERVAL must be greater than 0")
+	}
+	return problems
+}
@@ -1,9 +1,15 @@
 package configs
 
 import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
 	models "smkdevid/echocommercehub/internal/models/schema"
 
 	"github.com/jomei/notionapi"
+	"github.com/mitchellh/mapstructure"
 	"github.com/spf13/viper"
 )
 
@@ -15,12 +21,175 @@ type NotionServiceImpl struct {
 	NotionAuth *notionapi.Client
 }
 
-func LoadViperEnv() {
-	viper.AddConfigPath("/")
-	viper.SetConfigFile("env.yaml")
-	viper.AutomaticEnv()
+// EnvPrefix prefixes the environment variables overriding the config file,
+// e.g. ECHOCOMMERCEHUB_DATABASE_HOST overrides DATABASE.HOST
+const EnvPrefix = "ECHOCOMMERCEHUB"
+
+// DefaultConfigFile is read when no --config flag is given
+const DefaultConfigFile = "env.yaml"
+
+// Config holds every setting of the application, it is loaded once at startup
+// and passed to the constructors that need it
+type Config struct {
+	Database DatabaseConfig `mapstructure:"DATABASE"`
+	Server   ServerConfig   `mapstructure:"SERVER"`
+	Media    MediaConfig    `mapstructure:"MEDIA"`
+	Cart     CartConfig     `mapstructure:"CART"`
+	Payment  PaymentConfig  `mapstructure:"PAYMENT"`
+	Webhook  WebhookConfig  `mapstructure:"WEBHOOK"`
+	Notion   NotionConfig   `mapstructure:"NOTION"`
+	Supabase SupabaseConfig `mapstructure:"SUPABASE"`
+}
+
+// defaults lists every known key, so each of them can be overridden from the environment
+var defaults = map[string]interface{}{
+	"DATABASE.USER":                "",
+	"DATABASE.PASS":                "",
+	"DATABASE.HOST":                "",
+	"DATABASE.PORT":                5432,
+	"DATABASE.NAME":                "",
+	"DATABASE.SSL_MODE":            "disable",
+	"DATABASE.TIMEZONE":            "Asia/Jakarta",
+	"DATABASE.MAX_OPEN_CONNS":      25,
+	"DATABASE.MAX_IDLE_CONNS":      5,
+	"DATABASE.CONN_MAX_LIFETIME":   "30m",
+	"DATABASE.CONN_MAX_IDLE_TIME":  "5m",
+	"DATABASE.CONNECT_RETRIES":     5,
+	"DATABASE.CONNECT_BACKOFF":     "1s",
+	"DATABASE.CONNECT_MAX_BACKOFF": "30s",
+	"SERVER.ADDRESS":               ":8080",
+	"SERVER.READ_TIMEOUT":          "15s",
+	"SERVER.READ_HEADER_TIMEOUT":   "5s",
+	"SERVER.WRITE_TIMEOUT":         "30s",
+	"SERVER.IDLE_TIMEOUT":          "60s",
+	"SERVER.SHUTDOWN_TIMEOUT":      "30s",
+	"SERVER.TLS_CERT_FILE":         "",
+	"SERVER.TLS_KEY_FILE":          "",
+	"SERVER.ADMIN_TOKEN":           "",
+	"SERVER.TRUST_CUSTOMER_HEADER": false,
+	"SERVER.LEGACY_SUNSET":         "2027-04-30",
+	"MEDIA.STORAGE":                "local",
+	"MEDIA.LOCAL_DIR":              "uploads",
+	"MEDIA.BASE_URL":               "/media",
+	"MEDIA.MAX_IMAGE_SIZE":         10 << 20,
+	"MEDIA.MAX_VIDEO_SIZE":         100 << 20,
+	"MEDIA.CLEANUP_INTERVAL":       "1h",
+	"CART.GUEST_TTL":               "168h",
+	"CART.CUSTOMER_TTL":            "720h",
+	"CART.TAX_RATE":                0.11,
+	"CART.SHIPPING_COST":           0,
+	"CART.STACKING_MODE":           "best_for_customer",
+	"CART.CLEANUP_INTERVAL":        "15m",
+	"PAYMENT.PROVIDER":             "",
+	"PAYMENT.SERVER_KEY":           "",
+	"PAYMENT.BASE_URL":             "https://api.sandbox.midtrans.com",
+	"PAYMENT.CALLBACK_URL":         "",
+	"PAYMENT.TIMEOUT":              "30s",
+	"PAYMENT.EXPIRY":               "24h",
+	"PAYMENT.WEBHOOK_SECRET":       "",
+	"PAYMENT.DEV_SIMULATE":         false,
+	"WEBHOOK.MAX_ATTEMPTS":         8,
+	"WEBHOOK.RETRY_BACKOFF":        "30s",
+	"WEBHOOK.MAX_BACKOFF":          "1h",
+	"WEBHOOK.POLL_INTERVAL":        "10s",
+	"NOTION.AUTH":                  "",
+	"NOTION.ID":                    "",
+	"SUPABASE.URL":                 "",
+	"SUPABASE.KEY":                 "",
+	"SUPABASE.ROLE":                "",
+	"SUPABASE.JWT":                 "",
+}
+
+// Load reads the config file at path, applies the environment overrides and validates the result.
+// An empty path loads the config from the environment only.
+func Load(path string) (*Config, error) {
+	v := viper.New()
+	for key, value := range defaults {
+		v.SetDefault(key, value)
+	}
+
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+
+	if path != "" {
+		v.SetConfigFile(path)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+		}
+	}
+
+	// Dates are written as 2006-01-02, besides the durations and lists viper decodes by default
+	var config Config
+	if err := v.Unmarshal(&config, viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+		mapstructure.StringToTimeHookFunc(time.DateOnly),
+	))); err != nil {
+		return nil, fmt.Errorf("failed to decode config: %w", err)
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// ValidationError lists every missing or invalid key of the config
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid config:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Validate checks every section and reports all problems at once
+func (c Config) Validate() error {
+	var problems []string
+	problems = append(problems, c.Database.problems()...)
+	problems = append(problems, c.Server.problems()...)
+	problems = append(problems, c.Media.problems()...)
+	problems = append(problems, c.Cart.problems()...)
+	problems = append(problems, c.Payment.problems()...)
+	problems = append(problems, c.Webhook.problems()...)
+	problems = append(problems, c.Supabase.problems()...)
+	return validationError(problems)
+}
+
+func validationError(problems []string) error {
+	if len(problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: problems}
+}
+
+// NotionConfig is the Notion integration used for the promotion reports
+type NotionConfig struct {
+	Auth string `mapstructure:"AUTH"`
+	ID   string `mapstructure:"ID"`
+}
+
+// SupabaseConfig is the Supabase project used for authentication
+type SupabaseConfig struct {
+	URL  string `mapstructure:"URL"`
+	Key  string `mapstructure:"KEY"`
+	Role string `mapstructure:"ROLE"`
+	JWT  string `mapstructure:"JWT"`
+}
+
+// Supabase is optional, but once the URL is set the key is needed as well
+func (c SupabaseConfig) problems() []string {
+	if c.URL == "" {
+		return nil
+	}
 
-	if EnvException := viper.ReadInConfig(); EnvException != nil {
-		panic(EnvException)
+	var problems []string
+	if u, err := url.Parse(c.URL); err != nil || u.Scheme == "" || u.Host == "" {
+		problems = append(problems, "SUPABASE.URL must be an absolute URL")
+	}
+	if c.Key == "" {
+		problems = append(problems, "SUPABASE.KEY is required when SUPABASE.URL is set")
 	}
+	return problems
 }
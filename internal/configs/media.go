@@ -0,0 +1,57 @@
+package configs
+
+import (
+	"fmt"
+	"time"
+
+	"smkdevid/echocommercehub/pkg/storage"
+)
+
+// Values of MediaConfig.Storage
+const (
+	MediaStorageLocal = "local"
+)
+
+// MediaConfig controls where the product media are stored and how large they may be
+type MediaConfig struct {
+	Storage         string        `mapstructure:"STORAGE"`
+	LocalDir        string        `mapstructure:"LOCAL_DIR"`
+	BaseURL         string        `mapstructure:"BASE_URL"`
+	MaxImageSize    int64         `mapstructure:"MAX_IMAGE_SIZE"`
+	MaxVideoSize    int64         `mapstructure:"MAX_VIDEO_SIZE"`
+	CleanupInterval time.Duration `mapstructure:"CLEANUP_INTERVAL"`
+}
+
+func (c MediaConfig) problems() []string {
+	var problems []string
+	switch c.Storage {
+	case MediaStorageLocal:
+		if c.LocalDir == "" {
+			problems = append(problems, "MEDIA.LOCAL_DIR is required with the local storage")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("MEDIA.STORAGE must be one of [%s], got %q", MediaStorageLocal, c.Storage))
+	}
+	if c.BaseURL == "" {
+		problems = append(problems, "MEDIA.BASE_URL is required")
+	}
+	if c.MaxImageSize <= 0 {
+		problems = append(problems, "MEDIA.MAX_IMAGE_SIZE must be greater than 0")
+	}
+	if c.MaxVideoSize <= 0 {
+		problems = append(problems, "MEDIA.MAX_VIDEO_SIZE must be greater than 0")
+	}
+	if c.CleanupInterval <= 0 {
+		problems = append(problems, "MEDIA.CLEANUP_INTERVAL must be greater than 0")
+	}
+	return problems
+}
+
+// InitStorage opens the object storage of the product media
+func InitStorage(config MediaConfig) (storage.ObjectStorage, error) {
+	switch config.Storage {
+	case MediaStorageLocal:
+		return storage.NewLocalStorage(config.LocalDir, config.BaseURL)
+	}
+	return nil, fmt.Errorf("unknown media storage %q", config.Storage)
+}
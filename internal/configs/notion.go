@@ -4,21 +4,15 @@ import (
 	"context"
 
 	"github.com/jomei/notionapi"
-	"github.com/spf13/viper"
 )
 
-var (
-	NotionToken  = viper.GetString("NOTION.AUTH")
-	NotionPageID = viper.GetString("NOTION_ID")
-)
-
-func NotionInit() (*notionapi.Page, error) {
-	notion_client := notionapi.NewClient(notionapi.Token(NotionToken))
+func NotionInit(config NotionConfig) (*notionapi.Page, error) {
+	notion_client := notionapi.NewClient(notionapi.Token(config.Auth))
 
-	promotion_report, err := notion_client.Page.Get(context.Background(), notionapi.PageID(NotionPageID))
+	promotion_report, err := notion_client.Page.Get(context.Background(), notionapi.PageID(config.ID))
 
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	return promotion_report, nil
@@ -0,0 +1,65 @@
+package configs
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"smkdevid/echocommercehub/internal/services/products"
+)
+
+// PaymentConfig selects the payment provider and how long customers have to pay. The provider has no
+// default, a deployment must choose it. WebhookSecret signs the webhooks of the fake provider, Midtrans
+// signs them with the server key. DevSimulate serves the route settling fake payments, for development only.
+type PaymentConfig struct {
+	Provider      string        `mapstructure:"PROVIDER"`
+	ServerKey     string        `mapstructure:"SERVER_KEY"`
+	BaseURL       string        `mapstructure:"BASE_URL"`
+	CallbackURL   string        `mapstructure:"CALLBACK_URL"`
+	Timeout       time.Duration `mapstructure:"TIMEOUT"`
+	Expiry        time.Duration `mapstructure:"EXPIRY"`
+	WebhookSecret string        `mapstructure:"WEBHOOK_SECRET"`
+	DevSimulate   bool          `mapstructure:"DEV_SIMULATE"`
+}
+
+func (c PaymentConfig) problems() []string {
+	var problems []string
+	switch c.Provider {
+	case "":
+		problems = append(problems, "PAYMENT.PROVIDER is required")
+	case products.PaymentProviderFake:
+	case products.PaymentProviderMidtrans:
+		if c.ServerKey == "" {
+			problems = append(problems, "PAYMENT.SERVER_KEY is required with the midtrans provider")
+		}
+		if u, err := url.Parse(c.BaseURL); err != nil || u.Scheme == "" || u.Host == "" {
+			problems = append(problems, "PAYMENT.BASE_URL must be an absolute URL")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("PAYMENT.PROVIDER must be one of [%s %s], got %q",
+			products.PaymentProviderFake, products.PaymentProviderMidtrans, c.Provider))
+	}
+	if c.DevSimulate && c.Provider != products.PaymentProviderFake {
+		problems = append(problems, "PAYMENT.DEV_SIMULATE only works with the fake provider")
+	}
+	if c.Timeout <= 0 {
+		problems = append(problems, "PAYMENT.TIMEOUT must be greater than 0")
+	}
+	if c.Expiry < time.Minute {
+		problems = append(problems, "PAYMENT.EXPIRY must be at least 1m")
+	}
+	return problems
+}
+
+// InitPaymentProvider creates the provider the payments are charged through
+func InitPaymentProvider(config PaymentConfig) (products.PaymentProvider, error) {
+	switch config.Provider {
+	case products.PaymentProviderFake:
+		fake := products.NewFakeProvider()
+		fake.WebhookSecret = config.WebhookSecret
+		return fake, nil
+	case products.PaymentProviderMidtrans:
+		return products.NewMidtransProvider(config.ServerKey, config.BaseURL, config.CallbackURL, config.Timeout), nil
+	}
+	return nil, fmt.Errorf("unknown payment provider %q", config.Provider)
+}
@@ -1,34 +1,134 @@
 package configs
 
 import (
+	"context"
 	"fmt"
+	"log"
+	"time"
+
+	// Embed the time zone database, the runtime image does not ship one
+	_ "time/tzdata"
 
-	"github.com/spf13/viper"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-func InitDatabase() *gorm.DB {
-	dbUser := viper.GetString("DATABASE.USER")
-	dbPass := viper.GetString("DATABASE.PASS")
-	dbHost := viper.GetString("DATABASE.HOST")
-	dbPort := viper.GetString("DATABASE.PORT")
-	dbName := viper.GetString("DATABASE.NAME")
+// DatabaseConfig is the PostgreSQL connection and its pool
+type DatabaseConfig struct {
+	User     string `mapstructure:"USER"`
+	Pass     string `mapstructure:"PASS"`
+	Host     string `mapstructure:"HOST"`
+	Port     int    `mapstructure:"PORT"`
+	Name     string `mapstructure:"NAME"`
+	SSLMode  string `mapstructure:"SSL_MODE"`
+	TimeZone string `mapstructure:"TIMEZONE"`
+
+	MaxOpenConns    int           `mapstructure:"MAX_OPEN_CONNS"`
+	MaxIdleConns    int           `mapstructure:"MAX_IDLE_CONNS"`
+	ConnMaxLifetime time.Duration `mapstructure:"CONN_MAX_LIFETIME"`
+	ConnMaxIdleTime time.Duration `mapstructure:"CONN_MAX_IDLE_TIME"`
+
+	// Startup retries while the database is not reachable yet, the delay doubles up to ConnectMaxBackoff
+	ConnectRetries    int           `mapstructure:"CONNECT_RETRIES"`
+	ConnectBackoff    time.Duration `mapstructure:"CONNECT_BACKOFF"`
+	ConnectMaxBackoff time.Duration `mapstructure:"CONNECT_MAX_BACKOFF"`
+}
+
+func (c DatabaseConfig) problems() []string {
+	var problems []string
+	if c.User == "" {
+		problems = append(problems, "DATABASE.USER is required")
+	}
+	if c.Host == "" {
+		problems = append(problems, "DATABASE.HOST is required")
+	}
+	if c.Name == "" {
+		problems = append(problems, "DATABASE.NAME is required")
+	}
+	if c.Port <= 0 || c.Port > 65535 {
+		problems = append(problems, "DATABASE.PORT must be between 1 and 65535")
+	}
+
+	switch c.SSLMode {
+	case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		problems = append(problems, "DATABASE.SSL_MODE must be one of [disable allow prefer require verify-ca verify-full]")
+	}
+	if _, err := time.LoadLocation(c.TimeZone); err != nil || c.TimeZone == "" {
+		problems = append(problems, fmt.Sprintf("DATABASE.TIMEZONE %q is not a known time zone", c.TimeZone))
+	}
+
+	if c.MaxOpenConns < 0 {
+		problems = append(problems, "DATABASE.MAX_OPEN_CONNS must not be negative, 0 means unlimited")
+	}
+	if c.MaxIdleConns < 0 {
+		problems = append(problems, "DATABASE.MAX_IDLE_CONNS must not be negative")
+	}
+	if c.MaxOpenConns > 0 && c.MaxIdleConns > c.MaxOpenConns {
+		problems = append(problems, "DATABASE.MAX_IDLE_CONNS must not exceed DATABASE.MAX_OPEN_CONNS")
+	}
+	if c.ConnMaxLifetime < 0 || c.ConnMaxIdleTime < 0 {
+		problems = append(problems, "DATABASE.CONN_MAX_LIFETIME and DATABASE.CONN_MAX_IDLE_TIME must not be negative")
+	}
+
+	if c.ConnectRetries < 0 {
+		problems = append(problems, "DATABASE.CONNECT_RETRIES must not be negative")
+	}
+	if c.ConnectBackoff <= 0 || c.ConnectMaxBackoff < c.ConnectBackoff {
+		problems = append(problems, "DATABASE.CONNECT_BACKOFF must be greater than 0 and at most DATABASE.CONNECT_MAX_BACKOFF")
+	}
+	return problems
+}
+
+// DSN is the connection string of the database
+func (c DatabaseConfig) DSN() string {
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=%s TimeZone=%s",
+		c.Host, c.User, c.Pass, c.Name, c.Port, c.SSLMode, c.TimeZone)
+}
 
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=Asia/Jakarta",
-		dbHost, dbUser, dbPass, dbName, dbPort)
+// InitDatabase opens the PostgreSQL connection, retrying with exponential backoff while the
+// database is starting. The error tells why the database is unavailable.
+func InitDatabase(ctx context.Context, config DatabaseConfig) (*gorm.DB, error) {
+	backoff := config.ConnectBackoff
 
+	for attempt := 1; ; attempt++ {
+		DB, err := openDatabase(config)
+		if err == nil {
+			return DB, nil
+		}
+		if attempt > config.ConnectRetries {
+			return nil, fmt.Errorf("failed to connect to database %s at %s:%d after %d attempts: %w",
+				config.Name, config.Host, config.Port, attempt, err)
+		}
+
+		log.Printf("database: attempt %d failed, retrying in %s: %v", attempt, backoff, err)
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("gave up connecting to database %s: %w", config.Name, ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, config.ConnectMaxBackoff)
+	}
+}
+
+func openDatabase(config DatabaseConfig) (*gorm.DB, error) {
 	// Create a new database connection with proper error handling
-	DB, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	DB, err := gorm.Open(postgres.Open(config.DSN()), &gorm.Config{
+		// Translate driver errors such as unique violations into gorm.ErrDuplicatedKey
+		TranslateError: true,
+	})
 	if err != nil {
-		return nil
-		// fmt.Errorf("failed to connect to database: %w", err)
+		return nil, err
 	}
 
-	// Optionally run migrations using `migrations(DB)`
-	// migrations(DB)
-
-	// Set logging level (adjust as needed)
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(config.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(config.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(config.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(config.ConnMaxIdleTime)
 
-	return DB
+	return DB, nil
 }
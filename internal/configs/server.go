@@ -0,0 +1,71 @@
+package configs
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// MinAdminTokenLength keeps the admin token hard to guess
+const MinAdminTokenLength = 32
+
+// ServerConfig controls how the HTTP server listens and shuts down
+type ServerConfig struct {
+	Address           string        `mapstructure:"ADDRESS"`
+	ReadTimeout       time.Duration `mapstructure:"READ_TIMEOUT"`
+	ReadHeaderTimeout time.Duration `mapstructure:"READ_HEADER_TIMEOUT"`
+	WriteTimeout      time.Duration `mapstructure:"WRITE_TIMEOUT"`
+	IdleTimeout       time.Duration `mapstructure:"IDLE_TIMEOUT"`
+	ShutdownTimeout   time.Duration `mapstructure:"SHUTDOWN_TIMEOUT"`
+	TLSCertFile       string        `mapstructure:"TLS_CERT_FILE"`
+	TLSKeyFile        string        `mapstructure:"TLS_KEY_FILE"`
+
+	// AdminToken is the bearer token of the back office routes, they are not served without one
+	AdminToken string `mapstructure:"ADMIN_TOKEN"`
+
+	// TrustCustomerHeader accepts the X-Customer-ID header as the logged in customer. Only enable it behind
+	// a gateway that verifies the session and strips the header from client requests.
+	TrustCustomerHeader bool `mapstructure:"TRUST_CUSTOMER_HEADER"`
+
+	// LegacySunset is the date the unversioned API paths are removed
+	LegacySunset time.Time `mapstructure:"LEGACY_SUNSET"`
+}
+
+// TLSEnabled reports whether the server listens with TLS
+func (c ServerConfig) TLSEnabled() bool {
+	return c.TLSCertFile != "" || c.TLSKeyFile != ""
+}
+
+// Validate checks the config before the server starts, so a mistake fails at boot and not on the first request
+func (c ServerConfig) Validate() error {
+	return validationError(c.problems())
+}
+
+func (c ServerConfig) problems() []string {
+	var problems []string
+	if c.Address == "" {
+		problems = append(problems, "SERVER.ADDRESS is required")
+	}
+	if c.ShutdownTimeout <= 0 {
+		problems = append(problems, "SERVER.SHUTDOWN_TIMEOUT must be greater than 0")
+	}
+	if c.AdminToken != "" && len(c.AdminToken) < MinAdminTokenLength {
+		problems = append(problems, fmt.Sprintf("SERVER.ADMIN_TOKEN must be at least %d characters", MinAdminTokenLength))
+	}
+	if c.LegacySunset.IsZero() {
+		problems = append(problems, "SERVER.LEGACY_SUNSET is required")
+	}
+
+	if !c.TLSEnabled() {
+		return problems
+	}
+	if c.TLSCertFile == "" || c.TLSKeyFile == "" {
+		return append(problems, "SERVER.TLS_CERT_FILE and SERVER.TLS_KEY_FILE must be set together")
+	}
+	for _, file := range []string{c.TLSCertFile, c.TLSKeyFile} {
+		if _, err := os.Stat(file); err != nil {
+			problems = append(problems, fmt.Sprintf("TLS file %s is not readable: %v", file, err))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,30 @@
+package configs
+
+import (
+	"time"
+)
+
+// WebhookConfig controls how the received payment webhooks are processed and retried
+type WebhookConfig struct {
+	MaxAttempts  int           `mapstructure:"MAX_ATTEMPTS"`
+	RetryBackoff time.Duration `mapstructure:"RETRY_BACKOFF"`
+	MaxBackoff   time.Duration `mapstructure:"MAX_BACKOFF"`
+	PollInterval time.Duration `mapstructure:"POLL_INTERVAL"`
+}
+
+func (c WebhookConfig) problems() []string {
+	var problems []string
+	if c.MaxAttempts < 1 {
+		problems = append(problems, "WEBHOOK.MAX_ATTEMPTS must be at least 1")
+	}
+	if c.RetryBackoff <= 0 {
+		problems = append(problems, "WEBHOOK.RETRY_BACKOFF must be greater than 0")
+	}
+	if c.MaxBackoff < c.RetryBackoff {
+		problems = append(problems, "WEBHOOK.MAX_BACKOFF must be greater than or equal to WEBHOOK.RETRY_BACKOFF")
+	}
+	if c.PollInterval <= 0 {
+		problems = append(problems, "WEBHOOK.POLL_INTERVAL must be greater than 0")
+	}
+	return problems
+}
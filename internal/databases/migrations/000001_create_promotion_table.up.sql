@@ -0,0 +1,33 @@
+CREATE TABLE promotion_table (
+  id SERIAL PRIMARY KEY,
+  promotion_id VARCHAR(26) NOT NULL,
+  promotion_name VARCHAR(255) NOT NULL,
+  discount_type VARCHAR(50) NOT NULL,
+  discount_value NUMERIC(10,2) NOT NULL,
+  promotion_start_date TIMESTAMP WITH TIME ZONE NOT NULL,
+  promotion_end_date TIMESTAMP WITH TIME ZONE NOT null,
+  max_discount_amount NUMERIC(15,2) NOT NULL DEFAULT 0,
+  buy_quantity INTEGER NOT NULL DEFAULT 0,
+  get_quantity INTEGER NOT NULL DEFAULT 0,
+  discount_tiers JSONB NOT NULL DEFAULT '[]',
+  rules JSONB NOT NULL DEFAULT '{}',
+  priority INTEGER NOT NULL DEFAULT 0,
+  exclusive BOOLEAN NOT NULL DEFAULT FALSE,
+  stackable BOOLEAN NOT NULL DEFAULT FALSE,
+  status VARCHAR(20) NOT NULL DEFAULT 'scheduled',
+  version INTEGER NOT NULL DEFAULT 1,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX idx_promotion_table_promotion_id ON promotion_table (promotion_id);
+CREATE INDEX idx_promotion_table_status ON promotion_table (status);
+CREATE INDEX idx_promotion_table_deleted_at ON promotion_table (deleted_at);
+CREATE INDEX idx_promotion_table_discount_type ON promotion_table (LOWER(discount_type));
+CREATE INDEX idx_promotion_table_period ON promotion_table (promotion_start_date, promotion_end_date);
+CREATE INDEX idx_promotion_table_created_at ON promotion_table (created_at, id);
+
+-- Name search uses ILIKE '%...%', served by a trigram index
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX idx_promotion_table_name_trgm ON promotion_table USING GIN (promotion_name gin_trgm_ops);
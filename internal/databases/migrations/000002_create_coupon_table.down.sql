@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS coupon_redemption_table;
+DROP TABLE IF EXISTS coupon_table;
@@ -0,0 +1,24 @@
+CREATE TABLE coupon_table (
+  id SERIAL PRIMARY KEY,
+  coupon_code VARCHAR(64) NOT NULL UNIQUE,
+  promotion_id VARCHAR(26) NOT NULL,
+  max_redemptions INTEGER NOT NULL DEFAULT 1,
+  redemption_count INTEGER NOT NULL DEFAULT 0 CHECK (redemption_count <= max_redemptions),
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_coupon_table_promotion_id ON coupon_table (promotion_id);
+
+CREATE TABLE coupon_redemption_table (
+  id SERIAL PRIMARY KEY,
+  coupon_code VARCHAR(64) NOT NULL REFERENCES coupon_table (coupon_code),
+  promotion_id VARCHAR(26) NOT NULL,
+  customer_id VARCHAR(64) NOT NULL,
+  order_reference VARCHAR(64) NOT NULL,
+  redeemed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE (coupon_code, order_reference)
+);
+
+CREATE INDEX idx_promotion_customer ON coupon_redemption_table (promotion_id, customer_id);
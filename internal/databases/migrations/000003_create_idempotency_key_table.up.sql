@@ -0,0 +1,11 @@
+CREATE TABLE idempotency_key_table (
+  idempotency_key VARCHAR(255) PRIMARY KEY,
+  request_hash VARCHAR(64) NOT NULL,
+  status_code INTEGER NOT NULL DEFAULT 0,
+  content_type VARCHAR(255),
+  response_body BYTEA,
+  completed BOOLEAN NOT NULL DEFAULT FALSE,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_idempotency_key_table_created_at ON idempotency_key_table (created_at);
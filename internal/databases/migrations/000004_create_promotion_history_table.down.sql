@@ -0,0 +1 @@
+DROP TABLE IF EXISTS promotion_history_table;
@@ -0,0 +1,11 @@
+CREATE TABLE promotion_history_table (
+  id SERIAL PRIMARY KEY,
+  promotion_id VARCHAR(26) NOT NULL,
+  action VARCHAR(20) NOT NULL,
+  actor VARCHAR(255) NOT NULL,
+  before JSONB,
+  after JSONB,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_promotion_history_table_promotion_id ON promotion_history_table (promotion_id, created_at);
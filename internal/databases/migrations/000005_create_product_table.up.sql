@@ -0,0 +1,24 @@
+CREATE TABLE product_table (
+  id SERIAL PRIMARY KEY,
+  product_id VARCHAR(26) NOT NULL,
+  product_name VARCHAR(255) NOT NULL,
+  product_description TEXT NOT NULL DEFAULT '',
+  product_price NUMERIC(15,2) NOT NULL CHECK (product_price >= 0),
+  brand VARCHAR(100) NOT NULL DEFAULT '',
+  category_id VARCHAR(64) NOT NULL DEFAULT '',
+  images JSONB NOT NULL DEFAULT '[]',
+  status VARCHAR(20) NOT NULL DEFAULT 'draft',
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX idx_product_table_product_id ON product_table (product_id);
+CREATE INDEX idx_product_table_status ON product_table (status);
+CREATE INDEX idx_product_table_brand ON product_table (LOWER(brand));
+CREATE INDEX idx_product_table_category_id ON product_table (category_id);
+CREATE INDEX idx_product_table_deleted_at ON product_table (deleted_at);
+CREATE INDEX idx_product_table_created_at ON product_table (created_at, id);
+
+-- Name search uses ILIKE '%...%', served by a trigram index
+CREATE INDEX idx_product_table_name_trgm ON product_table USING GIN (product_name gin_trgm_ops);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS product_variant_table;
+DROP TABLE IF EXISTS product_option_table;
@@ -0,0 +1,32 @@
+CREATE TABLE product_option_table (
+  id SERIAL PRIMARY KEY,
+  product_id VARCHAR(26) NOT NULL,
+  name VARCHAR(50) NOT NULL,
+  option_values JSONB NOT NULL DEFAULT '[]',
+  position INTEGER NOT NULL DEFAULT 0,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE (product_id, name)
+);
+
+CREATE TABLE product_variant_table (
+  id SERIAL PRIMARY KEY,
+  variant_id VARCHAR(26) NOT NULL,
+  product_id VARCHAR(26) NOT NULL,
+  sku VARCHAR(64) NOT NULL,
+  options JSONB NOT NULL DEFAULT '{}',
+  option_key VARCHAR(512) NOT NULL,
+  price NUMERIC(15,2) CHECK (price >= 0),
+  barcode VARCHAR(64) NOT NULL DEFAULT '',
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX idx_product_variant_table_variant_id ON product_variant_table (variant_id);
+CREATE INDEX idx_product_variant_table_product_id ON product_variant_table (product_id);
+CREATE INDEX idx_product_variant_table_deleted_at ON product_variant_table (deleted_at);
+
+-- A deleted variant frees its SKU, barcode and option combination
+CREATE UNIQUE INDEX idx_product_variant_table_sku ON product_variant_table (sku) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX idx_product_variant_table_barcode ON product_variant_table (barcode) WHERE deleted_at IS NULL AND barcode <> '';
+CREATE UNIQUE INDEX idx_product_variant_table_option_key ON product_variant_table (product_id, option_key) WHERE deleted_at IS NULL;
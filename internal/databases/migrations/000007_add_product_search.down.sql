@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_product_table_brand_trgm;
+ALTER TABLE product_table DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE product_table DROP COLUMN IF EXISTS stock;
+ALTER TABLE product_table DROP COLUMN IF EXISTS tags;
@@ -0,0 +1,20 @@
+ALTER TABLE product_table
+  ADD COLUMN tags JSONB NOT NULL DEFAULT '[]',
+  ADD COLUMN stock INTEGER NOT NULL DEFAULT 0 CHECK (stock >= 0);
+
+-- Every text is indexed with the Indonesian and the English stemmer, so "sepatu lari" and "running shoes" both match.
+-- Weights rank a match on the name above the brand and tags, and those above the description.
+ALTER TABLE product_table ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+  setweight(to_tsvector('indonesian', product_name), 'A') ||
+  setweight(to_tsvector('english', product_name), 'A') ||
+  setweight(to_tsvector('simple', brand), 'B') ||
+  setweight(to_tsvector('indonesian', tags), 'B') ||
+  setweight(to_tsvector('english', tags), 'B') ||
+  setweight(to_tsvector('indonesian', product_description), 'C') ||
+  setweight(to_tsvector('english', product_description), 'C')
+) STORED;
+
+CREATE INDEX idx_product_table_search_vector ON product_table USING GIN (search_vector);
+
+-- Typo tolerant fallback when the full-text search finds nothing
+CREATE INDEX idx_product_table_brand_trgm ON product_table USING GIN (brand gin_trgm_ops);
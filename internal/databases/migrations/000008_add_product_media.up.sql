@@ -0,0 +1,24 @@
+CREATE TABLE product_media_table (
+  id SERIAL PRIMARY KEY,
+  media_id VARCHAR(26) NOT NULL,
+  product_id VARCHAR(26) NOT NULL,
+  variant_id VARCHAR(26) NOT NULL DEFAULT '',
+  kind VARCHAR(10) NOT NULL CHECK (kind IN ('image', 'video')),
+  storage_key VARCHAR(255) NOT NULL,
+  url VARCHAR(512) NOT NULL,
+  content_type VARCHAR(100) NOT NULL,
+  size BIGINT NOT NULL CHECK (size >= 0),
+  width INTEGER NOT NULL DEFAULT 0,
+  height INTEGER NOT NULL DEFAULT 0,
+  alt_text VARCHAR(255) NOT NULL DEFAULT '',
+  position INTEGER NOT NULL DEFAULT 0,
+  thumbnails JSONB NOT NULL DEFAULT '{}',
+  thumbnail_keys JSONB NOT NULL DEFAULT '{}',
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX idx_product_media_table_media_id ON product_media_table (media_id);
+CREATE INDEX idx_product_media_table_product_id ON product_media_table (product_id, position);
+CREATE INDEX idx_product_media_table_deleted_at ON product_media_table (deleted_at);
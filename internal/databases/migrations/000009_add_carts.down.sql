@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS cart_item_table;
+DROP TABLE IF EXISTS cart_table;
@@ -0,0 +1,30 @@
+CREATE TABLE cart_table (
+  id SERIAL PRIMARY KEY,
+  cart_id VARCHAR(26) NOT NULL,
+  token VARCHAR(64) NOT NULL DEFAULT '',
+  customer_id VARCHAR(255) NOT NULL DEFAULT '',
+  status VARCHAR(20) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'merged', 'checked_out', 'expired')),
+  expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX idx_cart_table_cart_id ON cart_table (cart_id);
+CREATE INDEX idx_cart_table_deleted_at ON cart_table (deleted_at);
+CREATE INDEX idx_cart_table_expires_at ON cart_table (expires_at) WHERE status = 'active';
+
+-- A shopper has at most one active cart
+CREATE UNIQUE INDEX idx_cart_table_token ON cart_table (token) WHERE status = 'active' AND token <> '' AND deleted_at IS NULL;
+CREATE UNIQUE INDEX idx_cart_table_customer_id ON cart_table (customer_id) WHERE status = 'active' AND customer_id <> '' AND deleted_at IS NULL;
+
+CREATE TABLE cart_item_table (
+  id SERIAL PRIMARY KEY,
+  cart_id VARCHAR(26) NOT NULL REFERENCES cart_table (cart_id) ON DELETE CASCADE,
+  product_id VARCHAR(26) NOT NULL,
+  variant_id VARCHAR(26) NOT NULL,
+  quantity INTEGER NOT NULL CHECK (quantity > 0),
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE (cart_id, variant_id)
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS order_transition_table;
+DROP TABLE IF EXISTS order_item_table;
+DROP TABLE IF EXISTS order_table;
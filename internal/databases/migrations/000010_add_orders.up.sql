@@ -0,0 +1,60 @@
+CREATE TABLE order_table (
+  id SERIAL PRIMARY KEY,
+  order_id VARCHAR(26) NOT NULL,
+  customer_id VARCHAR(255) NOT NULL,
+  cart_id VARCHAR(26) NOT NULL,
+  status VARCHAR(20) NOT NULL DEFAULT 'pending_payment'
+    CHECK (status IN ('pending_payment', 'paid', 'processing', 'shipped', 'delivered', 'cancelled', 'refunded')),
+  currency CHAR(3) NOT NULL DEFAULT 'IDR',
+  subtotal NUMERIC(15,2) NOT NULL,
+  item_discount NUMERIC(15,2) NOT NULL DEFAULT 0,
+  shipping NUMERIC(15,2) NOT NULL DEFAULT 0,
+  shipping_discount NUMERIC(15,2) NOT NULL DEFAULT 0,
+  total_discount NUMERIC(15,2) NOT NULL DEFAULT 0,
+  tax NUMERIC(15,2) NOT NULL DEFAULT 0,
+  grand_total NUMERIC(15,2) NOT NULL CHECK (grand_total >= 0),
+  promotions JSONB NOT NULL DEFAULT '[]',
+  shipping_address JSONB NOT NULL,
+  billing_address JSONB NOT NULL,
+  note TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX idx_order_table_order_id ON order_table (order_id);
+CREATE INDEX idx_order_table_customer_id ON order_table (customer_id, created_at);
+CREATE INDEX idx_order_table_status ON order_table (status);
+CREATE INDEX idx_order_table_deleted_at ON order_table (deleted_at);
+
+-- A cart is placed at most once
+CREATE UNIQUE INDEX idx_order_table_cart_id ON order_table (cart_id);
+
+CREATE TABLE order_item_table (
+  id SERIAL PRIMARY KEY,
+  order_id VARCHAR(26) NOT NULL REFERENCES order_table (order_id),
+  product_id VARCHAR(26) NOT NULL,
+  variant_id VARCHAR(26) NOT NULL,
+  product_name VARCHAR(255) NOT NULL,
+  sku VARCHAR(64) NOT NULL,
+  options JSONB NOT NULL DEFAULT '{}',
+  quantity INTEGER NOT NULL CHECK (quantity > 0),
+  unit_price NUMERIC(15,2) NOT NULL,
+  line_total NUMERIC(15,2) NOT NULL,
+  discount NUMERIC(15,2) NOT NULL DEFAULT 0,
+  total NUMERIC(15,2) NOT NULL
+);
+
+CREATE INDEX idx_order_item_table_order_id ON order_item_table (order_id);
+
+CREATE TABLE order_transition_table (
+  id SERIAL PRIMARY KEY,
+  order_id VARCHAR(26) NOT NULL REFERENCES order_table (order_id),
+  from_status VARCHAR(20) NOT NULL DEFAULT '',
+  to_status VARCHAR(20) NOT NULL,
+  actor VARCHAR(255) NOT NULL,
+  reason TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_order_transition_table_order_id ON order_transition_table (order_id, created_at);
@@ -0,0 +1,34 @@
+CREATE TABLE payment_table (
+  id SERIAL PRIMARY KEY,
+  payment_id VARCHAR(26) NOT NULL,
+  order_id VARCHAR(26) NOT NULL REFERENCES order_table (order_id),
+  provider VARCHAR(32) NOT NULL,
+  method VARCHAR(20) NOT NULL CHECK (method IN ('virtual_account', 'qris', 'ewallet')),
+  channel VARCHAR(32) NOT NULL DEFAULT '',
+  amount NUMERIC(15,2) NOT NULL CHECK (amount > 0),
+  refunded_amount NUMERIC(15,2) NOT NULL DEFAULT 0 CHECK (refunded_amount >= 0 AND refunded_amount <= amount),
+  currency CHAR(3) NOT NULL DEFAULT 'IDR',
+  status VARCHAR(20) NOT NULL DEFAULT 'pending'
+    CHECK (status IN ('pending', 'authorized', 'paid', 'failed', 'expired', 'voided', 'refunded')),
+  provider_reference VARCHAR(255) NOT NULL DEFAULT '',
+  instructions JSONB NOT NULL DEFAULT '{}',
+  failure_reason TEXT NOT NULL DEFAULT '',
+  expires_at TIMESTAMP WITH TIME ZONE,
+  paid_at TIMESTAMP WITH TIME ZONE,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX idx_payment_table_payment_id ON payment_table (payment_id);
+CREATE INDEX idx_payment_table_order_id ON payment_table (order_id, created_at);
+CREATE INDEX idx_payment_table_status ON payment_table (status);
+CREATE INDEX idx_payment_table_deleted_at ON payment_table (deleted_at);
+
+-- Notifications of the provider find the attempt by its reference
+CREATE UNIQUE INDEX idx_payment_table_provider_reference ON payment_table (provider, provider_reference)
+  WHERE provider_reference <> '';
+
+-- An order has at most one open attempt at a time
+CREATE UNIQUE INDEX idx_payment_table_open_order ON payment_table (order_id)
+  WHERE status IN ('pending', 'authorized');
@@ -0,0 +1,25 @@
+CREATE TABLE webhook_event_table (
+  id SERIAL PRIMARY KEY,
+  webhook_id VARCHAR(26) NOT NULL,
+  provider VARCHAR(32) NOT NULL,
+  event_id VARCHAR(255) NOT NULL,
+  event_type VARCHAR(64) NOT NULL DEFAULT '',
+  reference VARCHAR(255) NOT NULL DEFAULT '',
+  payload TEXT NOT NULL,
+  status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'processed', 'failed')),
+  attempts INTEGER NOT NULL DEFAULT 0,
+  last_error TEXT NOT NULL DEFAULT '',
+  next_attempt_at TIMESTAMP WITH TIME ZONE,
+  processed_at TIMESTAMP WITH TIME ZONE,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_webhook_event_table_webhook_id ON webhook_event_table (webhook_id);
+
+-- A redelivered event is stored once
+CREATE UNIQUE INDEX idx_webhook_event_table_provider_event ON webhook_event_table (provider, event_id);
+
+-- The processor picks the pending events that are due
+CREATE INDEX idx_webhook_event_table_due ON webhook_event_table (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_webhook_event_table_status ON webhook_event_table (status, created_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_order_table_promotions;
+ALTER TABLE order_table DROP COLUMN IF EXISTS coupon_code;
+ALTER TABLE cart_table DROP COLUMN IF EXISTS coupon_code;
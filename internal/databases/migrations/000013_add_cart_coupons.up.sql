@@ -0,0 +1,6 @@
+-- The coupon code a shopper entered on the cart, and the coupon redeemed by the order placed from it
+ALTER TABLE cart_table ADD COLUMN coupon_code VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE order_table ADD COLUMN coupon_code VARCHAR(64) NOT NULL DEFAULT '';
+
+-- Uses of a promotion by a customer are counted from the promotions of their orders
+CREATE INDEX idx_order_table_promotions ON order_table USING GIN (promotions jsonb_path_ops);
@@ -0,0 +1,2 @@
+DELETE FROM idempotency_key_table WHERE length(idempotency_key) > 255;
+ALTER TABLE idempotency_key_table ALTER COLUMN idempotency_key TYPE VARCHAR(255);
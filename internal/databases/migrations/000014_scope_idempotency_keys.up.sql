@@ -0,0 +1,2 @@
+-- Idempotency keys are stored with the caller they belong to in front of the key sent by the client
+ALTER TABLE idempotency_key_table ALTER COLUMN idempotency_key TYPE VARCHAR(512);
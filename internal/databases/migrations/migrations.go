@@ -0,0 +1,123 @@
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Files holds the migrations shipped with the binary
+//
+//go:embed *.sql
+var Files embed.FS
+
+// Dir is where the migrations live in the repository, `migrate create` writes new ones there
+const Dir = "internal/databases/migrations"
+
+// Migration is a versioned schema change, Down reverts Up
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// ID is the file prefix of the migration, e.g. 000001_create_promotion_table
+func (m Migration) ID() string {
+	return fmt.Sprintf("%06d_%s", m.Version, m.Name)
+}
+
+// fileName matches <version>_<name>.up.sql and <version>_<name>.down.sql
+var fileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+var migrationName = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// Load reads the migrations of fsys ordered by version, every migration needs both an up and a down file
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration file %s must be named <version>_<name>.up.sql or <version>_<name>.down.sql", entry.Name())
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration file %s: %w", entry.Name(), err)
+		}
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d is used by both %s and %s", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if strings.TrimSpace(migration.Up) == "" || strings.TrimSpace(migration.Down) == "" {
+			return nil, fmt.Errorf("migration %s needs a non empty up and down file", migration.ID())
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Create writes the up and down files of a new migration in dir, numbered after the latest one
+func Create(dir string, name string) (string, string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !migrationName.MatchString(name) {
+		return "", "", fmt.Errorf("migration name %q may only contain lowercase letters, digits and underscores", name)
+	}
+
+	migrations, err := Load(os.DirFS(dir))
+	if err != nil {
+		return "", "", err
+	}
+
+	next := Migration{Version: 1, Name: name}
+	if len(migrations) > 0 {
+		next.Version = migrations[len(migrations)-1].Version + 1
+	}
+
+	up := filepath.Join(dir, next.ID()+".up.sql")
+	down := filepath.Join(dir, next.ID()+".down.sql")
+	if err := os.WriteFile(up, []byte("-- "+next.ID()+" up\n"), 0o644); err != nil {
+		return "", "", err
+	}
+	if err := os.WriteFile(down, []byte("-- "+next.ID()+" down, reverts the up migration\n"), 0o644); err != nil {
+		return "", "", err
+	}
+	return up, down, nil
+}
@@ -0,0 +1,271 @@
+package migrations
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// HistoryTable records every applied migration
+const HistoryTable = "schema_migration_table"
+
+// lockKey is the advisory lock taken while a migration runs, so two instances never apply the same one
+const lockKey = 20240301
+
+// MigrationRecord is a row of the HistoryTable
+type MigrationRecord struct {
+	Version   int64     `gorm:"column:version;primarykey;autoIncrement:false"`
+	Name      string    `gorm:"column:name"`
+	AppliedAt time.Time `gorm:"column:applied_at"`
+}
+
+func (MigrationRecord) TableName() string {
+	return HistoryTable
+}
+
+// MigrationStatus tells whether a migration is applied to the database
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// SchemaBehindError is returned by CheckCurrent when migrations are still pending
+type SchemaBehindError struct {
+	Pending []Migration
+}
+
+func (e *SchemaBehindError) Error() string {
+	ids := make([]string, 0, len(e.Pending))
+	for _, migration := range e.Pending {
+		ids = append(ids, migration.ID())
+	}
+	return fmt.Sprintf("database schema is behind, %d pending migrations (%s), run `migrate up`", len(e.Pending), strings.Join(ids, ", "))
+}
+
+// Migrator applies and reverts the migrations, keeping track of them in the HistoryTable
+type Migrator struct {
+	DB         *gorm.DB
+	Migrations []Migration
+}
+
+// NewMigrator creates a new instance of Migrator
+func NewMigrator(DB *gorm.DB, Migrations []Migration) *Migrator {
+	return &Migrator{
+		DB:         DB,
+		Migrations: Migrations,
+	}
+}
+
+func (m *Migrator) ensureHistoryTable() error {
+	return m.DB.Exec(`CREATE TABLE IF NOT EXISTS ` + HistoryTable + ` (
+  version BIGINT PRIMARY KEY,
+  name VARCHAR(255) NOT NULL,
+  applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`).Error
+}
+
+func (m *Migrator) history() (map[int64]MigrationRecord, error) {
+	if err := m.ensureHistoryTable(); err != nil {
+		return nil, err
+	}
+
+	var records []MigrationRecord
+	if err := m.DB.Order("version").Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int64]MigrationRecord, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// Status lists every known migration and whether it is applied
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := m.history()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.Migrations))
+	for _, migration := range m.Migrations {
+		record, ok := applied[migration.Version]
+		statuses = append(statuses, MigrationStatus{
+			Migration: migration,
+			Applied:   ok,
+			AppliedAt: record.AppliedAt,
+		})
+	}
+	return statuses, nil
+}
+
+// Pending lists the migrations not applied yet, in the order they are applied
+func (m *Migrator) Pending() ([]Migration, error) {
+	statuses, err := m.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, status := range statuses {
+		if !status.Applied {
+			pending = append(pending, status.Migration)
+		}
+	}
+	return pending, nil
+}
+
+// CheckCurrent returns a SchemaBehindError when the database misses migrations of this binary
+func (m *Migrator) CheckCurrent() error {
+	pending, err := m.Pending()
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return &SchemaBehindError{Pending: pending}
+	}
+	return nil
+}
+
+// Up applies every pending migration, each one in its own transaction
+func (m *Migrator) Up() ([]Migration, error) {
+	pending, err := m.Pending()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, migration := range pending {
+		applied := false
+		err := m.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", lockKey).Error; err != nil {
+				return err
+			}
+
+			// Another instance may have applied it while this one waited for the lock
+			var count int64
+			if err := tx.Model(&MigrationRecord{}).Where("version = ?", migration.Version).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return nil
+			}
+
+			if err := tx.Exec(migration.Up).Error; err != nil {
+				return err
+			}
+			applied = true
+			return tx.Create(&MigrationRecord{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %s failed: %w", migration.ID(), err)
+		}
+		if applied {
+			done = append(done, migration)
+		}
+	}
+	return done, nil
+}
+
+// Down reverts the latest applied migrations, at most steps of them
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	if err := m.ensureHistoryTable(); err != nil {
+		return nil, err
+	}
+
+	known := make(map[int64]Migration, len(m.Migrations))
+	for _, migration := range m.Migrations {
+		known[migration.Version] = migration
+	}
+
+	var done []Migration
+	for i := 0; i < steps; i++ {
+		var reverted *Migration
+		err := m.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", lockKey).Error; err != nil {
+				return err
+			}
+
+			var latest MigrationRecord
+			if err := tx.Order("version DESC").First(&latest).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return nil
+				}
+				return err
+			}
+
+			migration, ok := known[latest.Version]
+			if !ok {
+				return fmt.Errorf("migration %06d_%s is applied but unknown to this binary", latest.Version, latest.Name)
+			}
+
+			if err := tx.Exec(migration.Down).Error; err != nil {
+				return fmt.Errorf("migration %s failed: %w", migration.ID(), err)
+			}
+			reverted = &migration
+			return tx.Delete(&MigrationRecord{}, "version = ?", latest.Version).Error
+		})
+		if err != nil {
+			return done, err
+		}
+		if reverted == nil {
+			break
+		}
+		done = append(done, *reverted)
+	}
+	return done, nil
+}
+
+// Run executes the migrate subcommand: up, down [steps] or status, and reports to out
+func Run(m *Migrator, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("usage: migrate up|down [steps]|status|create <name>")
+	}
+
+	switch args[0] {
+	case "up":
+		done, err := m.Up()
+		for _, migration := range done {
+			fmt.Fprintf(out, "applied %s\n", migration.ID())
+		}
+		if err == nil && len(done) == 0 {
+			fmt.Fprintln(out, "schema is up to date")
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("steps must be a positive number, got %q", args[1])
+			}
+			steps = n
+		}
+		done, err := m.Down(steps)
+		for _, migration := range done {
+			fmt.Fprintf(out, "reverted %s\n", migration.ID())
+		}
+		return err
+	case "status":
+		statuses, err := m.Status()
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			if status.Applied {
+				fmt.Fprintf(out, "applied  %s  %s\n", status.AppliedAt.Format(time.RFC3339), status.ID())
+			} else {
+				fmt.Fprintf(out, "pending  %-25s  %s\n", "", status.ID())
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown migrate command %q, expected up, down, status or create", args[0])
+}
@@ -0,0 +1,201 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	models "smkdevid/echocommercehub/internal/models/schema"
+	"smkdevid/echocommercehub/utils/exception"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CartRepository interface {
+	CreateCart(cart models.Cart) (models.Cart, error)
+	GetActiveCartbyToken(token string) (models.Cart, bool, error)
+	GetActiveCartbyCustomerID(customerID string) (models.Cart, bool, error)
+	SaveCartItem(item models.CartItem, expiresAt time.Time) error
+	DeleteCartItem(cartID string, variantID string, expiresAt time.Time) error
+	SetCartCoupon(cartID string, couponCode string, expiresAt time.Time) error
+	MergeCarts(from models.Cart, into models.Cart, maxQuantity uint, expiresAt time.Time) error
+	CloseCart(cartID string, status string) error
+	ExpireCarts(now time.Time) (int64, error)
+}
+
+type CartRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewCartRepository creates a new instance of CartRepository
+func NewCartRepository(db *gorm.DB) CartRepository {
+	return &CartRepositoryImpl{
+		db: db,
+	}
+}
+
+// CreateCart stores a new active cart. When a concurrent first request of the same shopper created
+// their active cart first, that cart is returned instead.
+func (r *CartRepositoryImpl) CreateCart(cart models.Cart) (models.Cart, error) {
+	if err := r.db.Omit("Items").Create(&cart).Error; err != nil {
+		if !errors.Is(err, gorm.ErrDuplicatedKey) {
+			return models.Cart{}, err
+		}
+
+		var existing models.Cart
+		var found bool
+		var readErr error
+		if cart.CustomerID != "" {
+			existing, found, readErr = r.GetActiveCartbyCustomerID(cart.CustomerID)
+		} else {
+			existing, found, readErr = r.GetActiveCartbyToken(cart.Token)
+		}
+		if readErr != nil {
+			return models.Cart{}, readErr
+		}
+		if !found {
+			return models.Cart{}, err
+		}
+		return existing, nil
+	}
+	if cart.Items == nil {
+		cart.Items = []models.CartItem{}
+	}
+	return cart, nil
+}
+
+// GetActiveCartbyToken throw the active guest cart of the cookie token with its items, found is false when there is none
+func (r *CartRepositoryImpl) GetActiveCartbyToken(token string) (models.Cart, bool, error) {
+	return r.activeCart("token = ?", token)
+}
+
+// GetActiveCartbyCustomerID throw the active cart of the customer with its items, found is false when there is none
+func (r *CartRepositoryImpl) GetActiveCartbyCustomerID(customerID string) (models.Cart, bool, error) {
+	return r.activeCart("customer_id = ?", customerID)
+}
+
+func (r *CartRepositoryImpl) activeCart(condition string, value string) (models.Cart, bool, error) {
+	var cart models.Cart
+	err := r.db.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).Where(condition, value).Where("status = ?", models.CartStatusActive).Take(&cart).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.Cart{}, false, nil
+	}
+	if err != nil {
+		return models.Cart{}, false, err
+	}
+	return cart, true, nil
+}
+
+// SaveCartItem sets the quantity of the variant in the cart, adding the line when it is missing,
+// and pushes back the expiry of the cart. A cart that was checked out, merged or expired meanwhile
+// is a conflict.
+func (r *CartRepositoryImpl) SaveCartItem(item models.CartItem, expiresAt time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := touchCart(tx, item.CartID, expiresAt); err != nil {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "cart_id"}, {Name: "variant_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"quantity", "updated_at"}),
+		}).Create(&item).Error
+	})
+}
+
+// DeleteCartItem removes the variant from the cart and pushes back the expiry of the cart
+func (r *CartRepositoryImpl) DeleteCartItem(cartID string, variantID string, expiresAt time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := touchCart(tx, cartID, expiresAt); err != nil {
+			return err
+		}
+		result := tx.Where("cart_id = ? AND variant_id = ?", cartID, variantID).Delete(&models.CartItem{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return &exception.CartItemNotFoundError{
+				Message:   "Cart Item Not Found",
+				VariantID: variantID,
+			}
+		}
+		return nil
+	})
+}
+
+// SetCartCoupon sets the coupon code of an active cart, an empty code removes the coupon
+func (r *CartRepositoryImpl) SetCartCoupon(cartID string, couponCode string, expiresAt time.Time) error {
+	return r.db.Model(&models.Cart{}).
+		Where("cart_id = ? AND status = ?", cartID, models.CartStatusActive).
+		Updates(map[string]interface{}{"coupon_code": couponCode, "expires_at": expiresAt}).Error
+}
+
+// MergeCarts moves the items of a guest cart into the customer cart in a single transaction. Quantities of
+// a variant in both carts are added up to maxQuantity, and the guest cart is closed as merged. The coupon
+// of the guest cart is kept when the customer cart has none.
+func (r *CartRepositoryImpl) MergeCarts(from models.Cart, into models.Cart, maxQuantity uint, expiresAt time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, item := range from.Items {
+			merged := models.CartItem{
+				CartID:    into.CartID,
+				ProductID: item.ProductID,
+				VariantID: item.VariantID,
+				Quantity:  item.Quantity,
+			}
+			err := tx.Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "cart_id"}, {Name: "variant_id"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{
+					"quantity":   gorm.Expr("LEAST(cart_item_table.quantity + EXCLUDED.quantity, ?)", maxQuantity),
+					"updated_at": gorm.Expr("EXCLUDED.updated_at"),
+				}),
+			}).Create(&merged).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		if from.CouponCode != "" && into.CouponCode == "" {
+			if err := tx.Model(&models.Cart{}).Where("cart_id = ?", into.CartID).Update("coupon_code", from.CouponCode).Error; err != nil {
+				return err
+			}
+		}
+
+		err := tx.Model(&models.Cart{}).
+			Where("cart_id = ? AND status = ?", from.CartID, models.CartStatusActive).
+			Update("status", models.CartStatusMerged).Error
+		if err != nil {
+			return err
+		}
+		return touchCart(tx, into.CartID, expiresAt)
+	})
+}
+
+// CloseCart moves an active cart to a final status, it can no longer be changed afterwards
+func (r *CartRepositoryImpl) CloseCart(cartID string, status string) error {
+	return r.db.Model(&models.Cart{}).
+		Where("cart_id = ? AND status = ?", cartID, models.CartStatusActive).
+		Update("status", status).Error
+}
+
+// ExpireCarts closes every active cart whose expiry passed and returns how many were closed
+func (r *CartRepositoryImpl) ExpireCarts(now time.Time) (int64, error) {
+	result := r.db.Model(&models.Cart{}).
+		Where("status = ? AND expires_at <= ?", models.CartStatusActive, now).
+		Update("status", models.CartStatusExpired)
+	return result.RowsAffected, result.Error
+}
+
+// touchCart pushes back the expiry of an active cart and locks it until the transaction ends, a cart
+// that is no longer active is a conflict
+func touchCart(tx *gorm.DB, cartID string, expiresAt time.Time) error {
+	result := tx.Model(&models.Cart{}).
+		Where("cart_id = ? AND status = ?", cartID, models.CartStatusActive).
+		Update("expires_at", expiresAt)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return &exception.ConflictError{Message: "Cart " + cartID + " is no longer active, read the cart again"}
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	models "smkdevid/echocommercehub/internal/models/schema"
+	"smkdevid/echocommercehub/utils/exception"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CouponRepository interface {
+	CreateCoupons(coupons []models.Coupon) ([]models.Coupon, error)
+	GetExistingCouponCodes(codes []string) ([]string, error)
+	GetCouponsbyPromotionID(promotionID string) ([]models.Coupon, error)
+	GetCouponbyCouponCode(couponCode string) (models.Coupon, error)
+	GetCouponPromotionIDs(promotionIDs []string) ([]string, error)
+	CountRedemptionsbyCustomer(promotionID string, customerID string) (uint, error)
+	FindRedemption(couponCode string, orderReference string) (models.CouponRedemption, bool, error)
+	RedeemCoupon(redemption models.CouponRedemption, maxUsesPerCustomer uint) (models.CouponRedemption, error)
+}
+
+type CouponRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewCouponRepository creates a new instance of CouponRepository
+func NewCouponRepository(db *gorm.DB) CouponRepository {
+	return &CouponRepositoryImpl{
+		db: db,
+	}
+}
+
+// CreateCoupons stores a batch of coupons in a single transaction
+func (r *CouponRepositoryImpl) CreateCoupons(coupons []models.Coupon) ([]models.Coupon, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		return tx.CreateInBatches(&coupons, 500).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return coupons, nil
+}
+
+// GetExistingCouponCodes returns which of the given codes are already taken
+func (r *CouponRepositoryImpl) GetExistingCouponCodes(codes []string) ([]string, error) {
+	var existing []string
+	if err := r.db.Unscoped().Model(&models.Coupon{}).Where("coupon_code IN ?", codes).Pluck("coupon_code", &existing).Error; err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+// GetCouponsbyPromotionID throw all coupons generated for the promotion
+func (r *CouponRepositoryImpl) GetCouponsbyPromotionID(promotionID string) ([]models.Coupon, error) {
+	var coupons []models.Coupon
+	if err := r.db.Where("promotion_id = ?", promotionID).Order("id").Find(&coupons).Error; err != nil {
+		return nil, err
+	}
+	return coupons, nil
+}
+
+// GetCouponbyCouponCode will throw data based on couponCode request
+func (r *CouponRepositoryImpl) GetCouponbyCouponCode(couponCode string) (models.Coupon, error) {
+	var coupon models.Coupon
+	if err := r.db.Where("coupon_code = ?", couponCode).Take(&coupon).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Coupon{}, &exception.CouponNotFoundError{
+				Message:    "Coupon Not Found",
+				CouponCode: couponCode,
+			}
+		}
+		return models.Coupon{}, err
+	}
+	return coupon, nil
+}
+
+// GetCouponPromotionIDs returns which of the given promotions were issued as coupons
+func (r *CouponRepositoryImpl) GetCouponPromotionIDs(promotionIDs []string) ([]string, error) {
+	var issued []string
+	if err := r.db.Model(&models.Coupon{}).Where("promotion_id IN ?", promotionIDs).Distinct().Pluck("promotion_id", &issued).Error; err != nil {
+		return nil, err
+	}
+	return issued, nil
+}
+
+// CountRedemptionsbyCustomer counts how many times the customer redeemed coupons of the promotion
+func (r *CouponRepositoryImpl) CountRedemptionsbyCustomer(promotionID string, customerID string) (uint, error) {
+	var count int64
+	if err := r.db.Model(&models.CouponRedemption{}).
+		Where("promotion_id = ? AND customer_id = ?", promotionID, customerID).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return uint(count), nil
+}
+
+// FindRedemption looks up the redemption of the coupon by an order, if any
+func (r *CouponRepositoryImpl) FindRedemption(couponCode string, orderReference string) (models.CouponRedemption, bool, error) {
+	var redemption models.CouponRedemption
+	err := r.db.Where("coupon_code = ? AND order_reference = ?", couponCode, orderReference).Take(&redemption).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.CouponRedemption{}, false, nil
+	}
+	if err != nil {
+		return models.CouponRedemption{}, false, err
+	}
+	return redemption, true, nil
+}
+
+// RedeemCoupon records the redemption and increments the coupon counter in one transaction.
+// The counter is only incremented while it is below the limit, so concurrent checkouts
+// can never redeem a coupon more often than allowed. When the promotion limits the uses
+// per customer, the promotion row is locked while the redemptions of the customer are
+// counted, so concurrent checkouts of one customer are checked one after the other.
+func (r *CouponRepositoryImpl) RedeemCoupon(redemption models.CouponRedemption, maxUsesPerCustomer uint) (models.CouponRedemption, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		return redeemCoupon(tx, &redemption, maxUsesPerCustomer)
+	})
+	if err != nil {
+		return models.CouponRedemption{}, err
+	}
+	return redemption, nil
+}
+
+// redeemCoupon redeems the coupon within the transaction, it is shared with the orders placed with a coupon
+func redeemCoupon(tx *gorm.DB, redemption *models.CouponRedemption, maxUsesPerCustomer uint) error {
+	var existing models.CouponRedemption
+	err := tx.Where("coupon_code = ? AND order_reference = ?", redemption.CouponCode, redemption.OrderReference).Take(&existing).Error
+	if err == nil {
+		// The order already redeemed this coupon, a retry must not count twice
+		*redemption = existing
+		return nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	if maxUsesPerCustomer > 0 {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("promotion_id = ?", redemption.PromotionID).Take(&models.Promotion{}).Error; err != nil {
+			return promotionNotFound(err, redemption.PromotionID)
+		}
+
+		var used int64
+		if err := tx.Model(&models.CouponRedemption{}).
+			Where("promotion_id = ? AND customer_id = ?", redemption.PromotionID, redemption.CustomerID).
+			Count(&used).Error; err != nil {
+			return err
+		}
+		if uint(used) >= maxUsesPerCustomer {
+			return &exception.CouponNotApplicableError{
+				Message:    fmt.Sprintf("Coupon Not Applicable: promotion can only be used %d time(s) per customer", maxUsesPerCustomer),
+				CouponCode: redemption.CouponCode,
+			}
+		}
+	}
+
+	result := tx.Model(&models.Coupon{}).
+		Where("coupon_code = ? AND redemption_count < max_redemptions", redemption.CouponCode).
+		UpdateColumn("redemption_count", gorm.Expr("redemption_count + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return &exception.CouponRedemptionLimitError{
+			Message:    "Coupon Redemption Limit Reached",
+			CouponCode: redemption.CouponCode,
+		}
+	}
+
+	if err := tx.Create(redemption).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			// A concurrent checkout of the same order redeemed the coupon first
+			return &exception.ConflictError{Message: "Coupon Already Redeemed for Order " + redemption.OrderReference}
+		}
+		return err
+	}
+	return nil
+}
+
+// releaseCoupons gives back the coupons redeemed for an order that was cancelled, within the transaction
+// of the cancellation, so the coupon and the uses of the customer count again
+func releaseCoupons(tx *gorm.DB, orderReference string) error {
+	var redemptions []models.CouponRedemption
+	if err := tx.Clauses(clause.Returning{}).Where("order_reference = ?", orderReference).Delete(&redemptions).Error; err != nil {
+		return err
+	}
+	for _, redemption := range redemptions {
+		if err := tx.Model(&models.Coupon{}).
+			Where("coupon_code = ? AND redemption_count > 0", redemption.CouponCode).
+			UpdateColumn("redemption_count", gorm.Expr("redemption_count - 1")).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+package database
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// PingDatabase checks that the database answers, it is used by the readiness endpoint
+func PingDatabase(db *gorm.DB) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	}
+}
@@ -0,0 +1,78 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	models "smkdevid/echocommercehub/internal/models/schema"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IdempotencyRepository interface {
+	GetIdempotencyKey(key string) (models.IdempotencyKey, bool, error)
+	ReserveIdempotencyKey(record models.IdempotencyKey, ttl time.Duration) (bool, error)
+	CompleteIdempotencyKey(record models.IdempotencyKey) error
+	DeleteIdempotencyKey(key string) error
+}
+
+type IdempotencyRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewIdempotencyRepository creates a new instance of IdempotencyRepository
+func NewIdempotencyRepository(db *gorm.DB) IdempotencyRepository {
+	return &IdempotencyRepositoryImpl{
+		db: db,
+	}
+}
+
+// GetIdempotencyKey throw the stored record of the key, the bool tells whether it exists
+func (r *IdempotencyRepositoryImpl) GetIdempotencyKey(key string) (models.IdempotencyKey, bool, error) {
+	var record models.IdempotencyKey
+	if err := r.db.Where("idempotency_key = ?", key).Take(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.IdempotencyKey{}, false, nil
+		}
+		return models.IdempotencyKey{}, false, err
+	}
+	return record, true, nil
+}
+
+// ReserveIdempotencyKey stores the key before the request is processed. It reports false
+// when another request already holds the key, keys older than ttl are released first.
+func (r *IdempotencyRepositoryImpl) ReserveIdempotencyKey(record models.IdempotencyKey, ttl time.Duration) (bool, error) {
+	var reserved bool
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("idempotency_key = ? AND created_at < ?", record.Key, time.Now().Add(-ttl)).
+			Delete(&models.IdempotencyKey{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+		if result.Error != nil {
+			return result.Error
+		}
+		reserved = result.RowsAffected > 0
+		return nil
+	})
+	return reserved, err
+}
+
+// CompleteIdempotencyKey stores the response sent for the key
+func (r *IdempotencyRepositoryImpl) CompleteIdempotencyKey(record models.IdempotencyKey) error {
+	return r.db.Model(&models.IdempotencyKey{}).
+		Where("idempotency_key = ?", record.Key).
+		Updates(map[string]interface{}{
+			"status_code":   record.StatusCode,
+			"content_type":  record.ContentType,
+			"response_body": record.ResponseBody,
+			"completed":     true,
+		}).Error
+}
+
+// DeleteIdempotencyKey releases the key so the request can be retried
+func (r *IdempotencyRepositoryImpl) DeleteIdempotencyKey(key string) error {
+	return r.db.Where("idempotency_key = ?", key).Delete(&models.IdempotencyKey{}).Error
+}
@@ -0,0 +1,90 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	models "smkdevid/echocommercehub/internal/models/schema"
+
+	"gorm.io/gorm"
+)
+
+// Pagination bounds of ListOrders
+const (
+	DefaultOrderLimit = 20
+	MaxOrderLimit     = 100
+)
+
+// OrderQuery filters and paginates the order list, newest orders first.
+// CustomerID restricts the list to the orders of one customer.
+type OrderQuery struct {
+	CustomerID string
+	Status     string
+	Limit      int
+	Offset     int
+}
+
+// OrderList is a page of orders with the total number of matching orders
+type OrderList struct {
+	Orders []models.Order
+	Total  int64
+}
+
+// Normalize fills the defaults of the query and checks its values
+func (q OrderQuery) Normalize() (OrderQuery, error) {
+	if q.Status != "" && !isOrderStatus(q.Status) {
+		return q, invalidQuery("status", "must be one of ["+strings.Join(models.OrderStatuses, " ")+"]")
+	}
+
+	if q.Limit == 0 {
+		q.Limit = DefaultOrderLimit
+	}
+	if q.Limit < 0 || q.Limit > MaxOrderLimit {
+		return q, invalidQuery("limit", fmt.Sprintf("must be between 1 and %d", MaxOrderLimit))
+	}
+	if q.Offset < 0 {
+		return q, invalidQuery("offset", "must be greater than or equal to 0")
+	}
+	return q, nil
+}
+
+// ListOrders throw a page of the orders matching the query with their items
+func (r *OrderRepositoryImpl) ListOrders(query OrderQuery) (OrderList, error) {
+	query, err := query.Normalize()
+	if err != nil {
+		return OrderList{}, err
+	}
+
+	filtered := r.db.Model(&models.Order{})
+	if query.CustomerID != "" {
+		filtered = filtered.Where("customer_id = ?", query.CustomerID)
+	}
+	if query.Status != "" {
+		filtered = filtered.Where("status = ?", query.Status)
+	}
+
+	var total int64
+	if err := filtered.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return OrderList{}, err
+	}
+
+	var orders []models.Order
+	if err := filtered.Session(&gorm.Session{}).
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("id")
+		}).
+		Order("created_at desc, id desc").
+		Limit(query.Limit).Offset(query.Offset).Find(&orders).Error; err != nil {
+		return OrderList{}, err
+	}
+	return OrderList{Orders: orders, Total: total}, nil
+}
+
+func isOrderStatus(status string) bool {
+	for _, s := range models.OrderStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,244 @@
+package database
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	models "smkdevid/echocommercehub/internal/models/schema"
+	"smkdevid/echocommercehub/utils/exception"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OrderRepository interface {
+	CreateOrder(order models.Order, actor string) (models.Order, error)
+	GetOrderbyOrderID(orderID string) (models.Order, error)
+	ListOrders(query OrderQuery) (OrderList, error)
+	TransitionOrder(orderID string, fromStatus string, toStatus string, actor string, reason string) (bool, error)
+	GetOrderTransitions(orderID string) ([]models.OrderTransition, error)
+	CountPromotionUsesbyCustomer(customerID string) (map[string]uint, error)
+	CountOrdersbyCustomer(customerID string) (int64, error)
+}
+
+type OrderRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewOrderRepository creates a new instance of OrderRepository
+func NewOrderRepository(db *gorm.DB) OrderRepository {
+	return &OrderRepositoryImpl{
+		db: db,
+	}
+}
+
+// CreateOrder stores the order with its items and checks out its cart in a single transaction.
+// A cart that is no longer active was placed or closed concurrently, the order is then rejected.
+// The uses per customer of the applied promotions are checked again while the promotions are
+// locked, a first order promotion is rejected when the customer placed another order meanwhile, and the
+// coupon of the order is redeemed in the same transaction.
+func (r *OrderRepositoryImpl) CreateOrder(order models.Order, actor string) (models.Order, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Cart{}).
+			Where("cart_id = ? AND status = ?", order.CartID, models.CartStatusActive).
+			Update("status", models.CartStatusCheckedOut)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return &exception.ConflictError{
+				Message: "Cart " + order.CartID + " was already checked out",
+			}
+		}
+
+		for _, applied := range order.Promotions {
+			if err := checkPromotionUses(tx, applied.PromotionID, order.CustomerID); err != nil {
+				return err
+			}
+		}
+		if order.CouponCode != "" {
+			if err := redeemOrderCoupon(tx, order); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Create(&order).Error; err != nil {
+			return err
+		}
+		return recordOrderTransition(tx, order.OrderID, "", order.Status, actor, "order placed")
+	})
+	if err != nil {
+		return models.Order{}, err
+	}
+	return order, nil
+}
+
+// GetOrderbyOrderID throw the order with its items
+func (r *OrderRepositoryImpl) GetOrderbyOrderID(orderID string) (models.Order, error) {
+	var order models.Order
+	err := r.db.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).Where("order_id = ?", orderID).Take(&order).Error
+	if err != nil {
+		return models.Order{}, orderNotFound(err, orderID)
+	}
+	return order, nil
+}
+
+// TransitionOrder moves the order to toStatus only if it is still in fromStatus and records the
+// transition, so concurrent changes of the same order never overwrite each other. A cancelled order
+// gives back the coupons it redeemed.
+func (r *OrderRepositoryImpl) TransitionOrder(orderID string, fromStatus string, toStatus string, actor string, reason string) (bool, error) {
+	updated := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Order{}).
+			Where("order_id = ? AND status = ?", orderID, fromStatus).
+			Update("status", toStatus)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		updated = true
+		if toStatus == models.OrderStatusCancelled {
+			if err := releaseCoupons(tx, orderID); err != nil {
+				return err
+			}
+		}
+		return recordOrderTransition(tx, orderID, fromStatus, toStatus, actor, reason)
+	})
+	if err != nil {
+		return false, err
+	}
+	return updated, nil
+}
+
+// GetOrderTransitions throw the status history of the order, oldest first
+func (r *OrderRepositoryImpl) GetOrderTransitions(orderID string) ([]models.OrderTransition, error) {
+	var transitions []models.OrderTransition
+	if err := r.db.Where("order_id = ?", orderID).Order("created_at, id").Find(&transitions).Error; err != nil {
+		return nil, err
+	}
+	return transitions, nil
+}
+
+// CountPromotionUsesbyCustomer counts the orders of the customer each promotion was applied to,
+// keyed by promotion ID. Cancelled orders did not use their promotions.
+func (r *OrderRepositoryImpl) CountPromotionUsesbyCustomer(customerID string) (map[string]uint, error) {
+	var rows []struct {
+		PromotionID string
+		Uses        uint
+	}
+	err := r.db.Raw(`SELECT applied->>'promotion_id' AS promotion_id, COUNT(*) AS uses
+		FROM order_table, jsonb_array_elements(promotions) AS applied
+		WHERE customer_id = ? AND status <> ? AND deleted_at IS NULL
+		GROUP BY 1`, customerID, models.OrderStatusCancelled).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	uses := make(map[string]uint, len(rows))
+	for _, row := range rows {
+		uses[row.PromotionID] = row.Uses
+	}
+	return uses, nil
+}
+
+// CountOrdersbyCustomer counts the orders of the customer that were not cancelled
+func (r *OrderRepositoryImpl) CountOrdersbyCustomer(customerID string) (int64, error) {
+	return countCustomerOrders(r.db, customerID)
+}
+
+func countCustomerOrders(db *gorm.DB, customerID string) (int64, error) {
+	var count int64
+	err := db.Model(&models.Order{}).
+		Where("customer_id = ? AND status <> ?", customerID, models.OrderStatusCancelled).
+		Count(&count).Error
+	return count, err
+}
+
+// checkPromotionUses locks the promotion and rejects the order when the customer already used it
+// as often as the promotion allows, or already has an order for a first order promotion
+func checkPromotionUses(tx *gorm.DB, promotionID string, customerID string) error {
+	var promo models.Promotion
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("promotion_id = ?", promotionID).Take(&promo).Error; err != nil {
+		return promotionNotFound(err, promotionID)
+	}
+	if promo.Rules.FirstOrderOnly {
+		orders, err := countCustomerOrders(tx, customerID)
+		if err != nil {
+			return err
+		}
+		if orders > 0 {
+			return &exception.ConflictError{
+				Message: fmt.Sprintf("Promotion %s only applies to the first order of a customer", promo.PromotionName),
+			}
+		}
+	}
+	if promo.Rules.MaxUsesPerCustomer == 0 {
+		return nil
+	}
+
+	// Orders whose promotions contain an entry with this promotion ID
+	applied, err := json.Marshal([]map[string]string{{"promotion_id": promotionID}})
+	if err != nil {
+		return err
+	}
+	var used int64
+	if err := tx.Model(&models.Order{}).
+		Where("customer_id = ? AND status <> ? AND promotions @> ?::jsonb", customerID, models.OrderStatusCancelled, string(applied)).
+		Count(&used).Error; err != nil {
+		return err
+	}
+	if uint(used) >= promo.Rules.MaxUsesPerCustomer {
+		return &exception.ConflictError{
+			Message: fmt.Sprintf("Promotion %s can only be used %d time(s) per customer", promo.PromotionName, promo.Rules.MaxUsesPerCustomer),
+		}
+	}
+	return nil
+}
+
+// redeemOrderCoupon redeems the coupon of the order, the order ID is the reference of the redemption
+func redeemOrderCoupon(tx *gorm.DB, order models.Order) error {
+	var coupon models.Coupon
+	if err := tx.Where("coupon_code = ?", order.CouponCode).Take(&coupon).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &exception.CouponNotFoundError{
+				Message:    "Coupon Not Found",
+				CouponCode: order.CouponCode,
+			}
+		}
+		return err
+	}
+
+	var promo models.Promotion
+	if err := tx.Where("promotion_id = ?", coupon.PromotionID).Take(&promo).Error; err != nil {
+		return promotionNotFound(err, coupon.PromotionID)
+	}
+	return redeemCoupon(tx, &models.CouponRedemption{
+		CouponCode:     coupon.CouponCode,
+		PromotionID:    coupon.PromotionID,
+		CustomerID:     order.CustomerID,
+		OrderReference: order.OrderID,
+	}, promo.Rules.MaxUsesPerCustomer)
+}
+
+func recordOrderTransition(tx *gorm.DB, orderID string, fromStatus string, toStatus string, actor string, reason string) error {
+	return tx.Create(&models.OrderTransition{
+		OrderID:    orderID,
+		FromStatus: fromStatus,
+		ToStatus:   toStatus,
+		Actor:      actor,
+		Reason:     reason,
+	}).Error
+}
+
+func orderNotFound(err error, orderID string) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &exception.OrderNotFoundError{
+			Message: "Order Not Found",
+			OrderID: orderID,
+		}
+	}
+	return err
+}
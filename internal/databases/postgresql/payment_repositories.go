@@ -0,0 +1,107 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/shopspring/decimal"
+
+	models "smkdevid/echocommercehub/internal/models/schema"
+	"smkdevid/echocommercehub/utils/exception"
+
+	"gorm.io/gorm"
+)
+
+type PaymentRepository interface {
+	CreatePayment(payment models.Payment) (models.Payment, error)
+	GetPaymentbyPaymentID(paymentID string) (models.Payment, error)
+	GetPaymentbyReference(provider string, reference string) (models.Payment, error)
+	GetPaymentsbyOrderID(orderID string) ([]models.Payment, error)
+	UpdatePayment(payment models.Payment, fromStatus string) (bool, error)
+	UpdateRefundedAmount(paymentID string, fromAmount decimal.Decimal, toAmount decimal.Decimal) (bool, error)
+}
+
+type PaymentRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewPaymentRepository creates a new instance of PaymentRepository
+func NewPaymentRepository(db *gorm.DB) PaymentRepository {
+	return &PaymentRepositoryImpl{
+		db: db,
+	}
+}
+
+// CreatePayment stores a new payment attempt, an order that already has an open attempt is a conflict
+func (r *PaymentRepositoryImpl) CreatePayment(payment models.Payment) (models.Payment, error) {
+	if err := r.db.Create(&payment).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return models.Payment{}, &exception.ConflictError{
+				Message: "Order " + payment.OrderID + " already has an open payment",
+			}
+		}
+		return models.Payment{}, err
+	}
+	return payment, nil
+}
+
+// GetPaymentbyPaymentID throw the payment attempt
+func (r *PaymentRepositoryImpl) GetPaymentbyPaymentID(paymentID string) (models.Payment, error) {
+	var payment models.Payment
+	if err := r.db.Where("payment_id = ?", paymentID).Take(&payment).Error; err != nil {
+		return models.Payment{}, paymentNotFound(err, paymentID)
+	}
+	return payment, nil
+}
+
+// GetPaymentbyReference throw the payment attempt the provider knows by the reference
+func (r *PaymentRepositoryImpl) GetPaymentbyReference(provider string, reference string) (models.Payment, error) {
+	var payment models.Payment
+	if err := r.db.Where("provider = ? AND provider_reference = ?", provider, reference).Take(&payment).Error; err != nil {
+		return models.Payment{}, paymentNotFound(err, reference)
+	}
+	return payment, nil
+}
+
+// GetPaymentsbyOrderID throw every payment attempt of the order, oldest first
+func (r *PaymentRepositoryImpl) GetPaymentsbyOrderID(orderID string) ([]models.Payment, error) {
+	var payments []models.Payment
+	if err := r.db.Where("order_id = ?", orderID).Order("created_at, id").Find(&payments).Error; err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+// UpdatePayment saves the outcome of the payment only if it is still in fromStatus, so a notification
+// and a manual action on the same attempt never overwrite each other
+func (r *PaymentRepositoryImpl) UpdatePayment(payment models.Payment, fromStatus string) (bool, error) {
+	result := r.db.Model(&models.Payment{}).
+		Where("payment_id = ? AND status = ?", payment.PaymentID, fromStatus).
+		Select("Status", "Reference", "Instructions", "RefundedAmount", "FailureReason", "ExpiresAt", "PaidAt").
+		Updates(&payment)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// UpdateRefundedAmount moves the refunded amount of a paid payment from fromAmount to toAmount only if
+// nobody changed it in the meantime, so two refunds of the same payment are never both given back
+func (r *PaymentRepositoryImpl) UpdateRefundedAmount(paymentID string, fromAmount decimal.Decimal, toAmount decimal.Decimal) (bool, error) {
+	result := r.db.Model(&models.Payment{}).
+		Where("payment_id = ? AND status = ? AND refunded_amount = ?", paymentID, models.PaymentStatusPaid, fromAmount).
+		Update("refunded_amount", toAmount)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func paymentNotFound(err error, paymentID string) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &exception.PaymentNotFoundError{
+			Message:   "Payment Not Found",
+			PaymentID: paymentID,
+		}
+	}
+	return err
+}
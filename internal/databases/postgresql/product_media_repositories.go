@@ -0,0 +1,133 @@
+package database
+
+import (
+	"errors"
+
+	models "smkdevid/echocommercehub/internal/models/schema"
+	"smkdevid/echocommercehub/utils/exception"
+
+	"gorm.io/gorm"
+)
+
+type ProductMediaRepository interface {
+	CreateMedia(media models.ProductMedia) (models.ProductMedia, error)
+	GetMediabyProductID(productID string, variantID string) ([]models.ProductMedia, error)
+	GetMediabyMediaID(productID string, mediaID string) (models.ProductMedia, error)
+	UpdateMedia(media models.ProductMedia) (models.ProductMedia, error)
+	ReorderMedia(productID string, mediaIDs []string) error
+	DeleteMedia(productID string, mediaID string) error
+	GetOrphanedMedia(limit int) ([]models.ProductMedia, error)
+	PurgeMedia(mediaID string) error
+}
+
+type ProductMediaRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewProductMediaRepository creates a new instance of ProductMediaRepository
+func NewProductMediaRepository(db *gorm.DB) ProductMediaRepository {
+	return &ProductMediaRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *ProductMediaRepositoryImpl) CreateMedia(media models.ProductMedia) (models.ProductMedia, error) {
+	if err := r.db.Create(&media).Error; err != nil {
+		return models.ProductMedia{}, err
+	}
+	return media, nil
+}
+
+// GetMediabyProductID throw the media of the product ordered by position. With a variantID only the
+// media of the product itself and of that variant are returned.
+func (r *ProductMediaRepositoryImpl) GetMediabyProductID(productID string, variantID string) ([]models.ProductMedia, error) {
+	query := r.db.Where("product_id = ?", productID)
+	if variantID != "" {
+		query = query.Where("variant_id IN ?", []string{"", variantID})
+	}
+
+	var media []models.ProductMedia
+	if err := query.Order("position, id").Find(&media).Error; err != nil {
+		return nil, err
+	}
+	return media, nil
+}
+
+// GetMediabyMediaID will throw the media only when it belongs to the product
+func (r *ProductMediaRepositoryImpl) GetMediabyMediaID(productID string, mediaID string) (models.ProductMedia, error) {
+	var media models.ProductMedia
+	if err := r.db.Where("product_id = ? AND media_id = ?", productID, mediaID).Take(&media).Error; err != nil {
+		return models.ProductMedia{}, mediaNotFound(err, mediaID)
+	}
+	return media, nil
+}
+
+// UpdateMedia saves the alt text and variant of the media
+func (r *ProductMediaRepositoryImpl) UpdateMedia(media models.ProductMedia) (models.ProductMedia, error) {
+	if err := r.db.Save(&media).Error; err != nil {
+		return models.ProductMedia{}, err
+	}
+	return media, nil
+}
+
+// ReorderMedia sets the position of every media of the product to its index in mediaIDs, in a single transaction
+func (r *ProductMediaRepositoryImpl) ReorderMedia(productID string, mediaIDs []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for position, mediaID := range mediaIDs {
+			result := tx.Model(&models.ProductMedia{}).
+				Where("product_id = ? AND media_id = ?", productID, mediaID).
+				Update("position", position)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return mediaNotFound(gorm.ErrRecordNotFound, mediaID)
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteMedia will soft delete the media, its files are removed by the media janitor
+func (r *ProductMediaRepositoryImpl) DeleteMedia(productID string, mediaID string) error {
+	result := r.db.Where("product_id = ? AND media_id = ?", productID, mediaID).Delete(&models.ProductMedia{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return mediaNotFound(gorm.ErrRecordNotFound, mediaID)
+	}
+	return nil
+}
+
+// GetOrphanedMedia throw the media whose files can be removed: deleted media, media of a deleted or missing
+// product and media of a deleted or missing variant
+func (r *ProductMediaRepositoryImpl) GetOrphanedMedia(limit int) ([]models.ProductMedia, error) {
+	var media []models.ProductMedia
+	err := r.db.Unscoped().
+		Where("deleted_at IS NOT NULL").
+		Or("NOT EXISTS (SELECT 1 FROM product_table p WHERE p.product_id = product_media_table.product_id AND p.deleted_at IS NULL)").
+		Or("variant_id <> '' AND NOT EXISTS (SELECT 1 FROM product_variant_table v WHERE v.variant_id = product_media_table.variant_id AND v.deleted_at IS NULL)").
+		Order("id").
+		Limit(limit).
+		Find(&media).Error
+	if err != nil {
+		return nil, err
+	}
+	return media, nil
+}
+
+// PurgeMedia removes the row of the media for good, once its files are gone
+func (r *ProductMediaRepositoryImpl) PurgeMedia(mediaID string) error {
+	return r.db.Unscoped().Where("media_id = ?", mediaID).Delete(&models.ProductMedia{}).Error
+}
+
+func mediaNotFound(err error, mediaID string) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &exception.MediaNotFoundError{
+			Message: "Media Not Found",
+			MediaID: mediaID,
+		}
+	}
+	return err
+}
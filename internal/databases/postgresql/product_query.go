@@ -0,0 +1,133 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	models "smkdevid/echocommercehub/internal/models/schema"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Pagination bounds of ListProducts
+const (
+	DefaultProductLimit = 20
+	MaxProductLimit     = 100
+)
+
+// productSortColumns are the columns products can be sorted by
+var productSortColumns = map[string]bool{
+	"created_at":    true,
+	"updated_at":    true,
+	"product_name":  true,
+	"product_price": true,
+}
+
+// ProductQuery filters, sorts and paginates the product list
+type ProductQuery struct {
+	Status     string
+	CategoryID string
+	Brand      string
+	Name       string
+	MinPrice   *decimal.Decimal
+	MaxPrice   *decimal.Decimal
+	SortBy     string
+	SortOrder  string
+	Limit      int
+	Offset     int
+}
+
+// ProductList is a page of products with the total number of matching products
+type ProductList struct {
+	Products []models.Product
+	Total    int64
+}
+
+// IsValidProductSortColumn reports whether products can be sorted by the column
+func IsValidProductSortColumn(column string) bool {
+	return productSortColumns[column]
+}
+
+// Normalize fills the defaults of the query and checks its values
+func (q ProductQuery) Normalize() (ProductQuery, error) {
+	if q.SortBy == "" {
+		q.SortBy = "created_at"
+	}
+	if !IsValidProductSortColumn(q.SortBy) {
+		return q, invalidQuery("sort", "cannot sort by "+q.SortBy)
+	}
+
+	q.SortOrder = strings.ToLower(q.SortOrder)
+	if q.SortOrder == "" {
+		q.SortOrder = SortDescending
+	}
+	if q.SortOrder != SortAscending && q.SortOrder != SortDescending {
+		return q, invalidQuery("order", "must be one of [asc desc]")
+	}
+
+	if q.Limit == 0 {
+		q.Limit = DefaultProductLimit
+	}
+	if q.Limit < 0 || q.Limit > MaxProductLimit {
+		return q, invalidQuery("limit", fmt.Sprintf("must be between 1 and %d", MaxProductLimit))
+	}
+	if q.Offset < 0 {
+		return q, invalidQuery("offset", "must be greater than or equal to 0")
+	}
+
+	if q.MinPrice != nil && q.MinPrice.IsNegative() {
+		return q, invalidQuery("min_price", "must be greater than or equal to 0")
+	}
+	if q.MinPrice != nil && q.MaxPrice != nil && q.MaxPrice.LessThan(*q.MinPrice) {
+		return q, invalidQuery("max_price", "must be greater than or equal to min_price")
+	}
+	return q, nil
+}
+
+// ListProducts throw a page of the products matching the query
+func (r *ProductRepositoryImpl) ListProducts(query ProductQuery) (ProductList, error) {
+	query, err := query.Normalize()
+	if err != nil {
+		return ProductList{}, err
+	}
+
+	filtered := r.filterProducts(query)
+
+	var total int64
+	if err := filtered.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return ProductList{}, err
+	}
+
+	var products []models.Product
+	if err := filtered.Session(&gorm.Session{}).
+		Order(fmt.Sprintf("%s %s, id %s", query.SortBy, query.SortOrder, query.SortOrder)).
+		Limit(query.Limit).Offset(query.Offset).Find(&products).Error; err != nil {
+		return ProductList{}, err
+	}
+	return ProductList{Products: products, Total: total}, nil
+}
+
+func (r *ProductRepositoryImpl) filterProducts(query ProductQuery) *gorm.DB {
+	tx := r.db.Model(&models.Product{})
+
+	if query.Status != "" {
+		tx = tx.Where("status = ?", query.Status)
+	}
+	if query.CategoryID != "" {
+		tx = tx.Where("category_id = ?", query.CategoryID)
+	}
+	if query.Brand != "" {
+		tx = tx.Where("LOWER(brand) = ?", strings.ToLower(query.Brand))
+	}
+	if query.Name != "" {
+		tx = tx.Where("product_name ILIKE ?", "%"+escapeLike(query.Name)+"%")
+	}
+	if query.MinPrice != nil {
+		tx = tx.Where("product_price >= ?", *query.MinPrice)
+	}
+	if query.MaxPrice != nil {
+		tx = tx.Where("product_price <= ?", *query.MaxPrice)
+	}
+	return tx
+}
@@ -0,0 +1,93 @@
+package database
+
+import (
+	"errors"
+
+	models "smkdevid/echocommercehub/internal/models/schema"
+	"smkdevid/echocommercehub/utils/exception"
+
+	"gorm.io/gorm"
+)
+
+type ProductRepository interface {
+	CreateProduct(product models.Product) (models.Product, error)
+	ListProducts(query ProductQuery) (ProductList, error)
+	SearchProducts(query ProductSearchQuery) (ProductSearchResult, error)
+	GetProductbyProductID(productID string) (models.Product, error)
+	UpdateProductbyProductID(product models.Product) (models.Product, error)
+	DeleteProductbyProductID(productID string) error
+}
+
+type ProductRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewProductRepository creates a new instance of ProductRepository
+func NewProductRepository(db *gorm.DB) ProductRepository {
+	return &ProductRepositoryImpl{
+		db: db,
+	}
+}
+
+// CreateProduct creates a new product in the database
+func (r *ProductRepositoryImpl) CreateProduct(product models.Product) (models.Product, error) {
+	if err := r.db.Create(&product).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return models.Product{}, &exception.ConflictError{
+				Message: "Product Already Exists with Product ID " + product.ProductID,
+			}
+		}
+		return models.Product{}, err
+	}
+	return product, nil
+}
+
+// GetProductbyProductID will throw data based on productID request
+func (r *ProductRepositoryImpl) GetProductbyProductID(productID string) (models.Product, error) {
+	var product models.Product
+	if err := r.db.Where("product_id = ?", productID).Take(&product).Error; err != nil {
+		return models.Product{}, productNotFound(err, productID)
+	}
+	return product, nil
+}
+
+// UpdateProductbyProductID will update data based on productID request
+func (r *ProductRepositoryImpl) UpdateProductbyProductID(product models.Product) (models.Product, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var existingProduct models.Product
+		if err := tx.Where("product_id = ?", product.ProductID).Take(&existingProduct).Error; err != nil {
+			return productNotFound(err, product.ProductID)
+		}
+
+		product.ID = existingProduct.ID
+		product.CreatedAt = existingProduct.CreatedAt
+		product.DeletedAt = existingProduct.DeletedAt
+		return tx.Save(&product).Error
+	})
+	if err != nil {
+		return models.Product{}, err
+	}
+	return product, nil
+}
+
+// DeleteProductbyProductID will soft delete data based on productID request
+func (r *ProductRepositoryImpl) DeleteProductbyProductID(productID string) error {
+	result := r.db.Where("product_id = ?", productID).Delete(&models.Product{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return productNotFound(gorm.ErrRecordNotFound, productID)
+	}
+	return nil
+}
+
+func productNotFound(err error, productID string) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &exception.ProductIDNotFoundError{
+			Message:   "Product Not Found",
+			ProductID: productID,
+		}
+	}
+	return err
+}
@@ -0,0 +1,308 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	models "smkdevid/echocommercehub/internal/models/schema"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Values of ProductSearchQuery.Availability
+const (
+	AvailabilityInStock    = "in_stock"
+	AvailabilityOutOfStock = "out_of_stock"
+)
+
+// FuzzySimilarityThreshold is the minimum trigram word similarity of a typo tolerant match
+const FuzzySimilarityThreshold = 0.3
+
+// maxFacetValues caps the category and brand facets to their most common values
+const maxFacetValues = 20
+
+// searchTSQuery matches the words in the Indonesian or the English stemming, both use the websearch syntax
+const searchTSQuery = "(websearch_to_tsquery('indonesian', @q) || websearch_to_tsquery('english', @q))"
+
+// PriceBand is a price range of the price facet, Max is exclusive and nil for the last band
+type PriceBand struct {
+	Key string
+	Min decimal.Decimal
+	Max *decimal.Decimal
+}
+
+// PriceBands are the ranges of the price facet, in rupiah
+var PriceBands = newPriceBands(50000, 100000, 250000, 500000, 1000000)
+
+func newPriceBands(bounds ...int64) []PriceBand {
+	bands := make([]PriceBand, 0, len(bounds)+1)
+	min := decimal.Zero
+	for _, bound := range bounds {
+		max := decimal.NewFromInt(bound)
+		bands = append(bands, PriceBand{Key: min.String() + "-" + max.String(), Min: min, Max: &max})
+		min = max
+	}
+	return append(bands, PriceBand{Key: min.String() + "+", Min: min})
+}
+
+// ProductSearchQuery searches the active products. Text is optional, without it the products are
+// only filtered and listed newest first.
+type ProductSearchQuery struct {
+	Text         string
+	CategoryID   string
+	Brand        string
+	MinPrice     *decimal.Decimal
+	MaxPrice     *decimal.Decimal
+	Availability string
+	Limit        int
+	Offset       int
+}
+
+// FacetCount is the number of matching products with a facet value
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// ProductFacets counts the matching products by facet. Each facet ignores its own filter,
+// so the other values of a filtered facet are still offered.
+type ProductFacets struct {
+	Category     []FacetCount `json:"category"`
+	Brand        []FacetCount `json:"brand"`
+	PriceBand    []FacetCount `json:"price_band"`
+	Availability []FacetCount `json:"availability"`
+}
+
+// ProductSearchHit is a matching product with its relevance, higher ranks first
+type ProductSearchHit struct {
+	models.Product
+	Rank float64 `json:"rank"`
+}
+
+// ProductSearchResult is a page of search hits. Fuzzy tells that the full-text search found nothing
+// and the hits come from the typo tolerant trigram match.
+type ProductSearchResult struct {
+	Hits   []ProductSearchHit
+	Total  int64
+	Facets ProductFacets
+	Fuzzy  bool
+}
+
+// Normalize fills the defaults of the query and checks its values
+func (q ProductSearchQuery) Normalize() (ProductSearchQuery, error) {
+	q.Text = strings.TrimSpace(q.Text)
+	if len(q.Text) > 200 {
+		return q, invalidQuery("q", "must be at most 200 characters")
+	}
+
+	if q.Availability != "" && q.Availability != AvailabilityInStock && q.Availability != AvailabilityOutOfStock {
+		return q, invalidQuery("availability", "must be one of [in_stock out_of_stock]")
+	}
+
+	if q.Limit == 0 {
+		q.Limit = DefaultProductLimit
+	}
+	if q.Limit < 0 || q.Limit > MaxProductLimit {
+		return q, invalidQuery("limit", fmt.Sprintf("must be between 1 and %d", MaxProductLimit))
+	}
+	if q.Offset < 0 {
+		return q, invalidQuery("offset", "must be greater than or equal to 0")
+	}
+
+	if q.MinPrice != nil && q.MinPrice.IsNegative() {
+		return q, invalidQuery("min_price", "must be greater than or equal to 0")
+	}
+	if q.MinPrice != nil && q.MaxPrice != nil && q.MaxPrice.LessThan(*q.MinPrice) {
+		return q, invalidQuery("max_price", "must be greater than or equal to min_price")
+	}
+	return q, nil
+}
+
+// SearchProducts ranks the active products matching the text and counts the facets.
+// When the full-text search has no match the trigram similarity of the name and brand is used instead.
+func (r *ProductRepositoryImpl) SearchProducts(query ProductSearchQuery) (ProductSearchResult, error) {
+	query, err := query.Normalize()
+	if err != nil {
+		return ProductSearchResult{}, err
+	}
+
+	result := ProductSearchResult{}
+	result.Total, err = r.countSearch(query, false)
+	if err != nil {
+		return ProductSearchResult{}, err
+	}
+
+	if result.Total == 0 && query.Text != "" {
+		result.Fuzzy = true
+		if result.Total, err = r.countSearch(query, true); err != nil {
+			return ProductSearchResult{}, err
+		}
+	}
+
+	if result.Hits, err = r.searchHits(query, result.Fuzzy); err != nil {
+		return ProductSearchResult{}, err
+	}
+	if result.Facets, err = r.searchFacets(query, result.Fuzzy); err != nil {
+		return ProductSearchResult{}, err
+	}
+	return result, nil
+}
+
+// searchBase is the active products matching the text and every filter but the excluded facet
+func (r *ProductRepositoryImpl) searchBase(query ProductSearchQuery, fuzzy bool, exclude string) *gorm.DB {
+	tx := r.db.Model(&models.Product{}).Where("status = ?", models.ProductStatusActive)
+
+	switch {
+	case query.Text == "":
+	case fuzzy:
+		tx = tx.Where("(word_similarity(@q, product_name) >= @threshold OR word_similarity(@q, brand) >= @threshold)",
+			map[string]interface{}{"q": query.Text, "threshold": FuzzySimilarityThreshold})
+	default:
+		tx = tx.Where("search_vector @@ "+searchTSQuery, map[string]interface{}{"q": query.Text})
+	}
+
+	if query.CategoryID != "" && exclude != "category" {
+		tx = tx.Where("category_id = ?", query.CategoryID)
+	}
+	if query.Brand != "" && exclude != "brand" {
+		tx = tx.Where("LOWER(brand) = ?", strings.ToLower(query.Brand))
+	}
+	if exclude != "price_band" {
+		if query.MinPrice != nil {
+			tx = tx.Where("product_price >= ?", *query.MinPrice)
+		}
+		if query.MaxPrice != nil {
+			tx = tx.Where("product_price <= ?", *query.MaxPrice)
+		}
+	}
+	if exclude != "availability" {
+		switch query.Availability {
+		case AvailabilityInStock:
+			tx = tx.Where("stock > 0")
+		case AvailabilityOutOfStock:
+			tx = tx.Where("stock = 0")
+		}
+	}
+	return tx
+}
+
+func (r *ProductRepositoryImpl) countSearch(query ProductSearchQuery, fuzzy bool) (int64, error) {
+	var total int64
+	err := r.searchBase(query, fuzzy, "").Count(&total).Error
+	return total, err
+}
+
+// searchHits loads the page of products in relevance order
+func (r *ProductRepositoryImpl) searchHits(query ProductSearchQuery, fuzzy bool) ([]ProductSearchHit, error) {
+	// Without text every product is as relevant, the newest come first
+	selectRank := r.searchBase(query, fuzzy, "").Select("id, 0 AS rank")
+	switch {
+	case query.Text == "":
+	case fuzzy:
+		selectRank = selectRank.Select("id, GREATEST(word_similarity(@q, product_name), word_similarity(@q, brand)) AS rank",
+			map[string]interface{}{"q": query.Text})
+	default:
+		selectRank = selectRank.Select("id, ts_rank_cd(search_vector, "+searchTSQuery+") AS rank",
+			map[string]interface{}{"q": query.Text})
+	}
+
+	var ranked []struct {
+		ID   uint
+		Rank float64
+	}
+	err := selectRank.
+		Order("rank DESC, created_at DESC, id DESC").
+		Limit(query.Limit).Offset(query.Offset).
+		Scan(&ranked).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(ranked) == 0 {
+		return []ProductSearchHit{}, nil
+	}
+
+	ids := make([]uint, 0, len(ranked))
+	for _, row := range ranked {
+		ids = append(ids, row.ID)
+	}
+	var products []models.Product
+	if err := r.db.Where("id IN ?", ids).Find(&products).Error; err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uint]models.Product, len(products))
+	for _, product := range products {
+		byID[product.ID] = product
+	}
+	hits := make([]ProductSearchHit, 0, len(ranked))
+	for _, row := range ranked {
+		if product, ok := byID[row.ID]; ok {
+			hits = append(hits, ProductSearchHit{Product: product, Rank: row.Rank})
+		}
+	}
+	return hits, nil
+}
+
+func (r *ProductRepositoryImpl) searchFacets(query ProductSearchQuery, fuzzy bool) (ProductFacets, error) {
+	var facets ProductFacets
+	var err error
+
+	if facets.Category, err = r.facetCounts(query, fuzzy, "category", "category_id"); err != nil {
+		return facets, err
+	}
+	if facets.Brand, err = r.facetCounts(query, fuzzy, "brand", "brand"); err != nil {
+		return facets, err
+	}
+	if facets.PriceBand, err = r.facetCounts(query, fuzzy, "price_band", priceBandExpression()); err != nil {
+		return facets, err
+	}
+	if facets.Availability, err = r.facetCounts(query, fuzzy, "availability",
+		fmt.Sprintf("CASE WHEN stock > 0 THEN '%s' ELSE '%s' END", AvailabilityInStock, AvailabilityOutOfStock)); err != nil {
+		return facets, err
+	}
+
+	facets.PriceBand = orderPriceBands(facets.PriceBand)
+	return facets, nil
+}
+
+// facetCounts groups the matching products by the expression, most common value first
+func (r *ProductRepositoryImpl) facetCounts(query ProductSearchQuery, fuzzy bool, facet string, expression string) ([]FacetCount, error) {
+	counts := []FacetCount{}
+	err := r.searchBase(query, fuzzy, facet).
+		Select(expression + " AS value, COUNT(*) AS count").
+		Where(expression + " <> ''").
+		Group(expression).
+		Order("count DESC, value").
+		Limit(maxFacetValues).
+		Scan(&counts).Error
+	return counts, err
+}
+
+// priceBandExpression maps product_price to the key of its PriceBands entry
+func priceBandExpression() string {
+	var b strings.Builder
+	b.WriteString("CASE")
+	for _, band := range PriceBands {
+		if band.Max == nil {
+			fmt.Fprintf(&b, " ELSE '%s'", band.Key)
+			continue
+		}
+		fmt.Fprintf(&b, " WHEN product_price < %s THEN '%s'", band.Max.String(), band.Key)
+	}
+	b.WriteString(" END")
+	return b.String()
+}
+
+// orderPriceBands lists the price bands from cheap to expensive instead of by count
+func orderPriceBands(counts []FacetCount) []FacetCount {
+	position := make(map[string]int, len(PriceBands))
+	for i, band := range PriceBands {
+		position[band.Key] = i
+	}
+	sort.SliceStable(counts, func(i, j int) bool {
+		return position[counts[i].Value] < position[counts[j].Value]
+	})
+	return counts
+}
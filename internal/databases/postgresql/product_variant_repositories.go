@@ -0,0 +1,133 @@
+package database
+
+import (
+	"errors"
+
+	models "smkdevid/echocommercehub/internal/models/schema"
+	"smkdevid/echocommercehub/utils/exception"
+
+	"gorm.io/gorm"
+)
+
+type ProductVariantRepository interface {
+	SaveProductOptions(productID string, options []models.ProductOption) ([]models.ProductOption, error)
+	GetProductOptions(productID string) ([]models.ProductOption, error)
+	CreateVariants(variants []models.ProductVariant) ([]models.ProductVariant, error)
+	GetVariantsbyProductID(productID string) ([]models.ProductVariant, error)
+	GetVariantbyVariantID(productID string, variantID string) (models.ProductVariant, error)
+	UpdateVariant(variant models.ProductVariant) (models.ProductVariant, error)
+	DeleteVariant(productID string, variantID string) error
+}
+
+type ProductVariantRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewProductVariantRepository creates a new instance of ProductVariantRepository
+func NewProductVariantRepository(db *gorm.DB) ProductVariantRepository {
+	return &ProductVariantRepositoryImpl{
+		db: db,
+	}
+}
+
+// SaveProductOptions replaces the option types of the product
+func (r *ProductVariantRepositoryImpl) SaveProductOptions(productID string, options []models.ProductOption) ([]models.ProductOption, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("product_id = ?", productID).Delete(&models.ProductOption{}).Error; err != nil {
+			return err
+		}
+		if len(options) == 0 {
+			return nil
+		}
+		return tx.Create(&options).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return options, nil
+}
+
+// GetProductOptions throw the option types of the product ordered by position
+func (r *ProductVariantRepositoryImpl) GetProductOptions(productID string) ([]models.ProductOption, error) {
+	var options []models.ProductOption
+	if err := r.db.Where("product_id = ?", productID).Order("position, id").Find(&options).Error; err != nil {
+		return nil, err
+	}
+	return options, nil
+}
+
+// CreateVariants creates the variants in a single transaction, none is created when one of them conflicts
+func (r *ProductVariantRepositoryImpl) CreateVariants(variants []models.ProductVariant) ([]models.ProductVariant, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for i := range variants {
+			if err := tx.Create(&variants[i]).Error; err != nil {
+				if errors.Is(err, gorm.ErrDuplicatedKey) {
+					return &exception.SKUConflictError{
+						Message: "Variant SKU, Barcode or Options Already Exist",
+						SKU:     variants[i].SKU,
+					}
+				}
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return variants, nil
+}
+
+// GetVariantsbyProductID throw every variant of the product
+func (r *ProductVariantRepositoryImpl) GetVariantsbyProductID(productID string) ([]models.ProductVariant, error) {
+	var variants []models.ProductVariant
+	if err := r.db.Where("product_id = ?", productID).Order("id").Find(&variants).Error; err != nil {
+		return nil, err
+	}
+	return variants, nil
+}
+
+// GetVariantbyVariantID will throw the variant only when it belongs to the product
+func (r *ProductVariantRepositoryImpl) GetVariantbyVariantID(productID string, variantID string) (models.ProductVariant, error) {
+	var variant models.ProductVariant
+	if err := r.db.Where("product_id = ? AND variant_id = ?", productID, variantID).Take(&variant).Error; err != nil {
+		return models.ProductVariant{}, variantNotFound(err, variantID)
+	}
+	return variant, nil
+}
+
+// UpdateVariant saves the SKU, price, barcode and options of the variant
+func (r *ProductVariantRepositoryImpl) UpdateVariant(variant models.ProductVariant) (models.ProductVariant, error) {
+	if err := r.db.Save(&variant).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return models.ProductVariant{}, &exception.SKUConflictError{
+				Message: "Variant SKU, Barcode or Options Already Exist",
+				SKU:     variant.SKU,
+			}
+		}
+		return models.ProductVariant{}, err
+	}
+	return variant, nil
+}
+
+// DeleteVariant will soft delete the variant of the product
+func (r *ProductVariantRepositoryImpl) DeleteVariant(productID string, variantID string) error {
+	result := r.db.Where("product_id = ? AND variant_id = ?", productID, variantID).Delete(&models.ProductVariant{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return variantNotFound(gorm.ErrRecordNotFound, variantID)
+	}
+	return nil
+}
+
+func variantNotFound(err error, variantID string) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &exception.VariantNotFoundError{
+			Message:   "Variant Not Found",
+			VariantID: variantID,
+		}
+	}
+	return err
+}
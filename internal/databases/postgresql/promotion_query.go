@@ -0,0 +1,268 @@
+package database
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	models "smkdevid/echocommercehub/internal/models/schema"
+	"smkdevid/echocommercehub/utils/exception"
+
+	"gorm.io/gorm"
+)
+
+// Pagination bounds of ListPromotions
+const (
+	DefaultPromotionLimit = 20
+	MaxPromotionLimit     = 100
+)
+
+// Sort orders of ListPromotions
+const (
+	SortAscending  = "asc"
+	SortDescending = "desc"
+)
+
+// promotionSortColumns are the columns promotions can be sorted by, true for date columns
+var promotionSortColumns = map[string]bool{
+	"created_at":           true,
+	"updated_at":           true,
+	"promotion_start_date": true,
+	"promotion_end_date":   true,
+	"discount_value":       false,
+	"max_discount_amount":  false,
+	"priority":             false,
+}
+
+// PromotionQuery filters, sorts and paginates the promotion list.
+// Cursor and Offset are exclusive, the cursor is the NextCursor of the previous page.
+type PromotionQuery struct {
+	Status         string
+	DiscountType   string
+	Name           string
+	ActiveFrom     *time.Time
+	ActiveTo       *time.Time
+	IncludeDeleted bool
+	SortBy         string
+	SortOrder      string
+	Limit          int
+	Offset         int
+	Cursor         string
+}
+
+// PromotionList is a page of promotions with the total number of matching promotions
+type PromotionList struct {
+	Promotions []models.Promotion
+	Total      int64
+	NextCursor string
+}
+
+// promotionCursor points after the last promotion of a page
+type promotionCursor struct {
+	SortBy string `json:"s"`
+	Value  string `json:"v"`
+	ID     uint   `json:"id"`
+}
+
+// IsValidPromotionSortColumn reports whether promotions can be sorted by the column
+func IsValidPromotionSortColumn(column string) bool {
+	_, ok := promotionSortColumns[column]
+	return ok
+}
+
+// Normalize fills the defaults of the query and checks its values
+func (q PromotionQuery) Normalize() (PromotionQuery, error) {
+	if q.SortBy == "" {
+		q.SortBy = "created_at"
+	}
+	if !IsValidPromotionSortColumn(q.SortBy) {
+		return q, invalidQuery("sort", "cannot sort by "+q.SortBy)
+	}
+
+	q.SortOrder = strings.ToLower(q.SortOrder)
+	if q.SortOrder == "" {
+		q.SortOrder = SortDescending
+	}
+	if q.SortOrder != SortAscending && q.SortOrder != SortDescending {
+		return q, invalidQuery("order", "must be one of [asc desc]")
+	}
+
+	if q.Limit == 0 {
+		q.Limit = DefaultPromotionLimit
+	}
+	if q.Limit < 0 || q.Limit > MaxPromotionLimit {
+		return q, invalidQuery("limit", fmt.Sprintf("must be between 1 and %d", MaxPromotionLimit))
+	}
+	if q.Offset < 0 {
+		return q, invalidQuery("offset", "must be greater than or equal to 0")
+	}
+	if q.Cursor != "" && q.Offset > 0 {
+		return q, invalidQuery("cursor", "cannot be combined with offset")
+	}
+
+	if q.ActiveFrom != nil && q.ActiveTo != nil && q.ActiveTo.Before(*q.ActiveFrom) {
+		return q, invalidQuery("active_to", "must be after active_from")
+	}
+	return q, nil
+}
+
+// ListPromotions throw a page of the promotions matching the query.
+// The status filter follows the lifecycle status, so promotions the scheduler
+// did not move yet are still listed under their current status.
+func (r *PromotionRepositoryImpl) ListPromotions(query PromotionQuery) (PromotionList, error) {
+	query, err := query.Normalize()
+	if err != nil {
+		return PromotionList{}, err
+	}
+
+	filtered := r.filterPromotions(query, time.Now())
+
+	var total int64
+	if err := filtered.Session(&gorm.Session{}).Model(&models.Promotion{}).Count(&total).Error; err != nil {
+		return PromotionList{}, err
+	}
+
+	page := filtered.Session(&gorm.Session{})
+	comparison := ">"
+	if query.SortOrder == SortDescending {
+		comparison = "<"
+	}
+
+	if query.Cursor != "" {
+		cursor, value, err := decodePromotionCursor(query.Cursor, query.SortBy)
+		if err != nil {
+			return PromotionList{}, err
+		}
+		page = page.Where(fmt.Sprintf("(%s, id) %s (?, ?)", query.SortBy, comparison), value, cursor.ID)
+	} else if query.Offset > 0 {
+		page = page.Offset(query.Offset)
+	}
+
+	// One more row than asked tells whether there is a next page
+	var promotions []models.Promotion
+	if err := page.Order(fmt.Sprintf("%s %s, id %s", query.SortBy, query.SortOrder, query.SortOrder)).
+		Limit(query.Limit + 1).Find(&promotions).Error; err != nil {
+		return PromotionList{}, err
+	}
+
+	list := PromotionList{Total: total}
+	if len(promotions) > query.Limit {
+		promotions = promotions[:query.Limit]
+		list.NextCursor = encodePromotionCursor(promotions[len(promotions)-1], query.SortBy)
+	}
+	list.Promotions = promotions
+	return list, nil
+}
+
+func (r *PromotionRepositoryImpl) filterPromotions(query PromotionQuery, now time.Time) *gorm.DB {
+	tx := r.db.Model(&models.Promotion{})
+	if query.IncludeDeleted {
+		tx = tx.Unscoped()
+	}
+
+	if query.DiscountType != "" {
+		tx = tx.Where("LOWER(discount_type) = ?", strings.ToLower(query.DiscountType))
+	}
+	if query.Name != "" {
+		tx = tx.Where("promotion_name ILIKE ?", "%"+escapeLike(query.Name)+"%")
+	}
+
+	// A promotion overlaps the range when it starts before the range ends and ends after it starts
+	if query.ActiveTo != nil {
+		tx = tx.Where("promotion_start_date <= ?", *query.ActiveTo)
+	}
+	if query.ActiveFrom != nil {
+		tx = tx.Where("promotion_end_date >= ?", *query.ActiveFrom)
+	}
+
+	if query.Status != "" {
+		tx = tx.Where(lifecycleStatusCondition(r.db, query.Status, now))
+	}
+	return tx
+}
+
+// lifecycleStatusCondition mirrors promotions.LifecycleStatus in SQL
+func lifecycleStatusCondition(db *gorm.DB, status string, now time.Time) *gorm.DB {
+	scheduled := []string{models.PromotionStatusScheduled, models.PromotionStatusActive, models.PromotionStatusExpired}
+	cond := db.Session(&gorm.Session{NewDB: true})
+
+	switch status {
+	case models.PromotionStatusScheduled:
+		return cond.Where("status IN ? AND promotion_start_date > ?", scheduled, now)
+	case models.PromotionStatusActive:
+		return cond.Where("status IN ? AND promotion_start_date <= ? AND promotion_end_date >= ?", scheduled, now, now)
+	case models.PromotionStatusExpired:
+		return cond.Where("status IN ? AND promotion_end_date < ?",
+			append(scheduled, models.PromotionStatusPaused), now)
+	case models.PromotionStatusPaused:
+		return cond.Where("status = ? AND promotion_end_date >= ?", status, now)
+	}
+	return cond.Where("status = ?", status)
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+func encodePromotionCursor(promo models.Promotion, sortBy string) string {
+	var value string
+	switch sortBy {
+	case "created_at":
+		value = promo.CreatedAt.Format(time.RFC3339Nano)
+	case "updated_at":
+		value = promo.UpdatedAt.Format(time.RFC3339Nano)
+	case "promotion_start_date":
+		value = promo.PromotionStartDate.Format(time.RFC3339Nano)
+	case "promotion_end_date":
+		value = promo.PromotionEndDate.Format(time.RFC3339Nano)
+	case "discount_value":
+		value = fmt.Sprint(promo.DiscountValue)
+	case "max_discount_amount":
+		value = fmt.Sprint(promo.MaxDiscountAmount)
+	case "priority":
+		value = fmt.Sprint(promo.Priority)
+	}
+
+	raw, _ := json.Marshal(promotionCursor{SortBy: sortBy, Value: value, ID: promo.ID})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// decodePromotionCursor reads the cursor and converts its value to the type of the sort column
+func decodePromotionCursor(encoded string, sortBy string) (promotionCursor, interface{}, error) {
+	var cursor promotionCursor
+
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || json.Unmarshal(raw, &cursor) != nil {
+		return cursor, nil, invalidQuery("cursor", "is malformed")
+	}
+	if cursor.SortBy != sortBy {
+		return cursor, nil, invalidQuery("cursor", "was issued for another sort")
+	}
+
+	if promotionSortColumns[sortBy] {
+		value, err := time.Parse(time.RFC3339Nano, cursor.Value)
+		if err != nil {
+			return cursor, nil, invalidQuery("cursor", "is malformed")
+		}
+		return cursor, value, nil
+	}
+
+	var value float64
+	if _, err := fmt.Sscan(cursor.Value, &value); err != nil {
+		return cursor, nil, invalidQuery("cursor", "is malformed")
+	}
+	return cursor, value, nil
+}
+
+func invalidQuery(field string, message string) error {
+	return &exception.ValidationError{
+		Message: "Invalid Query Parameters",
+		Fields: []exception.FieldError{{
+			Field:   field,
+			Rule:    "query",
+			Message: message,
+		}},
+	}
+}
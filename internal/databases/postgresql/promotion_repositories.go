@@ -7,14 +7,20 @@ import (
 	"smkdevid/echocommercehub/utils/exception"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PromotionRepository interface {
-	CreatePromotion(promo models.Promotion) (models.Promotion, error)
+	CreatePromotion(promo models.Promotion, actor string) (models.Promotion, error)
 	GetAllPromotions() ([]models.Promotion, error)
+	ListPromotions(query PromotionQuery) (PromotionList, error)
 	GetPromotionbyPromotionID(promotionID string) (models.Promotion, error)
-	UpdatePromotionbyPromotionID(promo models.Promotion) (models.Promotion, error)
-	DeletePromotionbyPromotionID(promotionID string) error
+	UpdatePromotionbyPromotionID(promo models.Promotion, actor string) (models.Promotion, error)
+	DeletePromotionbyPromotionID(promotionID string, actor string) error
+	RestorePromotionbyPromotionID(promotionID string, actor string) (models.Promotion, error)
+	GetPromotionHistory(promotionID string) ([]models.PromotionHistory, error)
+	GetPromotionsbyStatus(statuses []string) ([]models.Promotion, error)
+	UpdatePromotionStatus(promotionID string, fromStatus string, toStatus string, actor string) (bool, error)
 }
 
 type PromotionRepositoryImpl struct {
@@ -28,10 +34,24 @@ func NewPromotionRepository(db *gorm.DB) PromotionRepository {
 	}
 }
 
-// CreatePromotion creates a new promotion in the database
-func (r *PromotionRepositoryImpl) CreatePromotion(promo models.Promotion) (models.Promotion, error) {
-	err := r.db.Unscoped().Create(&promo).Error
-	return promo, err
+// CreatePromotion creates a new promotion in the database together with its history entry
+func (r *PromotionRepositoryImpl) CreatePromotion(promo models.Promotion, actor string) (models.Promotion, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&promo).Error; err != nil {
+			return err
+		}
+		return recordPromotionHistory(tx, promo.PromotionID, models.PromotionActionCreate, actor, nil, &promo)
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return models.Promotion{}, &exception.PromotionIDConflictError{
+				Message:     "Promotion Already Exists",
+				PromotionID: promo.PromotionID,
+			}
+		}
+		return models.Promotion{}, err
+	}
+	return promo, nil
 }
 
 // GetAllPromotions throw all data that recorded in the database
@@ -39,10 +59,8 @@ func (r *PromotionRepositoryImpl) GetAllPromotions() ([]models.Promotion, error)
 	var promotions []models.Promotion
 
 	// SELECT * FROM promotion_table
-	// WHERE promotion_table.deleted_at == NULL LIMIT 1
-
-	// Disable soft deletion with Unscoped() methods for now
-	if err := r.db.Debug().Unscoped().Find(&promotions).Error; err != nil {
+	// WHERE promotion_table.deleted_at IS NULL
+	if err := r.db.Find(&promotions).Error; err != nil {
 		return nil, err
 	}
 	return promotions, nil
@@ -51,47 +69,163 @@ func (r *PromotionRepositoryImpl) GetAllPromotions() ([]models.Promotion, error)
 // GetPromotionByPromotionID will throw data based on promotionID request
 func (r *PromotionRepositoryImpl) GetPromotionbyPromotionID(PromotionID string) (models.Promotion, error) {
 	var promo models.Promotion
-	if err := r.db.Unscoped().Where("promotion_id = ?", PromotionID).Take(&promo).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
+	if err := r.db.Where("promotion_id = ?", PromotionID).Take(&promo).Error; err != nil {
+		return models.Promotion{}, promotionNotFound(err, PromotionID)
+	}
+	return promo, nil
+}
+
+// UpdatePromotion will update data based on promotionID request and record the change.
+// The update only applies on the version the promotion was read at, the stored version is bumped.
+func (r *PromotionRepositoryImpl) UpdatePromotionbyPromotionID(promo models.Promotion, actor string) (models.Promotion, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var existingPromo models.Promotion
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("promotion_id = ?", promo.PromotionID).Take(&existingPromo).Error; err != nil {
+			return promotionNotFound(err, promo.PromotionID)
+		}
 
-			// Handle case where record is not found
-			// For example, you can return a specific error indicating that the record is not found
-			return models.Promotion{}, errors.New("promotion not found")
+		if existingPromo.Version != promo.Version {
+			return &exception.PromotionVersionConflictError{
+				Message:        "Promotion Was Modified",
+				PromotionID:    promo.PromotionID,
+				CurrentVersion: existingPromo.Version,
+			}
+		}
+		promo.Version = existingPromo.Version + 1
+
+		// Update the promotion
+		if err := tx.Save(&promo).Error; err != nil {
+			return err
 		}
-		// Handle other errors
+		return recordPromotionHistory(tx, promo.PromotionID, models.PromotionActionUpdate, actor, &existingPromo, &promo)
+	})
+	if err != nil {
 		return models.Promotion{}, err
 	}
 	return promo, nil
 }
 
-// UpdatePromotion will update data based on promotionID request
-func (r *PromotionRepositoryImpl) UpdatePromotionbyPromotionID(promo models.Promotion) (models.Promotion, error) {
+// DeletePromotionByPromotionID will soft delete data based on promotionID request and record the change
+func (r *PromotionRepositoryImpl) DeletePromotionbyPromotionID(promotionID string, actor string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var existingPromo models.Promotion
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("promotion_id = ?", promotionID).Take(&existingPromo).Error; err != nil {
+			return promotionNotFound(err, promotionID)
+		}
 
-	// Assuming you have unique constraints on promotion_id and dates,
-	// you can perform the duplicate check before updating
-	var existingPromo models.Promotion
-	if err := r.db.Where("promotion_id = ?", promo.PromotionID).First(&existingPromo).Error; err != nil {
-		// Duplicate promotion found
-		return models.Promotion{}, err
-	}
+		if err := tx.Where("promotion_id = ?", promotionID).Delete(&models.Promotion{}).Error; err != nil {
+			return err
+		}
+		return recordPromotionHistory(tx, promotionID, models.PromotionActionDelete, actor, &existingPromo, nil)
+	})
+}
+
+// RestorePromotionbyPromotionID brings back a soft deleted promotion and record the change
+func (r *PromotionRepositoryImpl) RestorePromotionbyPromotionID(promotionID string, actor string) (models.Promotion, error) {
+	var promo models.Promotion
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("promotion_id = ? AND deleted_at IS NOT NULL", promotionID).Take(&promo).Error; err != nil {
+			return promotionNotFound(err, promotionID)
+		}
+		deletedPromo := promo
 
-	// Update the promotion
-	if err := r.db.Unscoped().Save(&promo).Error; err != nil {
+		if err := tx.Unscoped().Model(&models.Promotion{}).
+			Where("promotion_id = ?", promotionID).
+			Updates(map[string]interface{}{"deleted_at": nil, "version": gorm.Expr("version + 1")}).Error; err != nil {
+			return err
+		}
+		promo.DeletedAt = gorm.DeletedAt{}
+		promo.Version++
+		return recordPromotionHistory(tx, promotionID, models.PromotionActionRestore, actor, &deletedPromo, &promo)
+	})
+	if err != nil {
 		return models.Promotion{}, err
 	}
 	return promo, nil
 }
 
-// DeletePromotionByPromotionID will delete data based on promotionID request
-func (r *PromotionRepositoryImpl) DeletePromotionbyPromotionID(promotionID string) error {
-	if err := r.db.Unscoped().Where("promotion_id = ?", promotionID).Delete(&models.Promotion{}).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return &exception.PromotionIDNotFoundError{
-				Message:     "Promotion Not Found",
-				PromotionID: promotionID,
-			}
+// GetPromotionHistory throw every recorded change of the promotion, oldest first
+func (r *PromotionRepositoryImpl) GetPromotionHistory(promotionID string) ([]models.PromotionHistory, error) {
+	var count int64
+	if err := r.db.Unscoped().Model(&models.Promotion{}).Where("promotion_id = ?", promotionID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, &exception.PromotionIDNotFoundError{
+			Message:     "Promotion Not Found",
+			PromotionID: promotionID,
+		}
+	}
+
+	var history []models.PromotionHistory
+	if err := r.db.Where("promotion_id = ?", promotionID).Order("created_at, id").Find(&history).Error; err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// GetPromotionsbyStatus throw all promotions whose stored status is one of the given statuses
+func (r *PromotionRepositoryImpl) GetPromotionsbyStatus(statuses []string) ([]models.Promotion, error) {
+	var promotions []models.Promotion
+	if err := r.db.Where("status IN ?", statuses).Find(&promotions).Error; err != nil {
+		return nil, err
+	}
+	return promotions, nil
+}
+
+// UpdatePromotionStatus moves the promotion to toStatus only if it is still in fromStatus,
+// so the scheduler and manual changes never overwrite each other. The version is bumped, so
+// a write based on the promotion read before the change gets a conflict. The change is recorded.
+func (r *PromotionRepositoryImpl) UpdatePromotionStatus(promotionID string, fromStatus string, toStatus string, actor string) (bool, error) {
+	var updated bool
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var existingPromo models.Promotion
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("promotion_id = ? AND status = ?", promotionID, fromStatus).Take(&existingPromo).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.Promotion{}).
+			Where("promotion_id = ?", promotionID).
+			Updates(map[string]interface{}{"status": toStatus, "version": gorm.Expr("version + 1")}).Error; err != nil {
+			return err
+		}
+		updated = true
+
+		promo := existingPromo
+		promo.Status = toStatus
+		promo.Version = existingPromo.Version + 1
+		return recordPromotionHistory(tx, promotionID, models.PromotionActionStatus, actor, &existingPromo, &promo)
+	})
+	if err != nil {
+		return false, err
+	}
+	return updated, nil
+}
+
+func recordPromotionHistory(tx *gorm.DB, promotionID string, action string, actor string, before *models.Promotion, after *models.Promotion) error {
+	return tx.Create(&models.PromotionHistory{
+		PromotionID: promotionID,
+		Action:      action,
+		Actor:       actor,
+		Before:      before,
+		After:       after,
+	}).Error
+}
+
+func promotionNotFound(err error, promotionID string) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &exception.PromotionIDNotFoundError{
+			Message:     "Promotion Not Found",
+			PromotionID: promotionID,
 		}
-		return err
 	}
-	return nil
+	return err
 }
@@ -0,0 +1,190 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	models "smkdevid/echocommercehub/internal/models/schema"
+	"smkdevid/echocommercehub/utils/exception"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Pagination bounds of ListWebhookEvents
+const (
+	DefaultWebhookEventLimit = 20
+	MaxWebhookEventLimit     = 100
+)
+
+type WebhookEventRepository interface {
+	CreateWebhookEvent(event models.WebhookEvent) (models.WebhookEvent, bool, error)
+	ClaimDueWebhookEvents(now time.Time, limit int, lease time.Duration) ([]models.WebhookEvent, error)
+	UpdateWebhookEvent(event models.WebhookEvent) error
+	ListWebhookEvents(query WebhookEventQuery) (WebhookEventList, error)
+	ReplayWebhookEvent(webhookID string, now time.Time) (models.WebhookEvent, error)
+}
+
+// WebhookEventQuery filters and paginates the webhook events, newest first
+type WebhookEventQuery struct {
+	Status string
+	Limit  int
+	Offset int
+}
+
+// WebhookEventList is a page of webhook events with the total number of matching events
+type WebhookEventList struct {
+	Events []models.WebhookEvent
+	Total  int64
+}
+
+// Normalize fills the defaults of the query and checks its values
+func (q WebhookEventQuery) Normalize() (WebhookEventQuery, error) {
+	switch q.Status {
+	case "", models.WebhookStatusPending, models.WebhookStatusProcessed, models.WebhookStatusFailed:
+	default:
+		return q, invalidQuery("status", fmt.Sprintf("must be one of [%s %s %s]",
+			models.WebhookStatusPending, models.WebhookStatusProcessed, models.WebhookStatusFailed))
+	}
+
+	if q.Limit == 0 {
+		q.Limit = DefaultWebhookEventLimit
+	}
+	if q.Limit < 0 || q.Limit > MaxWebhookEventLimit {
+		return q, invalidQuery("limit", fmt.Sprintf("must be between 1 and %d", MaxWebhookEventLimit))
+	}
+	if q.Offset < 0 {
+		return q, invalidQuery("offset", "must be greater than or equal to 0")
+	}
+	return q, nil
+}
+
+type WebhookEventRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewWebhookEventRepository creates a new instance of WebhookEventRepository
+func NewWebhookEventRepository(db *gorm.DB) WebhookEventRepository {
+	return &WebhookEventRepositoryImpl{
+		db: db,
+	}
+}
+
+// CreateWebhookEvent stores the event unless the provider delivered it before. created is false for a
+// redelivery, the event stored the first time is returned then.
+func (r *WebhookEventRepositoryImpl) CreateWebhookEvent(event models.WebhookEvent) (models.WebhookEvent, bool, error) {
+	result := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "provider"}, {Name: "event_id"}},
+		DoNothing: true,
+	}).Create(&event)
+	if result.Error != nil {
+		return models.WebhookEvent{}, false, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return event, true, nil
+	}
+
+	var existing models.WebhookEvent
+	if err := r.db.Where("provider = ? AND event_id = ?", event.Provider, event.EventID).Take(&existing).Error; err != nil {
+		return models.WebhookEvent{}, false, err
+	}
+	return existing, false, nil
+}
+
+// ClaimDueWebhookEvents takes the pending events due at now, oldest first. Their next attempt is pushed
+// back by the lease, so other processors skip them while they are handled here.
+func (r *WebhookEventRepositoryImpl) ClaimDueWebhookEvents(now time.Time, limit int, lease time.Duration) ([]models.WebhookEvent, error) {
+	var events []models.WebhookEvent
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND (next_attempt_at IS NULL OR next_attempt_at <= ?)", models.WebhookStatusPending, now).
+			Order("id").Limit(limit).Find(&events).Error
+		if err != nil || len(events) == 0 {
+			return err
+		}
+
+		ids := make([]uint, 0, len(events))
+		for _, event := range events {
+			ids = append(ids, event.ID)
+		}
+		return tx.Model(&models.WebhookEvent{}).Where("id IN ?", ids).Update("next_attempt_at", now.Add(lease)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// UpdateWebhookEvent saves the outcome of an attempt to process the event
+func (r *WebhookEventRepositoryImpl) UpdateWebhookEvent(event models.WebhookEvent) error {
+	return r.db.Model(&models.WebhookEvent{}).
+		Where("webhook_id = ?", event.WebhookID).
+		Select("Status", "Attempts", "LastError", "NextAttemptAt", "ProcessedAt").
+		Updates(&event).Error
+}
+
+// ListWebhookEvents throw a page of the events matching the query, newest first
+func (r *WebhookEventRepositoryImpl) ListWebhookEvents(query WebhookEventQuery) (WebhookEventList, error) {
+	query, err := query.Normalize()
+	if err != nil {
+		return WebhookEventList{}, err
+	}
+
+	filtered := r.db.Model(&models.WebhookEvent{})
+	if query.Status != "" {
+		filtered = filtered.Where("status = ?", query.Status)
+	}
+
+	var total int64
+	if err := filtered.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return WebhookEventList{}, err
+	}
+
+	var events []models.WebhookEvent
+	if err := filtered.Session(&gorm.Session{}).
+		Order("created_at desc, id desc").
+		Limit(query.Limit).Offset(query.Offset).Find(&events).Error; err != nil {
+		return WebhookEventList{}, err
+	}
+	return WebhookEventList{Events: events, Total: total}, nil
+}
+
+// ReplayWebhookEvent queues a failed event again with a fresh set of attempts
+func (r *WebhookEventRepositoryImpl) ReplayWebhookEvent(webhookID string, now time.Time) (models.WebhookEvent, error) {
+	var event models.WebhookEvent
+	if err := r.db.Where("webhook_id = ?", webhookID).Take(&event).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.WebhookEvent{}, &exception.WebhookEventNotFoundError{
+				Message:   "Webhook Event Not Found",
+				WebhookID: webhookID,
+			}
+		}
+		return models.WebhookEvent{}, err
+	}
+
+	result := r.db.Model(&models.WebhookEvent{}).
+		Where("webhook_id = ? AND status = ?", webhookID, models.WebhookStatusFailed).
+		Updates(map[string]interface{}{
+			"status":          models.WebhookStatusPending,
+			"attempts":        0,
+			"last_error":      "",
+			"next_attempt_at": now,
+		})
+	if result.Error != nil {
+		return models.WebhookEvent{}, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return models.WebhookEvent{}, &exception.InvalidStatusTransitionError{
+			Message: "Only failed webhook events can be replayed",
+			From:    event.Status,
+			To:      models.WebhookStatusPending,
+		}
+	}
+
+	event.Status = models.WebhookStatusPending
+	event.Attempts = 0
+	event.LastError = ""
+	event.NextAttemptAt = &now
+	return event, nil
+}
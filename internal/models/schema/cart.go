@@ -0,0 +1,62 @@
+package schema
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Values of Cart.Status, only active carts can be changed
+const (
+	CartStatusActive     = "active"
+	CartStatusMerged     = "merged"
+	CartStatusCheckedOut = "checked_out"
+	CartStatusExpired    = "expired"
+)
+
+// Cart holds the items a shopper is about to buy. A guest cart is found by its Token, kept in a cookie,
+// and a customer cart by its CustomerID. Prices are not stored, they are read again on every calculation.
+// CouponCode is the coupon entered by the shopper, the promotions issued as coupons only apply with it.
+type Cart struct {
+	gorm.Model
+	ID         uint           `gorm:"primarykey"`
+	CartID     string         `gorm:"column:cart_id;uniqueIndex" json:"cart_id"`
+	Token      string         `gorm:"column:token;not null;default:''" json:"-"`
+	CustomerID string         `gorm:"column:customer_id;not null;default:''" json:"customer_id"`
+	Status     string         `gorm:"not null;default:active" json:"status"`
+	CouponCode string         `gorm:"column:coupon_code;not null;default:''" json:"coupon_code"`
+	ExpiresAt  time.Time      `gorm:"column:expires_at;not null" json:"expires_at"`
+	Items      []CartItem     `gorm:"foreignKey:CartID;references:CartID" json:"items"`
+	CreatedAt  time.Time      `gorm:"autoCreatedTime"`
+	UpdatedAt  time.Time      `gorm:"autoUpdateTime:mili"`
+	DeletedAt  gorm.DeletedAt `gorm:"index"`
+}
+
+func (Cart) TableName() string {
+	return "cart_table"
+}
+
+// IsGuest reports whether the cart belongs to a shopper who is not logged in
+func (c Cart) IsGuest() bool {
+	return c.CustomerID == ""
+}
+
+// Expired reports whether the cart passed its expiry at the given time
+func (c Cart) Expired(now time.Time) bool {
+	return !now.Before(c.ExpiresAt)
+}
+
+// CartItem is a line of the cart, one per product variant
+type CartItem struct {
+	ID        uint      `gorm:"primarykey" json:"-"`
+	CartID    string    `gorm:"column:cart_id;not null;uniqueIndex:idx_cart_item" json:"-"`
+	ProductID string    `gorm:"column:product_id;not null" json:"product_id"`
+	VariantID string    `gorm:"column:variant_id;not null;uniqueIndex:idx_cart_item" json:"variant_id"`
+	Quantity  uint      `gorm:"not null" json:"quantity"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (CartItem) TableName() string {
+	return "cart_item_table"
+}
@@ -0,0 +1,46 @@
+package schema
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Coupon struct {
+	gorm.Model
+	ID              uint           `gorm:"primarykey"`
+	CouponCode      string         `gorm:"column:coupon_code;uniqueIndex;not null" json:"coupon_code"`
+	PromotionID     string         `gorm:"column:promotion_id;index;not null" json:"promotion_id"`
+	MaxRedemptions  uint           `gorm:"not null;default:1" json:"max_redemptions"`
+	RedemptionCount uint           `gorm:"not null;default:0" json:"redemption_count"`
+	CreatedAt       time.Time      `gorm:"autoCreatedTime"`
+	UpdatedAt       time.Time      `gorm:"autoUpdateTime:mili"`
+	DeletedAt       gorm.DeletedAt `gorm:"index"`
+}
+
+func (Coupon) TableName() string {
+	return "coupon_table"
+}
+
+// SingleUse reports whether the coupon can only be redeemed once
+func (c Coupon) SingleUse() bool {
+	return c.MaxRedemptions == 1
+}
+
+// Exhausted reports whether the coupon reached its redemption limit
+func (c Coupon) Exhausted() bool {
+	return c.RedemptionCount >= c.MaxRedemptions
+}
+
+type CouponRedemption struct {
+	ID             uint      `gorm:"primarykey" json:"id"`
+	CouponCode     string    `gorm:"column:coupon_code;not null;uniqueIndex:idx_coupon_order" json:"coupon_code"`
+	PromotionID    string    `gorm:"column:promotion_id;not null;index:idx_promotion_customer" json:"promotion_id"`
+	CustomerID     string    `gorm:"column:customer_id;not null;index:idx_promotion_customer" json:"customer_id"`
+	OrderReference string    `gorm:"column:order_reference;not null;uniqueIndex:idx_coupon_order" json:"order_reference"`
+	RedeemedAt     time.Time `gorm:"autoCreateTime" json:"redeemed_at"`
+}
+
+func (CouponRedemption) TableName() string {
+	return "coupon_redemption_table"
+}
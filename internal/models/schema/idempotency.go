@@ -0,0 +1,19 @@
+package schema
+
+import "time"
+
+// IdempotencyKey remembers the response of a request sent with an Idempotency-Key header,
+// so a retried request gets the same response instead of creating a duplicate
+type IdempotencyKey struct {
+	Key          string    `gorm:"column:idempotency_key;primarykey" json:"idempotency_key"`
+	RequestHash  string    `gorm:"column:request_hash;not null" json:"request_hash"`
+	StatusCode   int       `gorm:"column:status_code;not null;default:0" json:"status_code"`
+	ContentType  string    `gorm:"column:content_type" json:"content_type"`
+	ResponseBody []byte    `gorm:"column:response_body" json:"-"`
+	Completed    bool      `gorm:"column:completed;not null;default:false" json:"completed"`
+	CreatedAt    time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+}
+
+func (IdempotencyKey) TableName() string {
+	return "idempotency_key_table"
+}
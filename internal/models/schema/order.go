@@ -0,0 +1,117 @@
+package schema
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Values of Order.Status, see products.OrderTransitions for the allowed moves between them
+const (
+	OrderStatusPendingPayment = "pending_payment"
+	OrderStatusPaid           = "paid"
+	OrderStatusProcessing     = "processing"
+	OrderStatusShipped        = "shipped"
+	OrderStatusDelivered      = "delivered"
+	OrderStatusCancelled      = "cancelled"
+	OrderStatusRefunded       = "refunded"
+)
+
+// OrderStatuses are all the values of Order.Status
+var OrderStatuses = []string{
+	OrderStatusPendingPayment,
+	OrderStatusPaid,
+	OrderStatusProcessing,
+	OrderStatusShipped,
+	OrderStatusDelivered,
+	OrderStatusCancelled,
+	OrderStatusRefunded,
+}
+
+// Address is a shipping or billing address, copied into the order when it is placed
+type Address struct {
+	RecipientName string `json:"recipient_name" validate:"required,max=100"`
+	Phone         string `json:"phone" validate:"required,max=20"`
+	Line1         string `json:"line1" validate:"required,max=255"`
+	Line2         string `json:"line2" validate:"max=255"`
+	City          string `json:"city" validate:"required,max=100"`
+	Province      string `json:"province" validate:"required,max=100"`
+	PostalCode    string `json:"postal_code" validate:"required,numeric,len=5"`
+	Country       string `json:"country" validate:"omitempty,iso3166_1_alpha2"`
+}
+
+// OrderPromotion is a promotion applied to the order with the discount it gave
+type OrderPromotion struct {
+	PromotionID   string          `json:"promotion_id"`
+	PromotionName string          `json:"promotion_name"`
+	Discount      decimal.Decimal `json:"discount"`
+}
+
+// Order is a placed cart. Prices, promotions and addresses are snapshots taken when the order was
+// placed, later changes to the catalog or the promotions do not change the order.
+type Order struct {
+	gorm.Model
+	ID               uint             `gorm:"primarykey"`
+	OrderID          string           `gorm:"column:order_id;uniqueIndex" json:"order_id"`
+	CustomerID       string           `gorm:"column:customer_id;not null;index" json:"customer_id"`
+	CartID           string           `gorm:"column:cart_id;not null" json:"cart_id"`
+	Status           string           `gorm:"not null;default:pending_payment;index" json:"status"`
+	Currency         string           `gorm:"not null;default:IDR" json:"currency"`
+	Subtotal         decimal.Decimal  `gorm:"type:numeric(15,2);not null" json:"subtotal"`
+	ItemDiscount     decimal.Decimal  `gorm:"type:numeric(15,2);not null" json:"item_discount"`
+	Shipping         decimal.Decimal  `gorm:"type:numeric(15,2);not null" json:"shipping"`
+	ShippingDiscount decimal.Decimal  `gorm:"type:numeric(15,2);not null" json:"shipping_discount"`
+	TotalDiscount    decimal.Decimal  `gorm:"type:numeric(15,2);not null" json:"total_discount"`
+	Tax              decimal.Decimal  `gorm:"type:numeric(15,2);not null" json:"tax"`
+	GrandTotal       decimal.Decimal  `gorm:"type:numeric(15,2);not null" json:"grand_total"`
+	Promotions       []OrderPromotion `gorm:"type:jsonb;serializer:json" json:"promotions"`
+	CouponCode       string           `gorm:"column:coupon_code;not null;default:''" json:"coupon_code,omitempty"`
+	ShippingAddress  Address          `gorm:"type:jsonb;serializer:json" json:"shipping_address"`
+	BillingAddress   Address          `gorm:"type:jsonb;serializer:json" json:"billing_address"`
+	Note             string           `gorm:"not null;default:''" json:"note"`
+	Items            []OrderItem      `gorm:"foreignKey:OrderID;references:OrderID" json:"items"`
+	CreatedAt        time.Time        `gorm:"autoCreatedTime"`
+	UpdatedAt        time.Time        `gorm:"autoUpdateTime:mili"`
+	DeletedAt        gorm.DeletedAt   `gorm:"index"`
+}
+
+func (Order) TableName() string {
+	return "order_table"
+}
+
+// OrderItem is a line of the order with the product details and prices at the time of the order
+type OrderItem struct {
+	ID          uint              `gorm:"primarykey" json:"-"`
+	OrderID     string            `gorm:"column:order_id;not null;index" json:"-"`
+	ProductID   string            `gorm:"column:product_id;not null" json:"product_id"`
+	VariantID   string            `gorm:"column:variant_id;not null" json:"variant_id"`
+	ProductName string            `gorm:"column:product_name;not null" json:"product_name"`
+	SKU         string            `gorm:"column:sku;not null" json:"sku"`
+	Options     map[string]string `gorm:"type:jsonb;serializer:json" json:"options"`
+	Quantity    uint              `gorm:"not null" json:"quantity"`
+	UnitPrice   decimal.Decimal   `gorm:"type:numeric(15,2);not null" json:"unit_price"`
+	LineTotal   decimal.Decimal   `gorm:"type:numeric(15,2);not null" json:"line_total"`
+	Discount    decimal.Decimal   `gorm:"type:numeric(15,2);not null" json:"discount"`
+	Total       decimal.Decimal   `gorm:"type:numeric(15,2);not null" json:"total"`
+}
+
+func (OrderItem) TableName() string {
+	return "order_item_table"
+}
+
+// OrderTransition is an entry of the status history of an order. FromStatus is empty for the
+// entry recording the placement of the order.
+type OrderTransition struct {
+	ID         uint      `gorm:"primarykey" json:"id"`
+	OrderID    string    `gorm:"column:order_id;not null;index" json:"order_id"`
+	FromStatus string    `gorm:"column:from_status;not null;default:''" json:"from_status"`
+	ToStatus   string    `gorm:"column:to_status;not null" json:"to_status"`
+	Actor      string    `gorm:"not null" json:"actor"`
+	Reason     string    `gorm:"not null;default:''" json:"reason"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (OrderTransition) TableName() string {
+	return "order_transition_table"
+}
@@ -0,0 +1,70 @@
+package schema
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Values of Payment.Method
+const (
+	PaymentMethodVirtualAccount = "virtual_account"
+	PaymentMethodQRIS           = "qris"
+	PaymentMethodEWallet        = "ewallet"
+)
+
+// Values of Payment.Status. Pending and authorized attempts are still open, the others are final
+// except paid, which can still be refunded.
+const (
+	PaymentStatusPending    = "pending"
+	PaymentStatusAuthorized = "authorized"
+	PaymentStatusPaid       = "paid"
+	PaymentStatusFailed     = "failed"
+	PaymentStatusExpired    = "expired"
+	PaymentStatusVoided     = "voided"
+	PaymentStatusRefunded   = "refunded"
+)
+
+// PaymentInstructions tell the customer how to pay, only the fields of the payment method are set
+type PaymentInstructions struct {
+	Bank        string `json:"bank,omitempty"`
+	VANumber    string `json:"va_number,omitempty"`
+	QRString    string `json:"qr_string,omitempty"`
+	QRImageURL  string `json:"qr_image_url,omitempty"`
+	RedirectURL string `json:"redirect_url,omitempty"`
+	DeeplinkURL string `json:"deeplink_url,omitempty"`
+}
+
+// Payment is an attempt to pay an order through a payment provider. An order can have many
+// attempts, at most one of them open at a time.
+type Payment struct {
+	gorm.Model
+	ID             uint                `gorm:"primarykey"`
+	PaymentID      string              `gorm:"column:payment_id;uniqueIndex" json:"payment_id"`
+	OrderID        string              `gorm:"column:order_id;not null;index" json:"order_id"`
+	Provider       string              `gorm:"not null" json:"provider"`
+	Method         string              `gorm:"not null" json:"method"`
+	Channel        string              `gorm:"not null;default:''" json:"channel,omitempty"`
+	Amount         decimal.Decimal     `gorm:"type:numeric(15,2);not null" json:"amount"`
+	RefundedAmount decimal.Decimal     `gorm:"type:numeric(15,2);not null;default:0" json:"refunded_amount"`
+	Currency       string              `gorm:"not null;default:IDR" json:"currency"`
+	Status         string              `gorm:"not null;default:pending;index" json:"status"`
+	Reference      string              `gorm:"column:provider_reference;not null;default:''" json:"reference,omitempty"`
+	Instructions   PaymentInstructions `gorm:"type:jsonb;serializer:json" json:"instructions"`
+	FailureReason  string              `gorm:"not null;default:''" json:"failure_reason,omitempty"`
+	ExpiresAt      *time.Time          `json:"expires_at,omitempty"`
+	PaidAt         *time.Time          `json:"paid_at,omitempty"`
+	CreatedAt      time.Time           `gorm:"autoCreatedTime"`
+	UpdatedAt      time.Time           `gorm:"autoUpdateTime:mili"`
+	DeletedAt      gorm.DeletedAt      `gorm:"index"`
+}
+
+func (Payment) TableName() string {
+	return "payment_table"
+}
+
+// Open reports whether the payment still waits for the customer or for a capture
+func (p Payment) Open() bool {
+	return p.Status == PaymentStatusPending || p.Status == PaymentStatusAuthorized
+}
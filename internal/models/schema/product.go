@@ -0,0 +1,42 @@
+package schema
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+type Product struct {
+	gorm.Model
+	ID                 uint            `gorm:"primarykey"`
+	ProductID          string          `gorm:"column:product_id;uniqueIndex" json:"product_id"`
+	ProductName        string          `gorm:"not null" json:"product_name" validate:"required,max=255"`
+	ProductDescription string          `gorm:"not null;default:''" json:"product_description" validate:"max=5000"`
+	ProductPrice       decimal.Decimal `gorm:"type:numeric(15,2);not null" json:"product_price" validate:"gte=0"`
+	Brand              string          `gorm:"not null;default:'';index" json:"brand" validate:"max=100"`
+	CategoryID         string          `gorm:"column:category_id;not null;default:'';index" json:"category_id" validate:"max=64"`
+	Images             []string        `gorm:"type:jsonb;serializer:json" json:"images" validate:"max=20,dive,url"`
+	Tags               []string        `gorm:"type:jsonb;serializer:json" json:"tags" validate:"max=30,dive,required,max=50"`
+	Stock              uint            `gorm:"not null;default:0" json:"stock"`
+	Status             string          `gorm:"not null;default:draft;index" json:"status" validate:"omitempty,product_status"`
+	CreatedAt          time.Time       `gorm:"autoCreatedTime"`
+	UpdatedAt          time.Time       `gorm:"autoUpdateTime:mili"`
+	DeletedAt          gorm.DeletedAt  `gorm:"index"`
+}
+
+func (Product) TableName() string {
+	return "product_table"
+}
+
+// InStock reports whether the product can be ordered right away
+func (p Product) InStock() bool {
+	return p.Stock > 0
+}
+
+// Values of Product.Status, only active products are sold
+const (
+	ProductStatusDraft    = "draft"
+	ProductStatusActive   = "active"
+	ProductStatusArchived = "archived"
+)
@@ -0,0 +1,50 @@
+package schema
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Values of ProductMedia.Kind
+const (
+	MediaKindImage = "image"
+	MediaKindVideo = "video"
+)
+
+// ProductMedia is an image or a video of a product, or of one of its variants when VariantID is set.
+// Position orders the media of the product, the first image is the cover.
+type ProductMedia struct {
+	gorm.Model
+	ID            uint              `gorm:"primarykey"`
+	MediaID       string            `gorm:"column:media_id;uniqueIndex" json:"media_id"`
+	ProductID     string            `gorm:"column:product_id;not null;index" json:"product_id"`
+	VariantID     string            `gorm:"column:variant_id;not null;default:''" json:"variant_id"`
+	Kind          string            `gorm:"not null" json:"kind"`
+	StorageKey    string            `gorm:"column:storage_key;not null" json:"-"`
+	URL           string            `gorm:"column:url;not null" json:"url"`
+	ContentType   string            `gorm:"column:content_type;not null" json:"content_type"`
+	Size          int64             `gorm:"not null" json:"size"`
+	Width         int               `gorm:"not null;default:0" json:"width,omitempty"`
+	Height        int               `gorm:"not null;default:0" json:"height,omitempty"`
+	AltText       string            `gorm:"column:alt_text;not null;default:''" json:"alt_text"`
+	Position      int               `gorm:"not null;default:0" json:"position"`
+	Thumbnails    map[string]string `gorm:"type:jsonb;serializer:json" json:"thumbnails,omitempty"`
+	ThumbnailKeys map[string]string `gorm:"column:thumbnail_keys;type:jsonb;serializer:json" json:"-"`
+	CreatedAt     time.Time         `gorm:"autoCreatedTime"`
+	UpdatedAt     time.Time         `gorm:"autoUpdateTime:mili"`
+	DeletedAt     gorm.DeletedAt    `gorm:"index"`
+}
+
+func (ProductMedia) TableName() string {
+	return "product_media_table"
+}
+
+// StorageKeys are the keys of the original file and of every thumbnail
+func (m ProductMedia) StorageKeys() []string {
+	keys := []string{m.StorageKey}
+	for _, key := range m.ThumbnailKeys {
+		keys = append(keys, key)
+	}
+	return keys
+}
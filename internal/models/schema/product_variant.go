@@ -0,0 +1,50 @@
+package schema
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// ProductOption is an option type of a product, such as size or color, with the values it can take.
+// Position orders the options in the variant matrix and in generated SKUs.
+type ProductOption struct {
+	ID        uint      `gorm:"primarykey" json:"-"`
+	ProductID string    `gorm:"column:product_id;not null;uniqueIndex:idx_product_option" json:"product_id"`
+	Name      string    `gorm:"not null;uniqueIndex:idx_product_option" json:"name" validate:"required,max=50"`
+	Values    []string  `gorm:"column:option_values;type:jsonb;serializer:json" json:"values" validate:"required,min=1,max=100,unique,dive,required,max=50"`
+	Position  int       `gorm:"not null;default:0" json:"position"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (ProductOption) TableName() string {
+	return "product_option_table"
+}
+
+type ProductVariant struct {
+	gorm.Model
+	ID        uint              `gorm:"primarykey"`
+	VariantID string            `gorm:"column:variant_id;uniqueIndex" json:"variant_id"`
+	ProductID string            `gorm:"column:product_id;not null;index" json:"product_id"`
+	SKU       string            `gorm:"column:sku;not null;uniqueIndex" json:"sku" validate:"required,max=64,sku"`
+	Options   map[string]string `gorm:"type:jsonb;serializer:json" json:"options"`
+	OptionKey string            `gorm:"column:option_key;not null" json:"-"`
+	Price     *decimal.Decimal  `gorm:"type:numeric(15,2)" json:"price" validate:"omitempty,gte=0"`
+	Barcode   string            `gorm:"column:barcode;not null;default:''" json:"barcode" validate:"omitempty,max=64,barcode"`
+	CreatedAt time.Time         `gorm:"autoCreatedTime"`
+	UpdatedAt time.Time         `gorm:"autoUpdateTime:mili"`
+	DeletedAt gorm.DeletedAt    `gorm:"index"`
+}
+
+func (ProductVariant) TableName() string {
+	return "product_variant_table"
+}
+
+// EffectivePrice is the price the variant is sold at, its own price or else the product price
+func (v ProductVariant) EffectivePrice(product Product) decimal.Decimal {
+	if v.Price != nil {
+		return *v.Price
+	}
+	return product.ProductPrice
+}
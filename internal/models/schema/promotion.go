@@ -1,6 +1,7 @@
 package schema
 
 import (
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -9,12 +10,22 @@ import (
 type Promotion struct {
 	gorm.Model
 	ID                 uint           `gorm:"primarykey"`
-	PromotionID        string         `gorm:"column:promotion_id" json:"promotion_id"`
-	PromotionName      string         `gorm:"not null" json:"promotion_name"`
-	DiscountType       string         `gorm:"not null" json:"discount_type"`
-	DiscountValue      float64        `gorm:"not null" json:"discount_value"`
-	PromotionStartDate time.Time      `gorm:"not null" json:"promotion_start_date"`
-	PromotionEndDate   time.Time      `gorm:"not null" json:"promotion_end_date"`
+	PromotionID        string         `gorm:"column:promotion_id;uniqueIndex" json:"promotion_id"`
+	PromotionName      string         `gorm:"not null" json:"promotion_name" validate:"required,max=255"`
+	DiscountType       string         `gorm:"not null" json:"discount_type" validate:"required,discount_type"`
+	DiscountValue      float64        `gorm:"not null" json:"discount_value" validate:"gte=0"`
+	PromotionStartDate time.Time      `gorm:"not null" json:"promotion_start_date" validate:"required"`
+	PromotionEndDate   time.Time      `gorm:"not null" json:"promotion_end_date" validate:"required"`
+	MaxDiscountAmount  float64        `gorm:"not null;default:0" json:"max_discount_amount" validate:"gte=0"`
+	BuyQuantity        uint           `gorm:"not null;default:0" json:"buy_quantity"`
+	GetQuantity        uint           `gorm:"not null;default:0" json:"get_quantity"`
+	DiscountTiers      []DiscountTier `gorm:"type:jsonb;serializer:json" json:"discount_tiers" validate:"dive"`
+	Rules              PromotionRules `gorm:"type:jsonb;serializer:json" json:"rules"`
+	Priority           int            `gorm:"not null;default:0" json:"priority"`
+	Exclusive          bool           `gorm:"not null;default:false" json:"exclusive"`
+	Stackable          bool           `gorm:"not null;default:false" json:"stackable"`
+	Status             string         `gorm:"not null;default:scheduled;index" json:"status" validate:"omitempty,promotion_status"`
+	Version            uint           `gorm:"not null;default:1" json:"version"`
 	CreatedAt          time.Time      `gorm:"autoCreatedTime"`
 	UpdatedAt          time.Time      `gorm:"autoUpdateTime:mili"`
 	DeletedAt          gorm.DeletedAt `gorm:"index"`
@@ -24,30 +35,53 @@ func (Promotion) TableName() string {
 	return "promotion_table"
 }
 
-// type Products struct {
-// 	gorm.Model
-// 	ProductID          string
-// 	ProductName        string
-// 	ProductDescription string
-// 	ProductPrice       string
-// 	ProductStock       uint
-// 	ProductImage       string
-// 	ProductCategory    Categories
-// }
+// Supported values of Promotion.DiscountType
+const (
+	DiscountTypePercentage   = "percentage"
+	DiscountTypeFixed        = "fixed"
+	DiscountTypeBuyXGetY     = "buy_x_get_y"
+	DiscountTypeTiered       = "tiered"
+	DiscountTypeFreeShipping = "free_shipping"
+)
 
-// type ProductVariants struct {
-// 	VariantID    string
-// 	ProductID    string
-// 	ProductName  string
-// 	ProductPrice string
-// 	ProductStock string
-// }
+// NormalizedDiscountType returns the discount type in its canonical lower case form
+func (p Promotion) NormalizedDiscountType() string {
+	return strings.ToLower(strings.TrimSpace(p.DiscountType))
+}
 
-// type Categories struct {
-// 	gorm.Model
-// 	CategoryID   string
-// 	CategoryName string
-// }
+// Lifecycle values of Promotion.Status
+const (
+	PromotionStatusDraft     = "draft"
+	PromotionStatusScheduled = "scheduled"
+	PromotionStatusActive    = "active"
+	PromotionStatusPaused    = "paused"
+	PromotionStatusExpired   = "expired"
+	PromotionStatusArchived  = "archived"
+)
+
+// DiscountTier is a spend threshold of a tiered promotion.
+// Type is either percentage or fixed.
+type DiscountTier struct {
+	MinSpend float64 `json:"min_spend" validate:"gte=0"`
+	Type     string  `json:"type" validate:"omitempty,oneof=percentage fixed"`
+	Value    float64 `json:"value" validate:"gt=0"`
+}
+
+// PromotionRules holds the eligibility rules of a promotion.
+// Zero values mean the rule is not applied.
+type PromotionRules struct {
+	MinSubtotal        float64  `json:"min_subtotal,omitempty" validate:"gte=0"`
+	ProductIDs         []string `json:"product_ids,omitempty"`
+	CategoryIDs        []string `json:"category_ids,omitempty"`
+	FirstOrderOnly     bool     `json:"first_order_only,omitempty"`
+	CustomerSegments   []string `json:"customer_segments,omitempty"`
+	MaxUsesPerCustomer uint     `json:"max_uses_per_customer,omitempty"`
+}
+
+// HasItemRestriction reports whether the promotion only applies to specific products or categories
+func (r PromotionRules) HasItemRestriction() bool {
+	return len(r.ProductIDs) > 0 || len(r.CategoryIDs) > 0
+}
 
 // type Users struct {
 // 	UserID           string
@@ -55,21 +89,3 @@ func (Promotion) TableName() string {
 // 	UserEmail        string
 // 	UserPasswordHash string
 // }
-
-// type Orders struct {
-// 	OrderID     string
-// 	UserID      string
-// 	TotalAmount uint16
-// 	OrderStatus string
-// 	CreatedAt   time.Time
-// 	UpdatedAt   time.Time
-// }
-
-// type OrderItems struct {
-// 	ItemID    string
-// 	OrderID   string
-// 	ProductID string
-// 	VariantID string
-// 	Quantity  uint16
-// 	UnitPrice float64
-// }
@@ -0,0 +1,28 @@
+package schema
+
+import "time"
+
+// Actions recorded in the promotion history
+const (
+	PromotionActionCreate  = "create"
+	PromotionActionUpdate  = "update"
+	PromotionActionDelete  = "delete"
+	PromotionActionRestore = "restore"
+	PromotionActionStatus  = "status"
+)
+
+// PromotionHistory is an audit entry of a change made to a promotion. Before is empty
+// on create and After is empty on delete.
+type PromotionHistory struct {
+	ID          uint       `gorm:"primarykey" json:"id"`
+	PromotionID string     `gorm:"column:promotion_id;not null;index" json:"promotion_id"`
+	Action      string     `gorm:"not null" json:"action"`
+	Actor       string     `gorm:"not null" json:"actor"`
+	Before      *Promotion `gorm:"type:jsonb;serializer:json" json:"before"`
+	After       *Promotion `gorm:"type:jsonb;serializer:json" json:"after"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (PromotionHistory) TableName() string {
+	return "promotion_history_table"
+}
@@ -0,0 +1,35 @@
+package schema
+
+import (
+	"time"
+)
+
+// Values of WebhookEvent.Status
+const (
+	WebhookStatusPending   = "pending"
+	WebhookStatusProcessed = "processed"
+	WebhookStatusFailed    = "failed"
+)
+
+// WebhookEvent is a notification of a payment provider kept exactly as it was received. The provider
+// and its event ID identify the event, a redelivery of the same event is stored once.
+type WebhookEvent struct {
+	ID            uint       `gorm:"primarykey" json:"-"`
+	WebhookID     string     `gorm:"column:webhook_id;uniqueIndex" json:"webhook_id"`
+	Provider      string     `gorm:"not null;uniqueIndex:idx_webhook_event_table_provider_event" json:"provider"`
+	EventID       string     `gorm:"column:event_id;not null;uniqueIndex:idx_webhook_event_table_provider_event" json:"event_id"`
+	EventType     string     `gorm:"column:event_type;not null;default:''" json:"event_type"`
+	Reference     string     `gorm:"not null;default:''" json:"reference"`
+	Payload       string     `gorm:"type:text;not null" json:"payload"`
+	Status        string     `gorm:"not null;default:pending;index" json:"status"`
+	Attempts      int        `gorm:"not null;default:0" json:"attempts"`
+	LastError     string     `gorm:"not null;default:''" json:"last_error,omitempty"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	ProcessedAt   *time.Time `json:"processed_at,omitempty"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"received_at"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime:mili" json:"updated_at"`
+}
+
+func (WebhookEvent) TableName() string {
+	return "webhook_event_table"
+}
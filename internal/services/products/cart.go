@@ -0,0 +1,659 @@
+package products
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/shopspring/decimal"
+
+	postgresql "smkdevid/echocommercehub/internal/databases/postgresql"
+	models "smkdevid/echocommercehub/internal/models/schema"
+	"smkdevid/echocommercehub/internal/services/promotions"
+	"smkdevid/echocommercehub/utils/exception"
+)
+
+// Bounds of a cart
+const (
+	MaxCartItems        = 50
+	MaxCartItemQuantity = 99
+)
+
+// CartService manages the guest and customer carts and calculates their totals
+type CartService interface {
+	GetCart(owner CartOwner) (CartView, error)
+	AddItem(owner CartOwner, req AddCartItemRequest) (CartView, error)
+	UpdateItem(owner CartOwner, variantID string, req UpdateCartItemRequest) (CartView, error)
+	RemoveItem(owner CartOwner, variantID string) (CartView, error)
+	MergeCarts(customer CartOwner, token string) (CartView, error)
+	ApplyCoupon(owner CartOwner, req ApplyCouponRequest) (CartView, error)
+	RemoveCoupon(owner CartOwner) (CartView, error)
+}
+
+// CartOwner identifies the cart of a request, by the customer when logged in and else by the cookie token.
+// The segment of the customer decides which segment promotions the cart gets.
+type CartOwner struct {
+	CustomerID string
+	Token      string
+	Segment    string
+}
+
+// CartCustomer is what the promotions of a priced cart know about its customer, all empty for a guest.
+// PromotionUsage is how many times the customer already used each promotion.
+type CartCustomer struct {
+	CustomerID     string
+	Segment        string
+	FirstOrder     bool
+	PromotionUsage map[string]uint
+}
+
+// AddCartItemRequest adds a quantity of a product variant to the cart
+type AddCartItemRequest struct {
+	ProductID string `json:"product_id" validate:"required,max=26"`
+	VariantID string `json:"variant_id" validate:"required,max=26"`
+	Quantity  uint   `json:"quantity" validate:"required,gte=1,lte=99"`
+}
+
+// UpdateCartItemRequest sets the quantity of a line, 0 removes it
+type UpdateCartItemRequest struct {
+	Quantity uint `json:"quantity" validate:"lte=99"`
+}
+
+// ApplyCouponRequest enters a coupon code on the cart, it replaces the code entered before
+type ApplyCouponRequest struct {
+	CouponCode string `json:"coupon_code" validate:"required,max=64"`
+}
+
+// CartSettings are the lifetime of the carts and the rules of their totals
+type CartSettings struct {
+	GuestTTL     time.Duration
+	CustomerTTL  time.Duration
+	TaxRate      decimal.Decimal
+	ShippingCost decimal.Decimal
+	StackingMode string
+}
+
+// CartLine is a line of the cart priced at the current price of its variant. A line whose product is no
+// longer sold is not Available and left out of the totals.
+type CartLine struct {
+	ProductID   string            `json:"product_id"`
+	VariantID   string            `json:"variant_id"`
+	ProductName string            `json:"product_name"`
+	SKU         string            `json:"sku"`
+	Options     map[string]string `json:"options"`
+	Quantity    uint              `json:"quantity"`
+	UnitPrice   decimal.Decimal   `json:"unit_price"`
+	LineTotal   decimal.Decimal   `json:"line_total"`
+	Discount    decimal.Decimal   `json:"discount"`
+	Total       decimal.Decimal   `json:"total"`
+	Available   bool              `json:"available"`
+	InStock     bool              `json:"in_stock"`
+
+	categoryID string
+}
+
+// CartTotals is the breakdown of what the shopper pays
+type CartTotals struct {
+	Subtotal         decimal.Decimal `json:"subtotal"`
+	ItemDiscount     decimal.Decimal `json:"item_discount"`
+	Shipping         decimal.Decimal `json:"shipping"`
+	ShippingDiscount decimal.Decimal `json:"shipping_discount"`
+	TotalDiscount    decimal.Decimal `json:"total_discount"`
+	Tax              decimal.Decimal `json:"tax"`
+	GrandTotal       decimal.Decimal `json:"grand_total"`
+}
+
+// CartView is the cart with its lines and totals recalculated. Token is the cookie of a guest cart,
+// it is not part of the body. CouponApplied tells whether the promotion of the entered coupon applies.
+type CartView struct {
+	CartID        string                        `json:"cart_id"`
+	CustomerID    string                        `json:"customer_id,omitempty"`
+	Status        string                        `json:"status"`
+	ExpiresAt     *time.Time                    `json:"expires_at,omitempty"`
+	Lines         []CartLine                    `json:"lines"`
+	Totals        CartTotals                    `json:"totals"`
+	Promotions    []promotions.AppliedPromotion `json:"promotions"`
+	CouponCode    string                        `json:"coupon_code,omitempty"`
+	CouponApplied bool                          `json:"coupon_applied,omitempty"`
+	Token         string                        `json:"-"`
+}
+
+type CartServiceImpl struct {
+	CartRepo      postgresql.CartRepository
+	ProductRepo   postgresql.ProductRepository
+	VariantRepo   postgresql.ProductVariantRepository
+	PromotionRepo postgresql.PromotionRepository
+	CouponRepo    postgresql.CouponRepository
+	OrderRepo     postgresql.OrderRepository
+	Settings      CartSettings
+}
+
+// NewCartService creates a new instance of CartService
+func NewCartService(CartRepo postgresql.CartRepository, ProductRepo postgresql.ProductRepository, VariantRepo postgresql.ProductVariantRepository, PromotionRepo postgresql.PromotionRepository, CouponRepo postgresql.CouponRepository, OrderRepo postgresql.OrderRepository, Settings CartSettings) *CartServiceImpl {
+	return &CartServiceImpl{
+		CartRepo:      CartRepo,
+		ProductRepo:   ProductRepo,
+		VariantRepo:   VariantRepo,
+		PromotionRepo: PromotionRepo,
+		CouponRepo:    CouponRepo,
+		OrderRepo:     OrderRepo,
+		Settings:      Settings,
+	}
+}
+
+// NewCartID mints a ULID, unique and sortable by creation time
+func NewCartID() string {
+	return ulid.Make().String()
+}
+
+// NewCartToken mints the random token of a guest cart cookie
+func NewCartToken() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// GetCart throw the cart of the owner, an empty cart when there is none yet
+func (s *CartServiceImpl) GetCart(owner CartOwner) (CartView, error) {
+	cart, found, err := s.activeCart(owner, false)
+	if err != nil {
+		return CartView{}, err
+	}
+	if !found {
+		return s.price(models.Cart{Status: models.CartStatusActive}, owner.Segment)
+	}
+	return s.price(cart, owner.Segment)
+}
+
+// AddItem adds the quantity to the line of the variant, the cart is created on the first item
+func (s *CartServiceImpl) AddItem(owner CartOwner, req AddCartItemRequest) (CartView, error) {
+	product, err := s.ProductRepo.GetProductbyProductID(req.ProductID)
+	if err != nil {
+		return CartView{}, err
+	}
+	if product.Status != models.ProductStatusActive {
+		return CartView{}, invalidCart("product_id", "is not for sale")
+	}
+	if _, err := s.VariantRepo.GetVariantbyVariantID(req.ProductID, req.VariantID); err != nil {
+		return CartView{}, err
+	}
+
+	cart, _, err := s.activeCart(owner, true)
+	if err != nil {
+		return CartView{}, err
+	}
+
+	quantity := req.Quantity
+	if line, ok := cartItem(cart, req.VariantID); ok {
+		quantity += line.Quantity
+	} else if len(cart.Items) >= MaxCartItems {
+		return CartView{}, invalidCart("variant_id", fmt.Sprintf("cannot be added, a cart holds at most %d lines", MaxCartItems))
+	}
+	if quantity > MaxCartItemQuantity {
+		return CartView{}, invalidCart("quantity", fmt.Sprintf("makes %d, at most %d of a variant are allowed", quantity, MaxCartItemQuantity))
+	}
+
+	err = s.CartRepo.SaveCartItem(models.CartItem{
+		CartID:    cart.CartID,
+		ProductID: req.ProductID,
+		VariantID: req.VariantID,
+		Quantity:  quantity,
+	}, s.expiry(cart))
+	if err != nil {
+		return CartView{}, err
+	}
+	return s.reload(cart, owner.Segment)
+}
+
+// UpdateItem sets the quantity of the line of the variant, 0 removes the line
+func (s *CartServiceImpl) UpdateItem(owner CartOwner, variantID string, req UpdateCartItemRequest) (CartView, error) {
+	if req.Quantity == 0 {
+		return s.RemoveItem(owner, variantID)
+	}
+
+	cart, line, err := s.cartWithItem(owner, variantID)
+	if err != nil {
+		return CartView{}, err
+	}
+
+	line.Quantity = req.Quantity
+	if err := s.CartRepo.SaveCartItem(line, s.expiry(cart)); err != nil {
+		return CartView{}, err
+	}
+	return s.reload(cart, owner.Segment)
+}
+
+// RemoveItem removes the line of the variant from the cart
+func (s *CartServiceImpl) RemoveItem(owner CartOwner, variantID string) (CartView, error) {
+	cart, _, err := s.cartWithItem(owner, variantID)
+	if err != nil {
+		return CartView{}, err
+	}
+
+	if err := s.CartRepo.DeleteCartItem(cart.CartID, variantID, s.expiry(cart)); err != nil {
+		return CartView{}, err
+	}
+	return s.reload(cart, owner.Segment)
+}
+
+// MergeCarts moves the guest cart of the token into the cart of the customer who just logged in.
+// Without a guest cart the customer cart is returned as it is.
+func (s *CartServiceImpl) MergeCarts(customer CartOwner, token string) (CartView, error) {
+	if customer.CustomerID == "" {
+		return CartView{}, invalidCart("customer_id", "is required to merge carts")
+	}
+
+	customer.Token = ""
+	guest, found, err := s.activeCart(CartOwner{Token: token}, false)
+	if err != nil {
+		return CartView{}, err
+	}
+	if !found || len(guest.Items) == 0 {
+		if found {
+			if err := s.CartRepo.CloseCart(guest.CartID, models.CartStatusMerged); err != nil {
+				return CartView{}, err
+			}
+		}
+		return s.GetCart(customer)
+	}
+
+	cart, _, err := s.activeCart(customer, true)
+	if err != nil {
+		return CartView{}, err
+	}
+
+	lines := len(cart.Items)
+	for _, item := range guest.Items {
+		if _, ok := cartItem(cart, item.VariantID); !ok {
+			lines++
+		}
+	}
+	if lines > MaxCartItems {
+		return CartView{}, invalidCart("items", fmt.Sprintf("the merged cart would hold %d lines, at most %d are allowed", lines, MaxCartItems))
+	}
+
+	if err := s.CartRepo.MergeCarts(guest, cart, MaxCartItemQuantity, s.expiry(cart)); err != nil {
+		return CartView{}, err
+	}
+	return s.reload(cart, customer.Segment)
+}
+
+// ApplyCoupon enters the coupon code on the cart of the owner, the cart is created when there is none yet.
+// Whether the promotion of the coupon applies is only known once the cart is priced.
+func (s *CartServiceImpl) ApplyCoupon(owner CartOwner, req ApplyCouponRequest) (CartView, error) {
+	coupon, err := s.CouponRepo.GetCouponbyCouponCode(promotions.NormalizeCouponCode(req.CouponCode))
+	if err != nil {
+		return CartView{}, err
+	}
+	if coupon.Exhausted() {
+		return CartView{}, &exception.CouponRedemptionLimitError{
+			Message:    "Coupon Redemption Limit Reached",
+			CouponCode: coupon.CouponCode,
+		}
+	}
+
+	cart, _, err := s.activeCart(owner, true)
+	if err != nil {
+		return CartView{}, err
+	}
+	if err := s.CartRepo.SetCartCoupon(cart.CartID, coupon.CouponCode, s.expiry(cart)); err != nil {
+		return CartView{}, err
+	}
+	return s.reload(cart, owner.Segment)
+}
+
+// RemoveCoupon removes the coupon code from the cart of the owner
+func (s *CartServiceImpl) RemoveCoupon(owner CartOwner) (CartView, error) {
+	cart, found, err := s.activeCart(owner, false)
+	if err != nil {
+		return CartView{}, err
+	}
+	if !found {
+		return s.price(models.Cart{Status: models.CartStatusActive}, owner.Segment)
+	}
+	if cart.CouponCode == "" {
+		return s.price(cart, owner.Segment)
+	}
+
+	if err := s.CartRepo.SetCartCoupon(cart.CartID, "", s.expiry(cart)); err != nil {
+		return CartView{}, err
+	}
+	return s.reload(cart, owner.Segment)
+}
+
+// activeCart finds the cart of the owner. An expired cart is closed and, with create, replaced by a new one.
+func (s *CartServiceImpl) activeCart(owner CartOwner, create bool) (models.Cart, bool, error) {
+	var (
+		cart  models.Cart
+		found bool
+		err   error
+	)
+	switch {
+	case owner.CustomerID != "":
+		cart, found, err = s.CartRepo.GetActiveCartbyCustomerID(owner.CustomerID)
+	case owner.Token != "":
+		cart, found, err = s.CartRepo.GetActiveCartbyToken(owner.Token)
+	}
+	if err != nil {
+		return models.Cart{}, false, err
+	}
+
+	if found && cart.Expired(time.Now()) {
+		if err := s.CartRepo.CloseCart(cart.CartID, models.CartStatusExpired); err != nil {
+			return models.Cart{}, false, err
+		}
+		found = false
+	}
+	if found || !create {
+		return cart, found, nil
+	}
+
+	cart = models.Cart{
+		CartID:     NewCartID(),
+		CustomerID: owner.CustomerID,
+		Status:     models.CartStatusActive,
+	}
+	if owner.CustomerID == "" {
+		cart.Token = NewCartToken()
+	}
+	cart.ExpiresAt = s.expiry(cart)
+
+	cart, err = s.CartRepo.CreateCart(cart)
+	if err != nil {
+		return models.Cart{}, false, err
+	}
+	return cart, true, nil
+}
+
+// reload reads the cart again after a change and prices it, a new guest cart is only known by its token
+func (s *CartServiceImpl) reload(changed models.Cart, segment string) (CartView, error) {
+	cart, found, err := s.activeCart(CartOwner{CustomerID: changed.CustomerID, Token: changed.Token}, false)
+	if err != nil {
+		return CartView{}, err
+	}
+	if !found {
+		return s.price(models.Cart{Status: models.CartStatusActive}, segment)
+	}
+	return s.price(cart, segment)
+}
+
+func (s *CartServiceImpl) cartWithItem(owner CartOwner, variantID string) (models.Cart, models.CartItem, error) {
+	cart, found, err := s.activeCart(owner, false)
+	if err != nil {
+		return models.Cart{}, models.CartItem{}, err
+	}
+	line, ok := cartItem(cart, variantID)
+	if !found || !ok {
+		return models.Cart{}, models.CartItem{}, &exception.CartItemNotFoundError{
+			Message:   "Cart Item Not Found",
+			VariantID: variantID,
+		}
+	}
+	return cart, line, nil
+}
+
+// expiry is pushed back on every change, guest carts live shorter than customer carts
+func (s *CartServiceImpl) expiry(cart models.Cart) time.Time {
+	if cart.IsGuest() {
+		return time.Now().Add(s.Settings.GuestTTL)
+	}
+	return time.Now().Add(s.Settings.CustomerTTL)
+}
+
+// price reads the current price of every line and applies the active promotions, segment is the
+// segment of the customer of the cart
+func (s *CartServiceImpl) price(cart models.Cart, segment string) (CartView, error) {
+	lines := make([]CartLine, 0, len(cart.Items))
+	for _, item := range cart.Items {
+		line, err := s.cartLine(item)
+		if err != nil {
+			return CartView{}, err
+		}
+		lines = append(lines, line)
+	}
+
+	promos, customer, couponPromotionID, err := s.cartPromotions(cart, segment)
+	if err != nil {
+		return CartView{}, err
+	}
+
+	lines, totals, applied, err := PriceCart(lines, customer, promos, s.Settings, time.Now())
+	if err != nil {
+		return CartView{}, err
+	}
+
+	view := CartView{
+		CartID:     cart.CartID,
+		CustomerID: cart.CustomerID,
+		Status:     cart.Status,
+		Lines:      lines,
+		Totals:     totals,
+		Promotions: applied,
+		CouponCode: cart.CouponCode,
+		Token:      cart.Token,
+	}
+	for _, promo := range applied {
+		if couponPromotionID != "" && promo.PromotionID == couponPromotionID {
+			view.CouponApplied = true
+		}
+	}
+	if !cart.ExpiresAt.IsZero() {
+		view.ExpiresAt = &cart.ExpiresAt
+	}
+	return view, nil
+}
+
+// cartPromotions are the active promotions the cart can get and what they need to know about the
+// customer: whether this is the first order and how many times the customer already used them.
+// A promotion issued as coupons only comes with a coupon of it entered on the cart, the ID of that
+// promotion is returned as well.
+func (s *CartServiceImpl) cartPromotions(cart models.Cart, segment string) ([]models.Promotion, CartCustomer, string, error) {
+	var customer CartCustomer
+	if cart.CustomerID != "" {
+		customer = CartCustomer{CustomerID: cart.CustomerID, Segment: segment}
+	}
+
+	active, err := s.PromotionRepo.GetPromotionsbyStatus([]string{models.PromotionStatusActive})
+	if err != nil || len(active) == 0 {
+		return active, customer, "", err
+	}
+
+	ids := make([]string, 0, len(active))
+	for _, promo := range active {
+		ids = append(ids, promo.PromotionID)
+	}
+	issued, err := s.CouponRepo.GetCouponPromotionIDs(ids)
+	if err != nil {
+		return nil, CartCustomer{}, "", err
+	}
+	couponOnly := make(map[string]bool, len(issued))
+	for _, id := range issued {
+		couponOnly[id] = true
+	}
+
+	var couponPromotionID string
+	if cart.CouponCode != "" {
+		coupon, err := s.CouponRepo.GetCouponbyCouponCode(cart.CouponCode)
+		if err != nil && !errors.Is(err, exception.ErrNotFound) {
+			return nil, CartCustomer{}, "", err
+		}
+		if err == nil && !coupon.Exhausted() {
+			couponPromotionID = coupon.PromotionID
+		}
+	}
+
+	promos := make([]models.Promotion, 0, len(active))
+	limited, firstOrder := false, false
+	for _, promo := range active {
+		if couponOnly[promo.PromotionID] && promo.PromotionID != couponPromotionID {
+			continue
+		}
+		promos = append(promos, promo)
+		limited = limited || promo.Rules.MaxUsesPerCustomer > 0
+		firstOrder = firstOrder || promo.Rules.FirstOrderOnly
+	}
+	if cart.CustomerID == "" {
+		return promos, customer, couponPromotionID, nil
+	}
+
+	// A cancelled order does not count, the customer did not get anything from it
+	if firstOrder {
+		orders, err := s.OrderRepo.CountOrdersbyCustomer(cart.CustomerID)
+		if err != nil {
+			return nil, CartCustomer{}, "", err
+		}
+		customer.FirstOrder = orders == 0
+	}
+	if !limited {
+		return promos, customer, couponPromotionID, nil
+	}
+
+	// The orders record every use, the coupon may also have been redeemed outside of the cart
+	usage, err := s.OrderRepo.CountPromotionUsesbyCustomer(cart.CustomerID)
+	if err != nil {
+		return nil, CartCustomer{}, "", err
+	}
+	if couponPromotionID != "" {
+		redeemed, err := s.CouponRepo.CountRedemptionsbyCustomer(couponPromotionID, cart.CustomerID)
+		if err != nil {
+			return nil, CartCustomer{}, "", err
+		}
+		usage[couponPromotionID] = max(usage[couponPromotionID], redeemed)
+	}
+	customer.PromotionUsage = usage
+	return promos, customer, couponPromotionID, nil
+}
+
+// cartLine prices the item at the current price of its variant, a product or variant no longer sold
+// gives a line that is not available
+func (s *CartServiceImpl) cartLine(item models.CartItem) (CartLine, error) {
+	line := CartLine{
+		ProductID: item.ProductID,
+		VariantID: item.VariantID,
+		Quantity:  item.Quantity,
+		UnitPrice: decimal.Zero,
+		LineTotal: decimal.Zero,
+		Discount:  decimal.Zero,
+		Total:     decimal.Zero,
+	}
+
+	product, err := s.ProductRepo.GetProductbyProductID(item.ProductID)
+	if errors.Is(err, exception.ErrNotFound) {
+		return line, nil
+	}
+	if err != nil {
+		return CartLine{}, err
+	}
+	variant, err := s.VariantRepo.GetVariantbyVariantID(item.ProductID, item.VariantID)
+	if errors.Is(err, exception.ErrNotFound) {
+		line.ProductName = product.ProductName
+		return line, nil
+	}
+	if err != nil {
+		return CartLine{}, err
+	}
+
+	line.ProductName = product.ProductName
+	line.SKU = variant.SKU
+	line.Options = variant.Options
+	line.UnitPrice = variant.EffectivePrice(product)
+	line.Available = product.Status == models.ProductStatusActive
+	line.InStock = product.Stock >= item.Quantity
+	line.categoryID = product.CategoryID
+	return line, nil
+}
+
+// PriceCart calculates the totals of the available lines: the promotions are resolved with the
+// stacking mode of the settings, the tax is charged on the subtotal after the item discounts and
+// shipping is only charged when something is bought. The customer decides the first order, segment
+// and per customer limits of the promotions.
+func PriceCart(lines []CartLine, customer CartCustomer, promos []models.Promotion, settings CartSettings, now time.Time) ([]CartLine, CartTotals, []promotions.AppliedPromotion, error) {
+	totals := CartTotals{
+		Subtotal:         decimal.Zero,
+		ItemDiscount:     decimal.Zero,
+		Shipping:         decimal.Zero,
+		ShippingDiscount: decimal.Zero,
+		TotalDiscount:    decimal.Zero,
+		Tax:              decimal.Zero,
+		GrandTotal:       decimal.Zero,
+	}
+
+	promoCart := promotions.Cart{
+		CustomerID:      customer.CustomerID,
+		CustomerSegment: customer.Segment,
+		FirstOrder:      customer.FirstOrder,
+		PromotionUsage:  customer.PromotionUsage,
+	}
+	var priced []int
+	for i := range lines {
+		lines[i].LineTotal = lines[i].UnitPrice.Mul(decimal.NewFromInt(int64(lines[i].Quantity)))
+		lines[i].Discount = decimal.Zero
+		lines[i].Total = lines[i].LineTotal
+		if !lines[i].Available {
+			continue
+		}
+
+		totals.Subtotal = totals.Subtotal.Add(lines[i].LineTotal)
+		promoCart.Items = append(promoCart.Items, promotions.CartItem{
+			ProductID:  lines[i].ProductID,
+			CategoryID: lines[i].categoryID,
+			Quantity:   lines[i].Quantity,
+			UnitPrice:  lines[i].UnitPrice.InexactFloat64(),
+		})
+		priced = append(priced, i)
+	}
+	if len(priced) == 0 {
+		return lines, totals, []promotions.AppliedPromotion{}, nil
+	}
+
+	totals.Shipping = settings.ShippingCost
+	promoCart.ShippingCost = settings.ShippingCost.InexactFloat64()
+
+	result, err := promotions.ResolveStacking(promos, promoCart, settings.StackingMode, now)
+	if err != nil {
+		return nil, CartTotals{}, nil, err
+	}
+
+	// Every quote has one discount line per cart item, in the order of the items
+	for _, applied := range result.Applied {
+		for j, discountLine := range applied.Quote.Lines {
+			i := priced[j]
+			lines[i].Discount = decimal.Min(lines[i].LineTotal, lines[i].Discount.Add(decimal.NewFromFloat(discountLine.Discount)))
+		}
+	}
+	for _, i := range priced {
+		lines[i].Total = lines[i].LineTotal.Sub(lines[i].Discount)
+	}
+
+	totals.ItemDiscount = decimal.NewFromFloat(result.ItemDiscount)
+	totals.ShippingDiscount = decimal.NewFromFloat(result.ShippingDiscount)
+	totals.TotalDiscount = totals.ItemDiscount.Add(totals.ShippingDiscount)
+	totals.Tax = totals.Subtotal.Sub(totals.ItemDiscount).Mul(settings.TaxRate).Round(0)
+	totals.GrandTotal = totals.Subtotal.Sub(totals.TotalDiscount).Add(totals.Shipping).Add(totals.Tax)
+	return lines, totals, result.Applied, nil
+}
+
+func cartItem(cart models.Cart, variantID string) (models.CartItem, bool) {
+	for _, item := range cart.Items {
+		if item.VariantID == variantID {
+			return item, true
+		}
+	}
+	return models.CartItem{}, false
+}
+
+func invalidCart(field string, message string) error {
+	return &exception.ValidationError{
+		Message: "Invalid Cart",
+		Fields: []exception.FieldError{{
+			Field:   field,
+			Rule:    "cart",
+			Message: message,
+		}},
+	}
+}
@@ -1 +1,134 @@
 package products
+
+import (
+	"github.com/oklog/ulid/v2"
+
+	postgresql "smkdevid/echocommercehub/internal/databases/postgresql"
+	models "smkdevid/echocommercehub/internal/models/schema"
+)
+
+// ProductService provides the product catalog
+type ProductService interface {
+	CreateProduct(product models.Product) (models.Product, error)
+	ListProducts(query postgresql.ProductQuery) (ProductPage, error)
+	SearchProducts(query postgresql.ProductSearchQuery) (SearchPage, error)
+	GetProductbyProductID(productID string) (models.Product, error)
+	UpdateProductbyProductID(product models.Product) (models.Product, error)
+	DeleteProductbyProductID(productID string) error
+}
+
+// ProductPage is a page of the product list
+type ProductPage struct {
+	Data   []models.Product `json:"data"`
+	Total  int64            `json:"total"`
+	Limit  int              `json:"limit"`
+	Offset int              `json:"offset,omitempty"`
+}
+
+// SearchPage is a page of search hits with the facet counts of every matching product.
+// The pagination is not part of the body, it goes to the response meta.
+type SearchPage struct {
+	Hits   []postgresql.ProductSearchHit `json:"hits"`
+	Facets postgresql.ProductFacets      `json:"facets"`
+	Fuzzy  bool                          `json:"fuzzy"`
+	Total  int64                         `json:"-"`
+	Limit  int                           `json:"-"`
+	Offset int                           `json:"-"`
+}
+
+type ProductServiceImpl struct {
+	ProductRepo postgresql.ProductRepository
+}
+
+// NewProductService creates a new instance of ProductService
+func NewProductService(ProductRepo postgresql.ProductRepository) *ProductServiceImpl {
+	return &ProductServiceImpl{
+		ProductRepo: ProductRepo,
+	}
+}
+
+// CreateProduct creates a new product under a server generated product ID, as a draft unless a status is given
+func (s *ProductServiceImpl) CreateProduct(product models.Product) (models.Product, error) {
+	product.ProductID = NewProductID()
+	if product.Status == "" {
+		product.Status = models.ProductStatusDraft
+	}
+	if product.Images == nil {
+		product.Images = []string{}
+	}
+	if product.Tags == nil {
+		product.Tags = []string{}
+	}
+	return s.ProductRepo.CreateProduct(product)
+}
+
+// NewProductID mints a ULID, unique and sortable by creation time
+func NewProductID() string {
+	return ulid.Make().String()
+}
+
+// ListProducts throw a page of products matching the query
+func (s *ProductServiceImpl) ListProducts(query postgresql.ProductQuery) (ProductPage, error) {
+	query, err := query.Normalize()
+	if err != nil {
+		return ProductPage{}, err
+	}
+
+	list, err := s.ProductRepo.ListProducts(query)
+	if err != nil {
+		return ProductPage{}, err
+	}
+
+	return ProductPage{
+		Data:   list.Products,
+		Total:  list.Total,
+		Limit:  query.Limit,
+		Offset: query.Offset,
+	}, nil
+}
+
+// SearchProducts throw the active products matching the search text, ranked by relevance
+func (s *ProductServiceImpl) SearchProducts(query postgresql.ProductSearchQuery) (SearchPage, error) {
+	query, err := query.Normalize()
+	if err != nil {
+		return SearchPage{}, err
+	}
+
+	result, err := s.ProductRepo.SearchProducts(query)
+	if err != nil {
+		return SearchPage{}, err
+	}
+
+	return SearchPage{
+		Hits:   result.Hits,
+		Facets: result.Facets,
+		Fuzzy:  result.Fuzzy,
+		Total:  result.Total,
+		Limit:  query.Limit,
+		Offset: query.Offset,
+	}, nil
+}
+
+// GetProductbyProductID will throw data based on productID request
+func (s *ProductServiceImpl) GetProductbyProductID(productID string) (models.Product, error) {
+	return s.ProductRepo.GetProductbyProductID(productID)
+}
+
+// UpdateProductbyProductID replaces the product, a missing status keeps it a draft
+func (s *ProductServiceImpl) UpdateProductbyProductID(product models.Product) (models.Product, error) {
+	if product.Status == "" {
+		product.Status = models.ProductStatusDraft
+	}
+	if product.Images == nil {
+		product.Images = []string{}
+	}
+	if product.Tags == nil {
+		product.Tags = []string{}
+	}
+	return s.ProductRepo.UpdateProductbyProductID(product)
+}
+
+// DeleteProductbyProductID will soft delete data based on productID request
+func (s *ProductServiceImpl) DeleteProductbyProductID(productID string) error {
+	return s.ProductRepo.DeleteProductbyProductID(productID)
+}
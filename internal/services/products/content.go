@@ -1 +1,341 @@
 package products
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/oklog/ulid/v2"
+
+	postgresql "smkdevid/echocommercehub/internal/databases/postgresql"
+	models "smkdevid/echocommercehub/internal/models/schema"
+	"smkdevid/echocommercehub/pkg/storage"
+	"smkdevid/echocommercehub/utils/exception"
+)
+
+// mediaTypes are the accepted content types, detected from the file content and not from the client,
+// with the kind and file extension they are stored as
+var mediaTypes = map[string]struct {
+	kind      string
+	extension string
+}{
+	"image/jpeg": {models.MediaKindImage, ".jpg"},
+	"image/png":  {models.MediaKindImage, ".png"},
+	"image/webp": {models.MediaKindImage, ".webp"},
+	"video/mp4":  {models.MediaKindVideo, ".mp4"},
+	"video/webm": {models.MediaKindVideo, ".webm"},
+}
+
+// MaxAltTextLength bounds the alt text of a media
+const MaxAltTextLength = 255
+
+// MaxImagePixels bounds the width times height of an image. A small compressed file can declare
+// a huge image, so the size in bytes alone does not bound the memory taken to decode it.
+const MaxImagePixels = 50_000_000
+
+// MediaService manages the images and videos of products and their variants
+type MediaService interface {
+	UploadMedia(ctx context.Context, productID string, upload MediaUpload) (models.ProductMedia, error)
+	GetMediabyProductID(productID string, variantID string) ([]models.ProductMedia, error)
+	UpdateMedia(productID string, mediaID string, req UpdateMediaRequest) (models.ProductMedia, error)
+	ReorderMedia(productID string, req ReorderMediaRequest) ([]models.ProductMedia, error)
+	DeleteMedia(productID string, mediaID string) error
+}
+
+// MediaLimits are the largest files accepted, in bytes
+type MediaLimits struct {
+	MaxImageSize int64
+	MaxVideoSize int64
+}
+
+// MediaUpload is an uploaded file. Size is the size announced by the client, the content is
+// counted again while it is stored.
+type MediaUpload struct {
+	VariantID string
+	AltText   string
+	Size      int64
+	Body      io.Reader
+}
+
+// UpdateMediaRequest changes the alt text of a media or moves it to another variant, an empty VariantID
+// moves it to the product itself
+type UpdateMediaRequest struct {
+	AltText   string `json:"alt_text" validate:"max=255"`
+	VariantID string `json:"variant_id" validate:"max=26"`
+}
+
+// ReorderMediaRequest lists every media of the product in its new order
+type ReorderMediaRequest struct {
+	MediaIDs []string `json:"media_ids" validate:"required,min=1,unique,dive,required"`
+}
+
+type MediaServiceImpl struct {
+	ProductRepo postgresql.ProductRepository
+	VariantRepo postgresql.ProductVariantRepository
+	MediaRepo   postgresql.ProductMediaRepository
+	Storage     storage.ObjectStorage
+	Limits      MediaLimits
+}
+
+// NewMediaService creates a new instance of MediaService
+func NewMediaService(ProductRepo postgresql.ProductRepository, VariantRepo postgresql.ProductVariantRepository, MediaRepo postgresql.ProductMediaRepository, Storage storage.ObjectStorage, Limits MediaLimits) *MediaServiceImpl {
+	return &MediaServiceImpl{
+		ProductRepo: ProductRepo,
+		VariantRepo: VariantRepo,
+		MediaRepo:   MediaRepo,
+		Storage:     Storage,
+		Limits:      Limits,
+	}
+}
+
+// NewMediaID mints a ULID, unique and sortable by creation time
+func NewMediaID() string {
+	return ulid.Make().String()
+}
+
+// UploadMedia stores the file after the media already attached to the product. Images get a thumbnail
+// in every ThumbnailSizes, videos are stored as they are.
+func (s *MediaServiceImpl) UploadMedia(ctx context.Context, productID string, upload MediaUpload) (models.ProductMedia, error) {
+	if _, err := s.ProductRepo.GetProductbyProductID(productID); err != nil {
+		return models.ProductMedia{}, err
+	}
+	if upload.VariantID != "" {
+		if _, err := s.VariantRepo.GetVariantbyVariantID(productID, upload.VariantID); err != nil {
+			return models.ProductMedia{}, err
+		}
+	}
+	if utf8.RuneCountInString(upload.AltText) > MaxAltTextLength {
+		return models.ProductMedia{}, invalidMedia("alt_text", fmt.Sprintf("must be at most %d characters", MaxAltTextLength))
+	}
+
+	body := bufio.NewReaderSize(upload.Body, 512)
+	head, _ := body.Peek(512)
+	contentType := http.DetectContentType(head)
+	mediaType, ok := mediaTypes[contentType]
+	if !ok {
+		return models.ProductMedia{}, &exception.UnsupportedMediaTypeError{
+			Message:     "Only JPEG, PNG and WebP images and MP4 and WebM videos are accepted",
+			ContentType: contentType,
+		}
+	}
+
+	limit := s.Limits.MaxImageSize
+	if mediaType.kind == models.MediaKindVideo {
+		limit = s.Limits.MaxVideoSize
+	}
+	if upload.Size > limit {
+		return models.ProductMedia{}, mediaTooLarge(limit)
+	}
+
+	existing, err := s.MediaRepo.GetMediabyProductID(productID, "")
+	if err != nil {
+		return models.ProductMedia{}, err
+	}
+
+	media := models.ProductMedia{
+		MediaID:     NewMediaID(),
+		ProductID:   productID,
+		VariantID:   upload.VariantID,
+		Kind:        mediaType.kind,
+		ContentType: contentType,
+		AltText:     upload.AltText,
+		Position:    len(existing),
+	}
+	media.StorageKey = mediaKey(media, "original"+mediaType.extension)
+	media.URL = s.Storage.URL(media.StorageKey)
+
+	if mediaType.kind == models.MediaKindImage {
+		err = s.storeImage(ctx, &media, body, limit)
+	} else {
+		err = s.storeVideo(ctx, &media, body, limit)
+	}
+	if err != nil {
+		s.removeFiles(media)
+		return models.ProductMedia{}, err
+	}
+
+	createdMedia, err := s.MediaRepo.CreateMedia(media)
+	if err != nil {
+		s.removeFiles(media)
+		return models.ProductMedia{}, err
+	}
+	return createdMedia, nil
+}
+
+// storeImage decodes the image to read its size, then stores it with its thumbnails
+func (s *MediaServiceImpl) storeImage(ctx context.Context, media *models.ProductMedia, body io.Reader, limit int64) error {
+	data, err := io.ReadAll(io.LimitReader(body, limit+1))
+	if err != nil {
+		return err
+	}
+	if int64(len(data)) > limit {
+		return mediaTooLarge(limit)
+	}
+
+	// The header is read first so a huge image is rejected before it is decoded
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return &exception.UnsupportedMediaTypeError{
+			Message:     "The image cannot be decoded",
+			ContentType: media.ContentType,
+		}
+	}
+	if int64(config.Width)*int64(config.Height) > MaxImagePixels {
+		return invalidMedia("file", fmt.Sprintf("image must be at most %d pixels", MaxImagePixels))
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return &exception.UnsupportedMediaTypeError{
+			Message:     "The image cannot be decoded",
+			ContentType: media.ContentType,
+		}
+	}
+	media.Size = int64(len(data))
+	media.Width, media.Height = img.Bounds().Dx(), img.Bounds().Dy()
+
+	if err := s.Storage.Put(ctx, media.StorageKey, bytes.NewReader(data), media.ContentType); err != nil {
+		return err
+	}
+
+	media.Thumbnails = make(map[string]string, len(ThumbnailSizes))
+	media.ThumbnailKeys = make(map[string]string, len(ThumbnailSizes))
+	for _, size := range ThumbnailSizes {
+		thumbnail, contentType, extension, err := encodeThumbnail(Thumbnail(img, size.MaxSide), media.ContentType)
+		if err != nil {
+			return err
+		}
+
+		key := mediaKey(*media, size.Name+extension)
+		media.ThumbnailKeys[size.Name] = key
+		if err := s.Storage.Put(ctx, key, bytes.NewReader(thumbnail), contentType); err != nil {
+			return err
+		}
+		media.Thumbnails[size.Name] = s.Storage.URL(key)
+	}
+	return nil
+}
+
+// storeVideo streams the video to the storage, stopping as soon as it goes over the limit
+func (s *MediaServiceImpl) storeVideo(ctx context.Context, media *models.ProductMedia, body io.Reader, limit int64) error {
+	counter := &countingReader{r: body, limit: limit}
+	if err := s.Storage.Put(ctx, media.StorageKey, counter, media.ContentType); err != nil {
+		if errors.Is(err, errMediaTooLarge) {
+			return mediaTooLarge(limit)
+		}
+		return err
+	}
+	media.Size = counter.n
+	return nil
+}
+
+// removeFiles deletes the stored files of a media that could not be saved, the janitor cannot find them
+// since the media has no row
+func (s *MediaServiceImpl) removeFiles(media models.ProductMedia) {
+	for _, key := range media.StorageKeys() {
+		_ = s.Storage.Delete(context.Background(), key)
+	}
+}
+
+// GetMediabyProductID throw the media of the product, with a variantID only the ones shown for that variant
+func (s *MediaServiceImpl) GetMediabyProductID(productID string, variantID string) ([]models.ProductMedia, error) {
+	if _, err := s.ProductRepo.GetProductbyProductID(productID); err != nil {
+		return nil, err
+	}
+	return s.MediaRepo.GetMediabyProductID(productID, variantID)
+}
+
+// UpdateMedia changes the alt text and the variant of the media
+func (s *MediaServiceImpl) UpdateMedia(productID string, mediaID string, req UpdateMediaRequest) (models.ProductMedia, error) {
+	media, err := s.MediaRepo.GetMediabyMediaID(productID, mediaID)
+	if err != nil {
+		return models.ProductMedia{}, err
+	}
+	if req.VariantID != "" {
+		if _, err := s.VariantRepo.GetVariantbyVariantID(productID, req.VariantID); err != nil {
+			return models.ProductMedia{}, err
+		}
+	}
+
+	media.AltText = req.AltText
+	media.VariantID = req.VariantID
+	return s.MediaRepo.UpdateMedia(media)
+}
+
+// ReorderMedia moves the media of the product to the order of req.MediaIDs, which must list each of them once
+func (s *MediaServiceImpl) ReorderMedia(productID string, req ReorderMediaRequest) ([]models.ProductMedia, error) {
+	existing, err := s.GetMediabyProductID(productID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	current := make([]string, 0, len(existing))
+	for _, media := range existing {
+		current = append(current, media.MediaID)
+	}
+	requested := append([]string(nil), req.MediaIDs...)
+	sort.Strings(current)
+	sort.Strings(requested)
+	if !slices.Equal(current, requested) {
+		return nil, invalidMedia("media_ids", "must list every media of the product exactly once")
+	}
+
+	if err := s.MediaRepo.ReorderMedia(productID, req.MediaIDs); err != nil {
+		return nil, err
+	}
+	return s.MediaRepo.GetMediabyProductID(productID, "")
+}
+
+// DeleteMedia removes the media from the product, its files are removed by the MediaJanitor
+func (s *MediaServiceImpl) DeleteMedia(productID string, mediaID string) error {
+	return s.MediaRepo.DeleteMedia(productID, mediaID)
+}
+
+// mediaKey is the storage key of a file of the media, grouped by product so they are easy to find
+func mediaKey(media models.ProductMedia, name string) string {
+	return fmt.Sprintf("products/%s/%s/%s", media.ProductID, media.MediaID, name)
+}
+
+var errMediaTooLarge = errors.New("media too large")
+
+// countingReader counts the bytes read and fails once they go over the limit
+type countingReader struct {
+	r     io.Reader
+	n     int64
+	limit int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	if r.n > r.limit {
+		return n, errMediaTooLarge
+	}
+	return n, err
+}
+
+func mediaTooLarge(limit int64) error {
+	return &exception.MediaTooLargeError{
+		Message: "Media Too Large",
+		Limit:   limit,
+	}
+}
+
+func invalidMedia(field string, message string) error {
+	return &exception.ValidationError{
+		Message: "Invalid Media",
+		Fields: []exception.FieldError{{
+			Field:   field,
+			Rule:    "media",
+			Message: message,
+		}},
+	}
+}
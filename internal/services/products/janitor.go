@@ -0,0 +1,113 @@
+package products
+
+import (
+	"context"
+	"log"
+	"time"
+
+	postgresql "smkdevid/echocommercehub/internal/databases/postgresql"
+	"smkdevid/echocommercehub/pkg/storage"
+)
+
+// MediaCleanupBatch is the number of orphaned media removed on each sweep
+const MediaCleanupBatch = 100
+
+// MediaJanitor removes the files of deleted media and of the media left behind by deleted products
+// and variants, then deletes their rows for good
+type MediaJanitor struct {
+	MediaRepo postgresql.ProductMediaRepository
+	Storage   storage.ObjectStorage
+	Interval  time.Duration
+}
+
+// NewMediaJanitor creates a new instance of MediaJanitor
+func NewMediaJanitor(MediaRepo postgresql.ProductMediaRepository, Storage storage.ObjectStorage, Interval time.Duration) *MediaJanitor {
+	return &MediaJanitor{
+		MediaRepo: MediaRepo,
+		Storage:   Storage,
+		Interval:  Interval,
+	}
+}
+
+// Run sweeps on every interval until the context is cancelled
+func (j *MediaJanitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := j.Sweep(ctx); err != nil {
+			log.Printf("media janitor: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sweep removes a batch of orphaned media and returns how many were removed. A media whose files
+// cannot be deleted keeps its row, so it is tried again on the next sweep.
+func (j *MediaJanitor) Sweep(ctx context.Context) (int, error) {
+	orphans, err := j.MediaRepo.GetOrphanedMedia(MediaCleanupBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, media := range orphans {
+		if err := j.deleteFiles(ctx, media.StorageKeys()); err != nil {
+			log.Printf("media janitor: media %s: %v", media.MediaID, err)
+			continue
+		}
+		if err := j.MediaRepo.PurgeMedia(media.MediaID); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+func (j *MediaJanitor) deleteFiles(ctx context.Context, keys []string) error {
+	for _, key := range keys {
+		if err := j.Storage.Delete(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CartJanitor closes the carts whose expiry passed, so their shoppers start over with an empty cart
+type CartJanitor struct {
+	CartRepo postgresql.CartRepository
+	Interval time.Duration
+}
+
+// NewCartJanitor creates a new instance of CartJanitor
+func NewCartJanitor(CartRepo postgresql.CartRepository, Interval time.Duration) *CartJanitor {
+	return &CartJanitor{
+		CartRepo: CartRepo,
+		Interval: Interval,
+	}
+}
+
+// Run expires the carts on every interval until the context is cancelled
+func (j *CartJanitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.Interval)
+	defer ticker.Stop()
+
+	for {
+		if expired, err := j.CartRepo.ExpireCarts(time.Now()); err != nil {
+			log.Printf("cart janitor: %v", err)
+		} else if expired > 0 {
+			log.Printf("cart janitor: expired %d carts", expired)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
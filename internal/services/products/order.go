@@ -1 +1,288 @@
 package products
+
+import (
+	"github.com/oklog/ulid/v2"
+	"github.com/shopspring/decimal"
+
+	postgresql "smkdevid/echocommercehub/internal/databases/postgresql"
+	models "smkdevid/echocommercehub/internal/models/schema"
+	"smkdevid/echocommercehub/utils/exception"
+)
+
+// OrderTransitions are the statuses an order can move to from each status. Cancelled and refunded
+// orders are final.
+var OrderTransitions = map[string][]string{
+	models.OrderStatusPendingPayment: {models.OrderStatusPaid, models.OrderStatusCancelled},
+	models.OrderStatusPaid:           {models.OrderStatusProcessing, models.OrderStatusRefunded},
+	models.OrderStatusProcessing:     {models.OrderStatusShipped, models.OrderStatusRefunded},
+	models.OrderStatusShipped:        {models.OrderStatusDelivered},
+	models.OrderStatusDelivered:      {models.OrderStatusRefunded},
+}
+
+// paymentOrderStatuses are the statuses only the payment of an order moves it to, so the order never
+// claims money the payment records do not back
+var paymentOrderStatuses = map[string]bool{
+	models.OrderStatusPaid:     true,
+	models.OrderStatusRefunded: true,
+}
+
+// OrderService places orders from carts and moves them through their statuses
+type OrderService interface {
+	PlaceOrder(customer CartOwner, req PlaceOrderRequest) (models.Order, error)
+	GetOrder(orderID string) (models.Order, error)
+	GetCustomerOrder(customerID string, orderID string) (models.Order, error)
+	ListOrders(query postgresql.OrderQuery) (OrderPage, error)
+	TransitionOrder(orderID string, req TransitionOrderRequest, actor string) (models.Order, error)
+	FollowPayment(orderID string, status string, actor string, reason string) (models.Order, error)
+	CancelCustomerOrder(customerID string, orderID string, req CancelOrderRequest) (models.Order, error)
+	GetOrderHistory(orderID string) ([]models.OrderTransition, error)
+}
+
+// PlaceOrderRequest places the cart of the customer, the billing address defaults to the shipping address
+type PlaceOrderRequest struct {
+	ShippingAddress models.Address  `json:"shipping_address" validate:"required"`
+	BillingAddress  *models.Address `json:"billing_address" validate:"omitempty"`
+	Note            string          `json:"note" validate:"max=500"`
+}
+
+// TransitionOrderRequest moves an order to another status
+type TransitionOrderRequest struct {
+	Status string `json:"status" validate:"required,oneof=pending_payment processing shipped delivered cancelled"`
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+// CancelOrderRequest cancels an order that is not paid yet
+type CancelOrderRequest struct {
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+// OrderPage is a page of the order list
+type OrderPage struct {
+	Data   []models.Order `json:"data"`
+	Total  int64          `json:"total"`
+	Limit  int            `json:"limit"`
+	Offset int            `json:"offset,omitempty"`
+}
+
+type OrderServiceImpl struct {
+	OrderRepo   postgresql.OrderRepository
+	CartService CartService
+}
+
+// NewOrderService creates a new instance of OrderService
+func NewOrderService(OrderRepo postgresql.OrderRepository, CartService CartService) *OrderServiceImpl {
+	return &OrderServiceImpl{
+		OrderRepo:   OrderRepo,
+		CartService: CartService,
+	}
+}
+
+// NewOrderID mints a ULID, unique and sortable by creation time
+func NewOrderID() string {
+	return ulid.Make().String()
+}
+
+// CanTransition reports whether an order can move from one status to the other
+func CanTransition(from string, to string) bool {
+	for _, next := range OrderTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// PlaceOrder turns the cart of the customer into an order waiting for payment. The lines, totals and
+// promotions are priced once more and copied into the order, every line must still be for sale and in stock.
+func (s *OrderServiceImpl) PlaceOrder(customer CartOwner, req PlaceOrderRequest) (models.Order, error) {
+	if customer.CustomerID == "" {
+		return models.Order{}, invalidOrder("customer_id", "is required to place an order")
+	}
+
+	cart, err := s.CartService.GetCart(CartOwner{CustomerID: customer.CustomerID, Segment: customer.Segment})
+	if err != nil {
+		return models.Order{}, err
+	}
+	if cart.CartID == "" || len(cart.Lines) == 0 {
+		return models.Order{}, invalidOrder("cart", "is empty")
+	}
+
+	items := make([]models.OrderItem, 0, len(cart.Lines))
+	for _, line := range cart.Lines {
+		if !line.Available {
+			return models.Order{}, invalidOrder("cart", line.ProductName+" is no longer for sale")
+		}
+		if !line.InStock {
+			return models.Order{}, invalidOrder("cart", line.ProductName+" does not have enough stock")
+		}
+		items = append(items, models.OrderItem{
+			ProductID:   line.ProductID,
+			VariantID:   line.VariantID,
+			ProductName: line.ProductName,
+			SKU:         line.SKU,
+			Options:     line.Options,
+			Quantity:    line.Quantity,
+			UnitPrice:   line.UnitPrice,
+			LineTotal:   line.LineTotal,
+			Discount:    line.Discount,
+			Total:       line.Total,
+		})
+	}
+
+	applied := make([]models.OrderPromotion, 0, len(cart.Promotions))
+	for _, promo := range cart.Promotions {
+		applied = append(applied, models.OrderPromotion{
+			PromotionID:   promo.PromotionID,
+			PromotionName: promo.PromotionName,
+			Discount:      decimal.NewFromFloat(promo.Quote.TotalDiscount),
+		})
+	}
+
+	billing := req.ShippingAddress
+	if req.BillingAddress != nil {
+		billing = *req.BillingAddress
+	}
+
+	order := models.Order{
+		OrderID:          NewOrderID(),
+		CustomerID:       customer.CustomerID,
+		CartID:           cart.CartID,
+		Status:           models.OrderStatusPendingPayment,
+		Currency:         "IDR",
+		Subtotal:         cart.Totals.Subtotal,
+		ItemDiscount:     cart.Totals.ItemDiscount,
+		Shipping:         cart.Totals.Shipping,
+		ShippingDiscount: cart.Totals.ShippingDiscount,
+		TotalDiscount:    cart.Totals.TotalDiscount,
+		Tax:              cart.Totals.Tax,
+		GrandTotal:       cart.Totals.GrandTotal,
+		Promotions:       applied,
+		ShippingAddress:  req.ShippingAddress,
+		BillingAddress:   billing,
+		Note:             req.Note,
+		Items:            items,
+	}
+	// The coupon is redeemed with the order, only when its promotion was applied
+	if cart.CouponApplied {
+		order.CouponCode = cart.CouponCode
+	}
+	return s.OrderRepo.CreateOrder(order, customer.CustomerID)
+}
+
+// GetOrder throw the order with its items
+func (s *OrderServiceImpl) GetOrder(orderID string) (models.Order, error) {
+	return s.OrderRepo.GetOrderbyOrderID(orderID)
+}
+
+// GetCustomerOrder throw the order only when it belongs to the customer, the orders of other
+// customers are not found
+func (s *OrderServiceImpl) GetCustomerOrder(customerID string, orderID string) (models.Order, error) {
+	order, err := s.OrderRepo.GetOrderbyOrderID(orderID)
+	if err != nil {
+		return models.Order{}, err
+	}
+	if order.CustomerID != customerID {
+		return models.Order{}, &exception.OrderNotFoundError{
+			Message: "Order Not Found",
+			OrderID: orderID,
+		}
+	}
+	return order, nil
+}
+
+// ListOrders throw a page of the orders matching the query, newest first
+func (s *OrderServiceImpl) ListOrders(query postgresql.OrderQuery) (OrderPage, error) {
+	query, err := query.Normalize()
+	if err != nil {
+		return OrderPage{}, err
+	}
+
+	list, err := s.OrderRepo.ListOrders(query)
+	if err != nil {
+		return OrderPage{}, err
+	}
+
+	return OrderPage{
+		Data:   list.Orders,
+		Total:  list.Total,
+		Limit:  query.Limit,
+		Offset: query.Offset,
+	}, nil
+}
+
+// TransitionOrder moves the order to the requested status when OrderTransitions allows it. Paid and
+// refunded are left to the payment of the order, see FollowPayment.
+func (s *OrderServiceImpl) TransitionOrder(orderID string, req TransitionOrderRequest, actor string) (models.Order, error) {
+	if paymentOrderStatuses[req.Status] {
+		return models.Order{}, invalidOrder("status", "an order is paid or refunded through its payment")
+	}
+
+	order, err := s.OrderRepo.GetOrderbyOrderID(orderID)
+	if err != nil {
+		return models.Order{}, err
+	}
+	return s.transition(order, req.Status, actor, req.Reason)
+}
+
+// FollowPayment moves the order to the status its payment reached, it is the only way to paid and refunded
+func (s *OrderServiceImpl) FollowPayment(orderID string, status string, actor string, reason string) (models.Order, error) {
+	order, err := s.OrderRepo.GetOrderbyOrderID(orderID)
+	if err != nil {
+		return models.Order{}, err
+	}
+	return s.transition(order, status, actor, reason)
+}
+
+// CancelCustomerOrder lets the customer cancel their own order while it waits for payment, the open
+// payments of the order are voided by PaymentService.CancelCustomerOrder before it is called
+func (s *OrderServiceImpl) CancelCustomerOrder(customerID string, orderID string, req CancelOrderRequest) (models.Order, error) {
+	order, err := s.GetCustomerOrder(customerID, orderID)
+	if err != nil {
+		return models.Order{}, err
+	}
+	return s.transition(order, models.OrderStatusCancelled, customerID, req.Reason)
+}
+
+// GetOrderHistory throw every status change of the order, oldest first
+func (s *OrderServiceImpl) GetOrderHistory(orderID string) ([]models.OrderTransition, error) {
+	if _, err := s.OrderRepo.GetOrderbyOrderID(orderID); err != nil {
+		return nil, err
+	}
+	return s.OrderRepo.GetOrderTransitions(orderID)
+}
+
+func (s *OrderServiceImpl) transition(order models.Order, target string, actor string, reason string) (models.Order, error) {
+	if !CanTransition(order.Status, target) {
+		return models.Order{}, &exception.InvalidStatusTransitionError{
+			Message: "Order cannot change status",
+			From:    order.Status,
+			To:      target,
+		}
+	}
+
+	updated, err := s.OrderRepo.TransitionOrder(order.OrderID, order.Status, target, actor, reason)
+	if err != nil {
+		return models.Order{}, err
+	}
+	if !updated {
+		return models.Order{}, &exception.InvalidStatusTransitionError{
+			Message: "Order status changed concurrently",
+			From:    order.Status,
+			To:      target,
+		}
+	}
+
+	order.Status = target
+	return order, nil
+}
+
+func invalidOrder(field string, message string) error {
+	return &exception.ValidationError{
+		Message: "Invalid Order",
+		Fields: []exception.FieldError{{
+			Field:   field,
+			Rule:    "order",
+			Message: message,
+		}},
+	}
+}
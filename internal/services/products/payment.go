@@ -1 +1,542 @@
 package products
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/shopspring/decimal"
+
+	postgresql "smkdevid/echocommercehub/internal/databases/postgresql"
+	models "smkdevid/echocommercehub/internal/models/schema"
+	"smkdevid/echocommercehub/utils/exception"
+)
+
+// PaymentChannels are the banks and e-wallets accepted for each payment method, QRIS has no channel
+var PaymentChannels = map[string][]string{
+	models.PaymentMethodVirtualAccount: {"bca", "bni", "bri", "permata", "cimb"},
+	models.PaymentMethodQRIS:           {},
+	models.PaymentMethodEWallet:        {"gopay", "shopeepay"},
+}
+
+// DefaultPaymentExpiry is how long the customer has to pay when the request does not say
+const DefaultPaymentExpiry = 24 * time.Hour
+
+// PaymentProvider charges customers through a payment gateway. Every call returns the state of the
+// charge as the gateway knows it, the reference identifies the charge at the gateway.
+type PaymentProvider interface {
+	Name() string
+	CreateCharge(ctx context.Context, req ChargeRequest) (ChargeResult, error)
+	Capture(ctx context.Context, reference string, amount decimal.Decimal) (ChargeResult, error)
+	Void(ctx context.Context, reference string) (ChargeResult, error)
+	Refund(ctx context.Context, reference string, amount decimal.Decimal, reason string) (ChargeResult, error)
+	QueryStatus(ctx context.Context, reference string) (ChargeResult, error)
+}
+
+// ChargeRequest asks the provider to charge the amount of a payment attempt
+type ChargeRequest struct {
+	PaymentID string
+	OrderID   string
+	Amount    decimal.Decimal
+	Currency  string
+	Method    string
+	Channel   string
+	Customer  models.Address
+	ExpiresAt time.Time
+}
+
+// ChargeResult is the state of a charge at the provider. Status is one of the payment statuses,
+// RefundedAmount is the total refunded so far.
+type ChargeResult struct {
+	Reference      string
+	Status         string
+	Instructions   models.PaymentInstructions
+	RefundedAmount decimal.Decimal
+	FailureReason  string
+	ExpiresAt      *time.Time
+	PaidAt         *time.Time
+}
+
+// PaymentService charges orders and keeps their status in line with the outcome of the payments
+type PaymentService interface {
+	CreatePayment(ctx context.Context, customerID string, orderID string, req CreatePaymentRequest) (models.Payment, error)
+	GetPayment(paymentID string) (models.Payment, error)
+	GetOrderPayments(orderID string) ([]models.Payment, error)
+	GetCustomerOrderPayments(customerID string, orderID string) ([]models.Payment, error)
+	CapturePayment(ctx context.Context, paymentID string, actor string) (models.Payment, error)
+	VoidPayment(ctx context.Context, paymentID string, actor string) (models.Payment, error)
+	RefundPayment(ctx context.Context, paymentID string, req RefundPaymentRequest, actor string) (models.Payment, error)
+	SyncPayment(ctx context.Context, paymentID string) (models.Payment, error)
+	RecordOutcome(ctx context.Context, reference string, result ChargeResult) (models.Payment, error)
+	CancelCustomerOrder(ctx context.Context, customerID string, orderID string, req CancelOrderRequest) (models.Order, error)
+}
+
+// CreatePaymentRequest starts a payment of an order waiting for payment
+type CreatePaymentRequest struct {
+	Method  string `json:"method" validate:"required,oneof=virtual_account qris ewallet"`
+	Channel string `json:"channel" validate:"max=32"`
+}
+
+// RefundPaymentRequest refunds a paid payment, without an amount whatever was not refunded yet
+type RefundPaymentRequest struct {
+	Amount *decimal.Decimal `json:"amount"`
+	Reason string           `json:"reason" validate:"required,max=500"`
+}
+
+type PaymentServiceImpl struct {
+	PaymentRepo  postgresql.PaymentRepository
+	OrderService OrderService
+	Provider     PaymentProvider
+	Expiry       time.Duration
+}
+
+// NewPaymentService creates a new instance of PaymentService
+func NewPaymentService(PaymentRepo postgresql.PaymentRepository, OrderService OrderService, Provider PaymentProvider, Expiry time.Duration) *PaymentServiceImpl {
+	if Expiry <= 0 {
+		Expiry = DefaultPaymentExpiry
+	}
+	return &PaymentServiceImpl{
+		PaymentRepo:  PaymentRepo,
+		OrderService: OrderService,
+		Provider:     Provider,
+		Expiry:       Expiry,
+	}
+}
+
+// NewPaymentID mints a ULID, unique and sortable by creation time
+func NewPaymentID() string {
+	return ulid.Make().String()
+}
+
+// CreatePayment records a payment attempt for the order of the customer and charges it at the provider.
+// The attempt is stored before the provider is called, a charge the provider rejects is kept as failed.
+func (s *PaymentServiceImpl) CreatePayment(ctx context.Context, customerID string, orderID string, req CreatePaymentRequest) (models.Payment, error) {
+	if err := validatePaymentChannel(req.Method, req.Channel); err != nil {
+		return models.Payment{}, err
+	}
+
+	order, err := s.OrderService.GetCustomerOrder(customerID, orderID)
+	if err != nil {
+		return models.Payment{}, err
+	}
+	if order.Status != models.OrderStatusPendingPayment {
+		return models.Payment{}, &exception.InvalidStatusTransitionError{
+			Message: "Order cannot be paid",
+			From:    order.Status,
+			To:      models.OrderStatusPaid,
+		}
+	}
+
+	expiresAt := time.Now().Add(s.Expiry)
+	payment, err := s.PaymentRepo.CreatePayment(models.Payment{
+		PaymentID: NewPaymentID(),
+		OrderID:   order.OrderID,
+		Provider:  s.Provider.Name(),
+		Method:    req.Method,
+		Channel:   req.Channel,
+		Amount:    order.GrandTotal,
+		Currency:  order.Currency,
+		Status:    models.PaymentStatusPending,
+		ExpiresAt: &expiresAt,
+	})
+	if err != nil {
+		return models.Payment{}, err
+	}
+
+	result, chargeErr := s.Provider.CreateCharge(ctx, ChargeRequest{
+		PaymentID: payment.PaymentID,
+		OrderID:   order.OrderID,
+		Amount:    payment.Amount,
+		Currency:  payment.Currency,
+		Method:    payment.Method,
+		Channel:   payment.Channel,
+		Customer:  order.BillingAddress,
+		ExpiresAt: expiresAt,
+	})
+	if chargeErr != nil {
+		result = ChargeResult{Status: models.PaymentStatusFailed, FailureReason: chargeErr.Error()}
+	}
+
+	payment, err = s.apply(ctx, payment, result, "payment:"+payment.Provider)
+	if err != nil {
+		return models.Payment{}, err
+	}
+	if chargeErr != nil {
+		return payment, s.providerError(chargeErr)
+	}
+	return payment, nil
+}
+
+// GetPayment throw the payment attempt
+func (s *PaymentServiceImpl) GetPayment(paymentID string) (models.Payment, error) {
+	return s.PaymentRepo.GetPaymentbyPaymentID(paymentID)
+}
+
+// GetOrderPayments throw every payment attempt of the order, oldest first
+func (s *PaymentServiceImpl) GetOrderPayments(orderID string) ([]models.Payment, error) {
+	if _, err := s.OrderService.GetOrder(orderID); err != nil {
+		return nil, err
+	}
+	return s.PaymentRepo.GetPaymentsbyOrderID(orderID)
+}
+
+// GetCustomerOrderPayments throw the payment attempts only when the order belongs to the customer
+func (s *PaymentServiceImpl) GetCustomerOrderPayments(customerID string, orderID string) ([]models.Payment, error) {
+	if _, err := s.OrderService.GetCustomerOrder(customerID, orderID); err != nil {
+		return nil, err
+	}
+	return s.PaymentRepo.GetPaymentsbyOrderID(orderID)
+}
+
+// CapturePayment takes the money of an authorized payment
+func (s *PaymentServiceImpl) CapturePayment(ctx context.Context, paymentID string, actor string) (models.Payment, error) {
+	payment, err := s.paymentInStatus(paymentID, models.PaymentStatusPaid, models.PaymentStatusAuthorized)
+	if err != nil {
+		return models.Payment{}, err
+	}
+
+	result, err := s.Provider.Capture(ctx, payment.Reference, payment.Amount)
+	if err != nil {
+		return models.Payment{}, s.providerError(err)
+	}
+	return s.apply(ctx, payment, result, actor)
+}
+
+// VoidPayment cancels an open payment before any money is taken
+func (s *PaymentServiceImpl) VoidPayment(ctx context.Context, paymentID string, actor string) (models.Payment, error) {
+	payment, err := s.paymentInStatus(paymentID, models.PaymentStatusVoided, models.PaymentStatusPending, models.PaymentStatusAuthorized)
+	if err != nil {
+		return models.Payment{}, err
+	}
+
+	result, err := s.Provider.Void(ctx, payment.Reference)
+	if err != nil {
+		return models.Payment{}, s.providerError(err)
+	}
+	return s.apply(ctx, payment, result, actor)
+}
+
+// RefundPayment gives back part or all of a paid payment. The payment and its order are refunded
+// once the whole amount was given back.
+func (s *PaymentServiceImpl) RefundPayment(ctx context.Context, paymentID string, req RefundPaymentRequest, actor string) (models.Payment, error) {
+	payment, err := s.paymentInStatus(paymentID, models.PaymentStatusRefunded, models.PaymentStatusPaid)
+	if err != nil {
+		return models.Payment{}, err
+	}
+
+	remaining := payment.Amount.Sub(payment.RefundedAmount)
+	amount := remaining
+	if req.Amount != nil {
+		amount = *req.Amount
+	}
+	if !amount.IsPositive() || amount.GreaterThan(remaining) {
+		return models.Payment{}, invalidPayment("amount", "must be greater than 0 and at most "+remaining.String())
+	}
+
+	// Reserve the refund before asking the provider, a concurrent refund of the same payment then
+	// fails here instead of giving the money back twice
+	refunded := payment.RefundedAmount.Add(amount)
+	reserved, err := s.PaymentRepo.UpdateRefundedAmount(payment.PaymentID, payment.RefundedAmount, refunded)
+	if err != nil {
+		return models.Payment{}, err
+	}
+	if !reserved {
+		return models.Payment{}, &exception.ConflictError{
+			Message: "Payment " + payment.PaymentID + " was changed by another request, try again",
+		}
+	}
+
+	result, err := s.Provider.Refund(ctx, payment.Reference, amount, req.Reason)
+	if err != nil {
+		if _, releaseErr := s.PaymentRepo.UpdateRefundedAmount(payment.PaymentID, refunded, payment.RefundedAmount); releaseErr != nil {
+			return models.Payment{}, errors.Join(s.providerError(err), releaseErr)
+		}
+		return models.Payment{}, s.providerError(err)
+	}
+	payment.RefundedAmount = refunded
+
+	// Providers report either this refund or the total refunded, count at least what was asked for
+	result.RefundedAmount = decimal.Max(result.RefundedAmount, refunded)
+	if result.RefundedAmount.GreaterThanOrEqual(payment.Amount) {
+		result.Status = models.PaymentStatusRefunded
+	}
+	return s.apply(ctx, payment, result, actor)
+}
+
+// SyncPayment asks the provider for the state of an open payment, for when a notification got lost
+func (s *PaymentServiceImpl) SyncPayment(ctx context.Context, paymentID string) (models.Payment, error) {
+	payment, err := s.PaymentRepo.GetPaymentbyPaymentID(paymentID)
+	if err != nil {
+		return models.Payment{}, err
+	}
+	if payment.Reference == "" {
+		return payment, nil
+	}
+
+	result, err := s.Provider.QueryStatus(ctx, payment.Reference)
+	if err != nil {
+		return models.Payment{}, s.providerError(err)
+	}
+	return s.apply(ctx, payment, result, "payment:"+payment.Provider)
+}
+
+// RecordOutcome applies a state of the charge the provider reported on its own, e.g. in a notification
+func (s *PaymentServiceImpl) RecordOutcome(ctx context.Context, reference string, result ChargeResult) (models.Payment, error) {
+	payment, err := s.PaymentRepo.GetPaymentbyReference(s.Provider.Name(), reference)
+	if err != nil {
+		return models.Payment{}, err
+	}
+	return s.apply(ctx, payment, result, "payment:"+payment.Provider)
+}
+
+// CancelCustomerOrder voids the open payments of the order of the customer, then cancels the order, so a
+// cancelled order cannot be paid any more. The order stays as it is when a payment cannot be voided.
+func (s *PaymentServiceImpl) CancelCustomerOrder(ctx context.Context, customerID string, orderID string, req CancelOrderRequest) (models.Order, error) {
+	order, err := s.OrderService.GetCustomerOrder(customerID, orderID)
+	if err != nil {
+		return models.Order{}, err
+	}
+	if !CanTransition(order.Status, models.OrderStatusCancelled) {
+		return models.Order{}, &exception.InvalidStatusTransitionError{
+			Message: "Order cannot change status",
+			From:    order.Status,
+			To:      models.OrderStatusCancelled,
+		}
+	}
+
+	payments, err := s.PaymentRepo.GetPaymentsbyOrderID(order.OrderID)
+	if err != nil {
+		return models.Order{}, err
+	}
+	for _, payment := range payments {
+		if payment.Status != models.PaymentStatusPending && payment.Status != models.PaymentStatusAuthorized {
+			continue
+		}
+
+		// An attempt the provider never charged has nothing to void there
+		result := ChargeResult{Status: models.PaymentStatusVoided}
+		if payment.Reference != "" {
+			if result, err = s.Provider.Void(ctx, payment.Reference); err != nil {
+				return models.Order{}, s.providerError(err)
+			}
+		}
+
+		voided, saved, err := s.record(payment, result)
+		if err != nil {
+			return models.Order{}, err
+		}
+		if !saved || voided.Status != models.PaymentStatusVoided {
+			return models.Order{}, &exception.InvalidStatusTransitionError{
+				Message: "Payment " + payment.PaymentID + " of the order was settled meanwhile",
+				From:    voided.Status,
+				To:      models.PaymentStatusVoided,
+			}
+		}
+	}
+	return s.OrderService.CancelCustomerOrder(customerID, orderID, req)
+}
+
+// apply saves the state reported by the provider and moves the order along with it
+func (s *PaymentServiceImpl) apply(ctx context.Context, payment models.Payment, result ChargeResult, actor string) (models.Payment, error) {
+	from := payment.Status
+	payment, saved, err := s.record(payment, result)
+	if err != nil || !saved {
+		return payment, err
+	}
+
+	// A paid payment is checked against its order every time, so a repeated notification or a sync
+	// retries the refund of a payment whose order was cancelled
+	if from != payment.Status || payment.Status == models.PaymentStatusPaid {
+		if err := s.moveOrder(ctx, payment, actor); err != nil {
+			return models.Payment{}, err
+		}
+	}
+	return payment, nil
+}
+
+// record saves the state reported by the provider, saved is false when nothing was stored. A status the
+// payment cannot move to any more, e.g. a late expiry of a paid payment, is ignored. When another request
+// recorded an outcome first, the payment as it stands now is returned.
+func (s *PaymentServiceImpl) record(payment models.Payment, result ChargeResult) (models.Payment, bool, error) {
+	if result.Status == "" {
+		result.Status = payment.Status
+	}
+	if result.Status != payment.Status && !canMovePayment(payment.Status, result.Status) {
+		return payment, false, nil
+	}
+
+	from := payment.Status
+	payment.Status = result.Status
+	if result.Reference != "" {
+		payment.Reference = result.Reference
+	}
+	if result.Instructions != (models.PaymentInstructions{}) {
+		payment.Instructions = result.Instructions
+	}
+	if result.RefundedAmount.GreaterThan(payment.RefundedAmount) {
+		payment.RefundedAmount = decimal.Min(result.RefundedAmount, payment.Amount)
+	}
+	if payment.Status == models.PaymentStatusRefunded {
+		payment.RefundedAmount = payment.Amount
+	}
+	if result.FailureReason != "" {
+		payment.FailureReason = result.FailureReason
+	}
+	if result.ExpiresAt != nil {
+		payment.ExpiresAt = result.ExpiresAt
+	}
+	if payment.Status == models.PaymentStatusPaid && payment.PaidAt == nil {
+		paidAt := time.Now()
+		if result.PaidAt != nil {
+			paidAt = *result.PaidAt
+		}
+		payment.PaidAt = &paidAt
+	}
+
+	updated, err := s.PaymentRepo.UpdatePayment(payment, from)
+	if err != nil {
+		return models.Payment{}, false, err
+	}
+	if !updated {
+		// Another request recorded an outcome first, it also moved the order
+		current, err := s.PaymentRepo.GetPaymentbyPaymentID(payment.PaymentID)
+		return current, false, err
+	}
+	return payment, true, nil
+}
+
+// moveOrder follows the payment with its order: a paid payment pays the order, a full refund refunds it
+// and an order whose last open payment expired or was voided is cancelled. A payment settled after its
+// order was cancelled is refunded.
+func (s *PaymentServiceImpl) moveOrder(ctx context.Context, payment models.Payment, actor string) error {
+	var target string
+	switch payment.Status {
+	case models.PaymentStatusPaid:
+		target = models.OrderStatusPaid
+	case models.PaymentStatusRefunded:
+		target = models.OrderStatusRefunded
+	case models.PaymentStatusExpired, models.PaymentStatusVoided:
+		target = models.OrderStatusCancelled
+	default:
+		return nil
+	}
+
+	order, err := s.OrderService.GetOrder(payment.OrderID)
+	if err != nil {
+		return err
+	}
+	if target == models.OrderStatusPaid && order.Status == models.OrderStatusCancelled {
+		return s.refundCancelledOrder(ctx, payment, actor)
+	}
+	if !CanTransition(order.Status, target) {
+		return nil
+	}
+
+	_, err = s.OrderService.FollowPayment(order.OrderID, target, actor, fmt.Sprintf("payment %s %s", payment.PaymentID, payment.Status))
+	if !errors.Is(err, exception.ErrConflict) {
+		return err
+	}
+
+	// The order moved concurrently, a payment that came in while the order was cancelled is refunded
+	order, err = s.OrderService.GetOrder(payment.OrderID)
+	if err != nil {
+		return err
+	}
+	if target == models.OrderStatusPaid && order.Status == models.OrderStatusCancelled {
+		return s.refundCancelledOrder(ctx, payment, actor)
+	}
+	return nil
+}
+
+// refundCancelledOrder gives the money of a payment back when it settled after its order was cancelled,
+// the customer must not pay for an order they will not get. A refund the provider refuses is logged
+// and returned, the next notification or sync of the payment tries again.
+func (s *PaymentServiceImpl) refundCancelledOrder(ctx context.Context, payment models.Payment, actor string) error {
+	_, err := s.RefundPayment(ctx, payment.PaymentID, RefundPaymentRequest{
+		Reason: fmt.Sprintf("order %s was cancelled before the payment settled", payment.OrderID),
+	}, actor)
+	if err != nil {
+		log.Printf("payment: %s was paid for cancelled order %s and is not refunded yet: %v", payment.PaymentID, payment.OrderID, err)
+		return fmt.Errorf("refund of payment %s for cancelled order %s: %w", payment.PaymentID, payment.OrderID, err)
+	}
+	log.Printf("payment: refunded %s, it was paid for cancelled order %s", payment.PaymentID, payment.OrderID)
+	return nil
+}
+
+// paymentInStatus throw the payment when it is in one of the statuses it can move to target from
+func (s *PaymentServiceImpl) paymentInStatus(paymentID string, target string, statuses ...string) (models.Payment, error) {
+	payment, err := s.PaymentRepo.GetPaymentbyPaymentID(paymentID)
+	if err != nil {
+		return models.Payment{}, err
+	}
+	for _, status := range statuses {
+		if payment.Status == status {
+			return payment, nil
+		}
+	}
+	return models.Payment{}, &exception.InvalidStatusTransitionError{
+		Message: "Payment cannot change status",
+		From:    payment.Status,
+		To:      target,
+	}
+}
+
+// paymentTransitions are the statuses a payment can move to from each status
+var paymentTransitions = map[string][]string{
+	models.PaymentStatusPending: {models.PaymentStatusAuthorized, models.PaymentStatusPaid, models.PaymentStatusFailed,
+		models.PaymentStatusExpired, models.PaymentStatusVoided},
+	models.PaymentStatusAuthorized: {models.PaymentStatusPaid, models.PaymentStatusFailed, models.PaymentStatusExpired,
+		models.PaymentStatusVoided},
+	models.PaymentStatusPaid: {models.PaymentStatusRefunded},
+}
+
+// providerError tells that the provider failed, it is answered as a bad gateway
+func (s *PaymentServiceImpl) providerError(err error) error {
+	return &exception.UpstreamError{
+		Message: "Payment provider failed",
+		Service: s.Provider.Name(),
+		Err:     err,
+	}
+}
+
+func canMovePayment(from string, to string) bool {
+	for _, next := range paymentTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+func validatePaymentChannel(method string, channel string) error {
+	channels, ok := PaymentChannels[method]
+	if !ok {
+		return invalidPayment("method", "is not supported")
+	}
+	if len(channels) == 0 {
+		if channel != "" {
+			return invalidPayment("channel", "must be empty for "+method)
+		}
+		return nil
+	}
+	for _, c := range channels {
+		if c == channel {
+			return nil
+		}
+	}
+	return invalidPayment("channel", fmt.Sprintf("must be one of %v for %s", channels, method))
+}
+
+func invalidPayment(field string, message string) error {
+	return &exception.ValidationError{
+		Message: "Invalid Payment",
+		Fields: []exception.FieldError{{
+			Field:   field,
+			Rule:    "payment",
+			Message: message,
+		}},
+	}
+}
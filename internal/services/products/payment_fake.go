@@ -0,0 +1,201 @@
+package products
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	models "smkdevid/echocommercehub/internal/models/schema"
+)
+
+// PaymentProviderFake is the name of the FakeProvider
+const PaymentProviderFake = "fake"
+
+// FakeProvider is a payment provider kept in memory for tests and local development. Its charges
+// stay pending until SetStatus settles them the way a notification of a real gateway would.
+// Its webhooks are signed with WebhookSecret, see SignWebhook.
+type FakeProvider struct {
+	WebhookSecret string
+
+	mu      sync.Mutex
+	charges map[string]*fakeCharge
+}
+
+// FakeWebhook is the body of a webhook of the fake provider
+type FakeWebhook struct {
+	EventID        string           `json:"event_id"`
+	Type           string           `json:"type"`
+	Reference      string           `json:"reference"`
+	Status         string           `json:"status"`
+	RefundedAmount *decimal.Decimal `json:"refunded_amount,omitempty"`
+	FailureReason  string           `json:"failure_reason,omitempty"`
+}
+
+type fakeCharge struct {
+	amount decimal.Decimal
+	result ChargeResult
+}
+
+// NewFakeProvider creates a new instance of FakeProvider
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{
+		charges: map[string]*fakeCharge{},
+	}
+}
+
+func (p *FakeProvider) Name() string {
+	return PaymentProviderFake
+}
+
+// CreateCharge opens a pending charge with instructions shaped like the ones of the method
+func (p *FakeProvider) CreateCharge(ctx context.Context, req ChargeRequest) (ChargeResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	reference := "fake-" + req.PaymentID
+	if _, ok := p.charges[reference]; ok {
+		return ChargeResult{}, fmt.Errorf("charge %s already exists", reference)
+	}
+
+	expiresAt := req.ExpiresAt
+	result := ChargeResult{
+		Reference:      reference,
+		Status:         models.PaymentStatusPending,
+		RefundedAmount: decimal.Zero,
+		ExpiresAt:      &expiresAt,
+	}
+	switch req.Method {
+	case models.PaymentMethodVirtualAccount:
+		result.Instructions.Bank = req.Channel
+		result.Instructions.VANumber = fmt.Sprintf("8808%010d", crc32.ChecksumIEEE([]byte(req.PaymentID)))
+	case models.PaymentMethodQRIS:
+		result.Instructions.QRString = "FAKEQRIS." + reference
+	case models.PaymentMethodEWallet:
+		result.Instructions.DeeplinkURL = "fakepay://" + req.Channel + "/pay/" + reference
+	default:
+		return ChargeResult{}, fmt.Errorf("unsupported payment method %q", req.Method)
+	}
+
+	p.charges[reference] = &fakeCharge{amount: req.Amount, result: result}
+	return result, nil
+}
+
+func (p *FakeProvider) Capture(ctx context.Context, reference string, amount decimal.Decimal) (ChargeResult, error) {
+	return p.update(reference, func(charge *fakeCharge) error {
+		if charge.result.Status != models.PaymentStatusAuthorized {
+			return fmt.Errorf("charge %s is %s, only authorized charges can be captured", reference, charge.result.Status)
+		}
+		charge.settle()
+		return nil
+	})
+}
+
+func (p *FakeProvider) Void(ctx context.Context, reference string) (ChargeResult, error) {
+	return p.update(reference, func(charge *fakeCharge) error {
+		if charge.result.Status != models.PaymentStatusPending && charge.result.Status != models.PaymentStatusAuthorized {
+			return fmt.Errorf("charge %s is %s, only open charges can be voided", reference, charge.result.Status)
+		}
+		charge.result.Status = models.PaymentStatusVoided
+		return nil
+	})
+}
+
+func (p *FakeProvider) Refund(ctx context.Context, reference string, amount decimal.Decimal, reason string) (ChargeResult, error) {
+	return p.update(reference, func(charge *fakeCharge) error {
+		if charge.result.Status != models.PaymentStatusPaid {
+			return fmt.Errorf("charge %s is %s, only paid charges can be refunded", reference, charge.result.Status)
+		}
+		refunded := charge.result.RefundedAmount.Add(amount)
+		if refunded.GreaterThan(charge.amount) {
+			return fmt.Errorf("refund of %s exceeds the charge %s", amount, reference)
+		}
+		charge.result.RefundedAmount = refunded
+		if refunded.Equal(charge.amount) {
+			charge.result.Status = models.PaymentStatusRefunded
+		}
+		return nil
+	})
+}
+
+func (p *FakeProvider) QueryStatus(ctx context.Context, reference string) (ChargeResult, error) {
+	return p.update(reference, func(charge *fakeCharge) error {
+		return nil
+	})
+}
+
+// SetStatus moves the charge to a status as if the customer paid, the charge expired or the bank
+// declined it, and returns the result a notification would carry
+func (p *FakeProvider) SetStatus(reference string, status string) (ChargeResult, error) {
+	return p.update(reference, func(charge *fakeCharge) error {
+		switch status {
+		case models.PaymentStatusPaid:
+			charge.settle()
+		case models.PaymentStatusFailed:
+			charge.result.Status = status
+			charge.result.FailureReason = "declined by the fake provider"
+		case models.PaymentStatusAuthorized, models.PaymentStatusExpired:
+			charge.result.Status = status
+		default:
+			return fmt.Errorf("cannot simulate status %q", status)
+		}
+		return nil
+	})
+}
+
+// VerifyWebhook checks the HMAC signature of the webhook with WebhookSecret
+func (p *FakeProvider) VerifyWebhook(header http.Header, body []byte, now time.Time) error {
+	return VerifyWebhookSignature(p.WebhookSecret, header.Get(WebhookSignatureHeader), body, now)
+}
+
+// ParseWebhook reads a FakeWebhook
+func (p *FakeProvider) ParseWebhook(body []byte) (PaymentEvent, error) {
+	var webhook FakeWebhook
+	if err := json.Unmarshal(body, &webhook); err != nil {
+		return PaymentEvent{}, err
+	}
+	if webhook.Reference == "" {
+		return PaymentEvent{}, fmt.Errorf("reference is required")
+	}
+
+	result := ChargeResult{
+		Reference:      webhook.Reference,
+		Status:         webhook.Status,
+		RefundedAmount: decimal.Zero,
+		FailureReason:  webhook.FailureReason,
+	}
+	if webhook.RefundedAmount != nil {
+		result.RefundedAmount = *webhook.RefundedAmount
+	}
+	return PaymentEvent{
+		EventID:   webhook.EventID,
+		Type:      webhook.Type,
+		Reference: webhook.Reference,
+		Result:    result,
+	}, nil
+}
+
+func (p *FakeProvider) update(reference string, change func(charge *fakeCharge) error) (ChargeResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	charge, ok := p.charges[reference]
+	if !ok {
+		return ChargeResult{}, fmt.Errorf("charge %s not found", reference)
+	}
+	if err := change(charge); err != nil {
+		return ChargeResult{}, err
+	}
+	return charge.result, nil
+}
+
+func (c *fakeCharge) settle() {
+	paidAt := time.Now()
+	c.result.Status = models.PaymentStatusPaid
+	c.result.PaidAt = &paidAt
+}
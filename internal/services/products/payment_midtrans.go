@@ -0,0 +1,322 @@
+package products
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	models "smkdevid/echocommercehub/internal/models/schema"
+)
+
+// PaymentProviderMidtrans is the name of the MidtransProvider
+const PaymentProviderMidtrans = "midtrans"
+
+// Base URLs of the Midtrans Core API
+const (
+	MidtransSandboxURL    = "https://api.sandbox.midtrans.com"
+	MidtransProductionURL = "https://api.midtrans.com"
+)
+
+// midtransTimeLayout is how Midtrans writes times, in Asia/Jakarta
+const midtransTimeLayout = "2006-01-02 15:04:05"
+
+// MidtransProvider charges through the Midtrans Core API: bank transfers to a virtual account,
+// QRIS and the GoPay and ShopeePay e-wallets. The Midtrans order ID of a charge is the payment ID,
+// so every attempt of an order is a separate charge.
+type MidtransProvider struct {
+	ServerKey   string
+	BaseURL     string
+	CallbackURL string
+	Client      *http.Client
+}
+
+// NewMidtransProvider creates a new instance of MidtransProvider, callbackURL is where the e-wallet
+// apps send the customer back after paying
+func NewMidtransProvider(serverKey string, baseURL string, callbackURL string, timeout time.Duration) *MidtransProvider {
+	return &MidtransProvider{
+		ServerKey:   serverKey,
+		BaseURL:     strings.TrimRight(baseURL, "/"),
+		CallbackURL: callbackURL,
+		Client:      &http.Client{Timeout: timeout},
+	}
+}
+
+func (p *MidtransProvider) Name() string {
+	return PaymentProviderMidtrans
+}
+
+// midtransResponse holds the fields of the Core API responses that are read back
+type midtransResponse struct {
+	OrderID           string `json:"order_id"`
+	SignatureKey      string `json:"signature_key"`
+	StatusCode        string `json:"status_code"`
+	StatusMessage     string `json:"status_message"`
+	TransactionID     string `json:"transaction_id"`
+	TransactionStatus string `json:"transaction_status"`
+	FraudStatus       string `json:"fraud_status"`
+	GrossAmount       string `json:"gross_amount"`
+	RefundAmount      string `json:"refund_amount"`
+	SettlementTime    string `json:"settlement_time"`
+	ExpiryTime        string `json:"expiry_time"`
+	PermataVANumber   string `json:"permata_va_number"`
+	QRString          string `json:"qr_string"`
+	VANumbers         []struct {
+		Bank     string `json:"bank"`
+		VANumber string `json:"va_number"`
+	} `json:"va_numbers"`
+	Actions []struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	} `json:"actions"`
+}
+
+func (p *MidtransProvider) CreateCharge(ctx context.Context, req ChargeRequest) (ChargeResult, error) {
+	body := map[string]interface{}{
+		"transaction_details": map[string]interface{}{
+			"order_id":     req.PaymentID,
+			"gross_amount": midtransAmount(req.Amount),
+		},
+		"customer_details": map[string]interface{}{
+			"first_name": req.Customer.RecipientName,
+			"phone":      req.Customer.Phone,
+		},
+	}
+	if minutes := int(time.Until(req.ExpiresAt).Minutes()); minutes > 0 {
+		body["custom_expiry"] = map[string]interface{}{
+			"expiry_duration": minutes,
+			"unit":            "minute",
+		}
+	}
+
+	switch req.Method {
+	case models.PaymentMethodVirtualAccount:
+		body["payment_type"] = "bank_transfer"
+		body["bank_transfer"] = map[string]interface{}{"bank": req.Channel}
+	case models.PaymentMethodQRIS:
+		body["payment_type"] = "qris"
+	case models.PaymentMethodEWallet:
+		body["payment_type"] = req.Channel
+		switch req.Channel {
+		case "gopay":
+			body["gopay"] = map[string]interface{}{"enable_callback": p.CallbackURL != "", "callback_url": p.CallbackURL}
+		case "shopeepay":
+			body["shopeepay"] = map[string]interface{}{"callback_url": p.CallbackURL}
+		}
+	default:
+		return ChargeResult{}, fmt.Errorf("unsupported payment method %q", req.Method)
+	}
+
+	var res midtransResponse
+	if err := p.call(ctx, http.MethodPost, "/v2/charge", body, &res); err != nil {
+		return ChargeResult{}, err
+	}
+	return res.result(), nil
+}
+
+func (p *MidtransProvider) Capture(ctx context.Context, reference string, amount decimal.Decimal) (ChargeResult, error) {
+	var res midtransResponse
+	err := p.call(ctx, http.MethodPost, "/v2/capture", map[string]interface{}{
+		"transaction_id": reference,
+		"gross_amount":   midtransAmount(amount),
+	}, &res)
+	if err != nil {
+		return ChargeResult{}, err
+	}
+	return res.result(), nil
+}
+
+func (p *MidtransProvider) Void(ctx context.Context, reference string) (ChargeResult, error) {
+	var res midtransResponse
+	if err := p.call(ctx, http.MethodPost, "/v2/"+url.PathEscape(reference)+"/cancel", nil, &res); err != nil {
+		return ChargeResult{}, err
+	}
+	return res.result(), nil
+}
+
+func (p *MidtransProvider) Refund(ctx context.Context, reference string, amount decimal.Decimal, reason string) (ChargeResult, error) {
+	var res midtransResponse
+	err := p.call(ctx, http.MethodPost, "/v2/"+url.PathEscape(reference)+"/refund", map[string]interface{}{
+		"refund_key": fmt.Sprintf("%s-%d", reference, time.Now().UnixNano()),
+		"amount":     midtransAmount(amount),
+		"reason":     reason,
+	}, &res)
+	if err != nil {
+		return ChargeResult{}, err
+	}
+	return res.result(), nil
+}
+
+func (p *MidtransProvider) QueryStatus(ctx context.Context, reference string) (ChargeResult, error) {
+	var res midtransResponse
+	if err := p.call(ctx, http.MethodGet, "/v2/"+url.PathEscape(reference)+"/status", nil, &res); err != nil {
+		return ChargeResult{}, err
+	}
+	return res.result(), nil
+}
+
+// VerifyWebhook checks the signature_key of a Midtrans notification, the SHA-512 of the order ID,
+// status code and gross amount followed by the server key
+func (p *MidtransProvider) VerifyWebhook(header http.Header, body []byte, now time.Time) error {
+	var notification midtransResponse
+	if err := json.Unmarshal(body, &notification); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidWebhookSignature, err)
+	}
+
+	sum := sha512.Sum512([]byte(notification.OrderID + notification.StatusCode + notification.GrossAmount + p.ServerKey))
+	expected := hex.EncodeToString(sum[:])
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(notification.SignatureKey)) != 1 {
+		return ErrInvalidWebhookSignature
+	}
+	return nil
+}
+
+// ParseWebhook reads a Midtrans notification. Midtrans has no event ID, it sends one notification per
+// status of a transaction and one per partial refund, so these make up the event ID.
+func (p *MidtransProvider) ParseWebhook(body []byte) (PaymentEvent, error) {
+	var notification midtransResponse
+	if err := json.Unmarshal(body, &notification); err != nil {
+		return PaymentEvent{}, err
+	}
+	if notification.TransactionID == "" || notification.TransactionStatus == "" {
+		return PaymentEvent{}, fmt.Errorf("transaction_id and transaction_status are required")
+	}
+
+	eventID := notification.TransactionID + ":" + notification.TransactionStatus
+	if notification.RefundAmount != "" {
+		eventID += ":" + notification.RefundAmount
+	}
+	return PaymentEvent{
+		EventID:   eventID,
+		Type:      notification.TransactionStatus,
+		Reference: notification.TransactionID,
+		Result:    notification.result(),
+	}, nil
+}
+
+// call sends a Core API request authenticated with the server key. Midtrans answers most failures
+// with HTTP 200 and puts the actual status code in the body.
+func (p *MidtransProvider) call(ctx context.Context, method string, path string, body interface{}, out *midtransResponse) error {
+	var payload io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		payload = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, p.BaseURL+path, payload)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(p.ServerKey, "")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := p.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if err := json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(out); err != nil {
+		return fmt.Errorf("midtrans answered %s with an unreadable body: %w", res.Status, err)
+	}
+	if res.StatusCode >= 300 || !strings.HasPrefix(out.StatusCode, "2") {
+		return fmt.Errorf("midtrans answered %s: %s", out.StatusCode, out.StatusMessage)
+	}
+	return nil
+}
+
+// result maps the Midtrans transaction status to the payment statuses
+func (r midtransResponse) result() ChargeResult {
+	result := ChargeResult{
+		Reference:      r.TransactionID,
+		RefundedAmount: decimal.Zero,
+	}
+
+	switch r.TransactionStatus {
+	case "pending":
+		result.Status = models.PaymentStatusPending
+	case "authorize":
+		result.Status = models.PaymentStatusAuthorized
+	case "capture", "settlement":
+		result.Status = models.PaymentStatusPaid
+		if r.FraudStatus == "challenge" {
+			result.Status = models.PaymentStatusAuthorized
+		}
+	case "partial_refund":
+		result.Status = models.PaymentStatusPaid
+	case "refund":
+		result.Status = models.PaymentStatusRefunded
+	case "deny", "failure":
+		result.Status = models.PaymentStatusFailed
+		result.FailureReason = r.StatusMessage
+	case "expire":
+		result.Status = models.PaymentStatusExpired
+	case "cancel":
+		result.Status = models.PaymentStatusVoided
+	}
+
+	if refunded, err := decimal.NewFromString(r.RefundAmount); err == nil {
+		result.RefundedAmount = refunded
+	}
+	if r.TransactionStatus == "refund" && r.RefundAmount == "" {
+		if gross, err := decimal.NewFromString(r.GrossAmount); err == nil {
+			result.RefundedAmount = gross
+		}
+	}
+	if expiry, ok := midtransTime(r.ExpiryTime); ok {
+		result.ExpiresAt = &expiry
+	}
+	if settled, ok := midtransTime(r.SettlementTime); ok {
+		result.PaidAt = &settled
+	}
+
+	if r.PermataVANumber != "" {
+		result.Instructions.Bank = "permata"
+		result.Instructions.VANumber = r.PermataVANumber
+	}
+	if len(r.VANumbers) > 0 {
+		result.Instructions.Bank = r.VANumbers[0].Bank
+		result.Instructions.VANumber = r.VANumbers[0].VANumber
+	}
+	result.Instructions.QRString = r.QRString
+	for _, action := range r.Actions {
+		switch action.Name {
+		case "generate-qr-code":
+			result.Instructions.QRImageURL = action.URL
+		case "deeplink-redirect":
+			result.Instructions.DeeplinkURL = action.URL
+		}
+	}
+	return result
+}
+
+// midtransAmount is the gross amount in whole rupiah, Midtrans does not accept cents for IDR
+func midtransAmount(amount decimal.Decimal) int64 {
+	return amount.Round(0).IntPart()
+}
+
+func midtransTime(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	jakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		jakarta = time.FixedZone("WIB", 7*60*60)
+	}
+	parsed, err := time.ParseInLocation(midtransTimeLayout, value, jakarta)
+	return parsed, err == nil
+}
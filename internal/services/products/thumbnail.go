@@ -0,0 +1,66 @@
+package products
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"image/png"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// ThumbnailSize is a thumbnail generated for every uploaded image, it fits in a square of MaxSide pixels
+type ThumbnailSize struct {
+	Name    string
+	MaxSide int
+}
+
+// ThumbnailSizes are generated from every uploaded image
+var ThumbnailSizes = []ThumbnailSize{
+	{Name: "small", MaxSide: 160},
+	{Name: "medium", MaxSide: 480},
+	{Name: "large", MaxSide: 1024},
+}
+
+// thumbnailQuality is the JPEG quality of the thumbnails
+const thumbnailQuality = 85
+
+// Thumbnail scales the image down to fit in a square of maxSide pixels, keeping its aspect ratio.
+// Smaller images are kept at their size, they are never scaled up.
+func Thumbnail(src image.Image, maxSide int) image.Image {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= maxSide && height <= maxSide {
+		return src
+	}
+
+	if width >= height {
+		height = max(1, height*maxSide/width)
+		width = maxSide
+	} else {
+		width = max(1, width*maxSide/height)
+		height = maxSide
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+	return dst
+}
+
+// encodeThumbnail writes PNG for PNG sources to keep their transparency and JPEG for everything else.
+// It returns the content type and the file extension of the encoded thumbnail.
+func encodeThumbnail(img image.Image, sourceType string) ([]byte, string, string, error) {
+	var buf bytes.Buffer
+	if sourceType == "image/png" {
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, "", "", err
+		}
+		return buf.Bytes(), "image/png", ".png", nil
+	}
+
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+		return nil, "", "", err
+	}
+	return buf.Bytes(), "image/jpeg", ".jpg", nil
+}
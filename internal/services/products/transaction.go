@@ -1 +1,335 @@
 package products
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+
+	postgresql "smkdevid/echocommercehub/internal/databases/postgresql"
+	models "smkdevid/echocommercehub/internal/models/schema"
+	"smkdevid/echocommercehub/utils/exception"
+)
+
+// WebhookSignatureHeader carries the HMAC signature of a webhook, as "t=<unix time>,v1=<hex HMAC-SHA256>"
+// of the timestamp and the raw body joined by a dot
+const WebhookSignatureHeader = "X-Webhook-Signature"
+
+// WebhookSignatureTolerance is how old a signed webhook may be, older ones are taken for replays
+const WebhookSignatureTolerance = 5 * time.Minute
+
+// WebhookBatch is the number of events claimed by the processor at once
+const WebhookBatch = 50
+
+// webhookLease keeps a claimed event away from the other processors while it is handled
+const webhookLease = 2 * time.Minute
+
+// ErrInvalidWebhookSignature is returned for a webhook whose signature is missing, stale or wrong
+var ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+
+// WebhookProvider is a payment provider that notifies the outcome of its charges
+type WebhookProvider interface {
+	Name() string
+	VerifyWebhook(header http.Header, body []byte, now time.Time) error
+	ParseWebhook(body []byte) (PaymentEvent, error)
+}
+
+// PaymentEvent is a notification of the provider about a charge. EventID is the same for every
+// delivery of the notification.
+type PaymentEvent struct {
+	EventID   string
+	Type      string
+	Reference string
+	Result    ChargeResult
+}
+
+// WebhookSettings control how often a failing event is tried again
+type WebhookSettings struct {
+	MaxAttempts  int
+	RetryBackoff time.Duration
+	MaxBackoff   time.Duration
+}
+
+// WebhookService receives the payment webhooks, stores them and applies them to the payments
+type WebhookService interface {
+	ReceiveWebhook(header http.Header, body []byte) (models.WebhookEvent, bool, error)
+	ListWebhookEvents(query postgresql.WebhookEventQuery) (WebhookEventPage, error)
+	ReplayWebhookEvent(webhookID string) (models.WebhookEvent, error)
+	ProcessWebhookEvents(ctx context.Context, now time.Time) (int, error)
+}
+
+// WebhookEventPage is a page of the webhook events
+type WebhookEventPage struct {
+	Data   []models.WebhookEvent `json:"data"`
+	Total  int64                 `json:"total"`
+	Limit  int                   `json:"limit"`
+	Offset int                   `json:"offset,omitempty"`
+}
+
+type WebhookServiceImpl struct {
+	EventRepo      postgresql.WebhookEventRepository
+	PaymentService PaymentService
+	Provider       WebhookProvider
+	Settings       WebhookSettings
+
+	received chan struct{}
+}
+
+// NewWebhookService creates a new instance of WebhookService
+func NewWebhookService(EventRepo postgresql.WebhookEventRepository, PaymentService PaymentService, Provider WebhookProvider, Settings WebhookSettings) *WebhookServiceImpl {
+	return &WebhookServiceImpl{
+		EventRepo:      EventRepo,
+		PaymentService: PaymentService,
+		Provider:       Provider,
+		Settings:       Settings,
+		received:       make(chan struct{}, 1),
+	}
+}
+
+// NewWebhookID mints a ULID, unique and sortable by creation time
+func NewWebhookID() string {
+	return ulid.Make().String()
+}
+
+// SignWebhook signs the body the way VerifyWebhookSignature expects, for the fake provider and the tests
+func SignWebhook(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	return "t=" + t + ",v1=" + webhookMAC(secret, t, body)
+}
+
+// VerifyWebhookSignature checks the signature header of a webhook against the raw body
+func VerifyWebhookSignature(secret string, signature string, body []byte, now time.Time) error {
+	if secret == "" {
+		return fmt.Errorf("%w: no webhook secret is configured", ErrInvalidWebhookSignature)
+	}
+
+	var timestamp, mac string
+	for _, part := range strings.Split(signature, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			mac = value
+		}
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || mac == "" {
+		return fmt.Errorf("%w: malformed %s header", ErrInvalidWebhookSignature, WebhookSignatureHeader)
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > WebhookSignatureTolerance || age < -WebhookSignatureTolerance {
+		return fmt.Errorf("%w: signed %s ago", ErrInvalidWebhookSignature, age.Round(time.Second))
+	}
+	if !hmac.Equal([]byte(mac), []byte(webhookMAC(secret, timestamp, body))) {
+		return ErrInvalidWebhookSignature
+	}
+	return nil
+}
+
+// Received is signalled whenever a new event is stored, so the processor does not wait for its next tick
+func (s *WebhookServiceImpl) Received() <-chan struct{} {
+	return s.received
+}
+
+// ReceiveWebhook verifies the signature of the webhook and stores it raw for the processor. duplicate is
+// true for a redelivery of an event that was already received, it is not processed again.
+func (s *WebhookServiceImpl) ReceiveWebhook(header http.Header, body []byte) (models.WebhookEvent, bool, error) {
+	if err := s.Provider.VerifyWebhook(header, body, time.Now()); err != nil {
+		if !errors.Is(err, ErrInvalidWebhookSignature) {
+			err = fmt.Errorf("%w: %v", ErrInvalidWebhookSignature, err)
+		}
+		return models.WebhookEvent{}, false, err
+	}
+
+	parsed, err := s.Provider.ParseWebhook(body)
+	if err != nil {
+		return models.WebhookEvent{}, false, invalidWebhook("payload", err.Error())
+	}
+	if parsed.EventID == "" {
+		return models.WebhookEvent{}, false, invalidWebhook("payload", "has no event ID")
+	}
+
+	now := time.Now()
+	event, created, err := s.EventRepo.CreateWebhookEvent(models.WebhookEvent{
+		WebhookID:     NewWebhookID(),
+		Provider:      s.Provider.Name(),
+		EventID:       parsed.EventID,
+		EventType:     parsed.Type,
+		Reference:     parsed.Reference,
+		Payload:       string(body),
+		Status:        models.WebhookStatusPending,
+		NextAttemptAt: &now,
+	})
+	if err != nil {
+		return models.WebhookEvent{}, false, err
+	}
+
+	if created {
+		select {
+		case s.received <- struct{}{}:
+		default:
+		}
+	}
+	return event, !created, nil
+}
+
+// ListWebhookEvents throw a page of the stored events, newest first
+func (s *WebhookServiceImpl) ListWebhookEvents(query postgresql.WebhookEventQuery) (WebhookEventPage, error) {
+	query, err := query.Normalize()
+	if err != nil {
+		return WebhookEventPage{}, err
+	}
+
+	list, err := s.EventRepo.ListWebhookEvents(query)
+	if err != nil {
+		return WebhookEventPage{}, err
+	}
+
+	return WebhookEventPage{
+		Data:   list.Events,
+		Total:  list.Total,
+		Limit:  query.Limit,
+		Offset: query.Offset,
+	}, nil
+}
+
+// ReplayWebhookEvent queues a failed event again, e.g. once the cause of its failure is fixed
+func (s *WebhookServiceImpl) ReplayWebhookEvent(webhookID string) (models.WebhookEvent, error) {
+	event, err := s.EventRepo.ReplayWebhookEvent(webhookID, time.Now())
+	if err != nil {
+		return models.WebhookEvent{}, err
+	}
+
+	select {
+	case s.received <- struct{}{}:
+	default:
+	}
+	return event, nil
+}
+
+// ProcessWebhookEvents applies the pending events due at now to their payments and returns how many
+// were processed. A failing event is tried again with an exponential backoff until it runs out of attempts.
+func (s *WebhookServiceImpl) ProcessWebhookEvents(ctx context.Context, now time.Time) (int, error) {
+	events, err := s.EventRepo.ClaimDueWebhookEvents(now, WebhookBatch, webhookLease)
+	if err != nil {
+		return 0, err
+	}
+
+	processed := 0
+	for _, event := range events {
+		event.Attempts++
+
+		parsed, err := s.Provider.ParseWebhook([]byte(event.Payload))
+		retry := err == nil
+		if err == nil {
+			_, err = s.PaymentService.RecordOutcome(ctx, parsed.Reference, parsed.Result)
+		}
+
+		switch {
+		case err == nil:
+			processedAt := time.Now()
+			event.Status = models.WebhookStatusProcessed
+			event.LastError = ""
+			event.NextAttemptAt = nil
+			event.ProcessedAt = &processedAt
+			processed++
+		case !retry || event.Attempts >= s.Settings.MaxAttempts:
+			event.Status = models.WebhookStatusFailed
+			event.LastError = err.Error()
+			event.NextAttemptAt = nil
+			log.Printf("webhook processor: event %s failed for good: %v", event.WebhookID, err)
+		default:
+			next := now.Add(s.backoff(event.Attempts))
+			event.LastError = err.Error()
+			event.NextAttemptAt = &next
+		}
+
+		if err := s.EventRepo.UpdateWebhookEvent(event); err != nil {
+			return processed, err
+		}
+	}
+	return processed, nil
+}
+
+// backoff doubles the wait after every failed attempt, up to MaxBackoff
+func (s *WebhookServiceImpl) backoff(attempts int) time.Duration {
+	wait := s.Settings.RetryBackoff
+	for i := 1; i < attempts && wait < s.Settings.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if s.Settings.MaxBackoff > 0 && wait > s.Settings.MaxBackoff {
+		wait = s.Settings.MaxBackoff
+	}
+	return wait
+}
+
+// WebhookProcessor applies the stored webhook events in the background, on every interval and
+// as soon as a new event is received
+type WebhookProcessor struct {
+	Service  WebhookService
+	Received <-chan struct{}
+	Interval time.Duration
+}
+
+// NewWebhookProcessor creates a new instance of WebhookProcessor
+func NewWebhookProcessor(Service WebhookService, Received <-chan struct{}, Interval time.Duration) *WebhookProcessor {
+	return &WebhookProcessor{
+		Service:  Service,
+		Received: Received,
+		Interval: Interval,
+	}
+}
+
+// Run processes the due events until the context is cancelled
+func (p *WebhookProcessor) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			processed, err := p.Service.ProcessWebhookEvents(ctx, time.Now())
+			if err != nil {
+				log.Printf("webhook processor: %v", err)
+			}
+			if err != nil || processed < WebhookBatch {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-p.Received:
+		}
+	}
+}
+
+func webhookMAC(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func invalidWebhook(field string, message string) error {
+	return &exception.ValidationError{
+		Message: "Invalid Webhook",
+		Fields: []exception.FieldError{{
+			Field:   field,
+			Rule:    "webhook",
+			Message: message,
+		}},
+	}
+}
@@ -0,0 +1,70 @@
+package products
+
+import (
+	"regexp"
+
+	models "smkdevid/echocommercehub/internal/models/schema"
+	"smkdevid/echocommercehub/utils/requests"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// IsValidProductStatus reports whether the status is a known product status
+func IsValidProductStatus(status string) bool {
+	switch status {
+	case models.ProductStatusDraft, models.ProductStatusActive, models.ProductStatusArchived:
+		return true
+	}
+	return false
+}
+
+// skuPattern allows upper case letters, digits and dashes, starting with a letter or digit
+var skuPattern = regexp.MustCompile(`^[A-Z0-9][A-Z0-9-]*$`)
+
+// IsValidSKU reports whether the SKU only uses upper case letters, digits and dashes
+func IsValidSKU(sku string) bool {
+	return skuPattern.MatchString(sku)
+}
+
+// IsValidBarcode reports whether the barcode is a GTIN (EAN-8, UPC-A, EAN-13 or GTIN-14) with a valid check digit
+func IsValidBarcode(barcode string) bool {
+	switch len(barcode) {
+	case 8, 12, 13, 14:
+	default:
+		return false
+	}
+
+	sum := 0
+	for i := len(barcode) - 2; i >= 0; i-- {
+		digit := int(barcode[i] - '0')
+		if digit < 0 || digit > 9 {
+			return false
+		}
+		// Weights alternate 3 and 1 from the digit next to the check digit
+		if (len(barcode)-2-i)%2 == 0 {
+			digit *= 3
+		}
+		sum += digit
+	}
+
+	check := int(barcode[len(barcode)-1] - '0')
+	return check >= 0 && check <= 9 && (10-sum%10)%10 == check
+}
+
+// RegisterValidationRules adds the product rules to the request validator
+func RegisterValidationRules(v *requests.Validator) {
+	v.RegisterRule("product_status", func(fl validator.FieldLevel) bool {
+		return IsValidProductStatus(fl.Field().String())
+	}, "must be one of [draft active archived]")
+
+	v.RegisterRule("sku", func(fl validator.FieldLevel) bool {
+		return IsValidSKU(fl.Field().String())
+	}, "must only contain upper case letters, digits and dashes")
+
+	v.RegisterRule("barcode", func(fl validator.FieldLevel) bool {
+		return IsValidBarcode(fl.Field().String())
+	}, "must be an EAN-8, UPC-A, EAN-13 or GTIN-14 with a valid check digit")
+
+	v.RegisterMessage("url", "must be a valid URL")
+	v.RegisterMessage("unique", "must not contain duplicates")
+}
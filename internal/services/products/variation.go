@@ -1 +1,311 @@
 package products
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/shopspring/decimal"
+
+	postgresql "smkdevid/echocommercehub/internal/databases/postgresql"
+	models "smkdevid/echocommercehub/internal/models/schema"
+	"smkdevid/echocommercehub/utils/exception"
+)
+
+// Bounds of the variant matrix of a product
+const (
+	MaxProductOptions  = 3
+	MaxProductVariants = 250
+)
+
+// VariantService manages the option types and variants of products
+type VariantService interface {
+	GetProductOptions(productID string) ([]models.ProductOption, error)
+	GenerateVariants(productID string, req GenerateVariantsRequest) ([]models.ProductVariant, error)
+	CreateVariant(productID string, variant models.ProductVariant) (models.ProductVariant, error)
+	GetVariantsbyProductID(productID string) ([]models.ProductVariant, error)
+	GetVariantbyVariantID(productID string, variantID string) (models.ProductVariant, error)
+	UpdateVariant(productID string, variantID string, variant models.ProductVariant) (models.ProductVariant, error)
+	DeleteVariant(productID string, variantID string) error
+}
+
+// GenerateVariantsRequest sets the option types of a product and creates a variant for each combination
+// of their values. SKUs start with SKUPrefix, derived from the product name when empty, and Price
+// overrides the product price of the created variants.
+type GenerateVariantsRequest struct {
+	Options   []models.ProductOption `json:"options" validate:"required,min=1,max=3,dive"`
+	SKUPrefix string                 `json:"sku_prefix" validate:"omitempty,max=32,sku"`
+	Price     *decimal.Decimal       `json:"price" validate:"omitempty,gte=0"`
+}
+
+type VariantServiceImpl struct {
+	ProductRepo postgresql.ProductRepository
+	VariantRepo postgresql.ProductVariantRepository
+}
+
+// NewVariantService creates a new instance of VariantService
+func NewVariantService(ProductRepo postgresql.ProductRepository, VariantRepo postgresql.ProductVariantRepository) *VariantServiceImpl {
+	return &VariantServiceImpl{
+		ProductRepo: ProductRepo,
+		VariantRepo: VariantRepo,
+	}
+}
+
+// GetProductOptions throw the option types of the product
+func (s *VariantServiceImpl) GetProductOptions(productID string) ([]models.ProductOption, error) {
+	if _, err := s.ProductRepo.GetProductbyProductID(productID); err != nil {
+		return nil, err
+	}
+	return s.VariantRepo.GetProductOptions(productID)
+}
+
+// GenerateVariants replaces the option types of the product and creates the variants missing from the matrix.
+// Existing variants are kept as they are, so generating again after adding a value only adds the new combinations.
+func (s *VariantServiceImpl) GenerateVariants(productID string, req GenerateVariantsRequest) ([]models.ProductVariant, error) {
+	product, err := s.ProductRepo.GetProductbyProductID(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	options, err := normalizeOptions(productID, req.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	matrix := VariantMatrix(options)
+	if len(matrix) > MaxProductVariants {
+		return nil, invalidVariant("options", fmt.Sprintf("make %d variants, at most %d are allowed", len(matrix), MaxProductVariants))
+	}
+
+	if _, err := s.VariantRepo.SaveProductOptions(productID, options); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.VariantRepo.GetVariantsbyProductID(productID)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool, len(existing))
+	for _, variant := range existing {
+		known[variant.OptionKey] = true
+	}
+
+	prefix := req.SKUPrefix
+	if prefix == "" {
+		prefix = skuPart(product.ProductName)
+	}
+
+	var variants []models.ProductVariant
+	for _, combination := range matrix {
+		key := OptionKey(combination)
+		if known[key] {
+			continue
+		}
+		variants = append(variants, models.ProductVariant{
+			VariantID: NewVariantID(),
+			ProductID: productID,
+			SKU:       GenerateSKU(prefix, options, combination),
+			Options:   combination,
+			OptionKey: key,
+			Price:     req.Price,
+		})
+	}
+	if len(variants) == 0 {
+		return []models.ProductVariant{}, nil
+	}
+	return s.VariantRepo.CreateVariants(variants)
+}
+
+// CreateVariant adds a single variant, its options must be values of the product option types
+func (s *VariantServiceImpl) CreateVariant(productID string, variant models.ProductVariant) (models.ProductVariant, error) {
+	if _, err := s.ProductRepo.GetProductbyProductID(productID); err != nil {
+		return models.ProductVariant{}, err
+	}
+	if err := s.checkVariantOptions(productID, variant.Options); err != nil {
+		return models.ProductVariant{}, err
+	}
+
+	variant.VariantID = NewVariantID()
+	variant.ProductID = productID
+	variant.OptionKey = OptionKey(variant.Options)
+
+	created, err := s.VariantRepo.CreateVariants([]models.ProductVariant{variant})
+	if err != nil {
+		return models.ProductVariant{}, err
+	}
+	return created[0], nil
+}
+
+// GetVariantsbyProductID throw every variant of the product
+func (s *VariantServiceImpl) GetVariantsbyProductID(productID string) ([]models.ProductVariant, error) {
+	if _, err := s.ProductRepo.GetProductbyProductID(productID); err != nil {
+		return nil, err
+	}
+	return s.VariantRepo.GetVariantsbyProductID(productID)
+}
+
+// GetVariantbyVariantID will throw the variant of the product
+func (s *VariantServiceImpl) GetVariantbyVariantID(productID string, variantID string) (models.ProductVariant, error) {
+	return s.VariantRepo.GetVariantbyVariantID(productID, variantID)
+}
+
+// UpdateVariant replaces the SKU, options, price and barcode of the variant
+func (s *VariantServiceImpl) UpdateVariant(productID string, variantID string, variant models.ProductVariant) (models.ProductVariant, error) {
+	existing, err := s.VariantRepo.GetVariantbyVariantID(productID, variantID)
+	if err != nil {
+		return models.ProductVariant{}, err
+	}
+	if err := s.checkVariantOptions(productID, variant.Options); err != nil {
+		return models.ProductVariant{}, err
+	}
+
+	variant.ID = existing.ID
+	variant.VariantID = existing.VariantID
+	variant.ProductID = existing.ProductID
+	variant.CreatedAt = existing.CreatedAt
+	variant.DeletedAt = existing.DeletedAt
+	variant.OptionKey = OptionKey(variant.Options)
+	return s.VariantRepo.UpdateVariant(variant)
+}
+
+// DeleteVariant will soft delete the variant of the product
+func (s *VariantServiceImpl) DeleteVariant(productID string, variantID string) error {
+	return s.VariantRepo.DeleteVariant(productID, variantID)
+}
+
+// checkVariantOptions requires one value of every option type of the product and nothing else
+func (s *VariantServiceImpl) checkVariantOptions(productID string, values map[string]string) error {
+	options, err := s.VariantRepo.GetProductOptions(productID)
+	if err != nil {
+		return err
+	}
+
+	if len(values) != len(options) {
+		return invalidVariant("options", fmt.Sprintf("must have a value for each of the %d product options", len(options)))
+	}
+	for _, option := range options {
+		value, ok := values[option.Name]
+		if !ok {
+			return invalidVariant("options."+option.Name, "is required")
+		}
+		if !slices.Contains(option.Values, value) {
+			return invalidVariant("options."+option.Name, fmt.Sprintf("must be one of [%s]", strings.Join(option.Values, " ")))
+		}
+	}
+	return nil
+}
+
+// NewVariantID mints a ULID, unique and sortable by creation time
+func NewVariantID() string {
+	return ulid.Make().String()
+}
+
+// VariantMatrix lists every combination of the option values, the first option varying slowest
+func VariantMatrix(options []models.ProductOption) []map[string]string {
+	if len(options) == 0 {
+		return nil
+	}
+
+	matrix := []map[string]string{{}}
+	for _, option := range options {
+		next := make([]map[string]string, 0, len(matrix)*len(option.Values))
+		for _, combination := range matrix {
+			for _, value := range option.Values {
+				extended := make(map[string]string, len(combination)+1)
+				for name, v := range combination {
+					extended[name] = v
+				}
+				extended[option.Name] = value
+				next = append(next, extended)
+			}
+		}
+		matrix = next
+	}
+	return matrix
+}
+
+// OptionKey is the canonical form of a combination, two variants of a product never share it
+func OptionKey(combination map[string]string) string {
+	names := make([]string, 0, len(combination))
+	for name := range combination {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, name+"="+combination[name])
+	}
+	return strings.Join(pairs, ";")
+}
+
+// GenerateSKU joins the prefix and the option values in option order, e.g. KOPI-GAYO-250G-HITAM-XL
+func GenerateSKU(prefix string, options []models.ProductOption, combination map[string]string) string {
+	parts := []string{skuPart(prefix)}
+	for _, option := range options {
+		if part := skuPart(combination[option.Name]); part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	sku := strings.Join(parts, "-")
+	if len(sku) > 64 {
+		sku = strings.TrimRight(sku[:64], "-")
+	}
+	return sku
+}
+
+// skuPart upper cases the value and replaces everything but letters and digits with a dash
+func skuPart(value string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToUpper(value) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimRight(b.String(), "-")
+}
+
+// normalizeOptions trims and lower cases the option names, orders them as given and refuses duplicates
+func normalizeOptions(productID string, options []models.ProductOption) ([]models.ProductOption, error) {
+	normalized := make([]models.ProductOption, 0, len(options))
+	seen := make(map[string]bool, len(options))
+	for i, option := range options {
+		name := strings.ToLower(strings.TrimSpace(option.Name))
+		if seen[name] {
+			return nil, invalidVariant(fmt.Sprintf("options[%d].name", i), "must be unique")
+		}
+		seen[name] = true
+
+		values := make([]string, 0, len(option.Values))
+		for _, value := range option.Values {
+			values = append(values, strings.TrimSpace(value))
+		}
+
+		normalized = append(normalized, models.ProductOption{
+			ProductID: productID,
+			Name:      name,
+			Values:    values,
+			Position:  i,
+		})
+	}
+	return normalized, nil
+}
+
+func invalidVariant(field string, message string) error {
+	return &exception.ValidationError{
+		Message: "Invalid Variant",
+		Fields: []exception.FieldError{{
+			Field:   field,
+			Rule:    "variant",
+			Message: message,
+		}},
+	}
+}
@@ -0,0 +1,366 @@
+package promotions
+
+import (
+	"crypto/rand"
+	"math"
+	"strings"
+	"time"
+
+	postgresql "smkdevid/echocommercehub/internal/databases/postgresql"
+	models "smkdevid/echocommercehub/internal/models/schema"
+	"smkdevid/echocommercehub/utils/exception"
+)
+
+// RuleCouponRedemptionLimit is reported when a coupon has no redemption left
+const RuleCouponRedemptionLimit = "coupon_redemption_limit"
+
+const (
+	// couponAlphabet leaves out characters that are easily confused like 0/O and 1/I
+	couponAlphabet         = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	couponPlaceholder      = 'X'
+	defaultCouponLength    = 8
+	maxCouponLength        = 32
+	maxCouponPattern       = 64
+	maxCouponQuantity      = 10000
+	couponGenerateAttempts = 5
+)
+
+// GenerateCouponRequest describes a batch of coupon codes to generate.
+//
+// Pattern is optional, every segment of the pattern made only of X characters is
+// replaced by random characters, e.g. RAMADHAN-XXXX. Without a pattern a random
+// code of Length characters is generated. MaxRedemptions defaults to 1 (single use).
+type GenerateCouponRequest struct {
+	Quantity       int    `json:"quantity" validate:"gte=1,lte=10000"`
+	Pattern        string `json:"pattern" validate:"max=64"`
+	Length         int    `json:"length" validate:"omitempty,gte=4,lte=32"`
+	MaxRedemptions uint   `json:"max_redemptions"`
+}
+
+// RedeemCouponRequest is the checkout that redeems a coupon
+type RedeemCouponRequest struct {
+	CustomerID     string `json:"customer_id" validate:"required"`
+	OrderReference string `json:"order_reference" validate:"required"`
+	Cart           Cart   `json:"cart"`
+}
+
+// CouponValidation tells whether a coupon can be used for a cart
+type CouponValidation struct {
+	CouponCode           string                `json:"coupon_code"`
+	PromotionID          string                `json:"promotion_id"`
+	Valid                bool                  `json:"valid"`
+	RemainingRedemptions uint                  `json:"remaining_redemptions"`
+	Reasons              []IneligibilityReason `json:"reasons,omitempty"`
+}
+
+// CouponService provides coupon-related services
+type CouponService interface {
+	GenerateCoupons(promotionID string, req GenerateCouponRequest) ([]models.Coupon, error)
+	GetCouponsbyPromotionID(promotionID string) ([]models.Coupon, error)
+	ValidateCoupon(couponCode string, cart Cart) (CouponValidation, error)
+	RedeemCoupon(couponCode string, req RedeemCouponRequest) (models.CouponRedemption, error)
+}
+
+type CouponServiceImpl struct {
+	CouponRepo    postgresql.CouponRepository
+	PromotionRepo postgresql.PromotionRepository
+}
+
+// NewCouponService creates a new instance of CouponService
+func NewCouponService(CouponRepo postgresql.CouponRepository, PromotionRepo postgresql.PromotionRepository) *CouponServiceImpl {
+	return &CouponServiceImpl{
+		CouponRepo:    CouponRepo,
+		PromotionRepo: PromotionRepo,
+	}
+}
+
+// GenerateCoupons creates a batch of unique coupon codes tied to the promotion
+func (s *CouponServiceImpl) GenerateCoupons(promotionID string, req GenerateCouponRequest) ([]models.Coupon, error) {
+	pattern, err := couponPattern(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.PromotionRepo.GetPromotionbyPromotionID(promotionID); err != nil {
+		return nil, err
+	}
+
+	codes, err := s.uniqueCouponCodes(pattern, req.Quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	maxRedemptions := req.MaxRedemptions
+	if maxRedemptions == 0 {
+		maxRedemptions = 1
+	}
+
+	coupons := make([]models.Coupon, 0, len(codes))
+	for _, code := range codes {
+		coupons = append(coupons, models.Coupon{
+			CouponCode:     code,
+			PromotionID:    promotionID,
+			MaxRedemptions: maxRedemptions,
+		})
+	}
+	return s.CouponRepo.CreateCoupons(coupons)
+}
+
+// GetCouponsbyPromotionID throw all coupons generated for the promotion
+func (s *CouponServiceImpl) GetCouponsbyPromotionID(promotionID string) ([]models.Coupon, error) {
+	return s.CouponRepo.GetCouponsbyPromotionID(promotionID)
+}
+
+// ValidateCoupon checks the coupon limit and the rules of its promotion against the cart
+func (s *CouponServiceImpl) ValidateCoupon(couponCode string, cart Cart) (CouponValidation, error) {
+	coupon, err := s.CouponRepo.GetCouponbyCouponCode(NormalizeCouponCode(couponCode))
+	if err != nil {
+		return CouponValidation{}, err
+	}
+
+	validation, _, err := s.evaluateCoupon(coupon, cart)
+	if err != nil {
+		return CouponValidation{}, err
+	}
+
+	if coupon.Exhausted() {
+		validation.Valid = false
+		validation.Reasons = append([]IneligibilityReason{{
+			Rule:    RuleCouponRedemptionLimit,
+			Message: "coupon has reached its redemption limit",
+		}}, validation.Reasons...)
+	}
+	return validation, nil
+}
+
+// RedeemCoupon redeems the coupon for an order. Redeeming the same coupon again for the
+// same order returns the existing redemption instead of counting twice.
+func (s *CouponServiceImpl) RedeemCoupon(couponCode string, req RedeemCouponRequest) (models.CouponRedemption, error) {
+	if req.CustomerID == "" || req.OrderReference == "" {
+		return models.CouponRedemption{}, &exception.InvalidCouponRequestError{
+			Message: "customer_id and order_reference are required",
+		}
+	}
+
+	couponCode = NormalizeCouponCode(couponCode)
+	coupon, err := s.CouponRepo.GetCouponbyCouponCode(couponCode)
+	if err != nil {
+		return models.CouponRedemption{}, err
+	}
+
+	redemption, found, err := s.CouponRepo.FindRedemption(couponCode, req.OrderReference)
+	if err != nil {
+		return models.CouponRedemption{}, err
+	}
+	if found {
+		return redemption, nil
+	}
+
+	cart := req.Cart
+	cart.CustomerID = req.CustomerID
+
+	validation, promo, err := s.evaluateCoupon(coupon, cart)
+	if err != nil {
+		return models.CouponRedemption{}, err
+	}
+	if !validation.Valid {
+		return models.CouponRedemption{}, &exception.CouponNotApplicableError{
+			Message:    "Coupon Not Applicable: " + validation.Reasons[0].Message,
+			CouponCode: couponCode,
+		}
+	}
+
+	// The redemption limit and the uses per customer are enforced atomically by the repository
+	return s.CouponRepo.RedeemCoupon(models.CouponRedemption{
+		CouponCode:     couponCode,
+		PromotionID:    coupon.PromotionID,
+		CustomerID:     req.CustomerID,
+		OrderReference: req.OrderReference,
+	}, promo.Rules.MaxUsesPerCustomer)
+}
+
+// evaluateCoupon evaluates the promotion of the coupon, counting the previous
+// redemptions of the customer towards the max uses per customer rule
+func (s *CouponServiceImpl) evaluateCoupon(coupon models.Coupon, cart Cart) (CouponValidation, models.Promotion, error) {
+	promo, err := s.PromotionRepo.GetPromotionbyPromotionID(coupon.PromotionID)
+	if err != nil {
+		return CouponValidation{}, models.Promotion{}, err
+	}
+
+	if cart.CustomerID != "" && promo.Rules.MaxUsesPerCustomer > 0 {
+		used, err := s.CouponRepo.CountRedemptionsbyCustomer(promo.PromotionID, cart.CustomerID)
+		if err != nil {
+			return CouponValidation{}, models.Promotion{}, err
+		}
+
+		usage := make(map[string]uint, len(cart.PromotionUsage)+1)
+		for id, count := range cart.PromotionUsage {
+			usage[id] = count
+		}
+		if used > usage[promo.PromotionID] {
+			usage[promo.PromotionID] = used
+		}
+		cart.PromotionUsage = usage
+	}
+
+	eligibility := EvaluateEligibility(promo, cart, time.Now())
+
+	var remaining uint
+	if !coupon.Exhausted() {
+		remaining = coupon.MaxRedemptions - coupon.RedemptionCount
+	}
+
+	return CouponValidation{
+		CouponCode:           coupon.CouponCode,
+		PromotionID:          coupon.PromotionID,
+		Valid:                eligibility.Eligible,
+		RemainingRedemptions: remaining,
+		Reasons:              eligibility.Reasons,
+	}, promo, nil
+}
+
+// uniqueCouponCodes generates codes that are unique within the batch and not yet stored
+func (s *CouponServiceImpl) uniqueCouponCodes(pattern string, quantity int) ([]string, error) {
+	codes := make([]string, 0, quantity)
+	taken := make(map[string]bool, quantity)
+
+	for attempt := 0; attempt < couponGenerateAttempts && len(codes) < quantity; attempt++ {
+		var batch []string
+		for len(codes)+len(batch) < quantity {
+			code, err := GenerateCouponCode(pattern)
+			if err != nil {
+				return nil, err
+			}
+			if taken[code] {
+				continue
+			}
+			taken[code] = true
+			batch = append(batch, code)
+		}
+
+		existing, err := s.CouponRepo.GetExistingCouponCodes(batch)
+		if err != nil {
+			return nil, err
+		}
+		stored := make(map[string]bool, len(existing))
+		for _, code := range existing {
+			stored[code] = true
+		}
+
+		for _, code := range batch {
+			if !stored[code] {
+				codes = append(codes, code)
+			}
+		}
+	}
+
+	if len(codes) < quantity {
+		return nil, &exception.InvalidCouponRequestError{
+			Message: "could not generate enough unique coupon codes, use a longer pattern",
+		}
+	}
+	return codes, nil
+}
+
+// couponPattern validates the request and returns the pattern to generate codes from
+func couponPattern(req GenerateCouponRequest) (string, error) {
+	if req.Quantity < 1 || req.Quantity > maxCouponQuantity {
+		return "", &exception.InvalidCouponRequestError{
+			Message: "quantity must be between 1 and 10000",
+		}
+	}
+
+	pattern := NormalizeCouponCode(req.Pattern)
+	if pattern == "" {
+		length := req.Length
+		if length == 0 {
+			length = defaultCouponLength
+		}
+		if length < 4 || length > maxCouponLength {
+			return "", &exception.InvalidCouponRequestError{
+				Message: "length must be between 4 and 32",
+			}
+		}
+		pattern = strings.Repeat(string(couponPlaceholder), length)
+	}
+
+	if len(pattern) > maxCouponPattern {
+		return "", &exception.InvalidCouponRequestError{
+			Message: "pattern must not be longer than 64 characters",
+		}
+	}
+
+	placeholders := 0
+	for _, segment := range couponSegments(pattern) {
+		if isPlaceholderSegment(segment) {
+			placeholders += len(segment)
+		}
+	}
+	if placeholders == 0 {
+		return "", &exception.InvalidCouponRequestError{
+			Message: "pattern must contain at least one segment of X placeholders",
+		}
+	}
+
+	// Keep the code space at least twice the batch size so collisions stay rare
+	if math.Pow(float64(len(couponAlphabet)), float64(placeholders)) < float64(2*req.Quantity) {
+		return "", &exception.InvalidCouponRequestError{
+			Message: "pattern does not have enough placeholders for the requested quantity",
+		}
+	}
+	return pattern, nil
+}
+
+// GenerateCouponCode fills the placeholder segments of the pattern with random characters
+func GenerateCouponCode(pattern string) (string, error) {
+	var code strings.Builder
+	for _, segment := range couponSegments(pattern) {
+		if !isPlaceholderSegment(segment) {
+			code.WriteString(segment)
+			continue
+		}
+
+		random := make([]byte, len(segment))
+		if _, err := rand.Read(random); err != nil {
+			return "", err
+		}
+		for _, b := range random {
+			// 32 characters divide 256 evenly, so every character is equally likely
+			code.WriteByte(couponAlphabet[int(b)%len(couponAlphabet)])
+		}
+	}
+	return code.String(), nil
+}
+
+// NormalizeCouponCode makes coupon codes case insensitive
+func NormalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// couponSegments splits the pattern into alphanumeric segments and the separators between them
+func couponSegments(pattern string) []string {
+	var segments []string
+	start := 0
+	for i, r := range pattern {
+		if isAlphanumeric(r) {
+			continue
+		}
+		if i > start {
+			segments = append(segments, pattern[start:i])
+		}
+		segments = append(segments, string(r))
+		start = i + len(string(r))
+	}
+	if start < len(pattern) {
+		segments = append(segments, pattern[start:])
+	}
+	return segments
+}
+
+func isPlaceholderSegment(segment string) bool {
+	return segment != "" && strings.Trim(segment, string(couponPlaceholder)) == ""
+}
+
+func isAlphanumeric(r rune) bool {
+	return (r >= 'A' && r <= 'Z') || (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9')
+}
@@ -1 +1,271 @@
 package promotions
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	models "smkdevid/echocommercehub/internal/models/schema"
+	"smkdevid/echocommercehub/utils/exception"
+)
+
+// DiscountLine is the discount breakdown of a single cart item
+type DiscountLine struct {
+	ProductID  string  `json:"product_id"`
+	CategoryID string  `json:"category_id"`
+	Quantity   uint    `json:"quantity"`
+	UnitPrice  float64 `json:"unit_price"`
+	LineTotal  float64 `json:"line_total"`
+	Eligible   bool    `json:"eligible"`
+	Discount   float64 `json:"discount"`
+	Total      float64 `json:"total"`
+}
+
+// DiscountQuote is the amount a promotion takes off a cart
+type DiscountQuote struct {
+	PromotionID      string            `json:"promotion_id"`
+	DiscountType     string            `json:"discount_type"`
+	Eligibility      EligibilityResult `json:"eligibility"`
+	Lines            []DiscountLine    `json:"lines"`
+	Subtotal         float64           `json:"subtotal"`
+	ItemDiscount     float64           `json:"item_discount"`
+	ShippingCost     float64           `json:"shipping_cost"`
+	ShippingDiscount float64           `json:"shipping_discount"`
+	TotalDiscount    float64           `json:"total_discount"`
+	Capped           bool              `json:"capped"`
+	GrandTotal       float64           `json:"grand_total"`
+}
+
+// RoundIDR rounds an amount to whole rupiah, since IDR has no minor unit in practice
+func RoundIDR(amount float64) float64 {
+	return math.Round(amount)
+}
+
+// CalculateDiscount turns the promotion discount into a line-by-line quote for the cart.
+// Eligibility is not checked here, use PromotionService.QuoteDiscount for that.
+func CalculateDiscount(promo models.Promotion, cart Cart) (DiscountQuote, error) {
+	quote := newDiscountQuote(promo, cart)
+
+	var (
+		weights  []float64
+		shipping float64
+		err      error
+	)
+
+	switch promo.NormalizedDiscountType() {
+	case models.DiscountTypePercentage:
+		weights = percentageWeights(quote.Lines, promo.DiscountValue)
+	case models.DiscountTypeFixed:
+		weights = fixedWeights(quote.Lines, promo.DiscountValue)
+	case models.DiscountTypeBuyXGetY:
+		weights, err = buyXGetYWeights(quote.Lines, promo)
+	case models.DiscountTypeTiered:
+		weights, err = tieredWeights(quote.Lines, promo.DiscountTiers)
+	case models.DiscountTypeFreeShipping:
+		weights = make([]float64, len(quote.Lines))
+		shipping = cart.ShippingCost
+	default:
+		err = &exception.InvalidDiscountError{
+			Message:      "Unsupported discount",
+			DiscountType: promo.DiscountType,
+		}
+	}
+	if err != nil {
+		return DiscountQuote{}, err
+	}
+
+	itemDiscount := RoundIDR(sum(weights))
+	shippingDiscount := RoundIDR(shipping)
+
+	// Scale item and shipping discounts down together when the cap is exceeded
+	if maxDiscount := RoundIDR(promo.MaxDiscountAmount); maxDiscount > 0 && itemDiscount+shippingDiscount > maxDiscount {
+		ratio := maxDiscount / (itemDiscount + shippingDiscount)
+		shippingDiscount = RoundIDR(shippingDiscount * ratio)
+		itemDiscount = maxDiscount - shippingDiscount
+		quote.Capped = true
+	}
+
+	for i, share := range allocateIDR(itemDiscount, weights) {
+		quote.Lines[i].Discount = share
+		quote.Lines[i].Total = quote.Lines[i].LineTotal - share
+	}
+
+	quote.ItemDiscount = itemDiscount
+	quote.ShippingDiscount = shippingDiscount
+	quote.TotalDiscount = itemDiscount + shippingDiscount
+	quote.GrandTotal = quote.Subtotal + quote.ShippingCost - quote.TotalDiscount
+	return quote, nil
+}
+
+// newDiscountQuote builds a quote for the cart without any discount applied
+func newDiscountQuote(promo models.Promotion, cart Cart) DiscountQuote {
+	quote := DiscountQuote{
+		PromotionID:  promo.PromotionID,
+		DiscountType: promo.NormalizedDiscountType(),
+		Lines:        make([]DiscountLine, 0, len(cart.Items)),
+		ShippingCost: RoundIDR(cart.ShippingCost),
+	}
+
+	for _, item := range cart.Items {
+		lineTotal := RoundIDR(item.UnitPrice * float64(item.Quantity))
+		quote.Lines = append(quote.Lines, DiscountLine{
+			ProductID:  item.ProductID,
+			CategoryID: item.CategoryID,
+			Quantity:   item.Quantity,
+			UnitPrice:  item.UnitPrice,
+			LineTotal:  lineTotal,
+			Eligible:   isEligibleItem(promo.Rules, item),
+			Total:      lineTotal,
+		})
+		quote.Subtotal += lineTotal
+	}
+
+	quote.GrandTotal = quote.Subtotal + quote.ShippingCost
+	return quote
+}
+
+func percentageWeights(lines []DiscountLine, percent float64) []float64 {
+	percent = math.Min(math.Max(percent, 0), 100)
+
+	weights := make([]float64, len(lines))
+	for i, line := range lines {
+		if line.Eligible {
+			weights[i] = line.LineTotal * percent / 100
+		}
+	}
+	return weights
+}
+
+// fixedWeights spreads a fixed amount over the eligible lines proportionally to their totals
+func fixedWeights(lines []DiscountLine, amount float64) []float64 {
+	eligibleTotal := eligibleSubtotal(lines)
+	amount = math.Min(math.Max(amount, 0), eligibleTotal)
+
+	weights := make([]float64, len(lines))
+	if eligibleTotal == 0 {
+		return weights
+	}
+	for i, line := range lines {
+		if line.Eligible {
+			weights[i] = amount * line.LineTotal / eligibleTotal
+		}
+	}
+	return weights
+}
+
+// buyXGetYWeights discounts the cheapest GetQuantity units of every group of BuyQuantity+GetQuantity
+// eligible units. DiscountValue is the percentage taken off those units, 100 (free) when not set.
+func buyXGetYWeights(lines []DiscountLine, promo models.Promotion) ([]float64, error) {
+	if promo.BuyQuantity == 0 || promo.GetQuantity == 0 {
+		return nil, &exception.InvalidDiscountError{
+			Message:      "Buy and get quantity must be set",
+			DiscountType: promo.DiscountType,
+		}
+	}
+
+	percent := promo.DiscountValue
+	if percent <= 0 || percent > 100 {
+		percent = 100
+	}
+
+	// Units are counted per line and never expanded one by one, the quantity comes from the client
+	var eligible []int
+	var units uint64
+	for i, line := range lines {
+		if line.Eligible {
+			eligible = append(eligible, i)
+			units += uint64(line.Quantity)
+		}
+	}
+	sort.SliceStable(eligible, func(a, b int) bool { return lines[eligible[a]].UnitPrice < lines[eligible[b]].UnitPrice })
+
+	group := uint64(promo.BuyQuantity + promo.GetQuantity)
+	free := (units / group) * uint64(promo.GetQuantity)
+
+	weights := make([]float64, len(lines))
+	for _, i := range eligible {
+		if free == 0 {
+			break
+		}
+		taken := min(free, uint64(lines[i].Quantity))
+		weights[i] = float64(taken) * lines[i].UnitPrice * percent / 100
+		free -= taken
+	}
+	return weights, nil
+}
+
+// tieredWeights applies the highest tier whose minimum spend is reached by the eligible subtotal
+func tieredWeights(lines []DiscountLine, tiers []models.DiscountTier) ([]float64, error) {
+	eligibleTotal := eligibleSubtotal(lines)
+
+	var reached *models.DiscountTier
+	for i := range tiers {
+		if tiers[i].MinSpend <= eligibleTotal && (reached == nil || tiers[i].MinSpend > reached.MinSpend) {
+			reached = &tiers[i]
+		}
+	}
+	if reached == nil {
+		return make([]float64, len(lines)), nil
+	}
+
+	switch strings.ToLower(reached.Type) {
+	case models.DiscountTypePercentage:
+		return percentageWeights(lines, reached.Value), nil
+	case models.DiscountTypeFixed, "":
+		return fixedWeights(lines, reached.Value), nil
+	default:
+		return nil, &exception.InvalidDiscountError{
+			Message:      "Unsupported tier",
+			DiscountType: reached.Type,
+		}
+	}
+}
+
+// allocateIDR splits a whole rupiah total over the weights using the largest remainder method,
+// so the line discounts always add up to the total
+func allocateIDR(total float64, weights []float64) []float64 {
+	shares := make([]float64, len(weights))
+	weightSum := sum(weights)
+	if total <= 0 || weightSum <= 0 {
+		return shares
+	}
+
+	type remainder struct {
+		index int
+		value float64
+	}
+	remainders := make([]remainder, 0, len(weights))
+
+	var allocated float64
+	for i, weight := range weights {
+		exact := total * weight / weightSum
+		shares[i] = math.Floor(exact)
+		allocated += shares[i]
+		remainders = append(remainders, remainder{index: i, value: exact - shares[i]})
+	}
+
+	sort.SliceStable(remainders, func(a, b int) bool { return remainders[a].value > remainders[b].value })
+	for i := 0; allocated < total && i < len(remainders); i++ {
+		shares[remainders[i].index]++
+		allocated++
+	}
+	return shares
+}
+
+func eligibleSubtotal(lines []DiscountLine) float64 {
+	var total float64
+	for _, line := range lines {
+		if line.Eligible {
+			total += line.LineTotal
+		}
+	}
+	return total
+}
+
+func sum(values []float64) float64 {
+	var total float64
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
@@ -0,0 +1,158 @@
+package promotions
+
+import (
+	"fmt"
+	"time"
+
+	models "smkdevid/echocommercehub/internal/models/schema"
+)
+
+// Rule names reported when a promotion does not apply to a cart
+const (
+	RulePromotionStatus    = "promotion_status"
+	RulePromotionPeriod    = "promotion_period"
+	RuleMinSubtotal        = "min_subtotal"
+	RuleEligibleItems      = "eligible_items"
+	RuleFirstOrderOnly     = "first_order_only"
+	RuleCustomerSegment    = "customer_segment"
+	RuleMaxUsesPerCustomer = "max_uses_per_customer"
+)
+
+// CartItem is a single line of the cart that a promotion is evaluated against
+type CartItem struct {
+	ProductID  string  `json:"product_id"`
+	CategoryID string  `json:"category_id"`
+	Quantity   uint    `json:"quantity" validate:"gt=0,lte=10000"`
+	UnitPrice  float64 `json:"unit_price" validate:"gte=0"`
+}
+
+// Cart describes the customer and items of a checkout
+type Cart struct {
+	CustomerID      string     `json:"customer_id"`
+	CustomerSegment string     `json:"customer_segment"`
+	FirstOrder      bool       `json:"first_order"`
+	Items           []CartItem `json:"items" validate:"dive"`
+	ShippingCost    float64    `json:"shipping_cost" validate:"gte=0"`
+
+	// PromotionUsage is how many times the customer already used each promotion, keyed by promotion ID
+	PromotionUsage map[string]uint `json:"promotion_usage"`
+}
+
+// Subtotal of all cart items before any discount
+func (c Cart) Subtotal() float64 {
+	var subtotal float64
+	for _, item := range c.Items {
+		subtotal += item.UnitPrice * float64(item.Quantity)
+	}
+	return subtotal
+}
+
+// IneligibilityReason explains which rule rejected the cart
+type IneligibilityReason struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// EligibilityResult is the outcome of evaluating a promotion against a cart
+type EligibilityResult struct {
+	PromotionID string                `json:"promotion_id"`
+	Eligible    bool                  `json:"eligible"`
+	Reasons     []IneligibilityReason `json:"reasons,omitempty"`
+}
+
+// EvaluateEligibility checks every rule of the promotion against the cart at the given time.
+// All failing rules are reported, not only the first one.
+func EvaluateEligibility(promo models.Promotion, cart Cart, now time.Time) EligibilityResult {
+	var reasons []IneligibilityReason
+	rules := promo.Rules
+
+	switch promo.Status {
+	case models.PromotionStatusDraft, models.PromotionStatusPaused, models.PromotionStatusArchived:
+		reasons = append(reasons, IneligibilityReason{
+			Rule:    RulePromotionStatus,
+			Message: fmt.Sprintf("promotion is %s", promo.Status),
+		})
+	}
+
+	if now.Before(promo.PromotionStartDate) || now.After(promo.PromotionEndDate) {
+		reasons = append(reasons, IneligibilityReason{
+			Rule:    RulePromotionPeriod,
+			Message: "promotion is not running at this time",
+		})
+	}
+
+	if rules.MinSubtotal > 0 {
+		if subtotal := cart.Subtotal(); subtotal < rules.MinSubtotal {
+			reasons = append(reasons, IneligibilityReason{
+				Rule:    RuleMinSubtotal,
+				Message: fmt.Sprintf("cart subtotal %.2f is below the minimum of %.2f", subtotal, rules.MinSubtotal),
+			})
+		}
+	}
+
+	if rules.HasItemRestriction() && len(EligibleItems(rules, cart.Items)) == 0 {
+		reasons = append(reasons, IneligibilityReason{
+			Rule:    RuleEligibleItems,
+			Message: "cart has no product or category covered by this promotion",
+		})
+	}
+
+	if rules.FirstOrderOnly && !cart.FirstOrder {
+		reasons = append(reasons, IneligibilityReason{
+			Rule:    RuleFirstOrderOnly,
+			Message: "promotion is only valid for the first order",
+		})
+	}
+
+	if len(rules.CustomerSegments) > 0 && !contains(rules.CustomerSegments, cart.CustomerSegment) {
+		reasons = append(reasons, IneligibilityReason{
+			Rule:    RuleCustomerSegment,
+			Message: fmt.Sprintf("customer segment %q is not eligible", cart.CustomerSegment),
+		})
+	}
+
+	if rules.MaxUsesPerCustomer > 0 && cart.PromotionUsage[promo.PromotionID] >= rules.MaxUsesPerCustomer {
+		reasons = append(reasons, IneligibilityReason{
+			Rule:    RuleMaxUsesPerCustomer,
+			Message: fmt.Sprintf("promotion can only be used %d time(s) per customer", rules.MaxUsesPerCustomer),
+		})
+	}
+
+	return EligibilityResult{
+		PromotionID: promo.PromotionID,
+		Eligible:    len(reasons) == 0,
+		Reasons:     reasons,
+	}
+}
+
+// EligibleItems returns the cart items covered by the product and category rules.
+// Every item is eligible when the promotion has no item restriction.
+func EligibleItems(rules models.PromotionRules, items []CartItem) []CartItem {
+	if !rules.HasItemRestriction() {
+		return items
+	}
+
+	var eligible []CartItem
+	for _, item := range items {
+		if isEligibleItem(rules, item) {
+			eligible = append(eligible, item)
+		}
+	}
+	return eligible
+}
+
+func isEligibleItem(rules models.PromotionRules, item CartItem) bool {
+	if !rules.HasItemRestriction() {
+		return true
+	}
+	return contains(rules.ProductIDs, item.ProductID) || contains(rules.CategoryIDs, item.CategoryID)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
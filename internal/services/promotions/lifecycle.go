@@ -0,0 +1,60 @@
+package promotions
+
+import (
+	"time"
+
+	models "smkdevid/echocommercehub/internal/models/schema"
+)
+
+// LifecycleStatus computes the status of the promotion at the given time.
+//
+// Draft, paused and archived are set by hand and kept as is, except that a paused
+// promotion expires once its end date passed. Every other promotion is scheduled,
+// active or expired depending on its start and end dates.
+func LifecycleStatus(promo models.Promotion, now time.Time) string {
+	switch promo.Status {
+	case models.PromotionStatusDraft, models.PromotionStatusArchived:
+		return promo.Status
+	case models.PromotionStatusPaused:
+		if now.After(promo.PromotionEndDate) {
+			return models.PromotionStatusExpired
+		}
+		return promo.Status
+	}
+	return scheduledStatus(promo, now)
+}
+
+// scheduledStatus is the status of a published promotion based on its dates only
+func scheduledStatus(promo models.Promotion, now time.Time) string {
+	switch {
+	case now.Before(promo.PromotionStartDate):
+		return models.PromotionStatusScheduled
+	case now.After(promo.PromotionEndDate):
+		return models.PromotionStatusExpired
+	default:
+		return models.PromotionStatusActive
+	}
+}
+
+// IsValidPromotionStatus reports whether the status is a known lifecycle status
+func IsValidPromotionStatus(status string) bool {
+	switch status {
+	case models.PromotionStatusDraft, models.PromotionStatusScheduled, models.PromotionStatusActive,
+		models.PromotionStatusPaused, models.PromotionStatusExpired, models.PromotionStatusArchived:
+		return true
+	}
+	return false
+}
+
+// FilterPromotionsbyStatus refreshes the status of every promotion and keeps the ones
+// matching the given status. An empty status keeps every promotion.
+func FilterPromotionsbyStatus(promos []models.Promotion, status string, now time.Time) []models.Promotion {
+	filtered := make([]models.Promotion, 0, len(promos))
+	for _, promo := range promos {
+		promo.Status = LifecycleStatus(promo, now)
+		if status == "" || promo.Status == status {
+			filtered = append(filtered, promo)
+		}
+	}
+	return filtered
+}
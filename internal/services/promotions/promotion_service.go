@@ -1,17 +1,39 @@
 package promotions
 
 import (
+	"time"
+
+	"github.com/oklog/ulid/v2"
+
 	postgresql "smkdevid/echocommercehub/internal/databases/postgresql"
 	models "smkdevid/echocommercehub/internal/models/schema"
+	"smkdevid/echocommercehub/utils/exception"
 )
 
 // PromotionService provides promotion-related services
 type PromotionService interface {
-	CreatePromotion(promo models.Promotion) (models.Promotion, error)
+	CreatePromotion(promo models.Promotion, actor string) (models.Promotion, error)
 	GetAllPromotions() ([]models.Promotion, error)
 	GetPromotionbyPromotionID(promotionID string) (models.Promotion, error)
-	UpdatePromotionbyPromotionID(promo models.Promotion) (models.Promotion, error)
-	DeletePromotionbyPromotionID(promotionID string) error
+	UpdatePromotionbyPromotionID(promo models.Promotion, actor string) (models.Promotion, error)
+	DeletePromotionbyPromotionID(promotionID string, actor string) error
+	RestorePromotionbyPromotionID(promotionID string, actor string) (models.Promotion, error)
+	GetPromotionHistory(promotionID string) ([]models.PromotionHistory, error)
+	CheckEligibility(promotionID string, cart Cart) (EligibilityResult, error)
+	QuoteDiscount(promotionID string, cart Cart) (DiscountQuote, error)
+	ResolvePromotions(cart Cart, mode string) (StackingResult, error)
+	ListPromotions(query postgresql.PromotionQuery) (PromotionPage, error)
+	PausePromotion(promotionID string, actor string) (models.Promotion, error)
+	ResumePromotion(promotionID string, actor string) (models.Promotion, error)
+}
+
+// PromotionPage is the envelope of a promotion list page
+type PromotionPage struct {
+	Data       []models.Promotion `json:"data"`
+	Total      int64              `json:"total"`
+	Limit      int                `json:"limit"`
+	Offset     int                `json:"offset,omitempty"`
+	NextCursor string             `json:"next_cursor,omitempty"`
 }
 
 type PromotionServiceImpl struct {
@@ -25,9 +47,20 @@ func NewPromotionService(PromotionRepo postgresql.PromotionRepository) *Promotio
 	}
 }
 
-// CreatePromotion creates a new promotion
-func (s *PromotionServiceImpl) CreatePromotion(promo models.Promotion) (models.Promotion, error) {
-	return s.PromotionRepo.CreatePromotion(promo)
+// CreatePromotion creates a new promotion under a server generated promotion ID,
+// scheduled by its dates unless it is created as a draft
+func (s *PromotionServiceImpl) CreatePromotion(promo models.Promotion, actor string) (models.Promotion, error) {
+	promo.PromotionID = NewPromotionID()
+	promo.Version = 1
+	if promo.Status != models.PromotionStatusDraft {
+		promo.Status = scheduledStatus(promo, time.Now())
+	}
+	return s.PromotionRepo.CreatePromotion(promo, actor)
+}
+
+// NewPromotionID mints a ULID, unique and sortable by creation time
+func NewPromotionID() string {
+	return ulid.Make().String()
 }
 
 // GetAllPromotions that already recorded on database
@@ -45,9 +78,9 @@ func (s *PromotionServiceImpl) GetPromotionbyPromotionID(promotionID string) (mo
 }
 
 // UpdatePromotion will update data based on promotionID request
-func (s *PromotionServiceImpl) UpdatePromotionbyPromotionID(promo models.Promotion) (models.Promotion, error) {
+func (s *PromotionServiceImpl) UpdatePromotionbyPromotionID(promo models.Promotion, actor string) (models.Promotion, error) {
 	// Perform duplicate check and update promotion
-	updatePromo, err := s.PromotionRepo.UpdatePromotionbyPromotionID(promo)
+	updatePromo, err := s.PromotionRepo.UpdatePromotionbyPromotionID(promo, actor)
 
 	if err != nil {
 		return models.Promotion{}, err
@@ -55,7 +88,132 @@ func (s *PromotionServiceImpl) UpdatePromotionbyPromotionID(promo models.Promoti
 	return updatePromo, nil
 }
 
-// DeletePromotionByPromotionID will delete data based on promotionID request
-func (s *PromotionServiceImpl) DeletePromotionbyPromotionID(promotionID string) error {
-	return s.PromotionRepo.DeletePromotionbyPromotionID(promotionID)
+// DeletePromotionByPromotionID will soft delete data based on promotionID request
+func (s *PromotionServiceImpl) DeletePromotionbyPromotionID(promotionID string, actor string) error {
+	return s.PromotionRepo.DeletePromotionbyPromotionID(promotionID, actor)
+}
+
+// RestorePromotionbyPromotionID brings back a soft deleted promotion
+func (s *PromotionServiceImpl) RestorePromotionbyPromotionID(promotionID string, actor string) (models.Promotion, error) {
+	return s.PromotionRepo.RestorePromotionbyPromotionID(promotionID, actor)
+}
+
+// GetPromotionHistory throw the audit trail of the promotion, oldest change first
+func (s *PromotionServiceImpl) GetPromotionHistory(promotionID string) ([]models.PromotionHistory, error) {
+	return s.PromotionRepo.GetPromotionHistory(promotionID)
+}
+
+// CheckEligibility evaluates the promotion rules against the given cart
+func (s *PromotionServiceImpl) CheckEligibility(promotionID string, cart Cart) (EligibilityResult, error) {
+	promo, err := s.PromotionRepo.GetPromotionbyPromotionID(promotionID)
+	if err != nil {
+		return EligibilityResult{}, err
+	}
+	return EvaluateEligibility(promo, cart, time.Now()), nil
+}
+
+// QuoteDiscount calculates the discount of the promotion for the given cart.
+// An ineligible cart gets a quote without discount together with the reasons.
+func (s *PromotionServiceImpl) QuoteDiscount(promotionID string, cart Cart) (DiscountQuote, error) {
+	promo, err := s.PromotionRepo.GetPromotionbyPromotionID(promotionID)
+	if err != nil {
+		return DiscountQuote{}, err
+	}
+
+	eligibility := EvaluateEligibility(promo, cart, time.Now())
+	if !eligibility.Eligible {
+		quote := newDiscountQuote(promo, cart)
+		quote.Eligibility = eligibility
+		return quote, nil
+	}
+
+	quote, err := CalculateDiscount(promo, cart)
+	if err != nil {
+		return DiscountQuote{}, err
+	}
+	quote.Eligibility = eligibility
+	return quote, nil
+}
+
+// ResolvePromotions picks the promotions applied to the cart out of every recorded promotion
+func (s *PromotionServiceImpl) ResolvePromotions(cart Cart, mode string) (StackingResult, error) {
+	promos, err := s.PromotionRepo.GetAllPromotions()
+	if err != nil {
+		return StackingResult{}, err
+	}
+	return ResolveStacking(promos, cart, mode, time.Now())
+}
+
+// ListPromotions throw a page of promotions matching the query, each with its current lifecycle status
+func (s *PromotionServiceImpl) ListPromotions(query postgresql.PromotionQuery) (PromotionPage, error) {
+	query, err := query.Normalize()
+	if err != nil {
+		return PromotionPage{}, err
+	}
+
+	list, err := s.PromotionRepo.ListPromotions(query)
+	if err != nil {
+		return PromotionPage{}, err
+	}
+
+	return PromotionPage{
+		Data:       FilterPromotionsbyStatus(list.Promotions, "", time.Now()),
+		Total:      list.Total,
+		Limit:      query.Limit,
+		Offset:     query.Offset,
+		NextCursor: list.NextCursor,
+	}, nil
+}
+
+// PausePromotion stops a scheduled or active promotion from applying until it is resumed
+func (s *PromotionServiceImpl) PausePromotion(promotionID string, actor string) (models.Promotion, error) {
+	return s.transitionPromotion(promotionID, models.PromotionStatusPaused, actor, func(current string) bool {
+		return current == models.PromotionStatusScheduled || current == models.PromotionStatusActive
+	})
+}
+
+// ResumePromotion puts a paused promotion back on its schedule
+func (s *PromotionServiceImpl) ResumePromotion(promotionID string, actor string) (models.Promotion, error) {
+	return s.transitionPromotion(promotionID, "", actor, func(current string) bool {
+		return current == models.PromotionStatusPaused
+	})
+}
+
+// transitionPromotion moves the promotion to the target status when allowed from its current status.
+// An empty target means the status given by the promotion dates.
+func (s *PromotionServiceImpl) transitionPromotion(promotionID string, target string, actor string, allowed func(current string) bool) (models.Promotion, error) {
+	promo, err := s.PromotionRepo.GetPromotionbyPromotionID(promotionID)
+	if err != nil {
+		return models.Promotion{}, err
+	}
+
+	now := time.Now()
+	if target == "" {
+		target = scheduledStatus(promo, now)
+	}
+
+	current := LifecycleStatus(promo, now)
+	if !allowed(current) {
+		return models.Promotion{}, &exception.InvalidStatusTransitionError{
+			Message: "Promotion cannot change status",
+			From:    current,
+			To:      target,
+		}
+	}
+
+	updated, err := s.PromotionRepo.UpdatePromotionStatus(promotionID, promo.Status, target, actor)
+	if err != nil {
+		return models.Promotion{}, err
+	}
+	if !updated {
+		return models.Promotion{}, &exception.InvalidStatusTransitionError{
+			Message: "Promotion status changed concurrently",
+			From:    current,
+			To:      target,
+		}
+	}
+
+	promo.Status = target
+	promo.Version++
+	return promo, nil
 }
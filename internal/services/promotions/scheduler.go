@@ -0,0 +1,124 @@
+package promotions
+
+import (
+	"context"
+	"log"
+	"time"
+
+	postgresql "smkdevid/echocommercehub/internal/databases/postgresql"
+	models "smkdevid/echocommercehub/internal/models/schema"
+)
+
+// Events emitted by the PromotionScheduler
+const (
+	EventPromotionLive    = "promotion.live"
+	EventPromotionExpired = "promotion.expired"
+)
+
+// SchedulerActor is the actor recorded in the history for the transitions of the scheduler
+const SchedulerActor = "scheduler"
+
+// PromotionEvent tells that a promotion changed its lifecycle status
+type PromotionEvent struct {
+	Type        string    `json:"type"`
+	PromotionID string    `json:"promotion_id"`
+	From        string    `json:"from"`
+	To          string    `json:"to"`
+	OccurredAt  time.Time `json:"occurred_at"`
+}
+
+// PromotionEventPublisher receives the events emitted by the scheduler
+type PromotionEventPublisher interface {
+	Publish(event PromotionEvent)
+}
+
+// LogEventPublisher writes every promotion event to the standard logger
+type LogEventPublisher struct{}
+
+func (LogEventPublisher) Publish(event PromotionEvent) {
+	log.Printf("%s: promotion %s moved from %s to %s", event.Type, event.PromotionID, event.From, event.To)
+}
+
+// PromotionScheduler moves promotions between lifecycle statuses when they reach
+// their start or end date
+type PromotionScheduler struct {
+	PromotionRepo postgresql.PromotionRepository
+	Publisher     PromotionEventPublisher
+	Interval      time.Duration
+}
+
+// NewPromotionScheduler creates a new instance of PromotionScheduler
+func NewPromotionScheduler(PromotionRepo postgresql.PromotionRepository, Publisher PromotionEventPublisher, Interval time.Duration) *PromotionScheduler {
+	return &PromotionScheduler{
+		PromotionRepo: PromotionRepo,
+		Publisher:     Publisher,
+		Interval:      Interval,
+	}
+}
+
+// Run checks the promotions on every interval until the context is cancelled
+func (s *PromotionScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Tick(time.Now()); err != nil {
+			log.Printf("promotion scheduler: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick applies every status transition due at the given time
+func (s *PromotionScheduler) Tick(now time.Time) error {
+	promos, err := s.PromotionRepo.GetPromotionsbyStatus([]string{
+		models.PromotionStatusScheduled,
+		models.PromotionStatusActive,
+		models.PromotionStatusPaused,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, promo := range promos {
+		next := LifecycleStatus(promo, now)
+		if next == promo.Status {
+			continue
+		}
+
+		updated, err := s.PromotionRepo.UpdatePromotionStatus(promo.PromotionID, promo.Status, next, SchedulerActor)
+		if err != nil {
+			return err
+		}
+		if !updated {
+			// Changed by someone else in the meantime, it is picked up again on the next tick
+			continue
+		}
+
+		if eventType := transitionEvent(next); eventType != "" && s.Publisher != nil {
+			s.Publisher.Publish(PromotionEvent{
+				Type:        eventType,
+				PromotionID: promo.PromotionID,
+				From:        promo.Status,
+				To:          next,
+				OccurredAt:  now,
+			})
+		}
+	}
+	return nil
+}
+
+func transitionEvent(status string) string {
+	switch status {
+	case models.PromotionStatusActive:
+		return EventPromotionLive
+	case models.PromotionStatusExpired:
+		return EventPromotionExpired
+	}
+	return ""
+}
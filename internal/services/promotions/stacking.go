@@ -0,0 +1,234 @@
+package promotions
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	models "smkdevid/echocommercehub/internal/models/schema"
+)
+
+// Stacking modes used to pick the promotions applied to a cart.
+//
+// StackingModePriority walks the promotions from the highest priority down and applies
+// every promotion that does not conflict with the ones already applied.
+// StackingModeBestForCustomer applies the valid combination with the largest discount.
+//
+// A valid combination holds either one exclusive promotion alone, or at most one
+// non-stackable promotion together with any number of stackable promotions.
+const (
+	StackingModePriority        = "priority"
+	StackingModeBestForCustomer = "best_for_customer"
+)
+
+// IsValidStackingMode reports whether the mode is supported
+func IsValidStackingMode(mode string) bool {
+	return mode == StackingModePriority || mode == StackingModeBestForCustomer
+}
+
+// AppliedPromotion is a promotion applied to the cart along with its discount
+type AppliedPromotion struct {
+	PromotionID   string        `json:"promotion_id"`
+	PromotionName string        `json:"promotion_name"`
+	Priority      int           `json:"priority"`
+	Exclusive     bool          `json:"exclusive"`
+	Stackable     bool          `json:"stackable"`
+	Quote         DiscountQuote `json:"quote"`
+}
+
+// SkippedPromotion is a promotion that was not applied and why
+type SkippedPromotion struct {
+	PromotionID   string                `json:"promotion_id"`
+	PromotionName string                `json:"promotion_name"`
+	Reason        string                `json:"reason"`
+	Eligibility   []IneligibilityReason `json:"eligibility_reasons,omitempty"`
+}
+
+// StackingResult is the set of promotions applied to a cart with the combined totals
+type StackingResult struct {
+	Mode             string             `json:"mode"`
+	Applied          []AppliedPromotion `json:"applied"`
+	Skipped          []SkippedPromotion `json:"skipped"`
+	Subtotal         float64            `json:"subtotal"`
+	ShippingCost     float64            `json:"shipping_cost"`
+	ItemDiscount     float64            `json:"item_discount"`
+	ShippingDiscount float64            `json:"shipping_discount"`
+	TotalDiscount    float64            `json:"total_discount"`
+	GrandTotal       float64            `json:"grand_total"`
+}
+
+// ResolveStacking decides which of the promotions apply to the cart under the given mode.
+// Discounts of combined promotions are each calculated on the original cart and added up,
+// limited to the cart subtotal and shipping cost.
+func ResolveStacking(promos []models.Promotion, cart Cart, mode string, now time.Time) (StackingResult, error) {
+	if !IsValidStackingMode(mode) {
+		return StackingResult{}, fmt.Errorf("unsupported stacking mode %q", mode)
+	}
+
+	base := newDiscountQuote(models.Promotion{}, cart)
+	result := StackingResult{
+		Mode:         mode,
+		Applied:      []AppliedPromotion{},
+		Skipped:      []SkippedPromotion{},
+		Subtotal:     base.Subtotal,
+		ShippingCost: base.ShippingCost,
+	}
+
+	sorted := make([]models.Promotion, len(promos))
+	copy(sorted, promos)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Priority != sorted[j].Priority {
+			return sorted[i].Priority > sorted[j].Priority
+		}
+		return sorted[i].PromotionID < sorted[j].PromotionID
+	})
+
+	var candidates []AppliedPromotion
+	for _, promo := range sorted {
+		eligibility := EvaluateEligibility(promo, cart, now)
+		if !eligibility.Eligible {
+			result.Skipped = append(result.Skipped, skipped(promo, "promotion is not eligible for this cart", eligibility.Reasons))
+			continue
+		}
+
+		quote, err := CalculateDiscount(promo, cart)
+		if err != nil {
+			result.Skipped = append(result.Skipped, skipped(promo, err.Error(), nil))
+			continue
+		}
+		if quote.TotalDiscount <= 0 {
+			result.Skipped = append(result.Skipped, skipped(promo, "promotion gives no discount for this cart", nil))
+			continue
+		}
+
+		quote.Eligibility = eligibility
+		candidates = append(candidates, AppliedPromotion{
+			PromotionID:   promo.PromotionID,
+			PromotionName: promo.PromotionName,
+			Priority:      promo.Priority,
+			Exclusive:     promo.Exclusive,
+			Stackable:     promo.Stackable,
+			Quote:         quote,
+		})
+	}
+
+	if mode == StackingModeBestForCustomer {
+		result.Applied, result.Skipped = bestCombination(candidates, result)
+	} else {
+		result.Applied, result.Skipped = priorityCombination(candidates, result.Skipped)
+	}
+
+	result.ItemDiscount, result.ShippingDiscount = combinedDiscount(result.Applied, result.Subtotal, result.ShippingCost)
+	result.TotalDiscount = result.ItemDiscount + result.ShippingDiscount
+	result.GrandTotal = result.Subtotal + result.ShippingCost - result.TotalDiscount
+	return result, nil
+}
+
+func priorityCombination(candidates []AppliedPromotion, skippedPromos []SkippedPromotion) ([]AppliedPromotion, []SkippedPromotion) {
+	applied := []AppliedPromotion{}
+	var exclusiveID, nonStackableID string
+
+	for _, c := range candidates {
+		switch {
+		case exclusiveID != "":
+			skippedPromos = append(skippedPromos, skippedCandidate(c, fmt.Sprintf("exclusive promotion %s is already applied", exclusiveID)))
+		case c.Exclusive && len(applied) > 0:
+			skippedPromos = append(skippedPromos, skippedCandidate(c, fmt.Sprintf("exclusive promotion cannot be combined with %s", applied[0].PromotionID)))
+		case c.Exclusive:
+			exclusiveID = c.PromotionID
+			applied = append(applied, c)
+		case !c.Stackable && nonStackableID != "":
+			skippedPromos = append(skippedPromos, skippedCandidate(c, fmt.Sprintf("only one non-stackable promotion per order, %s is already applied", nonStackableID)))
+		case !c.Stackable:
+			nonStackableID = c.PromotionID
+			applied = append(applied, c)
+		default:
+			applied = append(applied, c)
+		}
+	}
+	return applied, skippedPromos
+}
+
+// bestCombination compares every valid combination. Since discounts only add up, the best
+// combination without an exclusive promotion always holds every stackable candidate.
+// On equal discounts the combination built from the higher priority promotion wins.
+func bestCombination(candidates []AppliedPromotion, result StackingResult) ([]AppliedPromotion, []SkippedPromotion) {
+	var stackables []AppliedPromotion
+	for _, c := range candidates {
+		if c.Stackable && !c.Exclusive {
+			stackables = append(stackables, c)
+		}
+	}
+
+	var options [][]AppliedPromotion
+	for _, c := range candidates {
+		switch {
+		case c.Exclusive:
+			options = append(options, []AppliedPromotion{c})
+		case !c.Stackable:
+			options = append(options, withPriorityOrder(append([]AppliedPromotion{c}, stackables...)))
+		}
+	}
+	if len(stackables) > 0 {
+		options = append(options, stackables)
+	}
+
+	best := []AppliedPromotion{}
+	bestDiscount := math.Inf(-1)
+	for _, option := range options {
+		item, shipping := combinedDiscount(option, result.Subtotal, result.ShippingCost)
+		if item+shipping > bestDiscount {
+			best, bestDiscount = option, item+shipping
+		}
+	}
+
+	chosen := make(map[string]bool, len(best))
+	for _, c := range best {
+		chosen[c.PromotionID] = true
+	}
+
+	skippedPromos := result.Skipped
+	for _, c := range candidates {
+		if !chosen[c.PromotionID] {
+			skippedPromos = append(skippedPromos, skippedCandidate(c, fmt.Sprintf("a combination with a larger discount (%.0f) was chosen", bestDiscount)))
+		}
+	}
+	return best, skippedPromos
+}
+
+func combinedDiscount(applied []AppliedPromotion, subtotal, shippingCost float64) (float64, float64) {
+	var item, shipping float64
+	for _, a := range applied {
+		item += a.Quote.ItemDiscount
+		shipping += a.Quote.ShippingDiscount
+	}
+	return math.Min(item, subtotal), math.Min(shipping, shippingCost)
+}
+
+func withPriorityOrder(applied []AppliedPromotion) []AppliedPromotion {
+	sort.SliceStable(applied, func(i, j int) bool {
+		if applied[i].Priority != applied[j].Priority {
+			return applied[i].Priority > applied[j].Priority
+		}
+		return applied[i].PromotionID < applied[j].PromotionID
+	})
+	return applied
+}
+
+func skipped(promo models.Promotion, reason string, eligibility []IneligibilityReason) SkippedPromotion {
+	return SkippedPromotion{
+		PromotionID:   promo.PromotionID,
+		PromotionName: promo.PromotionName,
+		Reason:        reason,
+		Eligibility:   eligibility,
+	}
+}
+
+func skippedCandidate(c AppliedPromotion, reason string) SkippedPromotion {
+	return SkippedPromotion{
+		PromotionID:   c.PromotionID,
+		PromotionName: c.PromotionName,
+		Reason:        reason,
+	}
+}
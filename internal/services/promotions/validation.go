@@ -0,0 +1,69 @@
+package promotions
+
+import (
+	models "smkdevid/echocommercehub/internal/models/schema"
+	"smkdevid/echocommercehub/utils/requests"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// IsValidDiscountType reports whether the discount type is supported by the calculator
+func IsValidDiscountType(discountType string) bool {
+	switch (models.Promotion{DiscountType: discountType}).NormalizedDiscountType() {
+	case models.DiscountTypePercentage, models.DiscountTypeFixed, models.DiscountTypeBuyXGetY,
+		models.DiscountTypeTiered, models.DiscountTypeFreeShipping:
+		return true
+	}
+	return false
+}
+
+// RegisterValidationRules adds the promotion rules to the request validator
+func RegisterValidationRules(v *requests.Validator) {
+	v.RegisterRule("discount_type", func(fl validator.FieldLevel) bool {
+		return IsValidDiscountType(fl.Field().String())
+	}, "must be one of [percentage fixed buy_x_get_y tiered free_shipping]")
+
+	v.RegisterRule("promotion_status", func(fl validator.FieldLevel) bool {
+		return IsValidPromotionStatus(fl.Field().String())
+	}, "must be one of [draft scheduled active paused expired archived]")
+
+	v.RegisterMessage("after_start_date", "must be after promotion_start_date")
+	v.RegisterMessage("percentage", "must be greater than 0 and at most 100")
+	v.RegisterMessage("excluded_with_exclusive", "cannot be combined with exclusive")
+	v.RegisterStructRule(promotionStructRule, models.Promotion{})
+}
+
+// promotionStructRule checks the fields that depend on each other
+func promotionStructRule(sl validator.StructLevel) {
+	promo := sl.Current().Interface().(models.Promotion)
+
+	if !promo.PromotionEndDate.After(promo.PromotionStartDate) {
+		sl.ReportError(promo.PromotionEndDate, "promotion_end_date", "PromotionEndDate", "after_start_date", "")
+	}
+
+	switch promo.NormalizedDiscountType() {
+	case models.DiscountTypePercentage:
+		if promo.DiscountValue <= 0 || promo.DiscountValue > 100 {
+			sl.ReportError(promo.DiscountValue, "discount_value", "DiscountValue", "percentage", "")
+		}
+	case models.DiscountTypeFixed:
+		if promo.DiscountValue <= 0 {
+			sl.ReportError(promo.DiscountValue, "discount_value", "DiscountValue", "gt", "0")
+		}
+	case models.DiscountTypeBuyXGetY:
+		if promo.BuyQuantity == 0 {
+			sl.ReportError(promo.BuyQuantity, "buy_quantity", "BuyQuantity", "gt", "0")
+		}
+		if promo.GetQuantity == 0 {
+			sl.ReportError(promo.GetQuantity, "get_quantity", "GetQuantity", "gt", "0")
+		}
+	case models.DiscountTypeTiered:
+		if len(promo.DiscountTiers) == 0 {
+			sl.ReportError(promo.DiscountTiers, "discount_tiers", "DiscountTiers", "required", "")
+		}
+	}
+
+	if promo.Exclusive && promo.Stackable {
+		sl.ReportError(promo.Stackable, "stackable", "Stackable", "excluded_with_exclusive", "")
+	}
+}
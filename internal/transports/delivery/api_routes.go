@@ -0,0 +1,21 @@
+package delivery
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// APIPrefix is the path of the current API version
+const APIPrefix = "/api/v1"
+
+func HelloServer(c echo.Context) error {
+	return c.String(http.StatusOK, "Hello, World!")
+}
+
+// APIRoute creates the group of the current API version, middleware runs on every route of the group
+func APIRoute(e *echo.Echo, middleware ...echo.MiddlewareFunc) *echo.Group {
+
+	e.GET("/", HelloServer)
+	return e.Group(APIPrefix, middleware...)
+}
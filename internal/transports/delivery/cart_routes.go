@@ -0,0 +1,21 @@
+package delivery
+
+import (
+	"smkdevid/echocommercehub/internal/app/handlers"
+	"smkdevid/echocommercehub/internal/services/products"
+
+	"github.com/labstack/echo/v4"
+)
+
+// CartRoute registers the cart of the current shopper, middleware runs on every cart route
+func CartRoute(api *echo.Group, CartService products.CartService, middleware ...echo.MiddlewareFunc) {
+
+	g := api.Group("/cart", middleware...)
+	g.GET("", handlers.PSQLGetCart(CartService))
+	g.POST("/items", handlers.PSQLAddCartItem(CartService))
+	g.PUT("/items/:variant_id", handlers.PSQLUpdateCartItem(CartService))
+	g.DELETE("/items/:variant_id", handlers.PSQLRemoveCartItem(CartService))
+	g.PUT("/coupon", handlers.PSQLApplyCartCoupon(CartService))
+	g.DELETE("/coupon", handlers.PSQLRemoveCartCoupon(CartService))
+	g.POST("/merge", handlers.PSQLMergeCarts(CartService))
+}
@@ -0,0 +1,38 @@
+package delivery
+
+import (
+	"time"
+
+	"smkdevid/echocommercehub/internal/app/handlers"
+	"smkdevid/echocommercehub/internal/app/middlewares"
+	"smkdevid/echocommercehub/internal/services/promotions"
+
+	"github.com/labstack/echo/v4"
+)
+
+// CouponRoute registers the coupon endpoints on the API group, middleware runs on every coupon route.
+// AdminOnly restricts generating and listing coupons and redeeming them outside of checkout, which
+// redeems the coupon of the cart itself.
+func CouponRoute(api *echo.Group, CouponService promotions.CouponService, AdminOnly echo.MiddlewareFunc, middleware ...echo.MiddlewareFunc) {
+
+	admin := append(append([]echo.MiddlewareFunc{}, middleware...), AdminOnly)
+	api.POST("/promotions/:promotion_id/coupons", handlers.PSQLGenerateCoupons(CouponService), admin...)
+	api.GET("/promotions/:promotion_id/coupons", handlers.PSQLGetCouponsbyPromotionID(CouponService), admin...)
+
+	g := api.Group("/coupons", middleware...)
+	g.POST("/:coupon_code/validate", handlers.PSQLValidateCoupon(CouponService))
+	g.POST("/:coupon_code/redeem", handlers.PSQLRedeemCoupon(CouponService), AdminOnly)
+}
+
+// LegacyCouponRoute keeps the unversioned coupon paths working until the sunset date
+func LegacyCouponRoute(e *echo.Echo, CouponService promotions.CouponService, AdminOnly echo.MiddlewareFunc, Sunset time.Time) {
+
+	deprecated := func(successor string) echo.MiddlewareFunc {
+		return middlewares.Deprecated(Sunset, APIPrefix+successor)
+	}
+
+	e.POST("/promotions/:promotion_id/coupons", handlers.PSQLGenerateCoupons(CouponService), deprecated("/promotions/:promotion_id/coupons"), AdminOnly)
+	e.GET("/promotions/:promotion_id/coupons", handlers.PSQLGetCouponsbyPromotionID(CouponService), deprecated("/promotions/:promotion_id/coupons"), AdminOnly)
+	e.POST("/coupons/:coupon_code/validate", handlers.PSQLValidateCoupon(CouponService), deprecated("/coupons/:coupon_code/validate"))
+	e.POST("/coupons/:coupon_code/redeem", handlers.PSQLRedeemCoupon(CouponService), deprecated("/coupons/:coupon_code/redeem"), AdminOnly)
+}
@@ -0,0 +1,22 @@
+package delivery
+
+import (
+	"time"
+
+	"smkdevid/echocommercehub/internal/app/handlers"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Paths of the health endpoints probed by the orchestrator, they are not versioned
+const (
+	LivenessPath  = "/healthz"
+	ReadinessPath = "/readyz"
+)
+
+// HealthRoute registers the liveness and readiness endpoints, each readiness check gets at most timeout
+func HealthRoute(e *echo.Echo, checks map[string]handlers.HealthCheck, timeout time.Duration) {
+
+	e.GET(LivenessPath, handlers.Liveness())
+	e.GET(ReadinessPath, handlers.Readiness(checks, timeout))
+}
@@ -0,0 +1,20 @@
+package delivery
+
+import (
+	"smkdevid/echocommercehub/internal/app/handlers"
+	"smkdevid/echocommercehub/internal/services/products"
+
+	"github.com/labstack/echo/v4"
+)
+
+// MediaRoute registers the images and videos under their product, UploadLimit caps the size of the upload body.
+// middleware runs on every media route.
+func MediaRoute(api *echo.Group, MediaService products.MediaService, UploadLimit echo.MiddlewareFunc, middleware ...echo.MiddlewareFunc) {
+
+	g := api.Group("/products/:product_id/media", middleware...)
+	g.GET("", handlers.PSQLGetMediabyProductID(MediaService))
+	g.POST("", handlers.PSQLUploadMedia(MediaService), UploadLimit)
+	g.PUT("/order", handlers.PSQLReorderMedia(MediaService))
+	g.PUT("/:media_id", handlers.PSQLUpdateMedia(MediaService))
+	g.DELETE("/:media_id", handlers.PSQLDeleteMedia(MediaService))
+}
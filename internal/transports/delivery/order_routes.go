@@ -0,0 +1,30 @@
+package delivery
+
+import (
+	"smkdevid/echocommercehub/internal/app/handlers"
+	"smkdevid/echocommercehub/internal/services/products"
+
+	"github.com/labstack/echo/v4"
+)
+
+// OrderRoute registers the orders of the logged in customer, Idempotency guards placing an order.
+// Cancelling goes through PaymentService to void the open payments. middleware runs on every customer order route.
+func OrderRoute(api *echo.Group, OrderService products.OrderService, PaymentService products.PaymentService, Idempotency echo.MiddlewareFunc, middleware ...echo.MiddlewareFunc) {
+
+	g := api.Group("/orders", middleware...)
+	g.GET("", handlers.PSQLListCustomerOrders(OrderService))
+	g.POST("", handlers.PSQLPlaceOrder(OrderService), Idempotency)
+	g.GET("/:order_id", handlers.PSQLGetCustomerOrder(OrderService))
+	g.GET("/:order_id/history", handlers.PSQLGetCustomerOrderHistory(OrderService))
+	g.POST("/:order_id/cancel", handlers.PSQLCancelCustomerOrder(PaymentService))
+}
+
+// AdminOrderRoute registers the back office order routes, middleware is where access to them is restricted
+func AdminOrderRoute(api *echo.Group, OrderService products.OrderService, middleware ...echo.MiddlewareFunc) {
+
+	g := api.Group("/admin/orders", middleware...)
+	g.GET("", handlers.PSQLListOrders(OrderService))
+	g.GET("/:order_id", handlers.PSQLGetOrder(OrderService))
+	g.GET("/:order_id/history", handlers.PSQLGetOrderHistory(OrderService))
+	g.POST("/:order_id/transitions", handlers.PSQLTransitionOrder(OrderService))
+}
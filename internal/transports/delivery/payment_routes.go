@@ -0,0 +1,37 @@
+package delivery
+
+import (
+	"smkdevid/echocommercehub/internal/app/handlers"
+	"smkdevid/echocommercehub/internal/services/products"
+
+	"github.com/labstack/echo/v4"
+)
+
+// PaymentRoute registers the payments of the orders of the logged in customer, Idempotency guards
+// starting a payment. middleware runs on every customer payment route.
+func PaymentRoute(api *echo.Group, PaymentService products.PaymentService, Idempotency echo.MiddlewareFunc, middleware ...echo.MiddlewareFunc) {
+
+	g := api.Group("/orders/:order_id/payments", middleware...)
+	g.GET("", handlers.PSQLGetCustomerOrderPayments(PaymentService))
+	g.POST("", handlers.PSQLCreatePayment(PaymentService), Idempotency)
+}
+
+// AdminPaymentRoute registers the back office payment routes, middleware is where access to them is restricted
+func AdminPaymentRoute(api *echo.Group, PaymentService products.PaymentService, middleware ...echo.MiddlewareFunc) {
+
+	api.GET("/admin/orders/:order_id/payments", handlers.PSQLGetOrderPayments(PaymentService), middleware...)
+
+	g := api.Group("/admin/payments", middleware...)
+	g.GET("/:payment_id", handlers.PSQLGetPayment(PaymentService))
+	g.POST("/:payment_id/capture", handlers.PSQLCapturePayment(PaymentService))
+	g.POST("/:payment_id/void", handlers.PSQLVoidPayment(PaymentService))
+	g.POST("/:payment_id/refund", handlers.PSQLRefundPayment(PaymentService))
+	g.POST("/:payment_id/sync", handlers.PSQLSyncPayment(PaymentService))
+}
+
+// FakePaymentRoute lets local development settle the charges of the fake provider, it must not be
+// registered with a real provider
+func FakePaymentRoute(api *echo.Group, PaymentService products.PaymentService, Fake *products.FakeProvider) {
+
+	api.POST("/dev/payments/:payment_id/simulate", handlers.PSQLSimulatePayment(PaymentService, Fake))
+}
@@ -0,0 +1,21 @@
+package delivery
+
+import (
+	"smkdevid/echocommercehub/internal/app/handlers"
+	"smkdevid/echocommercehub/internal/services/products"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ProductRoute registers the product catalog on the API group, Idempotency guards the create endpoint.
+// middleware runs on every product route.
+func ProductRoute(api *echo.Group, ProductService products.ProductService, Idempotency echo.MiddlewareFunc, middleware ...echo.MiddlewareFunc) {
+
+	g := api.Group("/products", middleware...)
+	g.GET("", handlers.PSQLListProducts(ProductService))
+	g.GET("/search", handlers.PSQLSearchProducts(ProductService))
+	g.POST("", handlers.PSQLCreateProduct(ProductService), Idempotency)
+	g.GET("/:product_id", handlers.PSQLGetProductbyProductID(ProductService))
+	g.PUT("/:product_id", handlers.PSQLUpdateProductbyProductID(ProductService))
+	g.DELETE("/:product_id", handlers.PSQLDeleteProductbyProductID(ProductService))
+}
@@ -1,24 +1,54 @@
 package delivery
 
 import (
-	"net/http"
+	"time"
 
 	"smkdevid/echocommercehub/internal/app/handlers"
+	"smkdevid/echocommercehub/internal/app/middlewares"
 	"smkdevid/echocommercehub/internal/services/promotions"
 
 	"github.com/labstack/echo/v4"
 )
 
-func HelloServer(c echo.Context) error {
-	return c.String(http.StatusOK, "Hello, World!")
+// PromotionRoute registers the promotion resource on the API group, Idempotency guards the create endpoint.
+// AdminOnly restricts the routes changing promotions, middleware runs on every promotion route.
+func PromotionRoute(api *echo.Group, PromoService promotions.PromotionService, Idempotency echo.MiddlewareFunc, AdminOnly echo.MiddlewareFunc, middleware ...echo.MiddlewareFunc) {
+
+	g := api.Group("/promotions", middleware...)
+	g.GET("", handlers.PSQLGetAllPromotionData(PromoService))
+	g.POST("", handlers.PSQLCreatePromotionData(PromoService), AdminOnly, Idempotency)
+	g.POST("/resolve", handlers.PSQLResolvePromotions(PromoService))
+	g.GET("/:promotion_id", handlers.PSQLGetPromotionbyPromotionID(PromoService))
+	g.PUT("/:promotion_id", handlers.PSQLUpdatePromotionbyPromotionID(PromoService), AdminOnly)
+	g.PATCH("/:promotion_id", handlers.PSQLPatchPromotionbyPromotionID(PromoService), AdminOnly)
+	g.DELETE("/:promotion_id", handlers.PSQLDeletePromotionbyPromotionID(PromoService), AdminOnly)
+	g.POST("/:promotion_id/restore", handlers.PSQLRestorePromotionbyPromotionID(PromoService), AdminOnly)
+	g.GET("/:promotion_id/history", handlers.PSQLGetPromotionHistory(PromoService))
+	g.POST("/:promotion_id/eligibility", handlers.PSQLCheckPromotionEligibility(PromoService))
+	g.POST("/:promotion_id/quote", handlers.PSQLQuotePromotionDiscount(PromoService))
+	g.POST("/:promotion_id/pause", handlers.PSQLPausePromotion(PromoService), AdminOnly)
+	g.POST("/:promotion_id/resume", handlers.PSQLResumePromotion(PromoService), AdminOnly)
 }
 
-func PromotionRoute(e *echo.Echo, PromoService promotions.PromotionService) {
+// LegacyPromotionRoute keeps the unversioned promotion paths working until the sunset date.
+// Their responses carry the Deprecation and Sunset headers and link to the /api/v1 route.
+func LegacyPromotionRoute(e *echo.Echo, PromoService promotions.PromotionService, Idempotency echo.MiddlewareFunc, AdminOnly echo.MiddlewareFunc, Sunset time.Time) {
+
+	deprecated := func(successor string) echo.MiddlewareFunc {
+		return middlewares.Deprecated(Sunset, APIPrefix+successor)
+	}
 
-	e.GET("/", HelloServer)
-	e.GET("/promotions", handlers.PSQLGetAllPromotionData(PromoService))
-	e.GET("/getpromotion/:promotion_id", handlers.PSQLGetPromotionbyPromotionID(PromoService))
-	e.POST("/createpromotion", handlers.PSQLCreatePromotionData(PromoService))
-	e.PUT("/updatepromotion/:promotion_id", handlers.PSQLUpdatePromotionbyPromotionID(PromoService))
-	e.DELETE("/deletepromotion/:promotion_id", handlers.PSQLDeletePromotionbyPromotionID(PromoService))
+	e.GET("/promotions", handlers.PSQLGetAllPromotionData(PromoService), deprecated("/promotions"))
+	e.GET("/getpromotion/:promotion_id", handlers.PSQLGetPromotionbyPromotionID(PromoService), deprecated("/promotions/:promotion_id"))
+	e.POST("/createpromotion", handlers.PSQLCreatePromotionData(PromoService), deprecated("/promotions"), AdminOnly, Idempotency)
+	e.PUT("/updatepromotion/:promotion_id", handlers.PSQLUpdatePromotionbyPromotionID(PromoService), deprecated("/promotions/:promotion_id"), AdminOnly)
+	e.PATCH("/promotions/:promotion_id", handlers.PSQLPatchPromotionbyPromotionID(PromoService), deprecated("/promotions/:promotion_id"), AdminOnly)
+	e.DELETE("/deletepromotion/:promotion_id", handlers.PSQLDeletePromotionbyPromotionID(PromoService), deprecated("/promotions/:promotion_id"), AdminOnly)
+	e.POST("/promotions/:promotion_id/restore", handlers.PSQLRestorePromotionbyPromotionID(PromoService), deprecated("/promotions/:promotion_id/restore"), AdminOnly)
+	e.GET("/promotions/:promotion_id/history", handlers.PSQLGetPromotionHistory(PromoService), deprecated("/promotions/:promotion_id/history"))
+	e.POST("/promotions/:promotion_id/eligibility", handlers.PSQLCheckPromotionEligibility(PromoService), deprecated("/promotions/:promotion_id/eligibility"))
+	e.POST("/promotions/:promotion_id/quote", handlers.PSQLQuotePromotionDiscount(PromoService), deprecated("/promotions/:promotion_id/quote"))
+	e.POST("/promotions/resolve", handlers.PSQLResolvePromotions(PromoService), deprecated("/promotions/resolve"))
+	e.POST("/promotions/:promotion_id/pause", handlers.PSQLPausePromotion(PromoService), deprecated("/promotions/:promotion_id/pause"), AdminOnly)
+	e.POST("/promotions/:promotion_id/resume", handlers.PSQLResumePromotion(PromoService), deprecated("/promotions/:promotion_id/resume"), AdminOnly)
 }
@@ -0,0 +1,21 @@
+package delivery
+
+import (
+	"smkdevid/echocommercehub/internal/app/handlers"
+	"smkdevid/echocommercehub/internal/services/products"
+
+	"github.com/labstack/echo/v4"
+)
+
+// VariantRoute registers the option types and variants under their product, middleware runs on every variant route
+func VariantRoute(api *echo.Group, VariantService products.VariantService, middleware ...echo.MiddlewareFunc) {
+
+	g := api.Group("/products/:product_id", middleware...)
+	g.GET("/options", handlers.PSQLGetProductOptions(VariantService))
+	g.POST("/variants/generate", handlers.PSQLGenerateVariants(VariantService))
+	g.GET("/variants", handlers.PSQLGetVariantsbyProductID(VariantService))
+	g.POST("/variants", handlers.PSQLCreateVariant(VariantService))
+	g.GET("/variants/:variant_id", handlers.PSQLGetVariantbyVariantID(VariantService))
+	g.PUT("/variants/:variant_id", handlers.PSQLUpdateVariant(VariantService))
+	g.DELETE("/variants/:variant_id", handlers.PSQLDeleteVariant(VariantService))
+}
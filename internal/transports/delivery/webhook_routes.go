@@ -0,0 +1,23 @@
+package delivery
+
+import (
+	"smkdevid/echocommercehub/internal/app/handlers"
+	"smkdevid/echocommercehub/internal/services/products"
+
+	"github.com/labstack/echo/v4"
+)
+
+// WebhookRoute registers the receiver of the payment provider webhooks, they are authenticated by
+// their signature instead of the middleware of the other routes
+func WebhookRoute(api *echo.Group, WebhookService products.WebhookService) {
+
+	api.POST("/webhooks/payments", handlers.PSQLReceivePaymentWebhook(WebhookService))
+}
+
+// AdminWebhookRoute registers the back office webhook routes, middleware is where access to them is restricted
+func AdminWebhookRoute(api *echo.Group, WebhookService products.WebhookService, middleware ...echo.MiddlewareFunc) {
+
+	g := api.Group("/admin/webhooks", middleware...)
+	g.GET("", handlers.PSQLListWebhookEvents(WebhookService))
+	g.POST("/:webhook_id/replay", handlers.PSQLReplayWebhookEvent(WebhookService))
+}
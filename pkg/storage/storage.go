@@ -0,0 +1,24 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrObjectNotFound tells that no object is stored under the key
+var ErrObjectNotFound = errors.New("object not found")
+
+// ObjectStorage stores the uploaded files under a slash separated key, such as
+// products/01HV.../original.jpg. The local filesystem implementation is meant for development,
+// a bucket of an object store can implement the same interface in production.
+type ObjectStorage interface {
+	// Put writes the object, replacing the one stored under the same key
+	Put(ctx context.Context, key string, body io.Reader, contentType string) error
+	// Open reads the object, ErrObjectNotFound when it does not exist
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the object, deleting a missing object is not an error
+	Delete(ctx context.Context, key string) error
+	// URL is the public address the object is served from
+	URL(key string) string
+}
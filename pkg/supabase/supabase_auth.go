@@ -7,7 +7,6 @@ import (
 	"smkdevid/echocommercehub/internal/configs"
 
 	supa "github.com/nedpals/supabase-go"
-	"github.com/spf13/viper"
 )
 
 type SupabaseClient interface {
@@ -30,12 +29,8 @@ type SupabaseClient interface {
 // }
 
 // func (supa *SupabaseClientImpl) SupabaseSignUp() {
-func SupabaseSignUp() {
-	configs.LoadViperEnv()
-
-	supabaseUrl := viper.GetString("SUPABASE.URL")
-	supabaseKey := viper.GetString("SUPABASE.KEY")
-	supabase := supa.CreateClient(supabaseUrl, supabaseKey)
+func SupabaseSignUp(config configs.SupabaseConfig) {
+	supabase := supa.CreateClient(config.URL, config.Key)
 
 	ctx := context.Background()
 	user, err := supabase.Auth.SignUp(ctx, supa.UserCredentials{
@@ -50,12 +45,8 @@ func SupabaseSignUp() {
 }
 
 // func (s *SupabaseClientImpl) SupabaseSignIn() {
-func SupabaseSignIn() {
-	configs.LoadViperEnv()
-
-	supabaseUrl := viper.GetString("SUPABASE.URL")
-	supabaseKey := viper.GetString("SUPABASE.KEY")
-	supabase := supa.CreateClient(supabaseUrl, supabaseKey)
+func SupabaseSignIn(config configs.SupabaseConfig) {
+	supabase := supa.CreateClient(config.URL, config.Key)
 
 	ctx := context.Background()
 	user, err := supabase.Auth.SignIn(ctx, supa.UserCredentials{
@@ -85,12 +76,8 @@ func SupabaseSignIn() {
 
 // }
 
-// func SupabaseAuth() {
-// 	configs.LoadViperEnv()
-
-// 	supabaseUrl := viper.GetString("SUPABASE.URL")
-// 	supabaseKey := viper.GetString("SUPABASE.KEY")
-// 	supabase := supa.CreateClient(supabaseUrl, supabaseKey)
+// func SupabaseAuth(config configs.SupabaseConfig) {
+// 	supabase := supa.CreateClient(config.URL, config.Key)
 
 // 	ctx := context.Background()
 // 	user, err := supabase.Auth.SignUp(ctx, supa.UserCredentials{
@@ -0,0 +1,384 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"smkdevid/echocommercehub/internal/app/handlers"
+	schema "smkdevid/echocommercehub/internal/models/schema"
+	"smkdevid/echocommercehub/internal/services/products"
+	"smkdevid/echocommercehub/tests/mocks"
+	"smkdevid/echocommercehub/utils/exception"
+	"smkdevid/echocommercehub/utils/requests"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var cartSettings = products.CartSettings{
+	GuestTTL:     7 * 24 * time.Hour,
+	CustomerTTL:  30 * 24 * time.Hour,
+	TaxRate:      decimal.NewFromFloat(0.11),
+	ShippingCost: decimal.NewFromInt(20000),
+	StackingMode: "best_for_customer",
+}
+
+func TestPriceCart(t *testing.T) {
+	now := time.Now()
+	lines := []products.CartLine{
+		{ProductID: "01HV", VariantID: "01HW", Quantity: 2, UnitPrice: decimal.NewFromInt(75000), Available: true},
+		{ProductID: "01HX", VariantID: "01HY", Quantity: 1, UnitPrice: decimal.NewFromInt(50000), Available: false},
+	}
+	promo := schema.Promotion{
+		PromotionID:        "cae8651b",
+		PromotionName:      "Diskon 10%",
+		DiscountType:       schema.DiscountTypePercentage,
+		DiscountValue:      10,
+		PromotionStartDate: now.Add(-time.Hour),
+		PromotionEndDate:   now.Add(time.Hour),
+		Status:             schema.PromotionStatusActive,
+	}
+
+	t.Run("Totals Breakdown with Promotion, Tax and Shipping", func(t *testing.T) {
+		priced, totals, applied, err := products.PriceCart(lines, products.CartCustomer{}, []schema.Promotion{promo}, cartSettings, now)
+		assert.NoError(t, err)
+		assert.Len(t, applied, 1)
+
+		assert.Equal(t, "150000", totals.Subtotal.String())
+		assert.Equal(t, "15000", totals.ItemDiscount.String())
+		assert.Equal(t, "20000", totals.Shipping.String())
+		assert.Equal(t, "14850", totals.Tax.String())
+		assert.Equal(t, "169850", totals.GrandTotal.String())
+
+		assert.Equal(t, "135000", priced[0].Total.String())
+		assert.Equal(t, "50000", priced[1].LineTotal.String())
+		assert.True(t, priced[1].Discount.IsZero())
+	})
+
+	t.Run("Empty Cart pays nothing", func(t *testing.T) {
+		_, totals, applied, err := products.PriceCart(nil, products.CartCustomer{}, []schema.Promotion{promo}, cartSettings, now)
+		assert.NoError(t, err)
+		assert.Empty(t, applied)
+		assert.True(t, totals.GrandTotal.IsZero())
+		assert.True(t, totals.Shipping.IsZero())
+	})
+
+	t.Run("Promotion used up by the Customer is not applied", func(t *testing.T) {
+		once := promo
+		once.Rules.MaxUsesPerCustomer = 1
+
+		_, totals, applied, err := products.PriceCart(lines, products.CartCustomer{CustomerID: "budi", PromotionUsage: map[string]uint{"cae8651b": 1}}, []schema.Promotion{once}, cartSettings, now)
+		assert.NoError(t, err)
+		assert.Empty(t, applied)
+		assert.True(t, totals.ItemDiscount.IsZero())
+	})
+}
+
+func TestCartService(t *testing.T) {
+	product := schema.Product{ProductID: "01HV", ProductName: "Kaos Polos", ProductPrice: decimal.NewFromInt(75000), Status: schema.ProductStatusActive, Stock: 10}
+	variant := schema.ProductVariant{VariantID: "01HW", ProductID: "01HV", SKU: "KAOS-POLOS-HITAM-M"}
+
+	newService := func() (*products.CartServiceImpl, *mocks.MockCartRepository) {
+		mockCartRepo := new(mocks.MockCartRepository)
+		mockProductRepo := new(mocks.MockProductRepository)
+		mockVariantRepo := new(mocks.MockProductVariantRepository)
+		mockPromotionRepo := new(mocks.MockPromotionRepository)
+
+		mockProductRepo.On("GetProductbyProductID", "01HV").Return(product, nil)
+		mockVariantRepo.On("GetVariantbyVariantID", "01HV", "01HW").Return(variant, nil)
+		mockPromotionRepo.On("GetPromotionsbyStatus", []string{schema.PromotionStatusActive}).Return([]schema.Promotion{}, nil)
+
+		return products.NewCartService(mockCartRepo, mockProductRepo, mockVariantRepo, mockPromotionRepo, new(mocks.MockCouponRepository), new(mocks.MockOrderRepository), cartSettings), mockCartRepo
+	}
+
+	t.Run("First Item creates a Guest Cart", func(t *testing.T) {
+		cartService, mockCartRepo := newService()
+
+		created := schema.Cart{CartID: "01HZ", Token: "guest-token", Status: schema.CartStatusActive, ExpiresAt: time.Now().Add(time.Hour)}
+		withItem := created
+		withItem.Items = []schema.CartItem{{CartID: "01HZ", ProductID: "01HV", VariantID: "01HW", Quantity: 2}}
+
+		mockCartRepo.On("CreateCart", mock.MatchedBy(func(c schema.Cart) bool {
+			return c.Token != "" && c.CustomerID == "" && c.ExpiresAt.After(time.Now().Add(6*24*time.Hour))
+		})).Return(created, nil)
+		mockCartRepo.On("SaveCartItem", schema.CartItem{CartID: "01HZ", ProductID: "01HV", VariantID: "01HW", Quantity: 2}, mock.AnythingOfType("time.Time")).Return(nil)
+		mockCartRepo.On("GetActiveCartbyToken", "guest-token").Return(withItem, true, nil)
+
+		view, err := cartService.AddItem(products.CartOwner{}, products.AddCartItemRequest{ProductID: "01HV", VariantID: "01HW", Quantity: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, "guest-token", view.Token)
+		assert.Equal(t, "150000", view.Totals.Subtotal.String())
+		assert.True(t, view.Lines[0].InStock)
+		mockCartRepo.AssertExpectations(t)
+	})
+
+	t.Run("Quantity of a Variant is capped", func(t *testing.T) {
+		cartService, mockCartRepo := newService()
+
+		cart := schema.Cart{CartID: "01HZ", CustomerID: "budi", Status: schema.CartStatusActive, ExpiresAt: time.Now().Add(time.Hour),
+			Items: []schema.CartItem{{CartID: "01HZ", ProductID: "01HV", VariantID: "01HW", Quantity: 98}}}
+		mockCartRepo.On("GetActiveCartbyCustomerID", "budi").Return(cart, true, nil)
+
+		_, err := cartService.AddItem(products.CartOwner{CustomerID: "budi"}, products.AddCartItemRequest{ProductID: "01HV", VariantID: "01HW", Quantity: 2})
+		assert.ErrorIs(t, err, exception.ErrValidation)
+		mockCartRepo.AssertNotCalled(t, "SaveCartItem", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Expired Cart is closed", func(t *testing.T) {
+		cartService, mockCartRepo := newService()
+
+		expired := schema.Cart{CartID: "01HZ", Token: "guest-token", Status: schema.CartStatusActive, ExpiresAt: time.Now().Add(-time.Minute),
+			Items: []schema.CartItem{{CartID: "01HZ", ProductID: "01HV", VariantID: "01HW", Quantity: 1}}}
+		mockCartRepo.On("GetActiveCartbyToken", "guest-token").Return(expired, true, nil)
+		mockCartRepo.On("CloseCart", "01HZ", schema.CartStatusExpired).Return(nil)
+
+		view, err := cartService.GetCart(products.CartOwner{Token: "guest-token"})
+		assert.NoError(t, err)
+		assert.Empty(t, view.Lines)
+		mockCartRepo.AssertExpectations(t)
+	})
+
+	t.Run("Guest Cart is merged on Login", func(t *testing.T) {
+		cartService, mockCartRepo := newService()
+
+		guest := schema.Cart{CartID: "01HG", Token: "guest-token", Status: schema.CartStatusActive, ExpiresAt: time.Now().Add(time.Hour),
+			Items: []schema.CartItem{{CartID: "01HG", ProductID: "01HV", VariantID: "01HW", Quantity: 1}}}
+		customer := schema.Cart{CartID: "01HC", CustomerID: "budi", Status: schema.CartStatusActive, ExpiresAt: time.Now().Add(time.Hour),
+			Items: []schema.CartItem{{CartID: "01HC", ProductID: "01HV", VariantID: "01HW", Quantity: 2}}}
+		merged := customer
+		merged.Items = []schema.CartItem{{CartID: "01HC", ProductID: "01HV", VariantID: "01HW", Quantity: 3}}
+
+		mockCartRepo.On("GetActiveCartbyToken", "guest-token").Return(guest, true, nil)
+		mockCartRepo.On("GetActiveCartbyCustomerID", "budi").Return(customer, true, nil).Once()
+		mockCartRepo.On("MergeCarts", guest, customer, uint(products.MaxCartItemQuantity), mock.AnythingOfType("time.Time")).Return(nil)
+		mockCartRepo.On("GetActiveCartbyCustomerID", "budi").Return(merged, true, nil).Once()
+
+		view, err := cartService.MergeCarts(products.CartOwner{CustomerID: "budi"}, "guest-token")
+		assert.NoError(t, err)
+		assert.Equal(t, uint(3), view.Lines[0].Quantity)
+		assert.Empty(t, view.Token)
+		mockCartRepo.AssertExpectations(t)
+	})
+}
+
+func TestCartCoupons(t *testing.T) {
+	now := time.Now()
+	product := schema.Product{ProductID: "01HV", ProductName: "Kaos Polos", ProductPrice: decimal.NewFromInt(100000), Status: schema.ProductStatusActive, Stock: 10}
+	variant := schema.ProductVariant{VariantID: "01HW", ProductID: "01HV", SKU: "KAOS-POLOS-HITAM-M"}
+	automatic := schema.Promotion{
+		PromotionID:        "cae8651b",
+		PromotionName:      "Diskon 10%",
+		DiscountType:       schema.DiscountTypePercentage,
+		DiscountValue:      10,
+		PromotionStartDate: now.Add(-time.Hour),
+		PromotionEndDate:   now.Add(time.Hour),
+		Status:             schema.PromotionStatusActive,
+		Stackable:          true,
+	}
+	couponOnly := automatic
+	couponOnly.PromotionID = "137ce1cf"
+	couponOnly.PromotionName = "Ramadhan Sale"
+	couponOnly.DiscountValue = 20
+	couponOnly.Rules.MaxUsesPerCustomer = 1
+
+	newService := func(cart schema.Cart) (*products.CartServiceImpl, *mocks.MockCouponRepository, *mocks.MockOrderRepository) {
+		mockCartRepo := new(mocks.MockCartRepository)
+		mockProductRepo := new(mocks.MockProductRepository)
+		mockVariantRepo := new(mocks.MockProductVariantRepository)
+		mockPromotionRepo := new(mocks.MockPromotionRepository)
+		mockCouponRepo := new(mocks.MockCouponRepository)
+		mockOrderRepo := new(mocks.MockOrderRepository)
+
+		mockCartRepo.On("GetActiveCartbyCustomerID", "budi").Return(cart, true, nil)
+		mockProductRepo.On("GetProductbyProductID", "01HV").Return(product, nil)
+		mockVariantRepo.On("GetVariantbyVariantID", "01HV", "01HW").Return(variant, nil)
+		mockPromotionRepo.On("GetPromotionsbyStatus", []string{schema.PromotionStatusActive}).Return([]schema.Promotion{automatic, couponOnly}, nil)
+		mockCouponRepo.On("GetCouponPromotionIDs", []string{"cae8651b", "137ce1cf"}).Return([]string{"137ce1cf"}, nil)
+
+		return products.NewCartService(mockCartRepo, mockProductRepo, mockVariantRepo, mockPromotionRepo, mockCouponRepo, mockOrderRepo, cartSettings), mockCouponRepo, mockOrderRepo
+	}
+	cart := schema.Cart{CartID: "01HZ", CustomerID: "budi", Status: schema.CartStatusActive, ExpiresAt: now.Add(time.Hour),
+		Items: []schema.CartItem{{CartID: "01HZ", ProductID: "01HV", VariantID: "01HW", Quantity: 1}}}
+
+	t.Run("Coupon Promotion needs its Code", func(t *testing.T) {
+		cartService, _, mockOrderRepo := newService(cart)
+
+		view, err := cartService.GetCart(products.CartOwner{CustomerID: "budi"})
+		assert.NoError(t, err)
+		assert.Len(t, view.Promotions, 1)
+		assert.Equal(t, "cae8651b", view.Promotions[0].PromotionID)
+		assert.False(t, view.CouponApplied)
+		mockOrderRepo.AssertNotCalled(t, "CountPromotionUsesbyCustomer", mock.Anything)
+	})
+
+	t.Run("Entered Coupon applies its Promotion", func(t *testing.T) {
+		withCoupon := cart
+		withCoupon.CouponCode = "RAMADHAN-AB2C"
+		cartService, mockCouponRepo, mockOrderRepo := newService(withCoupon)
+
+		mockCouponRepo.On("GetCouponbyCouponCode", "RAMADHAN-AB2C").Return(schema.Coupon{CouponCode: "RAMADHAN-AB2C", PromotionID: "137ce1cf", MaxRedemptions: 5}, nil)
+		mockOrderRepo.On("CountPromotionUsesbyCustomer", "budi").Return(map[string]uint{}, nil)
+		mockCouponRepo.On("CountRedemptionsbyCustomer", "137ce1cf", "budi").Return(uint(0), nil)
+
+		view, err := cartService.GetCart(products.CartOwner{CustomerID: "budi"})
+		assert.NoError(t, err)
+		assert.Len(t, view.Promotions, 2)
+		assert.True(t, view.CouponApplied)
+		assert.Equal(t, "RAMADHAN-AB2C", view.CouponCode)
+	})
+
+	t.Run("Coupon Promotion already used by the Customer", func(t *testing.T) {
+		withCoupon := cart
+		withCoupon.CouponCode = "RAMADHAN-AB2C"
+		cartService, mockCouponRepo, mockOrderRepo := newService(withCoupon)
+
+		mockCouponRepo.On("GetCouponbyCouponCode", "RAMADHAN-AB2C").Return(schema.Coupon{CouponCode: "RAMADHAN-AB2C", PromotionID: "137ce1cf", MaxRedemptions: 5}, nil)
+		mockOrderRepo.On("CountPromotionUsesbyCustomer", "budi").Return(map[string]uint{"137ce1cf": 1}, nil)
+		mockCouponRepo.On("CountRedemptionsbyCustomer", "137ce1cf", "budi").Return(uint(1), nil)
+
+		view, err := cartService.GetCart(products.CartOwner{CustomerID: "budi"})
+		assert.NoError(t, err)
+		assert.Len(t, view.Promotions, 1)
+		assert.False(t, view.CouponApplied)
+	})
+
+	t.Run("Exhausted Coupon cannot be entered", func(t *testing.T) {
+		cartService, mockCouponRepo, _ := newService(cart)
+
+		mockCouponRepo.On("GetCouponbyCouponCode", "RAMADHAN-AB2C").Return(schema.Coupon{CouponCode: "RAMADHAN-AB2C", PromotionID: "137ce1cf", MaxRedemptions: 1, RedemptionCount: 1}, nil)
+
+		_, err := cartService.ApplyCoupon(products.CartOwner{CustomerID: "budi"}, products.ApplyCouponRequest{CouponCode: "ramadhan-ab2c"})
+		assert.ErrorIs(t, err, exception.ErrConflict)
+	})
+}
+
+func TestCartCustomerRules(t *testing.T) {
+	now := time.Now()
+	product := schema.Product{ProductID: "01HV", ProductName: "Kaos Polos", ProductPrice: decimal.NewFromInt(100000), Status: schema.ProductStatusActive, Stock: 10}
+	variant := schema.ProductVariant{VariantID: "01HW", ProductID: "01HV", SKU: "KAOS-POLOS-HITAM-M"}
+	firstOrder := schema.Promotion{
+		PromotionID:        "cae8651b",
+		PromotionName:      "Diskon Pelanggan Baru",
+		DiscountType:       schema.DiscountTypePercentage,
+		DiscountValue:      10,
+		PromotionStartDate: now.Add(-time.Hour),
+		PromotionEndDate:   now.Add(time.Hour),
+		Status:             schema.PromotionStatusActive,
+	}
+	firstOrder.Rules.FirstOrderOnly = true
+	segment := firstOrder
+	segment.PromotionID = "137ce1cf"
+	segment.PromotionName = "Diskon Member Gold"
+	segment.Rules = schema.PromotionRules{CustomerSegments: []string{"gold"}}
+
+	newService := func(cart schema.Cart, promo schema.Promotion) (*products.CartServiceImpl, *mocks.MockOrderRepository) {
+		mockCartRepo := new(mocks.MockCartRepository)
+		mockProductRepo := new(mocks.MockProductRepository)
+		mockVariantRepo := new(mocks.MockProductVariantRepository)
+		mockPromotionRepo := new(mocks.MockPromotionRepository)
+		mockCouponRepo := new(mocks.MockCouponRepository)
+		mockOrderRepo := new(mocks.MockOrderRepository)
+
+		mockCartRepo.On("GetActiveCartbyCustomerID", cart.CustomerID).Return(cart, true, nil)
+		mockCartRepo.On("GetActiveCartbyToken", cart.Token).Return(cart, true, nil)
+		mockProductRepo.On("GetProductbyProductID", "01HV").Return(product, nil)
+		mockVariantRepo.On("GetVariantbyVariantID", "01HV", "01HW").Return(variant, nil)
+		mockPromotionRepo.On("GetPromotionsbyStatus", []string{schema.PromotionStatusActive}).Return([]schema.Promotion{promo}, nil)
+		mockCouponRepo.On("GetCouponPromotionIDs", []string{promo.PromotionID}).Return([]string{}, nil)
+
+		return products.NewCartService(mockCartRepo, mockProductRepo, mockVariantRepo, mockPromotionRepo, mockCouponRepo, mockOrderRepo, cartSettings), mockOrderRepo
+	}
+	items := []schema.CartItem{{CartID: "01HZ", ProductID: "01HV", VariantID: "01HW", Quantity: 1}}
+	cart := schema.Cart{CartID: "01HZ", CustomerID: "budi", Status: schema.CartStatusActive, ExpiresAt: now.Add(time.Hour), Items: items}
+
+	t.Run("First Order Promotion applies to a Customer without Orders", func(t *testing.T) {
+		cartService, mockOrderRepo := newService(cart, firstOrder)
+		mockOrderRepo.On("CountOrdersbyCustomer", "budi").Return(int64(0), nil)
+
+		view, err := cartService.GetCart(products.CartOwner{CustomerID: "budi"})
+		assert.NoError(t, err)
+		assert.Len(t, view.Promotions, 1)
+		assert.Equal(t, "10000", view.Totals.ItemDiscount.String())
+	})
+
+	t.Run("First Order Promotion skips a returning Customer", func(t *testing.T) {
+		cartService, mockOrderRepo := newService(cart, firstOrder)
+		mockOrderRepo.On("CountOrdersbyCustomer", "budi").Return(int64(1), nil)
+
+		view, err := cartService.GetCart(products.CartOwner{CustomerID: "budi"})
+		assert.NoError(t, err)
+		assert.Empty(t, view.Promotions)
+		assert.True(t, view.Totals.ItemDiscount.IsZero())
+	})
+
+	t.Run("First Order Promotion skips a Guest", func(t *testing.T) {
+		guest := cart
+		guest.CustomerID = ""
+		guest.Token = "guest-token"
+		cartService, mockOrderRepo := newService(guest, firstOrder)
+
+		view, err := cartService.GetCart(products.CartOwner{Token: "guest-token"})
+		assert.NoError(t, err)
+		assert.Empty(t, view.Promotions)
+		mockOrderRepo.AssertNotCalled(t, "CountOrdersbyCustomer", mock.Anything)
+	})
+
+	t.Run("Segment Promotion applies to the Customer Segment", func(t *testing.T) {
+		cartService, _ := newService(cart, segment)
+
+		view, err := cartService.GetCart(products.CartOwner{CustomerID: "budi", Segment: "gold"})
+		assert.NoError(t, err)
+		assert.Len(t, view.Promotions, 1)
+		assert.Equal(t, "10000", view.Totals.ItemDiscount.String())
+	})
+
+	t.Run("Segment Promotion skips other Segments", func(t *testing.T) {
+		cartService, _ := newService(cart, segment)
+
+		for _, owner := range []products.CartOwner{{CustomerID: "budi", Segment: "silver"}, {CustomerID: "budi"}} {
+			view, err := cartService.GetCart(owner)
+			assert.NoError(t, err)
+			assert.Empty(t, view.Promotions)
+		}
+	})
+}
+
+func TestCartHandlers(t *testing.T) {
+	t.Run("Guest Cart Cookie is set", func(t *testing.T) {
+		mockCartRepo := new(mocks.MockCartRepository)
+		mockProductRepo := new(mocks.MockProductRepository)
+		mockVariantRepo := new(mocks.MockProductVariantRepository)
+		mockPromotionRepo := new(mocks.MockPromotionRepository)
+		cartService := products.NewCartService(mockCartRepo, mockProductRepo, mockVariantRepo, mockPromotionRepo, new(mocks.MockCouponRepository), new(mocks.MockOrderRepository), cartSettings)
+
+		cart := schema.Cart{CartID: "01HZ", Token: "guest-token", Status: schema.CartStatusActive, ExpiresAt: time.Now().Add(time.Hour)}
+		mockCartRepo.On("GetActiveCartbyToken", "guest-token").Return(cart, true, nil)
+		mockPromotionRepo.On("GetPromotionsbyStatus", []string{schema.PromotionStatusActive}).Return([]schema.Promotion{}, nil)
+
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/cart", nil)
+		req.AddCookie(&http.Cookie{Name: handlers.CartCookie, Value: "guest-token"})
+		rec := httptest.NewRecorder()
+
+		assert.NoError(t, handlers.PSQLGetCart(cartService)(e.NewContext(req, rec)))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Header().Get("Set-Cookie"), "cart_token=guest-token")
+		assert.Contains(t, rec.Header().Get("Set-Cookie"), "HttpOnly")
+		assert.NotContains(t, rec.Body.String(), "guest-token")
+	})
+
+	t.Run("Merge requires a Customer", func(t *testing.T) {
+		e := echo.New()
+		e.Validator = requests.NewValidator()
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(http.MethodPost, "/api/v1/cart/merge", strings.NewReader("")), rec)
+
+		err := handlers.PSQLMergeCarts(products.NewCartService(nil, nil, nil, nil, nil, nil, cartSettings))(c)
+		assert.Equal(t, http.StatusUnauthorized, err.(*echo.HTTPError).Code)
+	})
+}
@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"smkdevid/echocommercehub/internal/configs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "env.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := writeConfigFile(t, `
+DATABASE:
+  USER: promotion
+  PASS: secret
+  HOST: localhost
+  PORT: 5432
+  NAME: echocommercehub
+SERVER:
+  SHUTDOWN_TIMEOUT: 10s
+PAYMENT:
+  PROVIDER: fake
+SUPABASE:
+  URL: https://example.supabase.co
+  KEY: anon
+`)
+
+	t.Run("Config File with Defaults", func(t *testing.T) {
+		config, err := configs.Load(path)
+		assert.NoError(t, err)
+		assert.Equal(t, "localhost", config.Database.Host)
+		assert.Equal(t, 5432, config.Database.Port)
+		assert.Equal(t, ":8080", config.Server.Address)
+		assert.Equal(t, 10*time.Second, config.Server.ShutdownTimeout)
+		assert.Equal(t, "anon", config.Supabase.Key)
+		assert.Equal(t, "disable", config.Database.SSLMode)
+		assert.Equal(t, 30*time.Minute, config.Database.ConnMaxLifetime)
+		assert.Equal(t, time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC), config.Server.LegacySunset)
+	})
+
+	t.Run("Environment overrides the Config File", func(t *testing.T) {
+		t.Setenv("ECHOCOMMERCEHUB_DATABASE_HOST", "db.internal")
+		t.Setenv("ECHOCOMMERCEHUB_SERVER_ADDRESS", ":9090")
+		t.Setenv("ECHOCOMMERCEHUB_SERVER_LEGACY_SUNSET", "2027-10-31")
+
+		config, err := configs.Load(path)
+		assert.NoError(t, err)
+		assert.Equal(t, "db.internal", config.Database.Host)
+		assert.Equal(t, ":9090", config.Server.Address)
+		assert.Equal(t, time.Date(2027, time.October, 31, 0, 0, 0, 0, time.UTC), config.Server.LegacySunset)
+	})
+
+	t.Run("Every Problem is reported", func(t *testing.T) {
+		_, err := configs.Load(writeConfigFile(t, `
+DATABASE:
+  PORT: 99999
+SUPABASE:
+  URL: https://example.supabase.co
+`))
+
+		var validationErr *configs.ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+		assert.ElementsMatch(t, []string{
+			"DATABASE.USER is required",
+			"DATABASE.HOST is required",
+			"DATABASE.NAME is required",
+			"DATABASE.PORT must be between 1 and 65535",
+			"PAYMENT.PROVIDER is required",
+			"SUPABASE.KEY is required when SUPABASE.URL is set",
+		}, validationErr.Problems)
+	})
+
+	t.Run("Payment Simulation only with the fake Provider", func(t *testing.T) {
+		t.Setenv("ECHOCOMMERCEHUB_PAYMENT_PROVIDER", "midtrans")
+		t.Setenv("ECHOCOMMERCEHUB_PAYMENT_SERVER_KEY", "SB-Mid-server-key")
+		t.Setenv("ECHOCOMMERCEHUB_PAYMENT_DEV_SIMULATE", "true")
+
+		_, err := configs.Load(path)
+
+		var validationErr *configs.ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, []string{"PAYMENT.DEV_SIMULATE only works with the fake provider"}, validationErr.Problems)
+	})
+
+	t.Run("Invalid Pool and Connection Settings", func(t *testing.T) {
+		t.Setenv("ECHOCOMMERCEHUB_DATABASE_SSL_MODE", "sometimes")
+		t.Setenv("ECHOCOMMERCEHUB_DATABASE_TIMEZONE", "Mars/Olympus")
+		t.Setenv("ECHOCOMMERCEHUB_DATABASE_MAX_IDLE_CONNS", "50")
+
+		_, err := configs.Load(path)
+
+		var validationErr *configs.ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+		assert.Len(t, validationErr.Problems, 3)
+	})
+
+	t.Run("Missing Config File", func(t *testing.T) {
+		_, err := configs.Load(filepath.Join(t.TempDir(), "missing.yaml"))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,171 @@
+package tests
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	schema "smkdevid/echocommercehub/internal/models/schema"
+	"smkdevid/echocommercehub/internal/services/promotions"
+	"smkdevid/echocommercehub/tests/mocks"
+	"smkdevid/echocommercehub/utils/exception"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func couponPromotion() schema.Promotion {
+	return schema.Promotion{
+		PromotionID:        "cae8651b",
+		PromotionName:      "Ramadhan Sale",
+		DiscountType:       "percentage",
+		DiscountValue:      10.5,
+		PromotionStartDate: time.Now().Add(-time.Hour),
+		PromotionEndDate:   time.Now().Add(24 * time.Hour),
+		Rules:              schema.PromotionRules{MaxUsesPerCustomer: 1},
+	}
+}
+
+func TestGenerateCoupons(t *testing.T) {
+	t.Run("Successful Pattern based Generation", func(t *testing.T) {
+		mockCouponRepo := new(mocks.MockCouponRepository)
+		mockPromotionRepo := new(mocks.MockPromotionRepository)
+
+		couponService := promotions.NewCouponService(mockCouponRepo, mockPromotionRepo)
+
+		mockPromotionRepo.On("GetPromotionbyPromotionID", "cae8651b").Return(couponPromotion(), nil)
+		mockCouponRepo.On("GetExistingCouponCodes", mock.AnythingOfType("[]string")).Return([]string{}, nil)
+		mockCouponRepo.On("CreateCoupons", mock.AnythingOfType("[]schema.Coupon")).Return(
+			func(coupons []schema.Coupon) []schema.Coupon { return coupons }, nil)
+
+		coupons, err := couponService.GenerateCoupons("cae8651b", promotions.GenerateCouponRequest{
+			Quantity: 50,
+			Pattern:  "ramadhan-xxxx",
+		})
+		assert.NoError(t, err)
+		assert.Len(t, coupons, 50)
+
+		seen := make(map[string]bool)
+		for _, coupon := range coupons {
+			assert.Regexp(t, regexp.MustCompile(`^RAMADHAN-[A-HJ-NP-Z2-9]{4}$`), coupon.CouponCode)
+			assert.True(t, coupon.SingleUse())
+			assert.False(t, seen[coupon.CouponCode])
+			seen[coupon.CouponCode] = true
+		}
+		mockCouponRepo.AssertExpectations(t)
+	})
+
+	t.Run("Pattern without Placeholder", func(t *testing.T) {
+		couponService := promotions.NewCouponService(new(mocks.MockCouponRepository), new(mocks.MockPromotionRepository))
+
+		_, err := couponService.GenerateCoupons("cae8651b", promotions.GenerateCouponRequest{
+			Quantity: 1,
+			Pattern:  "XMAS",
+		})
+		assert.IsType(t, &exception.InvalidCouponRequestError{}, err)
+	})
+
+	t.Run("Pattern too small for the Quantity", func(t *testing.T) {
+		couponService := promotions.NewCouponService(new(mocks.MockCouponRepository), new(mocks.MockPromotionRepository))
+
+		_, err := couponService.GenerateCoupons("cae8651b", promotions.GenerateCouponRequest{
+			Quantity: 100,
+			Pattern:  "SALE-X",
+		})
+		assert.IsType(t, &exception.InvalidCouponRequestError{}, err)
+	})
+}
+
+func TestRedeemCoupon(t *testing.T) {
+	coupon := schema.Coupon{CouponCode: "RAMADHAN-AB2C", PromotionID: "cae8651b", MaxRedemptions: 1}
+
+	t.Run("Successful Redemption", func(t *testing.T) {
+		mockCouponRepo := new(mocks.MockCouponRepository)
+		mockPromotionRepo := new(mocks.MockPromotionRepository)
+
+		couponService := promotions.NewCouponService(mockCouponRepo, mockPromotionRepo)
+
+		expected := schema.CouponRedemption{
+			CouponCode:     "RAMADHAN-AB2C",
+			PromotionID:    "cae8651b",
+			CustomerID:     "cust-1",
+			OrderReference: "order-1",
+		}
+
+		mockCouponRepo.On("GetCouponbyCouponCode", "RAMADHAN-AB2C").Return(coupon, nil)
+		mockCouponRepo.On("FindRedemption", "RAMADHAN-AB2C", "order-1").Return(schema.CouponRedemption{}, false, nil)
+		mockPromotionRepo.On("GetPromotionbyPromotionID", "cae8651b").Return(couponPromotion(), nil)
+		mockCouponRepo.On("CountRedemptionsbyCustomer", "cae8651b", "cust-1").Return(uint(0), nil)
+		mockCouponRepo.On("RedeemCoupon", expected, uint(1)).Return(expected, nil)
+
+		redemption, err := couponService.RedeemCoupon("ramadhan-ab2c", promotions.RedeemCouponRequest{
+			CustomerID:     "cust-1",
+			OrderReference: "order-1",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, expected, redemption)
+		mockCouponRepo.AssertExpectations(t)
+	})
+
+	t.Run("Retry for the same Order returns the existing Redemption", func(t *testing.T) {
+		mockCouponRepo := new(mocks.MockCouponRepository)
+		mockPromotionRepo := new(mocks.MockPromotionRepository)
+
+		couponService := promotions.NewCouponService(mockCouponRepo, mockPromotionRepo)
+
+		existing := schema.CouponRedemption{ID: 7, CouponCode: "RAMADHAN-AB2C", OrderReference: "order-1"}
+
+		exhausted := coupon
+		exhausted.RedemptionCount = 1
+
+		mockCouponRepo.On("GetCouponbyCouponCode", "RAMADHAN-AB2C").Return(exhausted, nil)
+		mockCouponRepo.On("FindRedemption", "RAMADHAN-AB2C", "order-1").Return(existing, true, nil)
+
+		redemption, err := couponService.RedeemCoupon("RAMADHAN-AB2C", promotions.RedeemCouponRequest{
+			CustomerID:     "cust-1",
+			OrderReference: "order-1",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, existing, redemption)
+		mockCouponRepo.AssertNotCalled(t, "RedeemCoupon", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Customer already used the Promotion", func(t *testing.T) {
+		mockCouponRepo := new(mocks.MockCouponRepository)
+		mockPromotionRepo := new(mocks.MockPromotionRepository)
+
+		couponService := promotions.NewCouponService(mockCouponRepo, mockPromotionRepo)
+
+		mockCouponRepo.On("GetCouponbyCouponCode", "RAMADHAN-AB2C").Return(coupon, nil)
+		mockCouponRepo.On("FindRedemption", "RAMADHAN-AB2C", "order-2").Return(schema.CouponRedemption{}, false, nil)
+		mockPromotionRepo.On("GetPromotionbyPromotionID", "cae8651b").Return(couponPromotion(), nil)
+		mockCouponRepo.On("CountRedemptionsbyCustomer", "cae8651b", "cust-1").Return(uint(1), nil)
+
+		_, err := couponService.RedeemCoupon("RAMADHAN-AB2C", promotions.RedeemCouponRequest{
+			CustomerID:     "cust-1",
+			OrderReference: "order-2",
+		})
+		assert.IsType(t, &exception.CouponNotApplicableError{}, err)
+		mockCouponRepo.AssertNotCalled(t, "RedeemCoupon", mock.Anything, mock.Anything)
+	})
+}
+
+func TestValidateCoupon(t *testing.T) {
+	t.Run("Exhausted Coupon is invalid", func(t *testing.T) {
+		mockCouponRepo := new(mocks.MockCouponRepository)
+		mockPromotionRepo := new(mocks.MockPromotionRepository)
+
+		couponService := promotions.NewCouponService(mockCouponRepo, mockPromotionRepo)
+
+		coupon := schema.Coupon{CouponCode: "RAMADHAN-AB2C", PromotionID: "cae8651b", MaxRedemptions: 3, RedemptionCount: 3}
+
+		mockCouponRepo.On("GetCouponbyCouponCode", "RAMADHAN-AB2C").Return(coupon, nil)
+		mockPromotionRepo.On("GetPromotionbyPromotionID", "cae8651b").Return(couponPromotion(), nil)
+
+		validation, err := couponService.ValidateCoupon("RAMADHAN-AB2C", promotions.Cart{})
+		assert.NoError(t, err)
+		assert.False(t, validation.Valid)
+		assert.Equal(t, uint(0), validation.RemainingRedemptions)
+		assert.Equal(t, promotions.RuleCouponRedemptionLimit, validation.Reasons[0].Rule)
+	})
+}
@@ -0,0 +1,155 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	schema "smkdevid/echocommercehub/internal/models/schema"
+	"smkdevid/echocommercehub/internal/services/promotions"
+	"smkdevid/echocommercehub/tests/mocks"
+	"smkdevid/echocommercehub/utils/exception"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func discountCart() promotions.Cart {
+	return promotions.Cart{
+		Items: []promotions.CartItem{
+			{ProductID: "prod-1", CategoryID: "fashion", Quantity: 3, UnitPrice: 33333},
+			{ProductID: "prod-2", CategoryID: "fashion", Quantity: 1, UnitPrice: 100000},
+			{ProductID: "prod-3", CategoryID: "electronic", Quantity: 1, UnitPrice: 250000},
+		},
+		ShippingCost: 20000,
+	}
+}
+
+func TestCalculateDiscount(t *testing.T) {
+	t.Run("Percentage Discount is rounded to whole Rupiah", func(t *testing.T) {
+		promo := schema.Promotion{PromotionID: "cae8651b", DiscountType: "Percentage", DiscountValue: 10.5}
+
+		quote, err := promotions.CalculateDiscount(promo, discountCart())
+		assert.NoError(t, err)
+		assert.Equal(t, float64(449999), quote.Subtotal)
+		assert.Equal(t, float64(47250), quote.ItemDiscount)
+		assert.Equal(t, []float64{10500, 10500, 26250}, lineDiscounts(quote))
+		assert.Equal(t, float64(449999+20000-47250), quote.GrandTotal)
+	})
+
+	t.Run("Fixed Discount only covers eligible Lines", func(t *testing.T) {
+		promo := schema.Promotion{
+			PromotionID:   "137ce1cf",
+			DiscountType:  "fixed",
+			DiscountValue: 50000,
+			Rules:         schema.PromotionRules{CategoryIDs: []string{"fashion"}},
+		}
+
+		quote, err := promotions.CalculateDiscount(promo, discountCart())
+		assert.NoError(t, err)
+		assert.Equal(t, float64(50000), quote.TotalDiscount)
+		assert.Equal(t, []float64{25000, 25000, 0}, lineDiscounts(quote))
+		assert.False(t, quote.Lines[2].Eligible)
+	})
+
+	t.Run("Buy X Get Y frees the cheapest Units", func(t *testing.T) {
+		promo := schema.Promotion{PromotionID: "dc6ef7bf", DiscountType: "buy_x_get_y", BuyQuantity: 2, GetQuantity: 1}
+
+		quote, err := promotions.CalculateDiscount(promo, discountCart())
+		assert.NoError(t, err)
+		assert.Equal(t, []float64{33333, 0, 0}, lineDiscounts(quote))
+	})
+
+	t.Run("Buy X Get Y counts large Quantities without expanding Units", func(t *testing.T) {
+		promo := schema.Promotion{PromotionID: "5b0e1f2a", DiscountType: "buy_x_get_y", BuyQuantity: 1, GetQuantity: 1}
+		cart := promotions.Cart{Items: []promotions.CartItem{
+			{ProductID: "prod-1", Quantity: 4000000000, UnitPrice: 1},
+			{ProductID: "prod-2", Quantity: 1, UnitPrice: 2},
+		}}
+
+		quote, err := promotions.CalculateDiscount(promo, cart)
+		assert.NoError(t, err)
+		assert.Equal(t, []float64{2000000000, 0}, lineDiscounts(quote))
+	})
+
+	t.Run("Tiered Discount uses the highest reached Tier", func(t *testing.T) {
+		promo := schema.Promotion{
+			PromotionID:  "e1d63455",
+			DiscountType: "tiered",
+			DiscountTiers: []schema.DiscountTier{
+				{MinSpend: 100000, Type: "fixed", Value: 10000},
+				{MinSpend: 400000, Type: "percentage", Value: 20},
+				{MinSpend: 1000000, Type: "percentage", Value: 50},
+			},
+		}
+
+		quote, err := promotions.CalculateDiscount(promo, discountCart())
+		assert.NoError(t, err)
+		assert.Equal(t, float64(90000), quote.ItemDiscount)
+	})
+
+	t.Run("Free Shipping with Cap", func(t *testing.T) {
+		promo := schema.Promotion{PromotionID: "c373b046", DiscountType: "free_shipping", MaxDiscountAmount: 15000}
+
+		quote, err := promotions.CalculateDiscount(promo, discountCart())
+		assert.NoError(t, err)
+		assert.True(t, quote.Capped)
+		assert.Equal(t, float64(15000), quote.ShippingDiscount)
+		assert.Equal(t, float64(0), quote.ItemDiscount)
+	})
+
+	t.Run("Cap keeps Line Discounts consistent", func(t *testing.T) {
+		promo := schema.Promotion{PromotionID: "1c5b25c1", DiscountType: "percentage", DiscountValue: 50, MaxDiscountAmount: 100001}
+
+		quote, err := promotions.CalculateDiscount(promo, discountCart())
+		assert.NoError(t, err)
+		assert.True(t, quote.Capped)
+		assert.Equal(t, float64(100001), quote.TotalDiscount)
+
+		var total float64
+		for _, discount := range lineDiscounts(quote) {
+			total += discount
+		}
+		assert.Equal(t, quote.TotalDiscount, total)
+	})
+
+	t.Run("Unsupported Discount Type", func(t *testing.T) {
+		promo := schema.Promotion{PromotionID: "7cb19c9d", DiscountType: "cashback"}
+
+		_, err := promotions.CalculateDiscount(promo, discountCart())
+		assert.IsType(t, &exception.InvalidDiscountError{}, err)
+	})
+}
+
+func TestQuoteDiscount(t *testing.T) {
+	t.Run("Ineligible Cart gets no Discount", func(t *testing.T) {
+		mockPromotionRepo := new(mocks.MockPromotionRepository)
+
+		userService := promotions.NewPromotionService(mockPromotionRepo)
+
+		promo := schema.Promotion{
+			PromotionID:        "cae8651b",
+			PromotionName:      "Ramadhan Sale",
+			DiscountType:       "percentage",
+			DiscountValue:      10.5,
+			PromotionStartDate: time.Now().Add(-time.Hour),
+			PromotionEndDate:   time.Now().Add(24 * time.Hour),
+			Rules:              schema.PromotionRules{MinSubtotal: 1000000},
+		}
+
+		mockPromotionRepo.On("GetPromotionbyPromotionID", "cae8651b").Return(promo, nil)
+
+		quote, err := userService.QuoteDiscount("cae8651b", discountCart())
+		assert.NoError(t, err)
+		assert.False(t, quote.Eligibility.Eligible)
+		assert.Equal(t, float64(0), quote.TotalDiscount)
+		assert.Equal(t, float64(449999+20000), quote.GrandTotal)
+		mockPromotionRepo.AssertExpectations(t)
+	})
+}
+
+func lineDiscounts(quote promotions.DiscountQuote) []float64 {
+	discounts := make([]float64, 0, len(quote.Lines))
+	for _, line := range quote.Lines {
+		discounts = append(discounts, line.Discount)
+	}
+	return discounts
+}
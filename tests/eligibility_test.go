@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	schema "smkdevid/echocommercehub/internal/models/schema"
+	"smkdevid/echocommercehub/internal/services/promotions"
+	"smkdevid/echocommercehub/tests/mocks"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluateEligibility(t *testing.T) {
+	now := time.Now()
+
+	basePromo := schema.Promotion{
+		PromotionID:        "cae8651b",
+		PromotionName:      "Ramadhan Sale",
+		DiscountType:       "percentage",
+		DiscountValue:      10.5,
+		PromotionStartDate: now.Add(-time.Hour),
+		PromotionEndDate:   now.Add(24 * time.Hour),
+	}
+
+	cart := promotions.Cart{
+		CustomerID:      "cust-1",
+		CustomerSegment: "gold",
+		Items: []promotions.CartItem{
+			{ProductID: "prod-1", CategoryID: "fashion", Quantity: 2, UnitPrice: 50000},
+			{ProductID: "prod-2", CategoryID: "electronic", Quantity: 1, UnitPrice: 150000},
+		},
+	}
+
+	t.Run("Promotion without Rules applies to every Cart", func(t *testing.T) {
+		result := promotions.EvaluateEligibility(basePromo, cart, now)
+		assert.True(t, result.Eligible)
+		assert.Empty(t, result.Reasons)
+	})
+
+	t.Run("Cart matching every Rule is eligible", func(t *testing.T) {
+		promo := basePromo
+		promo.Rules = schema.PromotionRules{
+			MinSubtotal:        200000,
+			CategoryIDs:        []string{"fashion"},
+			CustomerSegments:   []string{"gold", "platinum"},
+			MaxUsesPerCustomer: 2,
+		}
+
+		result := promotions.EvaluateEligibility(promo, cart, now)
+		assert.True(t, result.Eligible)
+	})
+
+	t.Run("Every failing Rule is reported", func(t *testing.T) {
+		promo := basePromo
+		promo.Rules = schema.PromotionRules{
+			MinSubtotal:        500000,
+			ProductIDs:         []string{"prod-9"},
+			FirstOrderOnly:     true,
+			CustomerSegments:   []string{"platinum"},
+			MaxUsesPerCustomer: 1,
+		}
+
+		usedCart := cart
+		usedCart.PromotionUsage = map[string]uint{"cae8651b": 1}
+
+		result := promotions.EvaluateEligibility(promo, usedCart, now.Add(48*time.Hour))
+		assert.False(t, result.Eligible)
+
+		var rules []string
+		for _, reason := range result.Reasons {
+			rules = append(rules, reason.Rule)
+		}
+		assert.Equal(t, []string{
+			promotions.RulePromotionPeriod,
+			promotions.RuleMinSubtotal,
+			promotions.RuleEligibleItems,
+			promotions.RuleFirstOrderOnly,
+			promotions.RuleCustomerSegment,
+			promotions.RuleMaxUsesPerCustomer,
+		}, rules)
+	})
+}
+
+func TestCheckEligibility(t *testing.T) {
+	t.Run("Successful Eligibility Check", func(t *testing.T) {
+		mockPromotionRepo := new(mocks.MockPromotionRepository)
+
+		userService := promotions.NewPromotionService(mockPromotionRepo)
+
+		promo := schema.Promotion{
+			PromotionID:        "cae8651b",
+			PromotionName:      "Ramadhan Sale",
+			DiscountType:       "percentage",
+			DiscountValue:      10.5,
+			PromotionStartDate: time.Now().Add(-time.Hour),
+			PromotionEndDate:   time.Now().Add(24 * time.Hour),
+			Rules:              schema.PromotionRules{FirstOrderOnly: true},
+		}
+
+		mockPromotionRepo.On("GetPromotionbyPromotionID", "cae8651b").Return(promo, nil)
+
+		result, err := userService.CheckEligibility("cae8651b", promotions.Cart{FirstOrder: false})
+		assert.NoError(t, err)
+		assert.False(t, result.Eligible)
+		assert.Equal(t, promotions.RuleFirstOrderOnly, result.Reasons[0].Rule)
+		mockPromotionRepo.AssertExpectations(t)
+	})
+
+	t.Run("Promotion not found", func(t *testing.T) {
+		mockPromotionRepo := new(mocks.MockPromotionRepository)
+
+		userService := promotions.NewPromotionService(mockPromotionRepo)
+
+		expectedErr := errors.New("Promotion not Found")
+		mockPromotionRepo.On("GetPromotionbyPromotionID", "cb7360g6").Return(schema.Promotion{}, expectedErr)
+
+		result, err := userService.CheckEligibility("cb7360g6", promotions.Cart{})
+		assert.Error(t, err)
+		assert.Equal(t, expectedErr, err)
+		assert.False(t, result.Eligible)
+		mockPromotionRepo.AssertExpectations(t)
+	})
+}
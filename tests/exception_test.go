@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"smkdevid/echocommercehub/utils/exception"
+	"smkdevid/echocommercehub/utils/responses"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDomainErrors(t *testing.T) {
+	t.Run("Errors match their Kind when wrapped", func(t *testing.T) {
+		cases := []struct {
+			err    error
+			kind   error
+			code   string
+			status int
+		}{
+			{&exception.PromotionIDNotFoundError{Message: "Promotion Not Found", PromotionID: "cae8651b"}, exception.ErrNotFound, "promotion_not_found", http.StatusNotFound},
+			{&exception.PromotionIDConflictError{Message: "Promotion Already Exists"}, exception.ErrConflict, "promotion_already_exists", http.StatusConflict},
+			{&exception.PromotionVersionConflictError{Message: "Promotion Was Modified"}, exception.ErrPreconditionFailed, "promotion_version_mismatch", http.StatusPreconditionFailed},
+			{&exception.ValidationError{Message: "Validation Failed"}, exception.ErrValidation, "validation_failed", http.StatusUnprocessableEntity},
+			{&exception.InvalidCouponRequestError{Message: "customer_id is required"}, exception.ErrInvalidRequest, "invalid_coupon_request", http.StatusBadRequest},
+			{&exception.ForbiddenError{Message: "Not Allowed"}, exception.ErrForbidden, "forbidden", http.StatusForbidden},
+			{&exception.RateLimitedError{Message: "Too Many Requests"}, exception.ErrRateLimited, "rate_limited", http.StatusTooManyRequests},
+			{&exception.UpstreamError{Message: "Timeout", Service: "payment gateway"}, exception.ErrUpstream, "upstream_error", http.StatusBadGateway},
+			{&exception.MediaTooLargeError{Message: "Media Too Large", Limit: 1024}, exception.ErrPayloadTooLarge, "media_too_large", http.StatusRequestEntityTooLarge},
+			{&exception.UnsupportedMediaTypeError{Message: "Unsupported Media Type", ContentType: "text/plain"}, exception.ErrUnsupportedMedia, "unsupported_media_type", http.StatusUnsupportedMediaType},
+		}
+
+		for _, tc := range cases {
+			wrapped := fmt.Errorf("promotion service: %w", tc.err)
+			assert.ErrorIs(t, wrapped, tc.kind)
+			assert.Equal(t, tc.code, exception.CodeOf(wrapped))
+			assert.Equal(t, tc.status, responses.StatusOf(wrapped))
+		}
+	})
+
+	t.Run("Upstream Error unwraps its Cause", func(t *testing.T) {
+		cause := errors.New("dial tcp: i/o timeout")
+		err := &exception.UpstreamError{Message: "Timeout", Service: "payment gateway", Err: cause}
+		assert.ErrorIs(t, err, cause)
+	})
+
+	t.Run("Handler maps Domain Errors", func(t *testing.T) {
+		e := echo.New()
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(http.MethodPost, "/api/v1/coupons/RAMADHAN/redeem", nil), rec)
+
+		responses.HTTPErrorHandler(fmt.Errorf("redeem: %w", &exception.RateLimitedError{
+			Message:    "Too Many Requests",
+			RetryAfter: 1500 * time.Millisecond,
+		}), c)
+
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+		assert.Contains(t, rec.Body.String(), `"code":"rate_limited"`)
+	})
+}
@@ -0,0 +1,90 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"smkdevid/echocommercehub/internal/app/handlers"
+	"smkdevid/echocommercehub/internal/configs"
+	"smkdevid/echocommercehub/internal/transports/delivery"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthRoutes(t *testing.T) {
+	databaseUp := true
+
+	e := echo.New()
+	delivery.HealthRoute(e, map[string]handlers.HealthCheck{
+		"database": func(ctx context.Context) error {
+			if !databaseUp {
+				return errors.New("connection refused")
+			}
+			return nil
+		},
+	}, time.Second)
+
+	probe := func(path string) (int, handlers.HealthReport) {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+		var body struct {
+			Data handlers.HealthReport `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		return rec.Code, body.Data
+	}
+
+	t.Run("Ready when every Dependency is up", func(t *testing.T) {
+		code, report := probe(delivery.ReadinessPath)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, handlers.HealthStatusOK, report.Checks["database"].Status)
+	})
+
+	t.Run("Not Ready while the Database is down, still Alive", func(t *testing.T) {
+		databaseUp = false
+		defer func() { databaseUp = true }()
+
+		code, report := probe(delivery.ReadinessPath)
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, handlers.HealthStatusFail, report.Status)
+		assert.Equal(t, "connection refused", report.Checks["database"].Error)
+
+		code, report = probe(delivery.LivenessPath)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, handlers.HealthStatusOK, report.Status)
+	})
+}
+
+func TestInitDatabaseRetries(t *testing.T) {
+	config := configs.DatabaseConfig{
+		User:              "promotion",
+		Host:              "127.0.0.1",
+		Port:              1,
+		Name:              "echocommercehub",
+		SSLMode:           "disable",
+		TimeZone:          "Asia/Jakarta",
+		ConnectRetries:    2,
+		ConnectBackoff:    10 * time.Millisecond,
+		ConnectMaxBackoff: 20 * time.Millisecond,
+	}
+
+	t.Run("Gives up after the last Retry", func(t *testing.T) {
+		_, err := configs.InitDatabase(context.Background(), config)
+		assert.ErrorContains(t, err, "after 3 attempts")
+	})
+
+	t.Run("Stops when the Context is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := configs.InitDatabase(ctx, config)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
@@ -0,0 +1,176 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"smkdevid/echocommercehub/internal/app/middlewares"
+	schema "smkdevid/echocommercehub/internal/models/schema"
+	"smkdevid/echocommercehub/tests/mocks"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const createPromotionBody = `{"promotion_name":"Ramadhan Sale"}`
+
+func idempotentRequest(e *echo.Echo, handler echo.HandlerFunc, key string, body string) (*httptest.ResponseRecorder, error) {
+	req := httptest.NewRequest(http.MethodPost, "/createpromotion", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	if key != "" {
+		req.Header.Set(middlewares.HeaderIdempotencyKey, key)
+	}
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	return rec, handler(c)
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	e := echo.New()
+	ttl := 24 * time.Hour
+
+	created := func(c echo.Context) error {
+		return c.JSON(http.StatusCreated, map[string]string{"promotion_id": "01HV6Z3KJ8Q9W4T2N5M7R0B1CX"})
+	}
+
+	t.Run("First Request is processed and stored", func(t *testing.T) {
+		mockIdempotencyRepo := new(mocks.MockIdempotencyRepository)
+		handler := middlewares.Idempotency(mockIdempotencyRepo, ttl)(created)
+
+		mockIdempotencyRepo.On("GetIdempotencyKey", "key-1").Return(schema.IdempotencyKey{}, false, nil)
+		mockIdempotencyRepo.On("ReserveIdempotencyKey", mock.AnythingOfType("schema.IdempotencyKey"), ttl).Return(true, nil)
+		mockIdempotencyRepo.On("CompleteIdempotencyKey", mock.MatchedBy(func(r schema.IdempotencyKey) bool {
+			return r.Key == "key-1" && r.StatusCode == http.StatusCreated && strings.Contains(string(r.ResponseBody), "01HV6Z3KJ8Q9W4T2N5M7R0B1CX")
+		})).Return(nil)
+
+		rec, err := idempotentRequest(e, handler, "key-1", createPromotionBody)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		mockIdempotencyRepo.AssertExpectations(t)
+	})
+
+	t.Run("Retried Request is replayed", func(t *testing.T) {
+		mockIdempotencyRepo := new(mocks.MockIdempotencyRepository)
+		handler := middlewares.Idempotency(mockIdempotencyRepo, ttl)(func(c echo.Context) error {
+			t.Fatal("handler must not run for a replayed request")
+			return nil
+		})
+
+		// Record the hash of the first request, then serve the retry from it
+		var stored schema.IdempotencyKey
+		first := new(mocks.MockIdempotencyRepository)
+		first.On("GetIdempotencyKey", "key-1").Return(schema.IdempotencyKey{}, false, nil)
+		first.On("ReserveIdempotencyKey", mock.AnythingOfType("schema.IdempotencyKey"), ttl).Return(true, nil)
+		first.On("CompleteIdempotencyKey", mock.AnythingOfType("schema.IdempotencyKey")).
+			Run(func(args mock.Arguments) { stored = args.Get(0).(schema.IdempotencyKey) }).Return(nil)
+		_, err := idempotentRequest(e, middlewares.Idempotency(first, ttl)(created), "key-1", createPromotionBody)
+		assert.NoError(t, err)
+
+		stored.Completed = true
+		stored.CreatedAt = time.Now()
+		mockIdempotencyRepo.On("GetIdempotencyKey", "key-1").Return(stored, true, nil)
+
+		rec, err := idempotentRequest(e, handler, "key-1", createPromotionBody)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, "true", rec.Header().Get(middlewares.HeaderIdempotentReplayed))
+		assert.Contains(t, rec.Body.String(), "01HV6Z3KJ8Q9W4T2N5M7R0B1CX")
+		mockIdempotencyRepo.AssertNotCalled(t, "ReserveIdempotencyKey", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Key reused with a different Payload", func(t *testing.T) {
+		mockIdempotencyRepo := new(mocks.MockIdempotencyRepository)
+		handler := middlewares.Idempotency(mockIdempotencyRepo, ttl)(created)
+
+		mockIdempotencyRepo.On("GetIdempotencyKey", "key-1").Return(schema.IdempotencyKey{
+			Key:         "key-1",
+			RequestHash: "another-request",
+			Completed:   true,
+			CreatedAt:   time.Now(),
+		}, true, nil)
+
+		_, err := idempotentRequest(e, handler, "key-1", createPromotionBody)
+		he, ok := err.(*echo.HTTPError)
+		assert.True(t, ok)
+		assert.Equal(t, http.StatusUnprocessableEntity, he.Code)
+	})
+
+	t.Run("Failed Request releases the Key", func(t *testing.T) {
+		mockIdempotencyRepo := new(mocks.MockIdempotencyRepository)
+		handler := middlewares.Idempotency(mockIdempotencyRepo, ttl)(func(c echo.Context) error {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create promotion")
+		})
+
+		mockIdempotencyRepo.On("GetIdempotencyKey", "key-1").Return(schema.IdempotencyKey{}, false, nil)
+		mockIdempotencyRepo.On("ReserveIdempotencyKey", mock.AnythingOfType("schema.IdempotencyKey"), ttl).Return(true, nil)
+		mockIdempotencyRepo.On("DeleteIdempotencyKey", "key-1").Return(nil)
+
+		_, err := idempotentRequest(e, handler, "key-1", createPromotionBody)
+		assert.Error(t, err)
+		mockIdempotencyRepo.AssertExpectations(t)
+		mockIdempotencyRepo.AssertNotCalled(t, "CompleteIdempotencyKey", mock.Anything)
+	})
+
+	t.Run("Keys belong to the Customer sending them", func(t *testing.T) {
+		mockIdempotencyRepo := new(mocks.MockIdempotencyRepository)
+		handler := middlewares.CustomerSession("", true)(middlewares.Idempotency(mockIdempotencyRepo, ttl)(created))
+
+		// budi stored a response under key-1, siti sending the same key and body gets her own
+		mockIdempotencyRepo.On("GetIdempotencyKey", "customer:siti:key-1").Return(schema.IdempotencyKey{}, false, nil)
+		mockIdempotencyRepo.On("ReserveIdempotencyKey", mock.MatchedBy(func(r schema.IdempotencyKey) bool {
+			return r.Key == "customer:siti:key-1"
+		}), ttl).Return(true, nil)
+		mockIdempotencyRepo.On("CompleteIdempotencyKey", mock.MatchedBy(func(r schema.IdempotencyKey) bool {
+			return r.Key == "customer:siti:key-1"
+		})).Return(nil)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/orders", strings.NewReader(createPromotionBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(middlewares.HeaderIdempotencyKey, "key-1")
+		req.Header.Set(middlewares.HeaderCustomer, "siti")
+		rec := httptest.NewRecorder()
+		assert.NoError(t, handler(e.NewContext(req, rec)))
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Empty(t, rec.Header().Get(middlewares.HeaderIdempotentReplayed))
+		mockIdempotencyRepo.AssertNotCalled(t, "GetIdempotencyKey", "key-1")
+		mockIdempotencyRepo.AssertExpectations(t)
+	})
+
+	t.Run("Key reused with another Query String", func(t *testing.T) {
+		var stored schema.IdempotencyKey
+		first := new(mocks.MockIdempotencyRepository)
+		first.On("GetIdempotencyKey", "key-1").Return(schema.IdempotencyKey{}, false, nil)
+		first.On("ReserveIdempotencyKey", mock.AnythingOfType("schema.IdempotencyKey"), ttl).Return(true, nil)
+		first.On("CompleteIdempotencyKey", mock.AnythingOfType("schema.IdempotencyKey")).
+			Run(func(args mock.Arguments) { stored = args.Get(0).(schema.IdempotencyKey) }).Return(nil)
+		_, err := idempotentRequest(e, middlewares.Idempotency(first, ttl)(created), "key-1", createPromotionBody)
+		assert.NoError(t, err)
+
+		stored.Completed = true
+		stored.CreatedAt = time.Now()
+		mockIdempotencyRepo := new(mocks.MockIdempotencyRepository)
+		mockIdempotencyRepo.On("GetIdempotencyKey", "key-1").Return(stored, true, nil)
+
+		req := httptest.NewRequest(http.MethodPost, "/createpromotion?dry_run=true", strings.NewReader(createPromotionBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(middlewares.HeaderIdempotencyKey, "key-1")
+		err = middlewares.Idempotency(mockIdempotencyRepo, ttl)(created)(e.NewContext(req, httptest.NewRecorder()))
+		he, ok := err.(*echo.HTTPError)
+		assert.True(t, ok)
+		assert.Equal(t, http.StatusUnprocessableEntity, he.Code)
+	})
+
+	t.Run("Request without Key", func(t *testing.T) {
+		mockIdempotencyRepo := new(mocks.MockIdempotencyRepository)
+		handler := middlewares.Idempotency(mockIdempotencyRepo, ttl)(created)
+
+		rec, err := idempotentRequest(e, handler, "", createPromotionBody)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		mockIdempotencyRepo.AssertNotCalled(t, "GetIdempotencyKey", mock.Anything)
+	})
+}
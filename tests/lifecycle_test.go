@@ -0,0 +1,135 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	schema "smkdevid/echocommercehub/internal/models/schema"
+	"smkdevid/echocommercehub/internal/services/promotions"
+	"smkdevid/echocommercehub/tests/mocks"
+	"smkdevid/echocommercehub/utils/exception"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingPublisher struct {
+	events []promotions.PromotionEvent
+}
+
+func (p *recordingPublisher) Publish(event promotions.PromotionEvent) {
+	p.events = append(p.events, event)
+}
+
+func TestLifecycleStatus(t *testing.T) {
+	now := time.Now()
+
+	promo := schema.Promotion{
+		PromotionID:        "cae8651b",
+		PromotionStartDate: now.Add(time.Hour),
+		PromotionEndDate:   now.Add(24 * time.Hour),
+		Status:             schema.PromotionStatusScheduled,
+	}
+
+	t.Run("Status follows the Promotion Dates", func(t *testing.T) {
+		assert.Equal(t, schema.PromotionStatusScheduled, promotions.LifecycleStatus(promo, now))
+		assert.Equal(t, schema.PromotionStatusActive, promotions.LifecycleStatus(promo, now.Add(2*time.Hour)))
+		assert.Equal(t, schema.PromotionStatusExpired, promotions.LifecycleStatus(promo, now.Add(48*time.Hour)))
+	})
+
+	t.Run("Manual Status is kept", func(t *testing.T) {
+		draft := promo
+		draft.Status = schema.PromotionStatusDraft
+		assert.Equal(t, schema.PromotionStatusDraft, promotions.LifecycleStatus(draft, now.Add(2*time.Hour)))
+
+		paused := promo
+		paused.Status = schema.PromotionStatusPaused
+		assert.Equal(t, schema.PromotionStatusPaused, promotions.LifecycleStatus(paused, now.Add(2*time.Hour)))
+		assert.Equal(t, schema.PromotionStatusExpired, promotions.LifecycleStatus(paused, now.Add(48*time.Hour)))
+	})
+
+	t.Run("Filter by Status", func(t *testing.T) {
+		expired := promo
+		expired.PromotionID = "137ce1cf"
+		expired.PromotionStartDate = now.Add(-2 * time.Hour)
+		expired.PromotionEndDate = now.Add(-time.Hour)
+
+		filtered := promotions.FilterPromotionsbyStatus([]schema.Promotion{promo, expired}, schema.PromotionStatusExpired, now)
+		assert.Len(t, filtered, 1)
+		assert.Equal(t, "137ce1cf", filtered[0].PromotionID)
+	})
+}
+
+func TestPromotionScheduler(t *testing.T) {
+	t.Run("Promotions go live and expire at their Boundaries", func(t *testing.T) {
+		mockPromotionRepo := new(mocks.MockPromotionRepository)
+		publisher := &recordingPublisher{}
+
+		scheduler := promotions.NewPromotionScheduler(mockPromotionRepo, publisher, time.Minute)
+
+		now := time.Now()
+		promos := []schema.Promotion{
+			{PromotionID: "cae8651b", Status: schema.PromotionStatusScheduled, PromotionStartDate: now.Add(-time.Minute), PromotionEndDate: now.Add(time.Hour)},
+			{PromotionID: "137ce1cf", Status: schema.PromotionStatusActive, PromotionStartDate: now.Add(-time.Hour), PromotionEndDate: now.Add(-time.Minute)},
+			{PromotionID: "dc6ef7bf", Status: schema.PromotionStatusActive, PromotionStartDate: now.Add(-time.Hour), PromotionEndDate: now.Add(time.Hour)},
+		}
+
+		mockPromotionRepo.On("GetPromotionsbyStatus", []string{"scheduled", "active", "paused"}).Return(promos, nil)
+		mockPromotionRepo.On("UpdatePromotionStatus", "cae8651b", "scheduled", "active", promotions.SchedulerActor).Return(true, nil)
+		mockPromotionRepo.On("UpdatePromotionStatus", "137ce1cf", "active", "expired", promotions.SchedulerActor).Return(true, nil)
+
+		err := scheduler.Tick(now)
+		assert.NoError(t, err)
+		assert.Len(t, publisher.events, 2)
+		assert.Equal(t, promotions.EventPromotionLive, publisher.events[0].Type)
+		assert.Equal(t, promotions.EventPromotionExpired, publisher.events[1].Type)
+		mockPromotionRepo.AssertExpectations(t)
+	})
+}
+
+func TestPausePromotion(t *testing.T) {
+	promo := schema.Promotion{
+		PromotionID:        "cae8651b",
+		PromotionName:      "Ramadhan Sale",
+		PromotionStartDate: time.Now().Add(-time.Hour),
+		PromotionEndDate:   time.Now().Add(24 * time.Hour),
+		Status:             schema.PromotionStatusActive,
+	}
+
+	t.Run("Successful Pause and Resume", func(t *testing.T) {
+		mockPromotionRepo := new(mocks.MockPromotionRepository)
+
+		userService := promotions.NewPromotionService(mockPromotionRepo)
+
+		paused := promo
+		paused.Status = schema.PromotionStatusPaused
+
+		mockPromotionRepo.On("GetPromotionbyPromotionID", "cae8651b").Return(promo, nil).Once()
+		mockPromotionRepo.On("UpdatePromotionStatus", "cae8651b", "active", "paused", "admin-1").Return(true, nil)
+		mockPromotionRepo.On("GetPromotionbyPromotionID", "cae8651b").Return(paused, nil).Once()
+		mockPromotionRepo.On("UpdatePromotionStatus", "cae8651b", "paused", "active", "admin-1").Return(true, nil)
+
+		results, err := userService.PausePromotion("cae8651b", "admin-1")
+		assert.NoError(t, err)
+		assert.Equal(t, schema.PromotionStatusPaused, results.Status)
+
+		results, err = userService.ResumePromotion("cae8651b", "admin-1")
+		assert.NoError(t, err)
+		assert.Equal(t, schema.PromotionStatusActive, results.Status)
+		mockPromotionRepo.AssertExpectations(t)
+	})
+
+	t.Run("Expired Promotion cannot be paused", func(t *testing.T) {
+		mockPromotionRepo := new(mocks.MockPromotionRepository)
+
+		userService := promotions.NewPromotionService(mockPromotionRepo)
+
+		expired := promo
+		expired.PromotionEndDate = time.Now().Add(-time.Minute)
+
+		mockPromotionRepo.On("GetPromotionbyPromotionID", "cae8651b").Return(expired, nil)
+
+		_, err := userService.PausePromotion("cae8651b", "admin-1")
+		assert.IsType(t, &exception.InvalidStatusTransitionError{}, err)
+		mockPromotionRepo.AssertNotCalled(t, "UpdatePromotionStatus", "cae8651b", "active", "paused", "admin-1")
+	})
+}
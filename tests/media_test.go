@@ -0,0 +1,205 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	schema "smkdevid/echocommercehub/internal/models/schema"
+	"smkdevid/echocommercehub/internal/services/products"
+	"smkdevid/echocommercehub/pkg/storage"
+	"smkdevid/echocommercehub/tests/mocks"
+	"smkdevid/echocommercehub/utils/exception"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func pngImage(t *testing.T, width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		img.Set(x, 0, color.RGBA{R: 200, A: 255})
+	}
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+// oversizedPNG is a tiny PNG whose header declares width x height pixels
+func oversizedPNG(t *testing.T, width, height uint32) []byte {
+	data := pngImage(t, 1, 1)
+	// The IHDR chunk follows the 8 byte signature: length, type, width, height, ... and its CRC
+	binary.BigEndian.PutUint32(data[16:20], width)
+	binary.BigEndian.PutUint32(data[20:24], height)
+	binary.BigEndian.PutUint32(data[29:33], crc32.ChecksumIEEE(data[12:29]))
+	return data
+}
+
+func TestLocalStorage(t *testing.T) {
+	store, err := storage.NewLocalStorage(t.TempDir(), "/media/")
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	t.Run("Put, Open and Delete", func(t *testing.T) {
+		assert.NoError(t, store.Put(ctx, "products/01HV/original.png", strings.NewReader("png"), "image/png"))
+
+		file, err := store.Open(ctx, "products/01HV/original.png")
+		assert.NoError(t, err)
+		content, _ := io.ReadAll(file)
+		file.Close()
+		assert.Equal(t, "png", string(content))
+		assert.Equal(t, "/media/products/01HV/original.png", store.URL("products/01HV/original.png"))
+
+		assert.NoError(t, store.Delete(ctx, "products/01HV/original.png"))
+		assert.NoError(t, store.Delete(ctx, "products/01HV/original.png"))
+		_, err = store.Open(ctx, "products/01HV/original.png")
+		assert.ErrorIs(t, err, storage.ErrObjectNotFound)
+	})
+
+	t.Run("Keys cannot escape the Root", func(t *testing.T) {
+		for _, key := range []string{"", "../secret", "products/../../secret", "/etc/passwd"} {
+			assert.Error(t, store.Put(ctx, key, strings.NewReader("x"), "text/plain"), key)
+		}
+	})
+}
+
+func TestThumbnail(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 2000, 1000))
+
+	thumbnail := products.Thumbnail(img, 480)
+	assert.Equal(t, 480, thumbnail.Bounds().Dx())
+	assert.Equal(t, 240, thumbnail.Bounds().Dy())
+
+	small := image.NewRGBA(image.Rect(0, 0, 100, 50))
+	assert.Equal(t, small.Bounds(), products.Thumbnail(small, 480).Bounds())
+}
+
+func TestUploadMedia(t *testing.T) {
+	product := schema.Product{ProductID: "01HV", ProductName: "Kaos Polos"}
+	limits := products.MediaLimits{MaxImageSize: 1 << 20, MaxVideoSize: 1 << 20}
+
+	t.Run("Image is stored with its Thumbnails after the existing Media", func(t *testing.T) {
+		root := t.TempDir()
+		store, _ := storage.NewLocalStorage(root, "/media")
+		mockProductRepo := new(mocks.MockProductRepository)
+		mockMediaRepo := new(mocks.MockProductMediaRepository)
+
+		mediaService := products.NewMediaService(mockProductRepo, new(mocks.MockProductVariantRepository), mockMediaRepo, store, limits)
+
+		mockProductRepo.On("GetProductbyProductID", "01HV").Return(product, nil)
+		mockMediaRepo.On("GetMediabyProductID", "01HV", "").Return([]schema.ProductMedia{{MediaID: "cover"}}, nil)
+		mockMediaRepo.On("CreateMedia", mock.MatchedBy(func(m schema.ProductMedia) bool {
+			return m.Kind == schema.MediaKindImage && m.Position == 1 && m.Width == 1200 && m.Height == 600 &&
+				m.AltText == "Kaos polos hitam" && len(m.Thumbnails) == len(products.ThumbnailSizes)
+		})).Return(schema.ProductMedia{}, nil)
+
+		data := pngImage(t, 1200, 600)
+		_, err := mediaService.UploadMedia(context.Background(), "01HV", products.MediaUpload{
+			AltText: "Kaos polos hitam",
+			Size:    int64(len(data)),
+			Body:    bytes.NewReader(data),
+		})
+		assert.NoError(t, err)
+		mockMediaRepo.AssertExpectations(t)
+
+		files, _ := filepath.Glob(filepath.Join(root, "products", "01HV", "*", "*.png"))
+		assert.Len(t, files, 1+len(products.ThumbnailSizes))
+	})
+
+	t.Run("Unsupported and too large Files are refused", func(t *testing.T) {
+		store, _ := storage.NewLocalStorage(t.TempDir(), "/media")
+		mockProductRepo := new(mocks.MockProductRepository)
+		mockMediaRepo := new(mocks.MockProductMediaRepository)
+
+		mediaService := products.NewMediaService(mockProductRepo, new(mocks.MockProductVariantRepository), mockMediaRepo, store, products.MediaLimits{MaxImageSize: 64, MaxVideoSize: 64})
+
+		mockProductRepo.On("GetProductbyProductID", "01HV").Return(product, nil)
+		mockMediaRepo.On("GetMediabyProductID", "01HV", "").Return([]schema.ProductMedia{}, nil)
+
+		_, err := mediaService.UploadMedia(context.Background(), "01HV", products.MediaUpload{Body: strings.NewReader("plain text")})
+		assert.ErrorIs(t, err, exception.ErrUnsupportedMedia)
+
+		data := pngImage(t, 200, 200)
+		_, err = mediaService.UploadMedia(context.Background(), "01HV", products.MediaUpload{Body: bytes.NewReader(data)})
+		assert.ErrorIs(t, err, exception.ErrPayloadTooLarge)
+		mockMediaRepo.AssertNotCalled(t, "CreateMedia", mock.Anything)
+	})
+
+	t.Run("Image declaring too many Pixels is refused before decoding", func(t *testing.T) {
+		store, _ := storage.NewLocalStorage(t.TempDir(), "/media")
+		mockProductRepo := new(mocks.MockProductRepository)
+		mockMediaRepo := new(mocks.MockProductMediaRepository)
+
+		mediaService := products.NewMediaService(mockProductRepo, new(mocks.MockProductVariantRepository), mockMediaRepo, store, limits)
+
+		mockProductRepo.On("GetProductbyProductID", "01HV").Return(product, nil)
+		mockMediaRepo.On("GetMediabyProductID", "01HV", "").Return([]schema.ProductMedia{}, nil)
+
+		data := oversizedPNG(t, 40000, 40000)
+		_, err := mediaService.UploadMedia(context.Background(), "01HV", products.MediaUpload{Body: bytes.NewReader(data)})
+		assert.ErrorIs(t, err, exception.ErrValidation)
+		mockMediaRepo.AssertNotCalled(t, "CreateMedia", mock.Anything)
+	})
+}
+
+func TestReorderMedia(t *testing.T) {
+	product := schema.Product{ProductID: "01HV"}
+	existing := []schema.ProductMedia{{MediaID: "a"}, {MediaID: "b"}, {MediaID: "c"}}
+
+	t.Run("Every Media is listed once", func(t *testing.T) {
+		mockProductRepo := new(mocks.MockProductRepository)
+		mockMediaRepo := new(mocks.MockProductMediaRepository)
+
+		mediaService := products.NewMediaService(mockProductRepo, new(mocks.MockProductVariantRepository), mockMediaRepo, nil, products.MediaLimits{})
+
+		mockProductRepo.On("GetProductbyProductID", "01HV").Return(product, nil)
+		mockMediaRepo.On("GetMediabyProductID", "01HV", "").Return(existing, nil)
+		mockMediaRepo.On("ReorderMedia", "01HV", []string{"c", "a", "b"}).Return(nil)
+
+		_, err := mediaService.ReorderMedia("01HV", products.ReorderMediaRequest{MediaIDs: []string{"c", "a", "b"}})
+		assert.NoError(t, err)
+
+		_, err = mediaService.ReorderMedia("01HV", products.ReorderMediaRequest{MediaIDs: []string{"c", "a"}})
+		assert.ErrorIs(t, err, exception.ErrValidation)
+		mockMediaRepo.AssertNumberOfCalls(t, "ReorderMedia", 1)
+	})
+}
+
+func TestMediaJanitor(t *testing.T) {
+	t.Run("Files and Rows of orphaned Media are removed", func(t *testing.T) {
+		root := t.TempDir()
+		store, _ := storage.NewLocalStorage(root, "/media")
+		mockMediaRepo := new(mocks.MockProductMediaRepository)
+
+		ctx := context.Background()
+		orphan := schema.ProductMedia{
+			MediaID:       "01HW",
+			StorageKey:    "products/01HV/01HW/original.png",
+			ThumbnailKeys: map[string]string{"small": "products/01HV/01HW/small.png"},
+		}
+		for _, key := range orphan.StorageKeys() {
+			assert.NoError(t, store.Put(ctx, key, strings.NewReader("png"), "image/png"))
+		}
+
+		mockMediaRepo.On("GetOrphanedMedia", products.MediaCleanupBatch).Return([]schema.ProductMedia{orphan}, nil)
+		mockMediaRepo.On("PurgeMedia", "01HW").Return(nil)
+
+		janitor := products.NewMediaJanitor(mockMediaRepo, store, 0)
+		removed, err := janitor.Sweep(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, removed)
+
+		_, err = os.Stat(filepath.Join(root, "products", "01HV", "01HW", "original.png"))
+		assert.True(t, os.IsNotExist(err))
+		mockMediaRepo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"smkdevid/echocommercehub/internal/databases/migrations"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	t.Run("Embedded Migrations are ordered", func(t *testing.T) {
+		loaded, err := migrations.Load(migrations.Files)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, loaded)
+		for i, migration := range loaded {
+			assert.Equal(t, int64(i+1), migration.Version)
+		}
+		assert.Equal(t, "000001_create_promotion_table", loaded[0].ID())
+	})
+
+	t.Run("Sorted by Version", func(t *testing.T) {
+		loaded, err := migrations.Load(fstest.MapFS{
+			"000010_add_index.up.sql":      {Data: []byte("CREATE INDEX idx ON t (c);")},
+			"000010_add_index.down.sql":    {Data: []byte("DROP INDEX idx;")},
+			"000002_create_table.up.sql":   {Data: []byte("CREATE TABLE t (c INT);")},
+			"000002_create_table.down.sql": {Data: []byte("DROP TABLE t;")},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{2, 10}, []int64{loaded[0].Version, loaded[1].Version})
+		assert.Equal(t, "DROP TABLE t;", loaded[0].Down)
+	})
+
+	t.Run("Missing Down File", func(t *testing.T) {
+		_, err := migrations.Load(fstest.MapFS{
+			"000001_create_table.up.sql": {Data: []byte("CREATE TABLE t (c INT);")},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("Invalid File Name", func(t *testing.T) {
+		_, err := migrations.Load(fstest.MapFS{
+			"create_table.sql": {Data: []byte("CREATE TABLE t (c INT);")},
+		})
+		assert.Error(t, err)
+	})
+}
+
+func TestCreateMigration(t *testing.T) {
+	dir := t.TempDir()
+
+	up, down, err := migrations.Create(dir, "create_product_table")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "000001_create_product_table.up.sql"), up)
+	assert.Equal(t, filepath.Join(dir, "000001_create_product_table.down.sql"), down)
+
+	up, _, err = migrations.Create(dir, "add_product_index")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "000002_add_product_index.up.sql"), up)
+
+	_, err = os.Stat(up)
+	assert.NoError(t, err)
+
+	_, _, err = migrations.Create(dir, "Add Product Index")
+	assert.Error(t, err)
+}
+
+func TestSchemaBehindError(t *testing.T) {
+	err := &migrations.SchemaBehindError{Pending: []migrations.Migration{
+		{Version: 5, Name: "create_product_table"},
+	}}
+	assert.Contains(t, err.Error(), "000005_create_product_table")
+	assert.Contains(t, err.Error(), "migrate up")
+}
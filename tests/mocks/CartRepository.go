@@ -0,0 +1,58 @@
+package mocks
+
+import (
+	"time"
+
+	schema "smkdevid/echocommercehub/internal/models/schema"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockCartRepository struct {
+	mock.Mock
+}
+
+func (m *MockCartRepository) CreateCart(cart schema.Cart) (schema.Cart, error) {
+	args := m.Called(cart)
+	return args.Get(0).(schema.Cart), args.Error(1)
+}
+
+func (m *MockCartRepository) GetActiveCartbyToken(token string) (schema.Cart, bool, error) {
+	args := m.Called(token)
+	return args.Get(0).(schema.Cart), args.Bool(1), args.Error(2)
+}
+
+func (m *MockCartRepository) GetActiveCartbyCustomerID(customerID string) (schema.Cart, bool, error) {
+	args := m.Called(customerID)
+	return args.Get(0).(schema.Cart), args.Bool(1), args.Error(2)
+}
+
+func (m *MockCartRepository) SaveCartItem(item schema.CartItem, expiresAt time.Time) error {
+	args := m.Called(item, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockCartRepository) DeleteCartItem(cartID string, variantID string, expiresAt time.Time) error {
+	args := m.Called(cartID, variantID, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockCartRepository) SetCartCoupon(cartID string, couponCode string, expiresAt time.Time) error {
+	args := m.Called(cartID, couponCode, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockCartRepository) MergeCarts(from schema.Cart, into schema.Cart, maxQuantity uint, expiresAt time.Time) error {
+	args := m.Called(from, into, maxQuantity, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockCartRepository) CloseCart(cartID string, status string) error {
+	args := m.Called(cartID, status)
+	return args.Error(0)
+}
+
+func (m *MockCartRepository) ExpireCarts(now time.Time) (int64, error) {
+	args := m.Called(now)
+	return args.Get(0).(int64), args.Error(1)
+}
@@ -0,0 +1,46 @@
+package mocks
+
+import (
+	"smkdevid/echocommercehub/internal/services/products"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockCartService struct {
+	mock.Mock
+}
+
+func (m *MockCartService) GetCart(owner products.CartOwner) (products.CartView, error) {
+	args := m.Called(owner)
+	return args.Get(0).(products.CartView), args.Error(1)
+}
+
+func (m *MockCartService) AddItem(owner products.CartOwner, req products.AddCartItemRequest) (products.CartView, error) {
+	args := m.Called(owner, req)
+	return args.Get(0).(products.CartView), args.Error(1)
+}
+
+func (m *MockCartService) UpdateItem(owner products.CartOwner, variantID string, req products.UpdateCartItemRequest) (products.CartView, error) {
+	args := m.Called(owner, variantID, req)
+	return args.Get(0).(products.CartView), args.Error(1)
+}
+
+func (m *MockCartService) RemoveItem(owner products.CartOwner, variantID string) (products.CartView, error) {
+	args := m.Called(owner, variantID)
+	return args.Get(0).(products.CartView), args.Error(1)
+}
+
+func (m *MockCartService) MergeCarts(customer products.CartOwner, token string) (products.CartView, error) {
+	args := m.Called(customer, token)
+	return args.Get(0).(products.CartView), args.Error(1)
+}
+
+func (m *MockCartService) ApplyCoupon(owner products.CartOwner, req products.ApplyCouponRequest) (products.CartView, error) {
+	args := m.Called(owner, req)
+	return args.Get(0).(products.CartView), args.Error(1)
+}
+
+func (m *MockCartService) RemoveCoupon(owner products.CartOwner) (products.CartView, error) {
+	args := m.Called(owner)
+	return args.Get(0).(products.CartView), args.Error(1)
+}
@@ -0,0 +1,54 @@
+package mocks
+
+import (
+	schema "smkdevid/echocommercehub/internal/models/schema"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockCouponRepository struct {
+	mock.Mock
+}
+
+func (m *MockCouponRepository) CreateCoupons(coupons []schema.Coupon) ([]schema.Coupon, error) {
+	args := m.Called(coupons)
+	if result, ok := args.Get(0).(func([]schema.Coupon) []schema.Coupon); ok {
+		return result(coupons), args.Error(1)
+	}
+	return args.Get(0).([]schema.Coupon), args.Error(1)
+}
+
+func (m *MockCouponRepository) GetExistingCouponCodes(codes []string) ([]string, error) {
+	args := m.Called(codes)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockCouponRepository) GetCouponsbyPromotionID(promotionID string) ([]schema.Coupon, error) {
+	args := m.Called(promotionID)
+	return args.Get(0).([]schema.Coupon), args.Error(1)
+}
+
+func (m *MockCouponRepository) GetCouponbyCouponCode(couponCode string) (schema.Coupon, error) {
+	args := m.Called(couponCode)
+	return args.Get(0).(schema.Coupon), args.Error(1)
+}
+
+func (m *MockCouponRepository) GetCouponPromotionIDs(promotionIDs []string) ([]string, error) {
+	args := m.Called(promotionIDs)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockCouponRepository) CountRedemptionsbyCustomer(promotionID string, customerID string) (uint, error) {
+	args := m.Called(promotionID, customerID)
+	return args.Get(0).(uint), args.Error(1)
+}
+
+func (m *MockCouponRepository) FindRedemption(couponCode string, orderReference string) (schema.CouponRedemption, bool, error) {
+	args := m.Called(couponCode, orderReference)
+	return args.Get(0).(schema.CouponRedemption), args.Bool(1), args.Error(2)
+}
+
+func (m *MockCouponRepository) RedeemCoupon(redemption schema.CouponRedemption, maxUsesPerCustomer uint) (schema.CouponRedemption, error) {
+	args := m.Called(redemption, maxUsesPerCustomer)
+	return args.Get(0).(schema.CouponRedemption), args.Error(1)
+}
@@ -0,0 +1,33 @@
+package mocks
+
+import (
+	"time"
+
+	schema "smkdevid/echocommercehub/internal/models/schema"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockIdempotencyRepository struct {
+	mock.Mock
+}
+
+func (m *MockIdempotencyRepository) GetIdempotencyKey(key string) (schema.IdempotencyKey, bool, error) {
+	args := m.Called(key)
+	return args.Get(0).(schema.IdempotencyKey), args.Bool(1), args.Error(2)
+}
+
+func (m *MockIdempotencyRepository) ReserveIdempotencyKey(record schema.IdempotencyKey, ttl time.Duration) (bool, error) {
+	args := m.Called(record, ttl)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockIdempotencyRepository) CompleteIdempotencyKey(record schema.IdempotencyKey) error {
+	args := m.Called(record)
+	return args.Error(0)
+}
+
+func (m *MockIdempotencyRepository) DeleteIdempotencyKey(key string) error {
+	args := m.Called(key)
+	return args.Error(0)
+}
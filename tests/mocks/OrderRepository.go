@@ -0,0 +1,47 @@
+package mocks
+
+import (
+	postgresql "smkdevid/echocommercehub/internal/databases/postgresql"
+	schema "smkdevid/echocommercehub/internal/models/schema"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockOrderRepository struct {
+	mock.Mock
+}
+
+func (m *MockOrderRepository) CreateOrder(order schema.Order, actor string) (schema.Order, error) {
+	args := m.Called(order, actor)
+	return args.Get(0).(schema.Order), args.Error(1)
+}
+
+func (m *MockOrderRepository) GetOrderbyOrderID(orderID string) (schema.Order, error) {
+	args := m.Called(orderID)
+	return args.Get(0).(schema.Order), args.Error(1)
+}
+
+func (m *MockOrderRepository) ListOrders(query postgresql.OrderQuery) (postgresql.OrderList, error) {
+	args := m.Called(query)
+	return args.Get(0).(postgresql.OrderList), args.Error(1)
+}
+
+func (m *MockOrderRepository) TransitionOrder(orderID string, fromStatus string, toStatus string, actor string, reason string) (bool, error) {
+	args := m.Called(orderID, fromStatus, toStatus, actor, reason)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockOrderRepository) GetOrderTransitions(orderID string) ([]schema.OrderTransition, error) {
+	args := m.Called(orderID)
+	return args.Get(0).([]schema.OrderTransition), args.Error(1)
+}
+
+func (m *MockOrderRepository) CountPromotionUsesbyCustomer(customerID string) (map[string]uint, error) {
+	args := m.Called(customerID)
+	return args.Get(0).(map[string]uint), args.Error(1)
+}
+
+func (m *MockOrderRepository) CountOrdersbyCustomer(customerID string) (int64, error) {
+	args := m.Called(customerID)
+	return args.Get(0).(int64), args.Error(1)
+}
@@ -0,0 +1,42 @@
+package mocks
+
+import (
+	schema "smkdevid/echocommercehub/internal/models/schema"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPaymentRepository struct {
+	mock.Mock
+}
+
+func (m *MockPaymentRepository) CreatePayment(payment schema.Payment) (schema.Payment, error) {
+	args := m.Called(payment)
+	return args.Get(0).(schema.Payment), args.Error(1)
+}
+
+func (m *MockPaymentRepository) GetPaymentbyPaymentID(paymentID string) (schema.Payment, error) {
+	args := m.Called(paymentID)
+	return args.Get(0).(schema.Payment), args.Error(1)
+}
+
+func (m *MockPaymentRepository) GetPaymentbyReference(provider string, reference string) (schema.Payment, error) {
+	args := m.Called(provider, reference)
+	return args.Get(0).(schema.Payment), args.Error(1)
+}
+
+func (m *MockPaymentRepository) GetPaymentsbyOrderID(orderID string) ([]schema.Payment, error) {
+	args := m.Called(orderID)
+	return args.Get(0).([]schema.Payment), args.Error(1)
+}
+
+func (m *MockPaymentRepository) UpdatePayment(payment schema.Payment, fromStatus string) (bool, error) {
+	args := m.Called(payment, fromStatus)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPaymentRepository) UpdateRefundedAmount(paymentID string, fromAmount decimal.Decimal, toAmount decimal.Decimal) (bool, error) {
+	args := m.Called(paymentID, fromAmount, toAmount)
+	return args.Bool(0), args.Error(1)
+}
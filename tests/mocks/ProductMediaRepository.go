@@ -0,0 +1,51 @@
+package mocks
+
+import (
+	schema "smkdevid/echocommercehub/internal/models/schema"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockProductMediaRepository struct {
+	mock.Mock
+}
+
+func (m *MockProductMediaRepository) CreateMedia(media schema.ProductMedia) (schema.ProductMedia, error) {
+	args := m.Called(media)
+	return args.Get(0).(schema.ProductMedia), args.Error(1)
+}
+
+func (m *MockProductMediaRepository) GetMediabyProductID(productID string, variantID string) ([]schema.ProductMedia, error) {
+	args := m.Called(productID, variantID)
+	return args.Get(0).([]schema.ProductMedia), args.Error(1)
+}
+
+func (m *MockProductMediaRepository) GetMediabyMediaID(productID string, mediaID string) (schema.ProductMedia, error) {
+	args := m.Called(productID, mediaID)
+	return args.Get(0).(schema.ProductMedia), args.Error(1)
+}
+
+func (m *MockProductMediaRepository) UpdateMedia(media schema.ProductMedia) (schema.ProductMedia, error) {
+	args := m.Called(media)
+	return args.Get(0).(schema.ProductMedia), args.Error(1)
+}
+
+func (m *MockProductMediaRepository) ReorderMedia(productID string, mediaIDs []string) error {
+	args := m.Called(productID, mediaIDs)
+	return args.Error(0)
+}
+
+func (m *MockProductMediaRepository) DeleteMedia(productID string, mediaID string) error {
+	args := m.Called(productID, mediaID)
+	return args.Error(0)
+}
+
+func (m *MockProductMediaRepository) GetOrphanedMedia(limit int) ([]schema.ProductMedia, error) {
+	args := m.Called(limit)
+	return args.Get(0).([]schema.ProductMedia), args.Error(1)
+}
+
+func (m *MockProductMediaRepository) PurgeMedia(mediaID string) error {
+	args := m.Called(mediaID)
+	return args.Error(0)
+}
@@ -0,0 +1,42 @@
+package mocks
+
+import (
+	postgresql "smkdevid/echocommercehub/internal/databases/postgresql"
+	schema "smkdevid/echocommercehub/internal/models/schema"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockProductRepository struct {
+	mock.Mock
+}
+
+func (m *MockProductRepository) CreateProduct(product schema.Product) (schema.Product, error) {
+	args := m.Called(product)
+	return args.Get(0).(schema.Product), args.Error(1)
+}
+
+func (m *MockProductRepository) ListProducts(query postgresql.ProductQuery) (postgresql.ProductList, error) {
+	args := m.Called(query)
+	return args.Get(0).(postgresql.ProductList), args.Error(1)
+}
+
+func (m *MockProductRepository) GetProductbyProductID(productID string) (schema.Product, error) {
+	args := m.Called(productID)
+	return args.Get(0).(schema.Product), args.Error(1)
+}
+
+func (m *MockProductRepository) UpdateProductbyProductID(product schema.Product) (schema.Product, error) {
+	args := m.Called(product)
+	return args.Get(0).(schema.Product), args.Error(1)
+}
+
+func (m *MockProductRepository) DeleteProductbyProductID(productID string) error {
+	args := m.Called(productID)
+	return args.Error(0)
+}
+
+func (m *MockProductRepository) SearchProducts(query postgresql.ProductSearchQuery) (postgresql.ProductSearchResult, error) {
+	args := m.Called(query)
+	return args.Get(0).(postgresql.ProductSearchResult), args.Error(1)
+}
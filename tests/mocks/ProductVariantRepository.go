@@ -0,0 +1,46 @@
+package mocks
+
+import (
+	schema "smkdevid/echocommercehub/internal/models/schema"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockProductVariantRepository struct {
+	mock.Mock
+}
+
+func (m *MockProductVariantRepository) SaveProductOptions(productID string, options []schema.ProductOption) ([]schema.ProductOption, error) {
+	args := m.Called(productID, options)
+	return args.Get(0).([]schema.ProductOption), args.Error(1)
+}
+
+func (m *MockProductVariantRepository) GetProductOptions(productID string) ([]schema.ProductOption, error) {
+	args := m.Called(productID)
+	return args.Get(0).([]schema.ProductOption), args.Error(1)
+}
+
+func (m *MockProductVariantRepository) CreateVariants(variants []schema.ProductVariant) ([]schema.ProductVariant, error) {
+	args := m.Called(variants)
+	return args.Get(0).([]schema.ProductVariant), args.Error(1)
+}
+
+func (m *MockProductVariantRepository) GetVariantsbyProductID(productID string) ([]schema.ProductVariant, error) {
+	args := m.Called(productID)
+	return args.Get(0).([]schema.ProductVariant), args.Error(1)
+}
+
+func (m *MockProductVariantRepository) GetVariantbyVariantID(productID string, variantID string) (schema.ProductVariant, error) {
+	args := m.Called(productID, variantID)
+	return args.Get(0).(schema.ProductVariant), args.Error(1)
+}
+
+func (m *MockProductVariantRepository) UpdateVariant(variant schema.ProductVariant) (schema.ProductVariant, error) {
+	args := m.Called(variant)
+	return args.Get(0).(schema.ProductVariant), args.Error(1)
+}
+
+func (m *MockProductVariantRepository) DeleteVariant(productID string, variantID string) error {
+	args := m.Called(productID, variantID)
+	return args.Error(0)
+}
@@ -1,6 +1,7 @@
 package mocks
 
 import (
+	postgresql "smkdevid/echocommercehub/internal/databases/postgresql"
 	schema "smkdevid/echocommercehub/internal/models/schema"
 
 	"time"
@@ -12,12 +13,12 @@ type MockPromotionRepository struct {
 	mock.Mock
 }
 
-func (m *MockPromotionRepository) CreatePromotion(promo schema.Promotion) (schema.Promotion, error) {
+func (m *MockPromotionRepository) CreatePromotion(promo schema.Promotion, actor string) (schema.Promotion, error) {
 	promo.ID = 1
 	promo.CreatedAt = time.Now()
 	promo.UpdatedAt = time.Now()
 
-	args := m.Called(promo)
+	args := m.Called(promo, actor)
 	return args.Get(0).(schema.Promotion), args.Error(1)
 }
 
@@ -26,17 +27,42 @@ func (m *MockPromotionRepository) GetAllPromotions() ([]schema.Promotion, error)
 	return args.Get(0).([]schema.Promotion), args.Error(1)
 }
 
+func (m *MockPromotionRepository) ListPromotions(query postgresql.PromotionQuery) (postgresql.PromotionList, error) {
+	args := m.Called(query)
+	return args.Get(0).(postgresql.PromotionList), args.Error(1)
+}
+
 func (m *MockPromotionRepository) GetPromotionbyPromotionID(promotionID string) (schema.Promotion, error) {
 	args := m.Called(promotionID)
 	return args.Get(0).(schema.Promotion), args.Error(1)
 }
 
-func (m *MockPromotionRepository) UpdatePromotionbyPromotionID(promo schema.Promotion) (schema.Promotion, error) {
-	args := m.Called(promo)
+func (m *MockPromotionRepository) UpdatePromotionbyPromotionID(promo schema.Promotion, actor string) (schema.Promotion, error) {
+	args := m.Called(promo, actor)
 	return args.Get(0).(schema.Promotion), args.Error(1)
 }
 
-func (m *MockPromotionRepository) DeletePromotionbyPromotionID(promotionID string) error {
-	args := m.Called(promotionID)
+func (m *MockPromotionRepository) DeletePromotionbyPromotionID(promotionID string, actor string) error {
+	args := m.Called(promotionID, actor)
 	return args.Error(0)
 }
+
+func (m *MockPromotionRepository) RestorePromotionbyPromotionID(promotionID string, actor string) (schema.Promotion, error) {
+	args := m.Called(promotionID, actor)
+	return args.Get(0).(schema.Promotion), args.Error(1)
+}
+
+func (m *MockPromotionRepository) GetPromotionHistory(promotionID string) ([]schema.PromotionHistory, error) {
+	args := m.Called(promotionID)
+	return args.Get(0).([]schema.PromotionHistory), args.Error(1)
+}
+
+func (m *MockPromotionRepository) GetPromotionsbyStatus(statuses []string) ([]schema.Promotion, error) {
+	args := m.Called(statuses)
+	return args.Get(0).([]schema.Promotion), args.Error(1)
+}
+
+func (m *MockPromotionRepository) UpdatePromotionStatus(promotionID string, fromStatus string, toStatus string, actor string) (bool, error) {
+	args := m.Called(promotionID, fromStatus, toStatus, actor)
+	return args.Bool(0), args.Error(1)
+}
@@ -1,6 +1,8 @@
 package mocks
 
 import (
+	postgresql "smkdevid/echocommercehub/internal/databases/postgresql"
+	"smkdevid/echocommercehub/internal/services/promotions"
 	schema "smkdevid/echocommercehub/internal/models/schema"
 
 	"github.com/stretchr/testify/mock"
@@ -10,8 +12,8 @@ type MockPromotionService struct {
 	mock.Mock
 }
 
-func (m *MockPromotionService) CreatePromotion(promo schema.Promotion) (schema.Promotion, error) {
-	args := m.Called(promo)
+func (m *MockPromotionService) CreatePromotion(promo schema.Promotion, actor string) (schema.Promotion, error) {
+	args := m.Called(promo, actor)
 	return args.Get(0).(schema.Promotion), args.Error(1)
 }
 
@@ -28,15 +30,55 @@ func (m *MockPromotionService) GetPromotionbyPromotionID(promotionID string) (sc
 	return schema.Promotion{}, args.Error(1)
 }
 
-func (m *MockPromotionService) UpdatePromotionbyPromotionID(promo schema.Promotion) (schema.Promotion, error) {
-	args := m.Called(promo)
+func (m *MockPromotionService) UpdatePromotionbyPromotionID(promo schema.Promotion, actor string) (schema.Promotion, error) {
+	args := m.Called(promo, actor)
 	if result := args.Get(0); result != nil {
 		return result.(schema.Promotion), nil
 	}
 	return schema.Promotion{}, args.Error(1)
 }
 
-func (m *MockPromotionService) DeletePromotionbyPromotionID(promotionID string) error {
-	args := m.Called(promotionID)
+func (m *MockPromotionService) DeletePromotionbyPromotionID(promotionID string, actor string) error {
+	args := m.Called(promotionID, actor)
 	return args.Error(0)
 }
+
+func (m *MockPromotionService) RestorePromotionbyPromotionID(promotionID string, actor string) (schema.Promotion, error) {
+	args := m.Called(promotionID, actor)
+	return args.Get(0).(schema.Promotion), args.Error(1)
+}
+
+func (m *MockPromotionService) GetPromotionHistory(promotionID string) ([]schema.PromotionHistory, error) {
+	args := m.Called(promotionID)
+	return args.Get(0).([]schema.PromotionHistory), args.Error(1)
+}
+
+func (m *MockPromotionService) CheckEligibility(promotionID string, cart promotions.Cart) (promotions.EligibilityResult, error) {
+	args := m.Called(promotionID, cart)
+	return args.Get(0).(promotions.EligibilityResult), args.Error(1)
+}
+
+func (m *MockPromotionService) QuoteDiscount(promotionID string, cart promotions.Cart) (promotions.DiscountQuote, error) {
+	args := m.Called(promotionID, cart)
+	return args.Get(0).(promotions.DiscountQuote), args.Error(1)
+}
+
+func (m *MockPromotionService) ResolvePromotions(cart promotions.Cart, mode string) (promotions.StackingResult, error) {
+	args := m.Called(cart, mode)
+	return args.Get(0).(promotions.StackingResult), args.Error(1)
+}
+
+func (m *MockPromotionService) ListPromotions(query postgresql.PromotionQuery) (promotions.PromotionPage, error) {
+	args := m.Called(query)
+	return args.Get(0).(promotions.PromotionPage), args.Error(1)
+}
+
+func (m *MockPromotionService) PausePromotion(promotionID string, actor string) (schema.Promotion, error) {
+	args := m.Called(promotionID, actor)
+	return args.Get(0).(schema.Promotion), args.Error(1)
+}
+
+func (m *MockPromotionService) ResumePromotion(promotionID string, actor string) (schema.Promotion, error) {
+	args := m.Called(promotionID, actor)
+	return args.Get(0).(schema.Promotion), args.Error(1)
+}
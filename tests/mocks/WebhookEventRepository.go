@@ -0,0 +1,39 @@
+package mocks
+
+import (
+	"time"
+
+	postgresql "smkdevid/echocommercehub/internal/databases/postgresql"
+	schema "smkdevid/echocommercehub/internal/models/schema"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockWebhookEventRepository struct {
+	mock.Mock
+}
+
+func (m *MockWebhookEventRepository) CreateWebhookEvent(event schema.WebhookEvent) (schema.WebhookEvent, bool, error) {
+	args := m.Called(event)
+	return args.Get(0).(schema.WebhookEvent), args.Bool(1), args.Error(2)
+}
+
+func (m *MockWebhookEventRepository) ClaimDueWebhookEvents(now time.Time, limit int, lease time.Duration) ([]schema.WebhookEvent, error) {
+	args := m.Called(now, limit, lease)
+	return args.Get(0).([]schema.WebhookEvent), args.Error(1)
+}
+
+func (m *MockWebhookEventRepository) UpdateWebhookEvent(event schema.WebhookEvent) error {
+	args := m.Called(event)
+	return args.Error(0)
+}
+
+func (m *MockWebhookEventRepository) ListWebhookEvents(query postgresql.WebhookEventQuery) (postgresql.WebhookEventList, error) {
+	args := m.Called(query)
+	return args.Get(0).(postgresql.WebhookEventList), args.Error(1)
+}
+
+func (m *MockWebhookEventRepository) ReplayWebhookEvent(webhookID string, now time.Time) (schema.WebhookEvent, error) {
+	args := m.Called(webhookID, now)
+	return args.Get(0).(schema.WebhookEvent), args.Error(1)
+}
@@ -0,0 +1,201 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"smkdevid/echocommercehub/internal/app/handlers"
+	schema "smkdevid/echocommercehub/internal/models/schema"
+	"smkdevid/echocommercehub/internal/services/products"
+	"smkdevid/echocommercehub/tests/mocks"
+	"smkdevid/echocommercehub/utils/exception"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var shippingAddress = schema.Address{
+	RecipientName: "Budi Santoso",
+	Phone:         "081234567890",
+	Line1:         "Jl. Merdeka No. 1",
+	City:          "Bandung",
+	Province:      "Jawa Barat",
+	PostalCode:    "40111",
+	Country:       "ID",
+}
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		allowed  bool
+	}{
+		{schema.OrderStatusPendingPayment, schema.OrderStatusPaid, true},
+		{schema.OrderStatusPendingPayment, schema.OrderStatusCancelled, true},
+		{schema.OrderStatusPendingPayment, schema.OrderStatusShipped, false},
+		{schema.OrderStatusPaid, schema.OrderStatusProcessing, true},
+		{schema.OrderStatusPaid, schema.OrderStatusCancelled, false},
+		{schema.OrderStatusProcessing, schema.OrderStatusShipped, true},
+		{schema.OrderStatusShipped, schema.OrderStatusDelivered, true},
+		{schema.OrderStatusShipped, schema.OrderStatusRefunded, false},
+		{schema.OrderStatusDelivered, schema.OrderStatusRefunded, true},
+		{schema.OrderStatusCancelled, schema.OrderStatusPaid, false},
+		{schema.OrderStatusRefunded, schema.OrderStatusPaid, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+" to "+tt.to, func(t *testing.T) {
+			assert.Equal(t, tt.allowed, products.CanTransition(tt.from, tt.to))
+		})
+	}
+}
+
+func TestOrderService(t *testing.T) {
+	cart := products.CartView{
+		CartID:     "01HC",
+		CustomerID: "budi",
+		Status:     schema.CartStatusActive,
+		Lines: []products.CartLine{{
+			ProductID: "01HV", VariantID: "01HW", ProductName: "Kaos Polos", SKU: "KAOS-POLOS-HITAM-M", Quantity: 2,
+			UnitPrice: decimal.NewFromInt(75000), LineTotal: decimal.NewFromInt(150000), Discount: decimal.Zero,
+			Total: decimal.NewFromInt(150000), Available: true, InStock: true,
+		}},
+		Totals: products.CartTotals{
+			Subtotal: decimal.NewFromInt(150000), Shipping: decimal.NewFromInt(20000),
+			Tax: decimal.NewFromInt(16500), GrandTotal: decimal.NewFromInt(186500),
+		},
+	}
+
+	newService := func() (*products.OrderServiceImpl, *mocks.MockOrderRepository, *mocks.MockCartService) {
+		mockOrderRepo := new(mocks.MockOrderRepository)
+		mockCartService := new(mocks.MockCartService)
+		return products.NewOrderService(mockOrderRepo, mockCartService), mockOrderRepo, mockCartService
+	}
+
+	t.Run("Order snapshots the Cart", func(t *testing.T) {
+		orderService, mockOrderRepo, mockCartService := newService()
+
+		mockCartService.On("GetCart", products.CartOwner{CustomerID: "budi"}).Return(cart, nil)
+		mockOrderRepo.On("CreateOrder", mock.MatchedBy(func(o schema.Order) bool {
+			return o.CartID == "01HC" && o.Status == schema.OrderStatusPendingPayment &&
+				o.GrandTotal.Equal(decimal.NewFromInt(186500)) && len(o.Items) == 1 &&
+				o.Items[0].UnitPrice.Equal(decimal.NewFromInt(75000)) && o.BillingAddress == shippingAddress
+		}), "budi").Return(schema.Order{OrderID: "01HO", Status: schema.OrderStatusPendingPayment}, nil)
+
+		order, err := orderService.PlaceOrder(products.CartOwner{CustomerID: "budi"}, products.PlaceOrderRequest{ShippingAddress: shippingAddress})
+		assert.NoError(t, err)
+		assert.Equal(t, "01HO", order.OrderID)
+		mockOrderRepo.AssertExpectations(t)
+	})
+
+	t.Run("Applied Coupon is redeemed with the Order", func(t *testing.T) {
+		orderService, mockOrderRepo, mockCartService := newService()
+
+		withCoupon := cart
+		withCoupon.CouponCode = "RAMADHAN-AB2C"
+		withCoupon.CouponApplied = true
+
+		mockCartService.On("GetCart", products.CartOwner{CustomerID: "budi"}).Return(withCoupon, nil)
+		mockOrderRepo.On("CreateOrder", mock.MatchedBy(func(o schema.Order) bool {
+			return o.CouponCode == "RAMADHAN-AB2C"
+		}), "budi").Return(schema.Order{OrderID: "01HO", CouponCode: "RAMADHAN-AB2C"}, nil)
+
+		_, err := orderService.PlaceOrder(products.CartOwner{CustomerID: "budi"}, products.PlaceOrderRequest{ShippingAddress: shippingAddress})
+		assert.NoError(t, err)
+		mockOrderRepo.AssertExpectations(t)
+	})
+
+	t.Run("Empty Cart cannot be placed", func(t *testing.T) {
+		orderService, mockOrderRepo, mockCartService := newService()
+
+		mockCartService.On("GetCart", products.CartOwner{CustomerID: "budi"}).Return(products.CartView{Status: schema.CartStatusActive}, nil)
+
+		_, err := orderService.PlaceOrder(products.CartOwner{CustomerID: "budi"}, products.PlaceOrderRequest{ShippingAddress: shippingAddress})
+		assert.ErrorIs(t, err, exception.ErrValidation)
+		mockOrderRepo.AssertNotCalled(t, "CreateOrder", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Line out of Stock cannot be placed", func(t *testing.T) {
+		orderService, mockOrderRepo, mockCartService := newService()
+
+		outOfStock := cart
+		outOfStock.Lines = []products.CartLine{cart.Lines[0]}
+		outOfStock.Lines[0].InStock = false
+		mockCartService.On("GetCart", products.CartOwner{CustomerID: "budi"}).Return(outOfStock, nil)
+
+		_, err := orderService.PlaceOrder(products.CartOwner{CustomerID: "budi"}, products.PlaceOrderRequest{ShippingAddress: shippingAddress})
+		assert.ErrorIs(t, err, exception.ErrValidation)
+		mockOrderRepo.AssertNotCalled(t, "CreateOrder", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Paid Order moves to Processing", func(t *testing.T) {
+		orderService, mockOrderRepo, _ := newService()
+
+		mockOrderRepo.On("GetOrderbyOrderID", "01HO").Return(schema.Order{OrderID: "01HO", Status: schema.OrderStatusPaid}, nil)
+		mockOrderRepo.On("TransitionOrder", "01HO", schema.OrderStatusPaid, schema.OrderStatusProcessing, "admin", "").Return(true, nil)
+
+		order, err := orderService.TransitionOrder("01HO", products.TransitionOrderRequest{Status: schema.OrderStatusProcessing}, "admin")
+		assert.NoError(t, err)
+		assert.Equal(t, schema.OrderStatusProcessing, order.Status)
+	})
+
+	t.Run("Paid and Refunded are left to the Payment", func(t *testing.T) {
+		orderService, mockOrderRepo, _ := newService()
+
+		for _, status := range []string{schema.OrderStatusPaid, schema.OrderStatusRefunded} {
+			_, err := orderService.TransitionOrder("01HO", products.TransitionOrderRequest{Status: status}, "admin")
+			assert.ErrorIs(t, err, exception.ErrValidation)
+		}
+		mockOrderRepo.AssertNotCalled(t, "TransitionOrder", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Pending Order cannot be Shipped", func(t *testing.T) {
+		orderService, mockOrderRepo, _ := newService()
+
+		mockOrderRepo.On("GetOrderbyOrderID", "01HO").Return(schema.Order{OrderID: "01HO", Status: schema.OrderStatusPendingPayment}, nil)
+
+		_, err := orderService.TransitionOrder("01HO", products.TransitionOrderRequest{Status: schema.OrderStatusShipped}, "admin")
+		assert.ErrorIs(t, err, exception.ErrConflict)
+		mockOrderRepo.AssertNotCalled(t, "TransitionOrder", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Customer cannot cancel a Paid Order", func(t *testing.T) {
+		orderService, mockOrderRepo, _ := newService()
+
+		mockOrderRepo.On("GetOrderbyOrderID", "01HO").Return(schema.Order{OrderID: "01HO", CustomerID: "budi", Status: schema.OrderStatusPaid}, nil)
+
+		_, err := orderService.CancelCustomerOrder("budi", "01HO", products.CancelOrderRequest{})
+		assert.ErrorIs(t, err, exception.ErrConflict)
+	})
+
+	t.Run("Order of another Customer is not found", func(t *testing.T) {
+		orderService, mockOrderRepo, _ := newService()
+
+		mockOrderRepo.On("GetOrderbyOrderID", "01HO").Return(schema.Order{OrderID: "01HO", CustomerID: "siti"}, nil)
+
+		_, err := orderService.GetCustomerOrder("budi", "01HO")
+		assert.ErrorIs(t, err, exception.ErrNotFound)
+	})
+}
+
+func TestOrderHandlers(t *testing.T) {
+	t.Run("Customer Orders require a Customer", func(t *testing.T) {
+		e := echo.New()
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/api/v1/orders", nil), rec)
+
+		err := handlers.PSQLListCustomerOrders(products.NewOrderService(nil, nil))(c)
+		assert.Equal(t, http.StatusUnauthorized, err.(*echo.HTTPError).Code)
+	})
+
+	t.Run("Unknown Status Filter is a Bad Request", func(t *testing.T) {
+		e := echo.New()
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/api/v1/admin/orders?status=lost", nil), rec)
+
+		err := handlers.PSQLListOrders(products.NewOrderService(new(mocks.MockOrderRepository), nil))(c)
+		assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code)
+	})
+}
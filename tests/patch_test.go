@@ -0,0 +1,154 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"smkdevid/echocommercehub/internal/app/handlers"
+	schema "smkdevid/echocommercehub/internal/models/schema"
+	"smkdevid/echocommercehub/tests/mocks"
+	"smkdevid/echocommercehub/utils/exception"
+	"smkdevid/echocommercehub/utils/requests"
+	"smkdevid/echocommercehub/utils/responses"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestApplyMergePatch(t *testing.T) {
+	// Examples of RFC 7396 appendix A
+	cases := []struct {
+		original string
+		patch    string
+		expected string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, tc := range cases {
+		patched, err := requests.ApplyMergePatch([]byte(tc.original), []byte(tc.patch))
+		assert.NoError(t, err)
+		assert.JSONEq(t, tc.expected, string(patched))
+	}
+}
+
+func TestPSQLPatchPromotionbyPromotionID(t *testing.T) {
+	e := echo.New()
+	e.Validator = promotionValidator()
+
+	current := schema.Promotion{
+		ID:                 7,
+		PromotionID:        "cae8651b",
+		PromotionName:      "Ramadhan Sale",
+		DiscountType:       "percentage",
+		DiscountValue:      10.5,
+		PromotionStartDate: time.Now(),
+		PromotionEndDate:   time.Now().Add(24 * time.Hour),
+		Rules:              schema.PromotionRules{MinSubtotal: 100000, FirstOrderOnly: true},
+		Status:             schema.PromotionStatusPaused,
+		Version:            3,
+	}
+
+	patchRequest := func(body string, ifMatch string) (echo.Context, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(http.MethodPatch, "/promotions/cae8651b", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, requests.MIMEMergePatchJSON)
+		if ifMatch != "" {
+			req.Header.Set(requests.HeaderIfMatch, ifMatch)
+		}
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("promotion_id")
+		c.SetParamValues("cae8651b")
+		return c, rec
+	}
+
+	t.Run("Only patched Fields change", func(t *testing.T) {
+		mockPromoService := new(mocks.MockPromotionService)
+		mockPromoService.On("GetPromotionbyPromotionID", "cae8651b").Return(current, nil)
+
+		var saved schema.Promotion
+		mockPromoService.On("UpdatePromotionbyPromotionID", mock.AnythingOfType("schema.Promotion"), "anonymous").
+			Run(func(args mock.Arguments) { saved = args.Get(0).(schema.Promotion) }).
+			Return(schema.Promotion{PromotionID: "cae8651b", Version: 4}, nil)
+
+		c, rec := patchRequest(`{"promotion_name":"Lebaran Sale","rules":{"first_order_only":null},"promotion_id":"hijacked","ID":99,"status":"active"}`, `"3"`)
+		err := handlers.PSQLPatchPromotionbyPromotionID(mockPromoService)(c)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, `"4"`, rec.Header().Get(requests.HeaderETag))
+
+		assert.Equal(t, "Lebaran Sale", saved.PromotionName)
+		assert.Equal(t, 10.5, saved.DiscountValue)
+		assert.Equal(t, float64(100000), saved.Rules.MinSubtotal)
+		assert.False(t, saved.Rules.FirstOrderOnly)
+		assert.Equal(t, "cae8651b", saved.PromotionID)
+		assert.Equal(t, uint(7), saved.ID)
+		assert.Equal(t, uint(3), saved.Version)
+		assert.Equal(t, schema.PromotionStatusPaused, saved.Status)
+	})
+
+	t.Run("Stale If-Match", func(t *testing.T) {
+		mockPromoService := new(mocks.MockPromotionService)
+		mockPromoService.On("GetPromotionbyPromotionID", "cae8651b").Return(current, nil)
+
+		c, rec := patchRequest(`{"promotion_name":"Lebaran Sale"}`, `"2"`)
+		err := handlers.PSQLPatchPromotionbyPromotionID(mockPromoService)(c)
+		assert.ErrorIs(t, err, exception.ErrPreconditionFailed)
+
+		responses.HTTPErrorHandler(err, c)
+		assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+		assert.Equal(t, `"3"`, rec.Header().Get(requests.HeaderETag))
+		mockPromoService.AssertNotCalled(t, "UpdatePromotionbyPromotionID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Missing If-Match", func(t *testing.T) {
+		mockPromoService := new(mocks.MockPromotionService)
+		mockPromoService.On("GetPromotionbyPromotionID", "cae8651b").Return(current, nil)
+
+		c, _ := patchRequest(`{"promotion_name":"Lebaran Sale"}`, "")
+		err := handlers.PSQLPatchPromotionbyPromotionID(mockPromoService)(c)
+		he, ok := err.(*echo.HTTPError)
+		assert.True(t, ok)
+		assert.Equal(t, http.StatusPreconditionRequired, he.Code)
+		mockPromoService.AssertNotCalled(t, "UpdatePromotionbyPromotionID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Concurrent Write", func(t *testing.T) {
+		mockPromoService := new(mocks.MockPromotionService)
+		mockPromoService.On("GetPromotionbyPromotionID", "cae8651b").Return(current, nil)
+		mockPromoService.On("UpdatePromotionbyPromotionID", mock.AnythingOfType("schema.Promotion"), "anonymous").
+			Return(nil, &exception.PromotionVersionConflictError{Message: "Promotion Was Modified", PromotionID: "cae8651b", CurrentVersion: 4})
+
+		c, rec := patchRequest(`{"promotion_name":"Lebaran Sale"}`, `"3"`)
+		err := handlers.PSQLPatchPromotionbyPromotionID(mockPromoService)(c)
+
+		responses.HTTPErrorHandler(err, c)
+		assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+		assert.Equal(t, `"4"`, rec.Header().Get(requests.HeaderETag))
+		assert.Contains(t, rec.Body.String(), "promotion_version_mismatch")
+	})
+
+	t.Run("Patched Promotion is validated", func(t *testing.T) {
+		mockPromoService := new(mocks.MockPromotionService)
+		mockPromoService.On("GetPromotionbyPromotionID", "cae8651b").Return(current, nil)
+
+		c, _ := patchRequest(`{"promotion_name":null}`, "*")
+		err := handlers.PSQLPatchPromotionbyPromotionID(mockPromoService)(c)
+		he, ok := err.(*echo.HTTPError)
+		assert.True(t, ok)
+		assert.Equal(t, http.StatusUnprocessableEntity, he.Code)
+	})
+}
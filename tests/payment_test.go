@@ -0,0 +1,301 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	schema "smkdevid/echocommercehub/internal/models/schema"
+	"smkdevid/echocommercehub/internal/services/products"
+	"smkdevid/echocommercehub/tests/mocks"
+	"smkdevid/echocommercehub/utils/exception"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestFakeProvider(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Virtual Account Charge is paid and refunded", func(t *testing.T) {
+		fake := products.NewFakeProvider()
+
+		charge, err := fake.CreateCharge(ctx, products.ChargeRequest{
+			PaymentID: "01HP", OrderID: "01HO", Amount: decimal.NewFromInt(186500),
+			Method: schema.PaymentMethodVirtualAccount, Channel: "bca", ExpiresAt: time.Now().Add(time.Hour),
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, schema.PaymentStatusPending, charge.Status)
+		assert.Equal(t, "bca", charge.Instructions.Bank)
+		assert.Len(t, charge.Instructions.VANumber, 14)
+
+		paid, err := fake.SetStatus(charge.Reference, schema.PaymentStatusPaid)
+		assert.NoError(t, err)
+		assert.Equal(t, schema.PaymentStatusPaid, paid.Status)
+		assert.NotNil(t, paid.PaidAt)
+
+		partial, err := fake.Refund(ctx, charge.Reference, decimal.NewFromInt(86500), "item rusak")
+		assert.NoError(t, err)
+		assert.Equal(t, schema.PaymentStatusPaid, partial.Status)
+
+		full, err := fake.Refund(ctx, charge.Reference, decimal.NewFromInt(100000), "item rusak")
+		assert.NoError(t, err)
+		assert.Equal(t, schema.PaymentStatusRefunded, full.Status)
+	})
+
+	t.Run("Pending Charge cannot be captured", func(t *testing.T) {
+		fake := products.NewFakeProvider()
+
+		charge, err := fake.CreateCharge(ctx, products.ChargeRequest{PaymentID: "01HP", Amount: decimal.NewFromInt(1000), Method: schema.PaymentMethodQRIS})
+		assert.NoError(t, err)
+		assert.NotEmpty(t, charge.Instructions.QRString)
+
+		_, err = fake.Capture(ctx, charge.Reference, decimal.NewFromInt(1000))
+		assert.Error(t, err)
+	})
+}
+
+func TestPaymentService(t *testing.T) {
+	ctx := context.Background()
+	order := schema.Order{OrderID: "01HO", CustomerID: "budi", Status: schema.OrderStatusPendingPayment,
+		Currency: "IDR", GrandTotal: decimal.NewFromInt(186500), BillingAddress: shippingAddress}
+
+	newService := func() (*products.PaymentServiceImpl, *mocks.MockPaymentRepository, *mocks.MockOrderRepository, *products.FakeProvider) {
+		mockPaymentRepo := new(mocks.MockPaymentRepository)
+		mockOrderRepo := new(mocks.MockOrderRepository)
+		fake := products.NewFakeProvider()
+		orderService := products.NewOrderService(mockOrderRepo, nil)
+		return products.NewPaymentService(mockPaymentRepo, orderService, fake, time.Hour), mockPaymentRepo, mockOrderRepo, fake
+	}
+
+	t.Run("Payment is charged at the Provider", func(t *testing.T) {
+		paymentService, mockPaymentRepo, mockOrderRepo, _ := newService()
+
+		mockOrderRepo.On("GetOrderbyOrderID", "01HO").Return(order, nil)
+		mockPaymentRepo.On("CreatePayment", mock.MatchedBy(func(p schema.Payment) bool {
+			return p.OrderID == "01HO" && p.Provider == "fake" && p.Amount.Equal(decimal.NewFromInt(186500))
+		})).Return(schema.Payment{PaymentID: "01HP", OrderID: "01HO", Provider: "fake", Method: schema.PaymentMethodVirtualAccount,
+			Channel: "bni", Amount: decimal.NewFromInt(186500), Status: schema.PaymentStatusPending}, nil)
+		mockPaymentRepo.On("UpdatePayment", mock.MatchedBy(func(p schema.Payment) bool {
+			return p.Reference != "" && p.Instructions.VANumber != ""
+		}), schema.PaymentStatusPending).Return(true, nil)
+
+		payment, err := paymentService.CreatePayment(ctx, "budi", "01HO", products.CreatePaymentRequest{Method: schema.PaymentMethodVirtualAccount, Channel: "bni"})
+		assert.NoError(t, err)
+		assert.Equal(t, schema.PaymentStatusPending, payment.Status)
+		assert.Equal(t, "bni", payment.Instructions.Bank)
+		mockOrderRepo.AssertNotCalled(t, "TransitionOrder", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Unknown Channel is rejected", func(t *testing.T) {
+		paymentService, mockPaymentRepo, _, _ := newService()
+
+		_, err := paymentService.CreatePayment(ctx, "budi", "01HO", products.CreatePaymentRequest{Method: schema.PaymentMethodEWallet, Channel: "bca"})
+		assert.ErrorIs(t, err, exception.ErrValidation)
+		mockPaymentRepo.AssertNotCalled(t, "CreatePayment", mock.Anything)
+	})
+
+	t.Run("Paid Outcome pays the Order", func(t *testing.T) {
+		paymentService, mockPaymentRepo, mockOrderRepo, _ := newService()
+
+		pending := schema.Payment{PaymentID: "01HP", OrderID: "01HO", Provider: "fake", Reference: "fake-01HP",
+			Amount: decimal.NewFromInt(186500), Status: schema.PaymentStatusPending}
+		mockPaymentRepo.On("GetPaymentbyReference", "fake", "fake-01HP").Return(pending, nil)
+		mockPaymentRepo.On("UpdatePayment", mock.MatchedBy(func(p schema.Payment) bool {
+			return p.Status == schema.PaymentStatusPaid && p.PaidAt != nil
+		}), schema.PaymentStatusPending).Return(true, nil)
+		mockOrderRepo.On("GetOrderbyOrderID", "01HO").Return(order, nil)
+		mockOrderRepo.On("TransitionOrder", "01HO", schema.OrderStatusPendingPayment, schema.OrderStatusPaid, "payment:fake", "payment 01HP paid").Return(true, nil)
+
+		payment, err := paymentService.RecordOutcome(ctx, "fake-01HP", products.ChargeResult{Status: schema.PaymentStatusPaid})
+		assert.NoError(t, err)
+		assert.Equal(t, schema.PaymentStatusPaid, payment.Status)
+		mockOrderRepo.AssertExpectations(t)
+	})
+
+	t.Run("Late Expiry of a Paid Payment is ignored", func(t *testing.T) {
+		paymentService, mockPaymentRepo, _, _ := newService()
+
+		paid := schema.Payment{PaymentID: "01HP", OrderID: "01HO", Provider: "fake", Reference: "fake-01HP", Status: schema.PaymentStatusPaid}
+		mockPaymentRepo.On("GetPaymentbyReference", "fake", "fake-01HP").Return(paid, nil)
+
+		payment, err := paymentService.RecordOutcome(ctx, "fake-01HP", products.ChargeResult{Status: schema.PaymentStatusExpired})
+		assert.NoError(t, err)
+		assert.Equal(t, schema.PaymentStatusPaid, payment.Status)
+		mockPaymentRepo.AssertNotCalled(t, "UpdatePayment", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Payment settled after the Order was cancelled is refunded", func(t *testing.T) {
+		paymentService, mockPaymentRepo, mockOrderRepo, fake := newService()
+
+		charge, err := fake.CreateCharge(ctx, products.ChargeRequest{PaymentID: "01HP", OrderID: "01HO", Amount: decimal.NewFromInt(186500),
+			Method: schema.PaymentMethodQRIS, ExpiresAt: time.Now().Add(time.Hour)})
+		assert.NoError(t, err)
+		_, err = fake.SetStatus(charge.Reference, schema.PaymentStatusPaid)
+		assert.NoError(t, err)
+
+		pending := schema.Payment{PaymentID: "01HP", OrderID: "01HO", Provider: "fake", Reference: charge.Reference,
+			Amount: decimal.NewFromInt(186500), Status: schema.PaymentStatusPending}
+		paid := pending
+		paid.Status = schema.PaymentStatusPaid
+		cancelled := order
+		cancelled.Status = schema.OrderStatusCancelled
+
+		mockPaymentRepo.On("GetPaymentbyReference", "fake", charge.Reference).Return(pending, nil)
+		mockPaymentRepo.On("UpdatePayment", mock.MatchedBy(func(p schema.Payment) bool {
+			return p.Status == schema.PaymentStatusPaid
+		}), schema.PaymentStatusPending).Return(true, nil)
+		mockOrderRepo.On("GetOrderbyOrderID", "01HO").Return(cancelled, nil)
+		mockPaymentRepo.On("GetPaymentbyPaymentID", "01HP").Return(paid, nil)
+		mockPaymentRepo.On("UpdateRefundedAmount", "01HP", mock.MatchedBy(decimal.Decimal.IsZero), mock.MatchedBy(func(d decimal.Decimal) bool {
+			return d.Equal(decimal.NewFromInt(186500))
+		})).Return(true, nil)
+		mockPaymentRepo.On("UpdatePayment", mock.MatchedBy(func(p schema.Payment) bool {
+			return p.Status == schema.PaymentStatusRefunded && p.RefundedAmount.Equal(decimal.NewFromInt(186500))
+		}), schema.PaymentStatusPaid).Return(true, nil)
+
+		_, err = paymentService.RecordOutcome(ctx, charge.Reference, products.ChargeResult{Status: schema.PaymentStatusPaid})
+		assert.NoError(t, err)
+		mockPaymentRepo.AssertExpectations(t)
+		mockOrderRepo.AssertNotCalled(t, "TransitionOrder", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Cancelling the Order voids its open Payment", func(t *testing.T) {
+		paymentService, mockPaymentRepo, mockOrderRepo, fake := newService()
+
+		charge, err := fake.CreateCharge(ctx, products.ChargeRequest{PaymentID: "01HP", OrderID: "01HO", Amount: decimal.NewFromInt(186500),
+			Method: schema.PaymentMethodQRIS, ExpiresAt: time.Now().Add(time.Hour)})
+		assert.NoError(t, err)
+
+		pending := schema.Payment{PaymentID: "01HP", OrderID: "01HO", Provider: "fake", Reference: charge.Reference,
+			Amount: decimal.NewFromInt(186500), Status: schema.PaymentStatusPending}
+		mockOrderRepo.On("GetOrderbyOrderID", "01HO").Return(order, nil)
+		mockPaymentRepo.On("GetPaymentsbyOrderID", "01HO").Return([]schema.Payment{pending}, nil)
+		mockPaymentRepo.On("UpdatePayment", mock.MatchedBy(func(p schema.Payment) bool {
+			return p.Status == schema.PaymentStatusVoided
+		}), schema.PaymentStatusPending).Return(true, nil)
+		mockOrderRepo.On("TransitionOrder", "01HO", schema.OrderStatusPendingPayment, schema.OrderStatusCancelled, "budi", "berubah pikiran").Return(true, nil).Once()
+
+		cancelled, err := paymentService.CancelCustomerOrder(ctx, "budi", "01HO", products.CancelOrderRequest{Reason: "berubah pikiran"})
+		assert.NoError(t, err)
+		assert.Equal(t, schema.OrderStatusCancelled, cancelled.Status)
+		mockOrderRepo.AssertExpectations(t)
+
+		atProvider, err := fake.QueryStatus(ctx, charge.Reference)
+		assert.NoError(t, err)
+		assert.Equal(t, schema.PaymentStatusVoided, atProvider.Status)
+	})
+
+	t.Run("Order stays open when its Payment cannot be voided", func(t *testing.T) {
+		paymentService, mockPaymentRepo, mockOrderRepo, _ := newService()
+
+		pending := schema.Payment{PaymentID: "01HP", OrderID: "01HO", Provider: "fake", Reference: "fake-unknown",
+			Amount: decimal.NewFromInt(186500), Status: schema.PaymentStatusPending}
+		mockOrderRepo.On("GetOrderbyOrderID", "01HO").Return(order, nil)
+		mockPaymentRepo.On("GetPaymentsbyOrderID", "01HO").Return([]schema.Payment{pending}, nil)
+
+		_, err := paymentService.CancelCustomerOrder(ctx, "budi", "01HO", products.CancelOrderRequest{})
+		assert.ErrorIs(t, err, exception.ErrUpstream)
+		mockOrderRepo.AssertNotCalled(t, "TransitionOrder", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Refund larger than the Payment is rejected", func(t *testing.T) {
+		paymentService, mockPaymentRepo, _, _ := newService()
+
+		paid := schema.Payment{PaymentID: "01HP", OrderID: "01HO", Provider: "fake", Reference: "fake-01HP",
+			Amount: decimal.NewFromInt(186500), RefundedAmount: decimal.NewFromInt(100000), Status: schema.PaymentStatusPaid}
+		mockPaymentRepo.On("GetPaymentbyPaymentID", "01HP").Return(paid, nil)
+
+		amount := decimal.NewFromInt(100000)
+		_, err := paymentService.RefundPayment(ctx, "01HP", products.RefundPaymentRequest{Amount: &amount, Reason: "retur"}, "admin")
+		assert.ErrorIs(t, err, exception.ErrValidation)
+	})
+
+	t.Run("Concurrent Refund is refused before reaching the Provider", func(t *testing.T) {
+		paymentService, mockPaymentRepo, _, _ := newService()
+
+		paid := schema.Payment{PaymentID: "01HP", OrderID: "01HO", Provider: "fake", Reference: "fake-01HP",
+			Amount: decimal.NewFromInt(186500), RefundedAmount: decimal.NewFromInt(100000), Status: schema.PaymentStatusPaid}
+		mockPaymentRepo.On("GetPaymentbyPaymentID", "01HP").Return(paid, nil)
+		mockPaymentRepo.On("UpdateRefundedAmount", "01HP", decimal.NewFromInt(100000), decimal.NewFromInt(150000)).Return(false, nil)
+
+		amount := decimal.NewFromInt(50000)
+		_, err := paymentService.RefundPayment(ctx, "01HP", products.RefundPaymentRequest{Amount: &amount, Reason: "retur"}, "admin")
+		assert.ErrorIs(t, err, exception.ErrConflict)
+		mockPaymentRepo.AssertNotCalled(t, "UpdatePayment", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Refund failing at the Provider releases the reserved Amount", func(t *testing.T) {
+		paymentService, mockPaymentRepo, _, _ := newService()
+
+		paid := schema.Payment{PaymentID: "01HP", OrderID: "01HO", Provider: "fake", Reference: "fake-01HP",
+			Amount: decimal.NewFromInt(186500), RefundedAmount: decimal.NewFromInt(100000), Status: schema.PaymentStatusPaid}
+		mockPaymentRepo.On("GetPaymentbyPaymentID", "01HP").Return(paid, nil)
+		mockPaymentRepo.On("UpdateRefundedAmount", "01HP", decimal.NewFromInt(100000), decimal.NewFromInt(150000)).Return(true, nil)
+		mockPaymentRepo.On("UpdateRefundedAmount", "01HP", decimal.NewFromInt(150000), decimal.NewFromInt(100000)).Return(true, nil)
+
+		amount := decimal.NewFromInt(50000)
+		_, err := paymentService.RefundPayment(ctx, "01HP", products.RefundPaymentRequest{Amount: &amount, Reason: "retur"}, "admin")
+		assert.Error(t, err)
+		mockPaymentRepo.AssertExpectations(t)
+		mockPaymentRepo.AssertNotCalled(t, "UpdatePayment", mock.Anything, mock.Anything)
+	})
+}
+
+func TestMidtransProvider(t *testing.T) {
+	t.Run("Bank Transfer Charge", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, _, _ := r.BasicAuth()
+			assert.Equal(t, "SB-Mid-server-key", user)
+			assert.Equal(t, "/v2/charge", r.URL.Path)
+
+			var body map[string]interface{}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, "bank_transfer", body["payment_type"])
+			assert.Equal(t, float64(186500), body["transaction_details"].(map[string]interface{})["gross_amount"])
+
+			w.Write([]byte(`{"status_code":"201","transaction_id":"9aed5972","transaction_status":"pending",
+				"va_numbers":[{"bank":"bca","va_number":"812785002530231"}],"expiry_time":"2026-10-19 10:00:00"}`))
+		}))
+		defer server.Close()
+
+		provider := products.NewMidtransProvider("SB-Mid-server-key", server.URL, "", time.Second)
+		charge, err := provider.CreateCharge(context.Background(), products.ChargeRequest{
+			PaymentID: "01HP", Amount: decimal.NewFromInt(186500), Method: schema.PaymentMethodVirtualAccount, Channel: "bca",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "9aed5972", charge.Reference)
+		assert.Equal(t, schema.PaymentStatusPending, charge.Status)
+		assert.Equal(t, "812785002530231", charge.Instructions.VANumber)
+		assert.NotNil(t, charge.ExpiresAt)
+	})
+
+	t.Run("Settlement is Paid", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/v2/9aed5972/status", r.URL.Path)
+			w.Write([]byte(`{"status_code":"200","transaction_id":"9aed5972","transaction_status":"settlement","settlement_time":"2026-10-18 10:00:00"}`))
+		}))
+		defer server.Close()
+
+		provider := products.NewMidtransProvider("SB-Mid-server-key", server.URL, "", time.Second)
+		charge, err := provider.QueryStatus(context.Background(), "9aed5972")
+		assert.NoError(t, err)
+		assert.Equal(t, schema.PaymentStatusPaid, charge.Status)
+		assert.NotNil(t, charge.PaidAt)
+	})
+
+	t.Run("Rejected Request is an Error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"status_code":"401","status_message":"Access denied"}`))
+		}))
+		defer server.Close()
+
+		provider := products.NewMidtransProvider("wrong", server.URL, "", time.Second)
+		_, err := provider.QueryStatus(context.Background(), "9aed5972")
+		assert.ErrorContains(t, err, "Access denied")
+	})
+}
@@ -0,0 +1,135 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"smkdevid/echocommercehub/internal/app/handlers"
+	postgresql "smkdevid/echocommercehub/internal/databases/postgresql"
+	schema "smkdevid/echocommercehub/internal/models/schema"
+	"smkdevid/echocommercehub/internal/services/products"
+	"smkdevid/echocommercehub/tests/mocks"
+	"smkdevid/echocommercehub/utils/exception"
+	"smkdevid/echocommercehub/utils/requests"
+	"smkdevid/echocommercehub/utils/responses"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreateProduct(t *testing.T) {
+	t.Run("Product ID is generated and the Product starts as Draft", func(t *testing.T) {
+		mockProductRepo := new(mocks.MockProductRepository)
+
+		productService := products.NewProductService(mockProductRepo)
+
+		product := schema.Product{
+			ProductID:    "client-id",
+			ProductName:  "Kopi Gayo 250g",
+			ProductPrice: decimal.NewFromInt(85000),
+			Brand:        "Gayo",
+		}
+
+		mockProductRepo.On("CreateProduct", mock.MatchedBy(func(p schema.Product) bool {
+			return len(p.ProductID) == 26 && p.Status == schema.ProductStatusDraft && p.Images != nil
+		})).Return(product, nil)
+
+		_, err := productService.CreateProduct(product)
+		assert.NoError(t, err)
+		mockProductRepo.AssertExpectations(t)
+	})
+}
+
+func TestGetProduct(t *testing.T) {
+	t.Run("Product Not Found", func(t *testing.T) {
+		mockProductRepo := new(mocks.MockProductRepository)
+
+		productService := products.NewProductService(mockProductRepo)
+
+		notFound := &exception.ProductIDNotFoundError{Message: "Product Not Found", ProductID: "missing"}
+		mockProductRepo.On("GetProductbyProductID", "missing").Return(schema.Product{}, notFound)
+
+		_, err := productService.GetProductbyProductID("missing")
+		assert.ErrorIs(t, err, exception.ErrNotFound)
+		assert.Equal(t, "product_not_found", exception.CodeOf(err))
+	})
+}
+
+func TestListProducts(t *testing.T) {
+	newContext := func(target string) (echo.Context, *httptest.ResponseRecorder) {
+		e := echo.New()
+		rec := httptest.NewRecorder()
+		return e.NewContext(httptest.NewRequest(http.MethodGet, target, nil), rec), rec
+	}
+
+	t.Run("Filters are passed to the Repository", func(t *testing.T) {
+		mockProductRepo := new(mocks.MockProductRepository)
+
+		productService := products.NewProductService(mockProductRepo)
+
+		minPrice, maxPrice := decimal.NewFromInt(50000), decimal.NewFromInt(100000)
+		expectedQuery := postgresql.ProductQuery{
+			Status:     schema.ProductStatusActive,
+			CategoryID: "coffee",
+			Brand:      "Gayo",
+			Name:       "kopi",
+			MinPrice:   &minPrice,
+			MaxPrice:   &maxPrice,
+			SortBy:     "product_price",
+			SortOrder:  "asc",
+			Limit:      10,
+		}
+		mockProductRepo.On("ListProducts", expectedQuery).Return(postgresql.ProductList{
+			Products: []schema.Product{{ProductID: "01HV", ProductName: "Kopi Gayo 250g"}},
+			Total:    1,
+		}, nil)
+
+		c, rec := newContext("/api/v1/products?status=active&category_id=coffee&brand=Gayo&q=kopi&min_price=50000&max_price=100000&sort=product_price&order=asc&limit=10")
+		assert.NoError(t, handlers.PSQLListProducts(productService)(c))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"total":1`)
+		mockProductRepo.AssertExpectations(t)
+	})
+
+	t.Run("Invalid Query Parameters", func(t *testing.T) {
+		productService := products.NewProductService(new(mocks.MockProductRepository))
+
+		for _, target := range []string{
+			"/api/v1/products?status=sold_out",
+			"/api/v1/products?min_price=cheap",
+			"/api/v1/products?sort=stock",
+			"/api/v1/products?min_price=100&max_price=10",
+		} {
+			c, rec := newContext(target)
+			responses.HTTPErrorHandler(handlers.PSQLListProducts(productService)(c), c)
+			assert.Equal(t, http.StatusBadRequest, rec.Code, target)
+		}
+	})
+}
+
+func TestProductValidation(t *testing.T) {
+	validator := requests.NewValidator()
+	products.RegisterValidationRules(validator)
+
+	e := echo.New()
+	e.Validator = validator
+
+	body := `{"product_name":"","product_price":-1,"images":["not a url"],"status":"sold_out"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/products", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	var product schema.Product
+	err := requests.BindAndValidate(c, &product)
+	responses.HTTPErrorHandler(err, c)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	for _, field := range []string{"product_name", "product_price", "images[0]", "status"} {
+		assert.Contains(t, rec.Body.String(), `"field":"`+field+`"`)
+	}
+}
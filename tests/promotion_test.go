@@ -5,9 +5,11 @@ import (
 	"testing"
 	"time"
 
+	postgresql "smkdevid/echocommercehub/internal/databases/postgresql"
 	schema "smkdevid/echocommercehub/internal/models/schema"
 	"smkdevid/echocommercehub/internal/services/promotions"
 	"smkdevid/echocommercehub/tests/mocks"
+	"smkdevid/echocommercehub/utils/exception"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -17,10 +19,7 @@ func TestCreatePromotion(t *testing.T) {
 	t.Run("Successful Promotion Created", func(t *testing.T) {
 		mockPromotionRepo := new(mocks.MockPromotionRepository)
 
-		userService := promotions.NewPromotionService(
-			&promotions.PromotionServiceImpl{
-				PromotionRepo: mockPromotionRepo,
-			})
+		userService := promotions.NewPromotionService(mockPromotionRepo)
 
 		expectedPromotion := schema.Promotion{
 			PromotionID:        "cae8651b",
@@ -31,9 +30,9 @@ func TestCreatePromotion(t *testing.T) {
 			PromotionEndDate:   time.Now().Add(24 * time.Hour),
 		}
 
-		mockPromotionRepo.On("CreatePromotion", mock.AnythingOfType("schema.Promotion")).Return(expectedPromotion, nil)
+		mockPromotionRepo.On("CreatePromotion", mock.AnythingOfType("schema.Promotion"), "admin").Return(expectedPromotion, nil)
 
-		results, err := userService.CreatePromotion(expectedPromotion)
+		results, err := userService.CreatePromotion(expectedPromotion, "admin")
 		assert.NoError(t, err)
 		assert.NotNil(t, results)
 		assert.Equal(t, expectedPromotion, results)
@@ -43,10 +42,7 @@ func TestCreatePromotion(t *testing.T) {
 	t.Run("Error on Promotion Creation", func(t *testing.T) {
 		mockPromotionRepo := new(mocks.MockPromotionRepository)
 
-		userService := promotions.NewPromotionService(
-			&promotions.PromotionServiceImpl{
-				PromotionRepo: mockPromotionRepo,
-			})
+		userService := promotions.NewPromotionService(mockPromotionRepo)
 
 		expectedPromotion := schema.Promotion{
 			PromotionID:        "cae8651b",
@@ -58,9 +54,9 @@ func TestCreatePromotion(t *testing.T) {
 		}
 
 		expectedErr := errors.New("failed to create promotion")
-		mockPromotionRepo.On("CreatePromotion", mock.AnythingOfType("schema.Promotion")).Return(schema.Promotion{}, expectedErr)
+		mockPromotionRepo.On("CreatePromotion", mock.AnythingOfType("schema.Promotion"), "admin").Return(schema.Promotion{}, expectedErr)
 
-		results, err := userService.CreatePromotion(expectedPromotion)
+		results, err := userService.CreatePromotion(expectedPromotion, "admin")
 		assert.Error(t, expectedErr)
 		assert.NotNil(t, results)
 		assert.Equal(t, expectedErr, err)
@@ -68,15 +64,58 @@ func TestCreatePromotion(t *testing.T) {
 	})
 }
 
+func TestCreatePromotionGeneratesID(t *testing.T) {
+	t.Run("Client Promotion ID is replaced", func(t *testing.T) {
+		mockPromotionRepo := new(mocks.MockPromotionRepository)
+
+		userService := promotions.NewPromotionService(mockPromotionRepo)
+
+		promo := schema.Promotion{
+			PromotionID:        "cae8651b",
+			PromotionName:      "Ramadhan Sale",
+			DiscountType:       "percentage",
+			DiscountValue:      10.5,
+			PromotionStartDate: time.Now(),
+			PromotionEndDate:   time.Now().Add(24 * time.Hour),
+		}
+
+		mockPromotionRepo.On("CreatePromotion", mock.MatchedBy(func(p schema.Promotion) bool {
+			return len(p.PromotionID) == 26 && p.PromotionID != "cae8651b"
+		}), "admin").Return(promo, nil)
+
+		_, err := userService.CreatePromotion(promo, "admin")
+		assert.NoError(t, err)
+		mockPromotionRepo.AssertExpectations(t)
+	})
+
+	t.Run("Generated IDs are unique", func(t *testing.T) {
+		seen := make(map[string]bool)
+		for i := 0; i < 1000; i++ {
+			id := promotions.NewPromotionID()
+			assert.False(t, seen[id])
+			seen[id] = true
+		}
+	})
+
+	t.Run("Conflict on Promotion ID", func(t *testing.T) {
+		mockPromotionRepo := new(mocks.MockPromotionRepository)
+
+		userService := promotions.NewPromotionService(mockPromotionRepo)
+
+		expectedErr := &exception.PromotionIDConflictError{Message: "Promotion Already Exists"}
+		mockPromotionRepo.On("CreatePromotion", mock.AnythingOfType("schema.Promotion"), "admin").Return(schema.Promotion{}, expectedErr)
+
+		_, err := userService.CreatePromotion(schema.Promotion{PromotionName: "Ramadhan Sale"}, "admin")
+		assert.Equal(t, expectedErr, err)
+	})
+}
+
 func TestPSQLGetAllPromotionData(t *testing.T) {
 	t.Run("Successful Retrieval of Promotions", func(t *testing.T) {
 		// Set up mocks
 		mockPromotionRepo := new(mocks.MockPromotionRepository)
 
-		userService := promotions.NewPromotionService(
-			&promotions.PromotionServiceImpl{
-				PromotionRepo: mockPromotionRepo,
-			})
+		userService := promotions.NewPromotionService(mockPromotionRepo)
 
 		expectedPromotion := []schema.Promotion{
 			{
@@ -102,10 +141,7 @@ func TestPSQLGetAllPromotionData(t *testing.T) {
 		// Set up mocks
 		mockPromotionRepo := new(mocks.MockPromotionRepository)
 
-		userService := promotions.NewPromotionService(
-			&promotions.PromotionServiceImpl{
-				PromotionRepo: mockPromotionRepo,
-			})
+		userService := promotions.NewPromotionService(mockPromotionRepo)
 
 		expectedErr := errors.New("Failed to Get Promotions")
 		mockPromotionRepo.On("GetAllPromotions").Return([]schema.Promotion{}, expectedErr)
@@ -126,10 +162,7 @@ func TestPSQLGetPromotionbyPromotionID(t *testing.T) {
 		// Set up mocks
 		mockPromotionRepo := new(mocks.MockPromotionRepository)
 
-		userService := promotions.NewPromotionService(
-			&promotions.PromotionServiceImpl{
-				PromotionRepo: mockPromotionRepo,
-			})
+		userService := promotions.NewPromotionService(mockPromotionRepo)
 
 		expectedPromotion := schema.Promotion{
 			PromotionID:        "cae8651b",
@@ -152,10 +185,7 @@ func TestPSQLGetPromotionbyPromotionID(t *testing.T) {
 	t.Run("Promotion not found", func(t *testing.T) {
 		mockPromotionRepo := new(mocks.MockPromotionRepository)
 
-		userService := promotions.NewPromotionService(
-			&promotions.PromotionServiceImpl{
-				PromotionRepo: mockPromotionRepo,
-			})
+		userService := promotions.NewPromotionService(mockPromotionRepo)
 
 		expectedErr := errors.New("Promotion not Found")
 		mockPromotionRepo.On("GetPromotionbyPromotionID", "cb7360g6").Return(schema.Promotion{}, expectedErr)
@@ -176,10 +206,7 @@ func TestPSQLUpdatePromotionbyPromotionID(t *testing.T) {
 		// Set up mocks
 		mockPromotionRepo := new(mocks.MockPromotionRepository)
 
-		userService := promotions.NewPromotionService(
-			&promotions.PromotionServiceImpl{
-				PromotionRepo: mockPromotionRepo,
-			})
+		userService := promotions.NewPromotionService(mockPromotionRepo)
 
 		existingPromo := schema.Promotion{
 			PromotionID:        "cae8651b",
@@ -195,9 +222,9 @@ func TestPSQLUpdatePromotionbyPromotionID(t *testing.T) {
 		updatedPromo.DiscountType = "fixed"
 		updatedPromo.DiscountValue = 15.0
 
-		mockPromotionRepo.On("UpdatePromotionbyPromotionID", updatedPromo).Return(updatedPromo, nil)
+		mockPromotionRepo.On("UpdatePromotionbyPromotionID", updatedPromo, "admin").Return(updatedPromo, nil)
 
-		results, err := userService.UpdatePromotionbyPromotionID(updatedPromo)
+		results, err := userService.UpdatePromotionbyPromotionID(updatedPromo, "admin")
 		assert.NoError(t, err)
 		assert.NotNil(t, results)
 		assert.Equal(t, updatedPromo, results)
@@ -208,10 +235,7 @@ func TestPSQLUpdatePromotionbyPromotionID(t *testing.T) {
 		// ... (set up mocks for error case)
 		mockPromotionRepo := new(mocks.MockPromotionRepository)
 
-		userService := promotions.NewPromotionService(
-			&promotions.PromotionServiceImpl{
-				PromotionRepo: mockPromotionRepo,
-			})
+		userService := promotions.NewPromotionService(mockPromotionRepo)
 
 		existingPromo := schema.Promotion{
 			PromotionID:        "cae8651b",
@@ -228,9 +252,9 @@ func TestPSQLUpdatePromotionbyPromotionID(t *testing.T) {
 		updatedPromo.DiscountValue = 15.0
 
 		expectedErr := errors.New("Failed to Update Promotion")
-		mockPromotionRepo.On("UpdatePromotionbyPromotionID", updatedPromo).Return(schema.Promotion{}, expectedErr)
+		mockPromotionRepo.On("UpdatePromotionbyPromotionID", updatedPromo, "admin").Return(schema.Promotion{}, expectedErr)
 
-		results, err := userService.UpdatePromotionbyPromotionID(updatedPromo)
+		results, err := userService.UpdatePromotionbyPromotionID(updatedPromo, "admin")
 		assert.Error(t, err)
 		assert.Empty(t, results)
 		assert.Equal(t, expectedErr, err)
@@ -244,15 +268,12 @@ func TestPSQLDeletePromotionbyPromotionID(t *testing.T) {
 		// Set up mocks
 		mockPromotionRepo := new(mocks.MockPromotionRepository)
 
-		userService := promotions.NewPromotionService(
-			&promotions.PromotionServiceImpl{
-				PromotionRepo: mockPromotionRepo,
-			})
+		userService := promotions.NewPromotionService(mockPromotionRepo)
 
-		mockPromotionRepo.On("DeletePromotionbyPromotionID", "cb7360g6").Return(nil)
+		mockPromotionRepo.On("DeletePromotionbyPromotionID", "cb7360g6", "admin").Return(nil)
 
 		// Assert response
-		results := userService.DeletePromotionbyPromotionID("cb7360g6")
+		results := userService.DeletePromotionbyPromotionID("cb7360g6", "admin")
 		assert.NoError(t, results)
 		mockPromotionRepo.AssertExpectations(t)
 	})
@@ -261,17 +282,128 @@ func TestPSQLDeletePromotionbyPromotionID(t *testing.T) {
 		// Set up mocks
 		mockPromotionRepo := new(mocks.MockPromotionRepository)
 
-		userService := promotions.NewPromotionService(
-			&promotions.PromotionServiceImpl{
-				PromotionRepo: mockPromotionRepo,
-			})
+		userService := promotions.NewPromotionService(mockPromotionRepo)
 
 		expectedErr := errors.New("Promotion Not Found")
-		mockPromotionRepo.On("DeletePromotionbyPromotionID", "cb7360g6").Return(expectedErr)
+		mockPromotionRepo.On("DeletePromotionbyPromotionID", "cb7360g6", "admin").Return(expectedErr)
 
 		// Assert response
-		results := userService.DeletePromotionbyPromotionID("cb7360g6")
+		results := userService.DeletePromotionbyPromotionID("cb7360g6", "admin")
 		assert.Error(t, results)
 		mockPromotionRepo.AssertExpectations(t)
 	})
 }
+
+func TestPSQLRestorePromotionbyPromotionID(t *testing.T) {
+	t.Run("Successful Restore of Promotion", func(t *testing.T) {
+		mockPromotionRepo := new(mocks.MockPromotionRepository)
+
+		userService := promotions.NewPromotionService(mockPromotionRepo)
+
+		restoredPromo := schema.Promotion{
+			PromotionID:   "cb7360g6",
+			PromotionName: "Ramadhan Sale",
+		}
+		mockPromotionRepo.On("RestorePromotionbyPromotionID", "cb7360g6", "admin").Return(restoredPromo, nil)
+
+		results, err := userService.RestorePromotionbyPromotionID("cb7360g6", "admin")
+		assert.NoError(t, err)
+		assert.Equal(t, restoredPromo, results)
+		mockPromotionRepo.AssertExpectations(t)
+	})
+
+	t.Run("Promotion is not deleted", func(t *testing.T) {
+		mockPromotionRepo := new(mocks.MockPromotionRepository)
+
+		userService := promotions.NewPromotionService(mockPromotionRepo)
+
+		expectedErr := &exception.PromotionIDNotFoundError{Message: "Promotion Not Found", PromotionID: "cb7360g6"}
+		mockPromotionRepo.On("RestorePromotionbyPromotionID", "cb7360g6", "admin").Return(schema.Promotion{}, expectedErr)
+
+		_, err := userService.RestorePromotionbyPromotionID("cb7360g6", "admin")
+		assert.Equal(t, expectedErr, err)
+		mockPromotionRepo.AssertExpectations(t)
+	})
+}
+
+func TestListPromotions(t *testing.T) {
+	now := time.Now()
+	scheduledPromo := schema.Promotion{
+		ID:                 2,
+		PromotionID:        "cae8651b",
+		PromotionStartDate: now.Add(-time.Hour),
+		PromotionEndDate:   now.Add(time.Hour),
+		Status:             schema.PromotionStatusScheduled,
+	}
+
+	t.Run("Page with Defaults and refreshed Status", func(t *testing.T) {
+		mockPromotionRepo := new(mocks.MockPromotionRepository)
+
+		userService := promotions.NewPromotionService(mockPromotionRepo)
+
+		expectedQuery := postgresql.PromotionQuery{
+			IncludeDeleted: true,
+			SortBy:         "created_at",
+			SortOrder:      postgresql.SortDescending,
+			Limit:          postgresql.DefaultPromotionLimit,
+		}
+		mockPromotionRepo.On("ListPromotions", expectedQuery).Return(postgresql.PromotionList{
+			Promotions: []schema.Promotion{scheduledPromo},
+			Total:      41,
+			NextCursor: "next",
+		}, nil)
+
+		page, err := userService.ListPromotions(postgresql.PromotionQuery{IncludeDeleted: true})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(41), page.Total)
+		assert.Equal(t, "next", page.NextCursor)
+		assert.Equal(t, postgresql.DefaultPromotionLimit, page.Limit)
+		assert.Equal(t, schema.PromotionStatusActive, page.Data[0].Status)
+		mockPromotionRepo.AssertExpectations(t)
+	})
+
+	t.Run("Invalid Query", func(t *testing.T) {
+		mockPromotionRepo := new(mocks.MockPromotionRepository)
+
+		userService := promotions.NewPromotionService(mockPromotionRepo)
+
+		invalidQueries := []postgresql.PromotionQuery{
+			{SortBy: "promotion_name"},
+			{SortOrder: "sideways"},
+			{Limit: postgresql.MaxPromotionLimit + 1},
+			{Offset: 20, Cursor: "next"},
+			{ActiveFrom: &now, ActiveTo: &scheduledPromo.PromotionStartDate},
+		}
+		for _, query := range invalidQueries {
+			_, err := userService.ListPromotions(query)
+			assert.IsType(t, &exception.ValidationError{}, err)
+		}
+		mockPromotionRepo.AssertNotCalled(t, "ListPromotions", mock.Anything)
+	})
+}
+
+func TestGetPromotionHistory(t *testing.T) {
+	t.Run("History lists every Change", func(t *testing.T) {
+		mockPromotionRepo := new(mocks.MockPromotionRepository)
+
+		userService := promotions.NewPromotionService(mockPromotionRepo)
+
+		before := schema.Promotion{PromotionID: "cae8651b", PromotionName: "Ramadhan Sale"}
+		after := before
+		after.PromotionName = "Lebaran Sale"
+
+		history := []schema.PromotionHistory{
+			{PromotionID: "cae8651b", Action: schema.PromotionActionCreate, Actor: "admin", After: &before},
+			{PromotionID: "cae8651b", Action: schema.PromotionActionUpdate, Actor: "admin", Before: &before, After: &after},
+			{PromotionID: "cae8651b", Action: schema.PromotionActionDelete, Actor: "admin", Before: &after},
+		}
+		mockPromotionRepo.On("GetPromotionHistory", "cae8651b").Return(history, nil)
+
+		results, err := userService.GetPromotionHistory("cae8651b")
+		assert.NoError(t, err)
+		assert.Len(t, results, 3)
+		assert.Equal(t, "Lebaran Sale", results[1].After.PromotionName)
+		assert.Nil(t, results[2].After)
+		mockPromotionRepo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"smkdevid/echocommercehub/utils/exception"
+	"smkdevid/echocommercehub/utils/requests"
+	"smkdevid/echocommercehub/utils/responses"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func envelopeOf(t *testing.T, rec *httptest.ResponseRecorder) map[string]interface{} {
+	var body map[string]interface{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	return body
+}
+
+func TestResponseEnvelope(t *testing.T) {
+	e := echo.New()
+
+	newContext := func() (echo.Context, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/promotions", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.Response().Header().Set(echo.HeaderXRequestID, "req-1")
+		return c, rec
+	}
+
+	t.Run("Data", func(t *testing.T) {
+		c, rec := newContext()
+
+		err := responses.JSON(c, http.StatusOK, map[string]string{"promotion_id": "cae8651b"})
+		assert.NoError(t, err)
+
+		body := envelopeOf(t, rec)
+		assert.Equal(t, map[string]interface{}{"promotion_id": "cae8651b"}, body["data"])
+		assert.Equal(t, "req-1", body["request_id"])
+		assert.NotContains(t, body, "errors")
+		assert.NotContains(t, body, "meta")
+	})
+
+	t.Run("Paginated Data", func(t *testing.T) {
+		c, rec := newContext()
+
+		err := responses.Paginated(c, []string{"cae8651b"}, responses.Pagination{Total: 41, Limit: 1, NextCursor: "next"})
+		assert.NoError(t, err)
+
+		body := envelopeOf(t, rec)
+		pagination := body["meta"].(map[string]interface{})["pagination"].(map[string]interface{})
+		assert.Equal(t, float64(41), pagination["total"])
+		assert.Equal(t, "next", pagination["next_cursor"])
+	})
+
+	t.Run("HTTP Error", func(t *testing.T) {
+		c, rec := newContext()
+
+		responses.HTTPErrorHandler(echo.NewHTTPError(http.StatusNotFound, "Promotion Not Found with Promotion ID cae8651b"), c)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		body := envelopeOf(t, rec)
+		assert.Nil(t, body["data"])
+		assert.Equal(t, "req-1", body["request_id"])
+		assert.Equal(t, []interface{}{map[string]interface{}{
+			"code":    responses.CodeNotFound,
+			"message": "Promotion Not Found with Promotion ID cae8651b",
+		}}, body["errors"])
+	})
+
+	t.Run("Validation Error", func(t *testing.T) {
+		c, rec := newContext()
+
+		responses.HTTPErrorHandler(requests.ValidationHTTPError(&exception.ValidationError{
+			Message: "Validation Failed",
+			Fields: []exception.FieldError{
+				{Field: "promotion_name", Rule: "required", Message: "is required"},
+				{Field: "discount_value", Rule: "gte", Message: "must be greater than or equal to 0"},
+			},
+		}), c)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		errs := envelopeOf(t, rec)["errors"].([]interface{})
+		assert.Len(t, errs, 2)
+		assert.Equal(t, map[string]interface{}{
+			"code":    responses.CodeValidationFailed,
+			"message": "is required",
+			"field":   "promotion_name",
+		}, errs[0])
+	})
+
+	t.Run("Unexpected Error is hidden", func(t *testing.T) {
+		c, rec := newContext()
+
+		responses.HTTPErrorHandler(errors.New("pq: connection refused"), c)
+
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.NotContains(t, rec.Body.String(), "connection refused")
+		assert.Contains(t, rec.Body.String(), responses.CodeInternalError)
+	})
+}
@@ -0,0 +1,263 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"smkdevid/echocommercehub/internal/app/middlewares"
+	schema "smkdevid/echocommercehub/internal/models/schema"
+	"smkdevid/echocommercehub/internal/services/products"
+	"smkdevid/echocommercehub/internal/services/promotions"
+	"smkdevid/echocommercehub/internal/transports/delivery"
+	"smkdevid/echocommercehub/tests/mocks"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func noopMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return next
+}
+
+func TestPromotionRoutes(t *testing.T) {
+	sunset := time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC)
+	adminToken := "0123456789abcdef0123456789abcdef"
+
+	newServer := func(mockPromoService *mocks.MockPromotionService, groupHook echo.MiddlewareFunc) *echo.Echo {
+		e := echo.New()
+		api := delivery.APIRoute(e)
+		delivery.PromotionRoute(api, mockPromoService, noopMiddleware, middlewares.AdminToken(adminToken), groupHook)
+		delivery.LegacyPromotionRoute(e, mockPromoService, noopMiddleware, middlewares.AdminToken(adminToken), sunset)
+		return e
+	}
+
+	promo := schema.Promotion{PromotionID: "cae8651b", PromotionName: "Ramadhan Sale", Version: 1}
+
+	t.Run("Versioned Resource Route", func(t *testing.T) {
+		mockPromoService := new(mocks.MockPromotionService)
+		mockPromoService.On("GetPromotionbyPromotionID", "cae8651b").Return(promo, nil)
+
+		hooked := false
+		e := newServer(mockPromoService, func(next echo.HandlerFunc) echo.HandlerFunc {
+			return func(c echo.Context) error {
+				hooked = true
+				return next(c)
+			}
+		})
+
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/promotions/cae8651b", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.True(t, hooked)
+		assert.Empty(t, rec.Header().Get("Deprecation"))
+		mockPromoService.AssertExpectations(t)
+	})
+
+	t.Run("Legacy Route is deprecated", func(t *testing.T) {
+		mockPromoService := new(mocks.MockPromotionService)
+		mockPromoService.On("GetPromotionbyPromotionID", "cae8651b").Return(promo, nil)
+
+		e := newServer(mockPromoService, noopMiddleware)
+
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/getpromotion/cae8651b", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "true", rec.Header().Get("Deprecation"))
+		assert.Equal(t, "Fri, 30 Apr 2027 00:00:00 GMT", rec.Header().Get("Sunset"))
+		assert.Equal(t, `</api/v1/promotions/cae8651b>; rel="successor-version"`, rec.Header().Get("Link"))
+	})
+
+	t.Run("Changing a Promotion requires the Admin Token", func(t *testing.T) {
+		mockPromoService := new(mocks.MockPromotionService)
+		e := newServer(mockPromoService, noopMiddleware)
+
+		for _, path := range []string{"/api/v1/promotions/cae8651b/pause", "/promotions/cae8651b/pause"} {
+			req := httptest.NewRequest(http.MethodPost, path, nil)
+			req.Header.Set("X-Actor", "budi")
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		}
+		mockPromoService.AssertNotCalled(t, "PausePromotion", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Admin is recorded as the Actor", func(t *testing.T) {
+		mockPromoService := new(mocks.MockPromotionService)
+		mockPromoService.On("PausePromotion", "cae8651b", middlewares.AdminPrincipal).Return(promo, nil)
+		e := newServer(mockPromoService, noopMiddleware)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/promotions/cae8651b/pause", nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+adminToken)
+		req.Header.Set("X-Actor", "budi")
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		mockPromoService.AssertExpectations(t)
+	})
+}
+
+func TestCouponRoutes(t *testing.T) {
+	adminToken := "0123456789abcdef0123456789abcdef"
+	sunset := time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC)
+
+	mockCouponRepo := new(mocks.MockCouponRepository)
+	mockCouponRepo.On("GetCouponsbyPromotionID", "cae8651b").Return([]schema.Coupon{{CouponCode: "RAMADHAN-AB2C"}}, nil)
+	couponService := promotions.NewCouponService(mockCouponRepo, new(mocks.MockPromotionRepository))
+
+	e := echo.New()
+	delivery.CouponRoute(delivery.APIRoute(e), couponService, middlewares.AdminToken(adminToken))
+	delivery.LegacyCouponRoute(e, couponService, middlewares.AdminToken(adminToken), sunset)
+
+	t.Run("Coupons are generated, listed and redeemed by the Admin only", func(t *testing.T) {
+		for _, route := range [][2]string{
+			{http.MethodPost, "/api/v1/promotions/cae8651b/coupons"},
+			{http.MethodGet, "/api/v1/promotions/cae8651b/coupons"},
+			{http.MethodPost, "/api/v1/coupons/RAMADHAN-AB2C/redeem"},
+			{http.MethodPost, "/promotions/cae8651b/coupons"},
+			{http.MethodGet, "/promotions/cae8651b/coupons"},
+			{http.MethodPost, "/coupons/RAMADHAN-AB2C/redeem"},
+		} {
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(route[0], route[1], nil))
+			assert.Equal(t, http.StatusUnauthorized, rec.Code, route[1])
+		}
+		mockCouponRepo.AssertNotCalled(t, "GetCouponsbyPromotionID", "cae8651b")
+	})
+
+	t.Run("Admin lists the Coupons", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/promotions/cae8651b/coupons", nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+adminToken)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "RAMADHAN-AB2C")
+	})
+}
+
+func TestCustomerSession(t *testing.T) {
+	secret := "super-secret-jwt-token-with-at-least-32-characters"
+	order := schema.Order{OrderID: "01HO", CustomerID: "budi"}
+
+	newServer := func(trustHeader bool) *echo.Echo {
+		mockOrderRepo := new(mocks.MockOrderRepository)
+		mockOrderRepo.On("GetOrderbyOrderID", "01HO").Return(order, nil)
+		e := echo.New()
+		api := delivery.APIRoute(e)
+		delivery.OrderRoute(api, products.NewOrderService(mockOrderRepo, nil), nil, noopMiddleware, middlewares.CustomerSession(secret, trustHeader))
+		return e
+	}
+	sign := func(key string, claims jwt.StandardClaims) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(key))
+		assert.NoError(t, err)
+		return token
+	}
+	valid := jwt.StandardClaims{Subject: "budi", ExpiresAt: time.Now().Add(time.Hour).Unix()}
+
+	t.Run("Signed Token identifies the Customer", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/orders/01HO", nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+sign(secret, valid))
+		newServer(false).ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("Forged, expired or unsigned Tokens are refused", func(t *testing.T) {
+		expired := jwt.StandardClaims{Subject: "budi", ExpiresAt: time.Now().Add(-time.Minute).Unix()}
+		unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, valid).SignedString(jwt.UnsafeAllowNoneSignatureType)
+		assert.NoError(t, err)
+
+		for _, token := range []string{sign("another-secret", valid), sign(secret, expired), sign(secret, jwt.StandardClaims{Subject: "budi"}), unsigned} {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/orders/01HO", nil)
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+			newServer(false).ServeHTTP(rec, req)
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Contains(t, rec.Header().Get(echo.HeaderWWWAuthenticate), "Bearer")
+		}
+	})
+
+	t.Run("Customer Header is ignored unless trusted", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/orders/01HO", nil)
+		req.Header.Set(middlewares.HeaderCustomer, "budi")
+		newServer(false).ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+		rec = httptest.NewRecorder()
+		newServer(true).ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("Segment comes from the App Metadata of the Token", func(t *testing.T) {
+		claims := jwt.MapClaims{"sub": "budi", "exp": time.Now().Add(time.Hour).Unix(), "app_metadata": map[string]string{"segment": "gold"}}
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+		assert.NoError(t, err)
+
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		req.Header.Set(middlewares.HeaderCustomerSegment, "platinum")
+		c := e.NewContext(req, httptest.NewRecorder())
+
+		err = middlewares.CustomerSession(secret, true)(func(c echo.Context) error {
+			assert.Equal(t, "budi", middlewares.CustomerID(c))
+			assert.Equal(t, "gold", middlewares.CustomerSegment(c))
+			return nil
+		})(c)
+		assert.NoError(t, err)
+	})
+}
+
+func TestAdminRoutes(t *testing.T) {
+	token := "0123456789abcdef0123456789abcdef"
+
+	newServer := func(token string) (*echo.Echo, *mocks.MockOrderRepository) {
+		mockOrderRepo := new(mocks.MockOrderRepository)
+		e := echo.New()
+		api := delivery.APIRoute(e)
+		delivery.AdminOrderRoute(api, products.NewOrderService(mockOrderRepo, nil), middlewares.AdminToken(token))
+		return e, mockOrderRepo
+	}
+	request := func(authorization string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/orders/01HO", nil)
+		if authorization != "" {
+			req.Header.Set(echo.HeaderAuthorization, authorization)
+		}
+		return req
+	}
+
+	t.Run("Admin Token is required", func(t *testing.T) {
+		e, mockOrderRepo := newServer(token)
+
+		for _, authorization := range []string{"", "Bearer wrong-token", token} {
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, request(authorization))
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Contains(t, rec.Header().Get(echo.HeaderWWWAuthenticate), "Bearer")
+		}
+		mockOrderRepo.AssertNotCalled(t, "GetOrderbyOrderID", "01HO")
+	})
+
+	t.Run("Valid Admin Token passes", func(t *testing.T) {
+		e, mockOrderRepo := newServer(token)
+		mockOrderRepo.On("GetOrderbyOrderID", "01HO").Return(schema.Order{OrderID: "01HO"}, nil)
+
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, request("Bearer "+token))
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("Without a configured Token every Request is refused", func(t *testing.T) {
+		e, _ := newServer("")
+
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, request("Bearer "))
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
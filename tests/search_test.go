@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"smkdevid/echocommercehub/internal/app/handlers"
+	postgresql "smkdevid/echocommercehub/internal/databases/postgresql"
+	schema "smkdevid/echocommercehub/internal/models/schema"
+	"smkdevid/echocommercehub/internal/services/products"
+	"smkdevid/echocommercehub/tests/mocks"
+	"smkdevid/echocommercehub/utils/responses"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriceBands(t *testing.T) {
+	keys := make([]string, 0, len(postgresql.PriceBands))
+	for _, band := range postgresql.PriceBands {
+		keys = append(keys, band.Key)
+	}
+	assert.Equal(t, []string{"0-50000", "50000-100000", "100000-250000", "250000-500000", "500000-1000000", "1000000+"}, keys)
+	assert.Nil(t, postgresql.PriceBands[len(postgresql.PriceBands)-1].Max)
+}
+
+func TestSearchProducts(t *testing.T) {
+	newContext := func(target string) (echo.Context, *httptest.ResponseRecorder) {
+		e := echo.New()
+		rec := httptest.NewRecorder()
+		return e.NewContext(httptest.NewRequest(http.MethodGet, target, nil), rec), rec
+	}
+
+	t.Run("Search Text and Filters are passed to the Repository", func(t *testing.T) {
+		mockProductRepo := new(mocks.MockProductRepository)
+
+		productService := products.NewProductService(mockProductRepo)
+
+		minPrice := decimal.NewFromInt(50000)
+		expectedQuery := postgresql.ProductSearchQuery{
+			Text:         "kopi arabika",
+			Brand:        "Gayo",
+			MinPrice:     &minPrice,
+			Availability: postgresql.AvailabilityInStock,
+			Limit:        postgresql.DefaultProductLimit,
+		}
+		mockProductRepo.On("SearchProducts", expectedQuery).Return(postgresql.ProductSearchResult{
+			Hits: []postgresql.ProductSearchHit{{
+				Product: schema.Product{ProductID: "01HV", ProductName: "Kopi Arabika Gayo 250g"},
+				Rank:    0.6,
+			}},
+			Total: 1,
+			Facets: postgresql.ProductFacets{
+				Brand: []postgresql.FacetCount{{Value: "Gayo", Count: 1}},
+			},
+		}, nil)
+
+		c, rec := newContext("/api/v1/products/search?q=+kopi+arabika+&brand=Gayo&min_price=50000&availability=in_stock")
+		assert.NoError(t, handlers.PSQLSearchProducts(productService)(c))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"rank":0.6`)
+		assert.Contains(t, rec.Body.String(), `"brand":[{"value":"Gayo","count":1}]`)
+		assert.Contains(t, rec.Body.String(), `"total":1`)
+		mockProductRepo.AssertExpectations(t)
+	})
+
+	t.Run("Fuzzy Fallback is reported", func(t *testing.T) {
+		mockProductRepo := new(mocks.MockProductRepository)
+
+		productService := products.NewProductService(mockProductRepo)
+
+		mockProductRepo.On("SearchProducts", postgresql.ProductSearchQuery{Text: "kopii", Limit: postgresql.DefaultProductLimit}).
+			Return(postgresql.ProductSearchResult{Fuzzy: true}, nil)
+
+		page, err := productService.SearchProducts(postgresql.ProductSearchQuery{Text: "kopii"})
+		assert.NoError(t, err)
+		assert.True(t, page.Fuzzy)
+	})
+
+	t.Run("Invalid Query Parameters", func(t *testing.T) {
+		productService := products.NewProductService(new(mocks.MockProductRepository))
+
+		for _, target := range []string{
+			"/api/v1/products/search?availability=sold_out",
+			"/api/v1/products/search?max_price=cheap",
+			"/api/v1/products/search?min_price=100&max_price=10",
+			"/api/v1/products/search?limit=1000",
+		} {
+			c, rec := newContext(target)
+			responses.HTTPErrorHandler(handlers.PSQLSearchProducts(productService)(c), c)
+			assert.Equal(t, http.StatusBadRequest, rec.Code, target)
+		}
+	})
+}
@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"smkdevid/echocommercehub/internal/app"
+	"smkdevid/echocommercehub/internal/configs"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerConfigValidate(t *testing.T) {
+	config := configs.ServerConfig{
+		Address:         ":8080",
+		ShutdownTimeout: 30 * time.Second,
+		LegacySunset:    time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC),
+	}
+	assert.NoError(t, config.Validate())
+
+	shortAdminToken := config
+	shortAdminToken.AdminToken = "admin"
+	assert.Error(t, shortAdminToken.Validate())
+
+	missingSunset := config
+	missingSunset.LegacySunset = time.Time{}
+	assert.Error(t, missingSunset.Validate())
+
+	missingKey := config
+	missingKey.TLSCertFile = "/etc/ssl/server.crt"
+	assert.Error(t, missingKey.Validate())
+
+	missingFiles := config
+	missingFiles.TLSCertFile = "/nonexistent/server.crt"
+	missingFiles.TLSKeyFile = "/nonexistent/server.key"
+	assert.Error(t, missingFiles.Validate())
+}
+
+func TestServerGracefulShutdown(t *testing.T) {
+	e := echo.New()
+	e.HideBanner = true
+	e.HidePort = true
+
+	inFlight := make(chan struct{})
+	e.GET("/slow", func(c echo.Context) error {
+		close(inFlight)
+		time.Sleep(200 * time.Millisecond)
+		return c.String(http.StatusOK, "done")
+	})
+
+	server := app.NewServer(e, configs.ServerConfig{Address: "127.0.0.1:0", ShutdownTimeout: 5 * time.Second})
+
+	workerStopped := make(chan struct{})
+	server.AddWorker(func(ctx context.Context) {
+		<-ctx.Done()
+		close(workerStopped)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- server.Run(ctx)
+	}()
+
+	assert.Eventually(t, func() bool { return e.ListenerAddr() != nil }, 2*time.Second, 10*time.Millisecond)
+
+	status := make(chan int, 1)
+	go func() {
+		resp, err := http.Get("http://" + e.ListenerAddr().String() + "/slow")
+		if err != nil {
+			status <- 0
+			return
+		}
+		resp.Body.Close()
+		status <- resp.StatusCode
+	}()
+
+	// Shut down while the request is being served, it still completes
+	<-inFlight
+	cancel()
+
+	assert.Equal(t, http.StatusOK, <-status)
+	assert.NoError(t, <-runErr)
+	<-workerStopped
+}
@@ -0,0 +1,103 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	schema "smkdevid/echocommercehub/internal/models/schema"
+	"smkdevid/echocommercehub/internal/services/promotions"
+	"smkdevid/echocommercehub/tests/mocks"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func stackingPromotions() []schema.Promotion {
+	start := time.Now().Add(-time.Hour)
+	end := time.Now().Add(24 * time.Hour)
+
+	return []schema.Promotion{
+		{PromotionID: "cae8651b", PromotionName: "Ramadhan Sale", DiscountType: "percentage", DiscountValue: 10, Priority: 10, PromotionStartDate: start, PromotionEndDate: end},
+		{PromotionID: "137ce1cf", PromotionName: "New Year Sale", DiscountType: "fixed", DiscountValue: 60000, Priority: 5, PromotionStartDate: start, PromotionEndDate: end},
+		{PromotionID: "dc6ef7bf", PromotionName: "Free Ongkir", DiscountType: "free_shipping", Stackable: true, Priority: 1, PromotionStartDate: start, PromotionEndDate: end},
+		{PromotionID: "e1d63455", PromotionName: "Flash Sale", DiscountType: "percentage", DiscountValue: 30, Exclusive: true, Priority: 0, PromotionStartDate: start, PromotionEndDate: end},
+		{PromotionID: "c373b046", PromotionName: "First Order", DiscountType: "fixed", DiscountValue: 5000, Priority: 20, Rules: schema.PromotionRules{FirstOrderOnly: true}, PromotionStartDate: start, PromotionEndDate: end},
+	}
+}
+
+func stackingCart() promotions.Cart {
+	return promotions.Cart{
+		Items: []promotions.CartItem{
+			{ProductID: "prod-1", CategoryID: "fashion", Quantity: 2, UnitPrice: 200000},
+		},
+		ShippingCost: 20000,
+	}
+}
+
+func TestResolveStacking(t *testing.T) {
+	t.Run("Priority Mode applies the highest priority non-stackable Promotion", func(t *testing.T) {
+		result, err := promotions.ResolveStacking(stackingPromotions(), stackingCart(), promotions.StackingModePriority, time.Now())
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"cae8651b", "dc6ef7bf"}, appliedIDs(result))
+		assert.Equal(t, float64(40000+20000), result.TotalDiscount)
+		assert.Equal(t, float64(400000+20000-60000), result.GrandTotal)
+
+		reasons := skippedReasons(result)
+		assert.Equal(t, "promotion is not eligible for this cart", reasons["c373b046"])
+		assert.Contains(t, reasons["137ce1cf"], "only one non-stackable promotion")
+		assert.Contains(t, reasons["e1d63455"], "exclusive promotion cannot be combined")
+	})
+
+	t.Run("Best for Customer Mode picks the largest Discount", func(t *testing.T) {
+		result, err := promotions.ResolveStacking(stackingPromotions(), stackingCart(), promotions.StackingModeBestForCustomer, time.Now())
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"e1d63455"}, appliedIDs(result))
+		assert.Equal(t, float64(120000), result.TotalDiscount)
+		assert.Len(t, result.Skipped, 4)
+	})
+
+	t.Run("Exclusive Promotion with the highest Priority blocks the others", func(t *testing.T) {
+		promos := stackingPromotions()
+		promos[3].Priority = 100
+
+		result, err := promotions.ResolveStacking(promos, stackingCart(), promotions.StackingModePriority, time.Now())
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"e1d63455"}, appliedIDs(result))
+		assert.Contains(t, skippedReasons(result)["dc6ef7bf"], "exclusive promotion e1d63455 is already applied")
+	})
+
+	t.Run("Unsupported Mode", func(t *testing.T) {
+		_, err := promotions.ResolveStacking(stackingPromotions(), stackingCart(), "random", time.Now())
+		assert.Error(t, err)
+	})
+}
+
+func TestResolvePromotions(t *testing.T) {
+	t.Run("Successful Resolution from recorded Promotions", func(t *testing.T) {
+		mockPromotionRepo := new(mocks.MockPromotionRepository)
+
+		userService := promotions.NewPromotionService(mockPromotionRepo)
+
+		mockPromotionRepo.On("GetAllPromotions").Return(stackingPromotions(), nil)
+
+		result, err := userService.ResolvePromotions(stackingCart(), promotions.StackingModeBestForCustomer)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"e1d63455"}, appliedIDs(result))
+		mockPromotionRepo.AssertExpectations(t)
+	})
+}
+
+func appliedIDs(result promotions.StackingResult) []string {
+	ids := make([]string, 0, len(result.Applied))
+	for _, applied := range result.Applied {
+		ids = append(ids, applied.PromotionID)
+	}
+	return ids
+}
+
+func skippedReasons(result promotions.StackingResult) map[string]string {
+	reasons := make(map[string]string, len(result.Skipped))
+	for _, skipped := range result.Skipped {
+		reasons[skipped.PromotionID] = skipped.Reason
+	}
+	return reasons
+}
@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	schema "smkdevid/echocommercehub/internal/models/schema"
+	"smkdevid/echocommercehub/internal/services/promotions"
+	"smkdevid/echocommercehub/utils/exception"
+	"smkdevid/echocommercehub/utils/requests"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func promotionValidator() *requests.Validator {
+	validator := requests.NewValidator()
+	promotions.RegisterValidationRules(validator)
+	return validator
+}
+
+func TestPromotionValidation(t *testing.T) {
+	t.Run("Valid Promotion", func(t *testing.T) {
+		promo := schema.Promotion{
+			PromotionID:        "cae8651b",
+			PromotionName:      "Ramadhan Sale",
+			DiscountType:       "Percentage",
+			DiscountValue:      10.5,
+			PromotionStartDate: time.Now(),
+			PromotionEndDate:   time.Now().Add(24 * time.Hour),
+		}
+
+		assert.NoError(t, promotionValidator().Validate(promo))
+	})
+
+	t.Run("Every invalid Field is reported", func(t *testing.T) {
+		promo := schema.Promotion{
+			PromotionID:        "cae8651b",
+			DiscountType:       "percentage",
+			DiscountValue:      150,
+			PromotionStartDate: time.Now(),
+			PromotionEndDate:   time.Now().Add(-24 * time.Hour),
+			MaxDiscountAmount:  -1,
+			Exclusive:          true,
+			Stackable:          true,
+			Rules:              schema.PromotionRules{MinSubtotal: -10},
+		}
+
+		err := promotionValidator().Validate(promo)
+
+		validationErr, ok := err.(*exception.ValidationError)
+		assert.True(t, ok)
+
+		fields := make(map[string]string)
+		for _, field := range validationErr.Fields {
+			fields[field.Field] = field.Rule
+		}
+		assert.Equal(t, map[string]string{
+			"promotion_name":      "required",
+			"max_discount_amount": "gte",
+			"rules.min_subtotal":  "gte",
+			"promotion_end_date":  "after_start_date",
+			"discount_value":      "percentage",
+			"stackable":           "excluded_with_exclusive",
+		}, fields)
+	})
+
+	t.Run("Unknown Discount Type and invalid Tiers", func(t *testing.T) {
+		promo := schema.Promotion{
+			PromotionName:      "Winter Sale",
+			DiscountType:       "cashback",
+			PromotionStartDate: time.Now(),
+			PromotionEndDate:   time.Now().Add(24 * time.Hour),
+			DiscountTiers:      []schema.DiscountTier{{MinSpend: 100000, Type: "bonus", Value: 0}},
+			Status:             "running",
+		}
+
+		err := promotionValidator().Validate(promo)
+
+		validationErr, ok := err.(*exception.ValidationError)
+		assert.True(t, ok)
+
+		fields := make(map[string]string)
+		for _, field := range validationErr.Fields {
+			fields[field.Field] = field.Message
+		}
+		assert.Equal(t, "must be one of [percentage fixed buy_x_get_y tiered free_shipping]", fields["discount_type"])
+		assert.Equal(t, "must be one of [percentage fixed]", fields["discount_tiers[0].type"])
+		assert.Equal(t, "must be greater than 0", fields["discount_tiers[0].value"])
+		assert.Contains(t, fields, "status")
+	})
+}
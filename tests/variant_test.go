@@ -0,0 +1,122 @@
+package tests
+
+import (
+	"testing"
+
+	schema "smkdevid/echocommercehub/internal/models/schema"
+	"smkdevid/echocommercehub/internal/services/products"
+	"smkdevid/echocommercehub/tests/mocks"
+	"smkdevid/echocommercehub/utils/exception"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var variantOptions = []schema.ProductOption{
+	{Name: "color", Values: []string{"Hitam", "Putih"}, Position: 0},
+	{Name: "size", Values: []string{"S", "M", "XL"}, Position: 1},
+}
+
+func TestVariantMatrix(t *testing.T) {
+	matrix := products.VariantMatrix(variantOptions)
+
+	assert.Len(t, matrix, 6)
+	assert.Equal(t, map[string]string{"color": "Hitam", "size": "S"}, matrix[0])
+	assert.Equal(t, map[string]string{"color": "Putih", "size": "XL"}, matrix[5])
+	assert.Empty(t, products.VariantMatrix(nil))
+}
+
+func TestGenerateSKU(t *testing.T) {
+	combination := map[string]string{"color": "Merah Tua", "size": "XL"}
+
+	assert.Equal(t, "KAOS-POLOS-MERAH-TUA-XL", products.GenerateSKU("Kaos Polos", variantOptions, combination))
+	assert.Equal(t, "color=Merah Tua;size=XL", products.OptionKey(combination))
+}
+
+func TestGenerateVariants(t *testing.T) {
+	product := schema.Product{ProductID: "01HV", ProductName: "Kaos Polos", ProductPrice: decimal.NewFromInt(75000)}
+
+	t.Run("Only missing Combinations are created", func(t *testing.T) {
+		mockProductRepo := new(mocks.MockProductRepository)
+		mockVariantRepo := new(mocks.MockProductVariantRepository)
+
+		variantService := products.NewVariantService(mockProductRepo, mockVariantRepo)
+
+		mockProductRepo.On("GetProductbyProductID", "01HV").Return(product, nil)
+		mockVariantRepo.On("SaveProductOptions", "01HV", mock.AnythingOfType("[]schema.ProductOption")).Return(variantOptions, nil)
+		mockVariantRepo.On("GetVariantsbyProductID", "01HV").Return([]schema.ProductVariant{
+			{SKU: "KAOS-POLOS-HITAM-S", OptionKey: "color=Hitam;size=S"},
+		}, nil)
+		mockVariantRepo.On("CreateVariants", mock.MatchedBy(func(variants []schema.ProductVariant) bool {
+			return len(variants) == 5 && variants[0].SKU == "KAOS-POLOS-HITAM-M" && variants[0].ProductID == "01HV"
+		})).Return([]schema.ProductVariant{}, nil)
+
+		_, err := variantService.GenerateVariants("01HV", products.GenerateVariantsRequest{
+			Options: []schema.ProductOption{
+				{Name: " Color ", Values: []string{"Hitam", "Putih"}},
+				{Name: "size", Values: []string{"S", "M", "XL"}},
+			},
+		})
+		assert.NoError(t, err)
+		mockVariantRepo.AssertExpectations(t)
+	})
+
+	t.Run("Duplicate Option Names", func(t *testing.T) {
+		mockProductRepo := new(mocks.MockProductRepository)
+		mockVariantRepo := new(mocks.MockProductVariantRepository)
+
+		variantService := products.NewVariantService(mockProductRepo, mockVariantRepo)
+
+		mockProductRepo.On("GetProductbyProductID", "01HV").Return(product, nil)
+
+		_, err := variantService.GenerateVariants("01HV", products.GenerateVariantsRequest{
+			Options: []schema.ProductOption{
+				{Name: "size", Values: []string{"S"}},
+				{Name: "Size", Values: []string{"M"}},
+			},
+		})
+		assert.ErrorIs(t, err, exception.ErrValidation)
+		mockVariantRepo.AssertNotCalled(t, "SaveProductOptions", mock.Anything, mock.Anything)
+	})
+}
+
+func TestCreateVariant(t *testing.T) {
+	product := schema.Product{ProductID: "01HV", ProductName: "Kaos Polos", ProductPrice: decimal.NewFromInt(75000)}
+
+	t.Run("Option Value must belong to the Product", func(t *testing.T) {
+		mockProductRepo := new(mocks.MockProductRepository)
+		mockVariantRepo := new(mocks.MockProductVariantRepository)
+
+		variantService := products.NewVariantService(mockProductRepo, mockVariantRepo)
+
+		mockProductRepo.On("GetProductbyProductID", "01HV").Return(product, nil)
+		mockVariantRepo.On("GetProductOptions", "01HV").Return(variantOptions, nil)
+
+		_, err := variantService.CreateVariant("01HV", schema.ProductVariant{
+			SKU:     "KAOS-POLOS-HIJAU-S",
+			Options: map[string]string{"color": "Hijau", "size": "S"},
+		})
+
+		var validationErr *exception.ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "options.color", validationErr.Fields[0].Field)
+		mockVariantRepo.AssertNotCalled(t, "CreateVariants", mock.Anything)
+	})
+
+	t.Run("Variant Price overrides the Product Price", func(t *testing.T) {
+		price := decimal.RequireFromString("82500.50")
+
+		assert.True(t, schema.ProductVariant{}.EffectivePrice(product).Equal(decimal.NewFromInt(75000)))
+		assert.Equal(t, "82500.5", schema.ProductVariant{Price: &price}.EffectivePrice(product).String())
+	})
+}
+
+func TestIsValidBarcode(t *testing.T) {
+	assert.True(t, products.IsValidBarcode("4006381333931"))
+	assert.True(t, products.IsValidBarcode("96385074"))
+	assert.False(t, products.IsValidBarcode("4006381333932"))
+	assert.False(t, products.IsValidBarcode("40063813339A1"))
+	assert.True(t, products.IsValidSKU("KAOS-POLOS-HITAM-S"))
+	assert.False(t, products.IsValidSKU("kaos polos"))
+}
@@ -0,0 +1,206 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha512"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"smkdevid/echocommercehub/internal/app/handlers"
+	schema "smkdevid/echocommercehub/internal/models/schema"
+	"smkdevid/echocommercehub/internal/services/products"
+	"smkdevid/echocommercehub/tests/mocks"
+	"smkdevid/echocommercehub/utils/exception"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const webhookSecret = "whsec_test"
+
+var webhookSettings = products.WebhookSettings{
+	MaxAttempts:  3,
+	RetryBackoff: 30 * time.Second,
+	MaxBackoff:   time.Hour,
+}
+
+func signedHeader(body []byte, at time.Time) http.Header {
+	header := http.Header{}
+	header.Set(products.WebhookSignatureHeader, products.SignWebhook(webhookSecret, at, body))
+	return header
+}
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	body := []byte(`{"event_id":"evt_1","reference":"fake-01HP","status":"paid"}`)
+	now := time.Now()
+
+	t.Run("Valid Signature", func(t *testing.T) {
+		signature := products.SignWebhook(webhookSecret, now, body)
+		assert.NoError(t, products.VerifyWebhookSignature(webhookSecret, signature, body, now))
+	})
+
+	t.Run("Tampered Body", func(t *testing.T) {
+		signature := products.SignWebhook(webhookSecret, now, body)
+		tampered := bytes.Replace(body, []byte("paid"), []byte("refunded"), 1)
+		assert.ErrorIs(t, products.VerifyWebhookSignature(webhookSecret, signature, tampered, now), products.ErrInvalidWebhookSignature)
+	})
+
+	t.Run("Stale Signature", func(t *testing.T) {
+		signature := products.SignWebhook(webhookSecret, now.Add(-time.Hour), body)
+		assert.ErrorIs(t, products.VerifyWebhookSignature(webhookSecret, signature, body, now), products.ErrInvalidWebhookSignature)
+	})
+
+	t.Run("Missing Secret rejects everything", func(t *testing.T) {
+		signature := products.SignWebhook("", now, body)
+		assert.ErrorIs(t, products.VerifyWebhookSignature("", signature, body, now), products.ErrInvalidWebhookSignature)
+	})
+}
+
+func TestWebhookService(t *testing.T) {
+	body := []byte(`{"event_id":"evt_1","type":"payment.paid","reference":"fake-01HP","status":"paid"}`)
+
+	newService := func() (*products.WebhookServiceImpl, *mocks.MockWebhookEventRepository, *mocks.MockPaymentRepository, *mocks.MockOrderRepository) {
+		mockEventRepo := new(mocks.MockWebhookEventRepository)
+		mockPaymentRepo := new(mocks.MockPaymentRepository)
+		mockOrderRepo := new(mocks.MockOrderRepository)
+		fake := products.NewFakeProvider()
+		fake.WebhookSecret = webhookSecret
+		paymentService := products.NewPaymentService(mockPaymentRepo, products.NewOrderService(mockOrderRepo, nil), fake, time.Hour)
+		return products.NewWebhookService(mockEventRepo, paymentService, fake, webhookSettings), mockEventRepo, mockPaymentRepo, mockOrderRepo
+	}
+
+	t.Run("Signed Event is stored", func(t *testing.T) {
+		webhookService, mockEventRepo, _, _ := newService()
+
+		stored := schema.WebhookEvent{WebhookID: "01HWE", Provider: "fake", EventID: "evt_1", Status: schema.WebhookStatusPending}
+		mockEventRepo.On("CreateWebhookEvent", mock.MatchedBy(func(e schema.WebhookEvent) bool {
+			return e.Provider == "fake" && e.EventID == "evt_1" && e.Reference == "fake-01HP" && e.Payload == string(body)
+		})).Return(stored, true, nil)
+
+		event, duplicate, err := webhookService.ReceiveWebhook(signedHeader(body, time.Now()), body)
+		assert.NoError(t, err)
+		assert.False(t, duplicate)
+		assert.Equal(t, "01HWE", event.WebhookID)
+		assert.Len(t, webhookService.Received(), 1)
+	})
+
+	t.Run("Redelivery is not stored twice", func(t *testing.T) {
+		webhookService, mockEventRepo, _, _ := newService()
+
+		stored := schema.WebhookEvent{WebhookID: "01HWE", Provider: "fake", EventID: "evt_1", Status: schema.WebhookStatusProcessed}
+		mockEventRepo.On("CreateWebhookEvent", mock.AnythingOfType("schema.WebhookEvent")).Return(stored, false, nil)
+
+		event, duplicate, err := webhookService.ReceiveWebhook(signedHeader(body, time.Now()), body)
+		assert.NoError(t, err)
+		assert.True(t, duplicate)
+		assert.Equal(t, schema.WebhookStatusProcessed, event.Status)
+		assert.Len(t, webhookService.Received(), 0)
+	})
+
+	t.Run("Unsigned Event is rejected", func(t *testing.T) {
+		webhookService, mockEventRepo, _, _ := newService()
+
+		_, _, err := webhookService.ReceiveWebhook(http.Header{}, body)
+		assert.ErrorIs(t, err, products.ErrInvalidWebhookSignature)
+		mockEventRepo.AssertNotCalled(t, "CreateWebhookEvent", mock.Anything)
+	})
+
+	t.Run("Event pays the Payment", func(t *testing.T) {
+		webhookService, mockEventRepo, mockPaymentRepo, mockOrderRepo := newService()
+		now := time.Now()
+
+		event := schema.WebhookEvent{WebhookID: "01HWE", Provider: "fake", EventID: "evt_1", Payload: string(body), Status: schema.WebhookStatusPending}
+		mockEventRepo.On("ClaimDueWebhookEvents", now, products.WebhookBatch, mock.AnythingOfType("time.Duration")).Return([]schema.WebhookEvent{event}, nil)
+		mockPaymentRepo.On("GetPaymentbyReference", "fake", "fake-01HP").Return(schema.Payment{PaymentID: "01HP", OrderID: "01HO",
+			Provider: "fake", Reference: "fake-01HP", Amount: decimal.NewFromInt(186500), Status: schema.PaymentStatusPending}, nil)
+		mockPaymentRepo.On("UpdatePayment", mock.AnythingOfType("schema.Payment"), schema.PaymentStatusPending).Return(true, nil)
+		mockOrderRepo.On("GetOrderbyOrderID", "01HO").Return(schema.Order{OrderID: "01HO", Status: schema.OrderStatusPendingPayment}, nil)
+		mockOrderRepo.On("TransitionOrder", "01HO", schema.OrderStatusPendingPayment, schema.OrderStatusPaid, "payment:fake", "payment 01HP paid").Return(true, nil)
+		mockEventRepo.On("UpdateWebhookEvent", mock.MatchedBy(func(e schema.WebhookEvent) bool {
+			return e.Status == schema.WebhookStatusProcessed && e.Attempts == 1 && e.ProcessedAt != nil
+		})).Return(nil)
+
+		processed, err := webhookService.ProcessWebhookEvents(context.Background(), now)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, processed)
+		mockEventRepo.AssertExpectations(t)
+		mockOrderRepo.AssertExpectations(t)
+	})
+
+	t.Run("Failing Event is retried with Backoff", func(t *testing.T) {
+		webhookService, mockEventRepo, mockPaymentRepo, _ := newService()
+		now := time.Now()
+
+		event := schema.WebhookEvent{WebhookID: "01HWE", Provider: "fake", EventID: "evt_1", Payload: string(body), Status: schema.WebhookStatusPending, Attempts: 1}
+		mockEventRepo.On("ClaimDueWebhookEvents", now, products.WebhookBatch, mock.AnythingOfType("time.Duration")).Return([]schema.WebhookEvent{event}, nil)
+		mockPaymentRepo.On("GetPaymentbyReference", "fake", "fake-01HP").Return(schema.Payment{}, &exception.PaymentNotFoundError{Message: "Payment Not Found", PaymentID: "fake-01HP"})
+		mockEventRepo.On("UpdateWebhookEvent", mock.MatchedBy(func(e schema.WebhookEvent) bool {
+			return e.Status == schema.WebhookStatusPending && e.Attempts == 2 && e.NextAttemptAt.Equal(now.Add(time.Minute))
+		})).Return(nil)
+
+		processed, err := webhookService.ProcessWebhookEvents(context.Background(), now)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, processed)
+		mockEventRepo.AssertExpectations(t)
+	})
+
+	t.Run("Event fails after the last Attempt", func(t *testing.T) {
+		webhookService, mockEventRepo, mockPaymentRepo, _ := newService()
+		now := time.Now()
+
+		event := schema.WebhookEvent{WebhookID: "01HWE", Provider: "fake", EventID: "evt_1", Payload: string(body), Status: schema.WebhookStatusPending, Attempts: 2}
+		mockEventRepo.On("ClaimDueWebhookEvents", now, products.WebhookBatch, mock.AnythingOfType("time.Duration")).Return([]schema.WebhookEvent{event}, nil)
+		mockPaymentRepo.On("GetPaymentbyReference", "fake", "fake-01HP").Return(schema.Payment{}, &exception.PaymentNotFoundError{Message: "Payment Not Found", PaymentID: "fake-01HP"})
+		mockEventRepo.On("UpdateWebhookEvent", mock.MatchedBy(func(e schema.WebhookEvent) bool {
+			return e.Status == schema.WebhookStatusFailed && e.Attempts == 3 && e.NextAttemptAt == nil && e.LastError != ""
+		})).Return(nil)
+
+		_, err := webhookService.ProcessWebhookEvents(context.Background(), now)
+		assert.NoError(t, err)
+		mockEventRepo.AssertExpectations(t)
+	})
+}
+
+func TestMidtransWebhook(t *testing.T) {
+	provider := products.NewMidtransProvider("SB-Mid-server-key", products.MidtransSandboxURL, "", time.Second)
+	sum := sha512.Sum512([]byte("01HP" + "200" + "186500.00" + "SB-Mid-server-key"))
+	body := []byte(`{"order_id":"01HP","status_code":"200","gross_amount":"186500.00","transaction_id":"9aed5972",
+		"transaction_status":"settlement","signature_key":"` + hex.EncodeToString(sum[:]) + `"}`)
+
+	t.Run("Signature Key is verified", func(t *testing.T) {
+		assert.NoError(t, provider.VerifyWebhook(http.Header{}, body, time.Now()))
+
+		forged := bytes.Replace(body, []byte("186500.00"), []byte("1000.00"), 1)
+		assert.ErrorIs(t, provider.VerifyWebhook(http.Header{}, forged, time.Now()), products.ErrInvalidWebhookSignature)
+	})
+
+	t.Run("Notification is a Paid Event", func(t *testing.T) {
+		event, err := provider.ParseWebhook(body)
+		assert.NoError(t, err)
+		assert.Equal(t, "9aed5972:settlement", event.EventID)
+		assert.Equal(t, "9aed5972", event.Reference)
+		assert.Equal(t, schema.PaymentStatusPaid, event.Result.Status)
+	})
+}
+
+func TestWebhookHandlers(t *testing.T) {
+	t.Run("Invalid Signature is Unauthorized", func(t *testing.T) {
+		fake := products.NewFakeProvider()
+		fake.WebhookSecret = webhookSecret
+		webhookService := products.NewWebhookService(new(mocks.MockWebhookEventRepository), nil, fake, webhookSettings)
+
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/webhooks/payments", bytes.NewReader([]byte(`{"event_id":"evt_1"}`)))
+		req.Header.Set(products.WebhookSignatureHeader, "t=1,v1=deadbeef")
+		rec := httptest.NewRecorder()
+
+		err := handlers.PSQLReceivePaymentWebhook(webhookService)(e.NewContext(req, rec))
+		assert.Equal(t, http.StatusUnauthorized, err.(*echo.HTTPError).Code)
+	})
+}
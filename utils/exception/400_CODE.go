@@ -1,6 +1,9 @@
 package exception
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 type NotFoundError struct {
 	Message string
@@ -12,6 +15,122 @@ type PromotionIDNotFoundError struct {
 	PromotionID string
 }
 
+type PromotionIDConflictError struct {
+	Message     string
+	PromotionID string
+}
+
+type PromotionVersionConflictError struct {
+	Message        string
+	PromotionID    string
+	CurrentVersion uint
+}
+
+type ProductIDNotFoundError struct {
+	Message   string
+	ProductID string
+}
+
+type VariantNotFoundError struct {
+	Message   string
+	VariantID string
+}
+
+type SKUConflictError struct {
+	Message string
+	SKU     string
+}
+
+type MediaNotFoundError struct {
+	Message string
+	MediaID string
+}
+
+type MediaTooLargeError struct {
+	Message string
+	Limit   int64
+}
+
+type UnsupportedMediaTypeError struct {
+	Message     string
+	ContentType string
+}
+
+type CartItemNotFoundError struct {
+	Message   string
+	VariantID string
+}
+
+type OrderNotFoundError struct {
+	Message string
+	OrderID string
+}
+
+type PaymentNotFoundError struct {
+	Message   string
+	PaymentID string
+}
+
+type WebhookEventNotFoundError struct {
+	Message   string
+	WebhookID string
+}
+
+type InvalidDiscountError struct {
+	Message      string
+	DiscountType string
+}
+
+type CouponNotFoundError struct {
+	Message    string
+	CouponCode string
+}
+
+type CouponRedemptionLimitError struct {
+	Message    string
+	CouponCode string
+}
+
+type CouponNotApplicableError struct {
+	Message    string
+	CouponCode string
+}
+
+type InvalidCouponRequestError struct {
+	Message string
+}
+
+type InvalidStatusTransitionError struct {
+	Message string
+	From    string
+	To      string
+}
+
+type ConflictError struct {
+	Message string
+}
+
+type ForbiddenError struct {
+	Message string
+}
+
+type RateLimitedError struct {
+	Message    string
+	RetryAfter time.Duration
+}
+
+// FieldError describes a single invalid field of a request payload
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+type ValidationError struct {
+	Message string
+	Fields  []FieldError
+}
+
 func (e *NotFoundError) Error() string {
 	return fmt.Sprintf("%s with ID %d", e.Message, e.ID)
 }
@@ -19,3 +138,283 @@ func (e *NotFoundError) Error() string {
 func (e *PromotionIDNotFoundError) Error() string {
 	return fmt.Sprintf("%s with Promotion ID %s", e.Message, e.PromotionID)
 }
+
+func (e *PromotionIDConflictError) Error() string {
+	return fmt.Sprintf("%s with Promotion ID %s", e.Message, e.PromotionID)
+}
+
+func (e *PromotionVersionConflictError) Error() string {
+	return fmt.Sprintf("%s for Promotion ID %s, current version is %d", e.Message, e.PromotionID, e.CurrentVersion)
+}
+
+func (e *ProductIDNotFoundError) Error() string {
+	return fmt.Sprintf("%s with Product ID %s", e.Message, e.ProductID)
+}
+
+func (e *VariantNotFoundError) Error() string {
+	return fmt.Sprintf("%s with Variant ID %s", e.Message, e.VariantID)
+}
+
+func (e *SKUConflictError) Error() string {
+	return fmt.Sprintf("%s with SKU %s", e.Message, e.SKU)
+}
+
+func (e *MediaNotFoundError) Error() string {
+	return fmt.Sprintf("%s with media ID %s", e.Message, e.MediaID)
+}
+
+func (e *MediaTooLargeError) Error() string {
+	return fmt.Sprintf("%s, the limit is %d bytes", e.Message, e.Limit)
+}
+
+func (e *UnsupportedMediaTypeError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Message, e.ContentType)
+}
+
+func (e *CartItemNotFoundError) Error() string {
+	return fmt.Sprintf("%s with variant ID %s", e.Message, e.VariantID)
+}
+
+func (e *OrderNotFoundError) Error() string {
+	return fmt.Sprintf("%s with Order ID %s", e.Message, e.OrderID)
+}
+
+func (e *PaymentNotFoundError) Error() string {
+	return fmt.Sprintf("%s with Payment ID %s", e.Message, e.PaymentID)
+}
+
+func (e *WebhookEventNotFoundError) Error() string {
+	return fmt.Sprintf("%s with Webhook ID %s", e.Message, e.WebhookID)
+}
+
+func (e *InvalidDiscountError) Error() string {
+	return fmt.Sprintf("%s for Discount Type %s", e.Message, e.DiscountType)
+}
+
+func (e *CouponNotFoundError) Error() string {
+	return fmt.Sprintf("%s with Coupon Code %s", e.Message, e.CouponCode)
+}
+
+func (e *CouponRedemptionLimitError) Error() string {
+	return fmt.Sprintf("%s for Coupon Code %s", e.Message, e.CouponCode)
+}
+
+func (e *CouponNotApplicableError) Error() string {
+	return fmt.Sprintf("%s for Coupon Code %s", e.Message, e.CouponCode)
+}
+
+func (e *InvalidCouponRequestError) Error() string {
+	return e.Message
+}
+
+func (e *InvalidStatusTransitionError) Error() string {
+	return fmt.Sprintf("%s from %s to %s", e.Message, e.From, e.To)
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %d invalid field(s)", e.Message, len(e.Fields))
+}
+
+func (e *ConflictError) Error() string {
+	return e.Message
+}
+
+func (e *ForbiddenError) Error() string {
+	return e.Message
+}
+
+func (e *RateLimitedError) Error() string {
+	return fmt.Sprintf("%s, retry after %s", e.Message, e.RetryAfter)
+}
+
+func (e *NotFoundError) Code() string {
+	return "not_found"
+}
+
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+func (e *PromotionIDNotFoundError) Code() string {
+	return "promotion_not_found"
+}
+
+func (e *PromotionIDNotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+func (e *PromotionIDConflictError) Code() string {
+	return "promotion_already_exists"
+}
+
+func (e *PromotionIDConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+func (e *PromotionVersionConflictError) Code() string {
+	return "promotion_version_mismatch"
+}
+
+func (e *PromotionVersionConflictError) Is(target error) bool {
+	return target == ErrPreconditionFailed
+}
+
+func (e *ProductIDNotFoundError) Code() string {
+	return "product_not_found"
+}
+
+func (e *ProductIDNotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+func (e *VariantNotFoundError) Code() string {
+	return "variant_not_found"
+}
+
+func (e *VariantNotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+func (e *SKUConflictError) Code() string {
+	return "sku_already_exists"
+}
+
+func (e *SKUConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+func (e *MediaNotFoundError) Code() string {
+	return "media_not_found"
+}
+
+func (e *MediaNotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+func (e *MediaTooLargeError) Code() string {
+	return "media_too_large"
+}
+
+func (e *MediaTooLargeError) Is(target error) bool {
+	return target == ErrPayloadTooLarge
+}
+
+func (e *UnsupportedMediaTypeError) Code() string {
+	return "unsupported_media_type"
+}
+
+func (e *UnsupportedMediaTypeError) Is(target error) bool {
+	return target == ErrUnsupportedMedia
+}
+
+func (e *CartItemNotFoundError) Code() string {
+	return "cart_item_not_found"
+}
+
+func (e *CartItemNotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+func (e *OrderNotFoundError) Code() string {
+	return "order_not_found"
+}
+
+func (e *OrderNotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+func (e *PaymentNotFoundError) Code() string {
+	return "payment_not_found"
+}
+
+func (e *PaymentNotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+func (e *WebhookEventNotFoundError) Code() string {
+	return "webhook_event_not_found"
+}
+
+func (e *WebhookEventNotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+func (e *InvalidDiscountError) Code() string {
+	return "invalid_discount_type"
+}
+
+func (e *InvalidDiscountError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+func (e *CouponNotFoundError) Code() string {
+	return "coupon_not_found"
+}
+
+func (e *CouponNotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+func (e *CouponRedemptionLimitError) Code() string {
+	return "coupon_redemption_limit_reached"
+}
+
+func (e *CouponRedemptionLimitError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+func (e *CouponNotApplicableError) Code() string {
+	return "coupon_not_applicable"
+}
+
+func (e *CouponNotApplicableError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+func (e *InvalidCouponRequestError) Code() string {
+	return "invalid_coupon_request"
+}
+
+func (e *InvalidCouponRequestError) Is(target error) bool {
+	return target == ErrInvalidRequest
+}
+
+func (e *InvalidStatusTransitionError) Code() string {
+	return "invalid_status_transition"
+}
+
+func (e *InvalidStatusTransitionError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+func (e *ConflictError) Code() string {
+	return "conflict"
+}
+
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+func (e *ForbiddenError) Code() string {
+	return "forbidden"
+}
+
+func (e *ForbiddenError) Is(target error) bool {
+	return target == ErrForbidden
+}
+
+func (e *RateLimitedError) Code() string {
+	return "rate_limited"
+}
+
+func (e *RateLimitedError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+func (e *ValidationError) Code() string {
+	return "validation_failed"
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
@@ -0,0 +1,26 @@
+package exception
+
+import "fmt"
+
+// UpstreamError tells that a service we depend on failed, Err is the original failure
+type UpstreamError struct {
+	Message string
+	Service string
+	Err     error
+}
+
+func (e *UpstreamError) Error() string {
+	return fmt.Sprintf("%s from %s", e.Message, e.Service)
+}
+
+func (e *UpstreamError) Unwrap() error {
+	return e.Err
+}
+
+func (e *UpstreamError) Code() string {
+	return "upstream_error"
+}
+
+func (e *UpstreamError) Is(target error) bool {
+	return target == ErrUpstream
+}
@@ -0,0 +1,34 @@
+package exception
+
+import "errors"
+
+// Kinds of domain errors. Every error of the catalogue matches exactly one kind with errors.Is,
+// even when wrapped with fmt.Errorf("...: %w", err).
+var (
+	ErrInvalidRequest     = errors.New("invalid request")
+	ErrForbidden          = errors.New("forbidden")
+	ErrNotFound           = errors.New("not found")
+	ErrConflict           = errors.New("conflict")
+	ErrPreconditionFailed = errors.New("precondition failed")
+	ErrPayloadTooLarge    = errors.New("payload too large")
+	ErrUnsupportedMedia   = errors.New("unsupported media type")
+	ErrValidation         = errors.New("validation failed")
+	ErrRateLimited        = errors.New("rate limited")
+	ErrUpstream           = errors.New("upstream failure")
+)
+
+// DomainError is implemented by every error of the catalogue. Code is a stable machine
+// readable identifier clients can switch on, unlike the message.
+type DomainError interface {
+	error
+	Code() string
+}
+
+// CodeOf is the code of the domain error wrapped in err, empty when there is none
+func CodeOf(err error) string {
+	var domainErr DomainError
+	if errors.As(err, &domainErr) {
+		return domainErr.Code()
+	}
+	return ""
+}
@@ -0,0 +1,50 @@
+package requests
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Headers of conditional requests
+const (
+	HeaderETag    = "ETag"
+	HeaderIfMatch = "If-Match"
+)
+
+// VersionETag is the strong entity tag of a resource version
+func VersionETag(version uint) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// RequireIfMatch refuses a write sent without If-Match with 428, so a client cannot overwrite a change
+// it never saw. "*" is accepted from a client that deliberately writes over any version.
+func RequireIfMatch(c echo.Context) error {
+	if strings.TrimSpace(c.Request().Header.Get(HeaderIfMatch)) == "" {
+		return echo.NewHTTPError(http.StatusPreconditionRequired, "If-Match is required, send the ETag of the resource you changed")
+	}
+	return nil
+}
+
+// IfMatchVersion reads the version expected by the If-Match header.
+// The bool is false when the header is missing or is "*", meaning any version.
+func IfMatchVersion(c echo.Context) (uint, bool, error) {
+	ifMatch := strings.TrimSpace(c.Request().Header.Get(HeaderIfMatch))
+	if ifMatch == "" || ifMatch == "*" {
+		return 0, false, nil
+	}
+
+	// Weak tags never match on If-Match, see RFC 9110 section 13.1.1
+	if strings.HasPrefix(ifMatch, "W/") {
+		return 0, false, echo.NewHTTPError(http.StatusPreconditionFailed, "If-Match requires a strong ETag")
+	}
+
+	version, err := strconv.ParseUint(strings.Trim(ifMatch, `"`), 10, 64)
+	if err != nil {
+		return 0, false, echo.NewHTTPError(http.StatusBadRequest, "Invalid If-Match header")
+	}
+	return uint(version), true, nil
+}
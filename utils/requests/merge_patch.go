@@ -0,0 +1,106 @@
+package requests
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+
+	"github.com/labstack/echo/v4"
+)
+
+// MIMEMergePatchJSON is the media type of a JSON Merge Patch (RFC 7396) document
+const MIMEMergePatchJSON = "application/merge-patch+json"
+
+// ApplyMergePatch applies a JSON Merge Patch to the original document: members of the
+// patch replace the original ones, null removes them and objects are merged recursively
+func ApplyMergePatch(original []byte, patch []byte) ([]byte, error) {
+	var patchValue interface{}
+	if err := json.Unmarshal(patch, &patchValue); err != nil {
+		return nil, err
+	}
+
+	var originalValue interface{}
+	if len(original) > 0 {
+		if err := json.Unmarshal(original, &originalValue); err != nil {
+			return nil, err
+		}
+	}
+
+	return json.Marshal(mergePatch(originalValue, patchValue))
+}
+
+func mergePatch(original interface{}, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	originalObject, ok := original.(map[string]interface{})
+	if !ok {
+		originalObject = make(map[string]interface{}, len(patchObject))
+	}
+
+	for name, value := range patchObject {
+		if value == nil {
+			delete(originalObject, name)
+			continue
+		}
+		originalObject[name] = mergePatch(originalObject[name], value)
+	}
+	return originalObject
+}
+
+// BindMergePatchAndValidate applies the JSON Merge Patch of the request body on i and validates the result.
+// The returned error is ready to be returned by the handler.
+func BindMergePatchAndValidate(c echo.Context, i interface{}) error {
+	if contentType := c.Request().Header.Get(echo.HeaderContentType); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || (mediaType != MIMEMergePatchJSON && mediaType != echo.MIMEApplicationJSON) {
+			return echo.NewHTTPError(http.StatusUnsupportedMediaType, "Content-Type must be "+MIMEMergePatchJSON)
+		}
+	}
+
+	patch, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	// A patch that is not an object would replace the whole resource
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &members); err != nil || members == nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Merge patch must be a JSON object")
+	}
+
+	if err := mergeInto(i, patch); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(i); err != nil {
+		return ValidationHTTPError(err)
+	}
+	return nil
+}
+
+// mergeInto replaces the value pointed by i with the patched value
+func mergeInto(i interface{}, patch []byte) error {
+	target := reflect.ValueOf(i)
+	if target.Kind() != reflect.Pointer || target.IsNil() {
+		return errors.New("merge patch target must be a non nil pointer")
+	}
+
+	original, err := json.Marshal(i)
+	if err != nil {
+		return err
+	}
+	patched, err := ApplyMergePatch(original, patch)
+	if err != nil {
+		return err
+	}
+
+	// Start from the zero value, so members removed by the patch are reset
+	target.Elem().Set(reflect.Zero(target.Elem().Type()))
+	return json.Unmarshal(patched, i)
+}
@@ -0,0 +1,156 @@
+package requests
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"smkdevid/echocommercehub/utils/exception"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+)
+
+// ValidationResponse is the 422 body listing every failing field
+type ValidationResponse struct {
+	Message string                 `json:"message"`
+	Errors  []exception.FieldError `json:"errors"`
+}
+
+// Validator validates request payloads using `validate` struct tags plus custom rules.
+// It implements echo.Validator, so handlers can call c.Validate once it is set on echo.Echo.
+type Validator struct {
+	validate *validator.Validate
+	messages map[string]string
+}
+
+// NewValidator creates a Validator reporting fields by their JSON name
+func NewValidator() *Validator {
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		switch name {
+		case "-":
+			return ""
+		case "":
+			return field.Name
+		}
+		return name
+	})
+
+	// Decimal amounts are checked by the numeric rules such as gte, like any other number
+	validate.RegisterCustomTypeFunc(func(field reflect.Value) interface{} {
+		if d, ok := field.Interface().(decimal.Decimal); ok {
+			f, _ := d.Float64()
+			return f
+		}
+		return nil
+	}, decimal.Decimal{})
+
+	return &Validator{
+		validate: validate,
+		messages: map[string]string{
+			"required": "is required",
+			"gt":       "must be greater than %s",
+			"gte":      "must be greater than or equal to %s",
+			"lt":       "must be less than %s",
+			"lte":      "must be less than or equal to %s",
+			"min":      "must be at least %s",
+			"max":      "must be at most %s",
+			"oneof":    "must be one of [%s]",
+		},
+	}
+}
+
+// RegisterRule adds a custom tag rule and the message reported when it fails
+func (v *Validator) RegisterRule(tag string, fn validator.Func, message string) {
+	if err := v.validate.RegisterValidation(tag, fn); err != nil {
+		panic(err)
+	}
+	v.messages[tag] = message
+}
+
+// RegisterStructRule adds a rule validating the whole struct, for checks spanning several fields.
+// Tags reported by the rule get their message from RegisterMessage.
+func (v *Validator) RegisterStructRule(fn validator.StructLevelFunc, types ...interface{}) {
+	v.validate.RegisterStructValidation(fn, types...)
+}
+
+// RegisterMessage sets the message of a tag, %s is replaced by the tag parameter
+func (v *Validator) RegisterMessage(tag string, message string) {
+	v.messages[tag] = message
+}
+
+// Validate checks every rule of the payload and reports all failing fields at once
+func (v *Validator) Validate(i interface{}) error {
+	err := v.validate.Struct(i)
+	if err == nil {
+		return nil
+	}
+
+	var fieldErrors validator.ValidationErrors
+	if !errors.As(err, &fieldErrors) {
+		return err
+	}
+
+	fields := make([]exception.FieldError, 0, len(fieldErrors))
+	for _, fe := range fieldErrors {
+		fields = append(fields, exception.FieldError{
+			Field:   fieldPath(fe),
+			Rule:    fe.Tag(),
+			Message: v.message(fe),
+		})
+	}
+
+	return &exception.ValidationError{
+		Message: "Validation Failed",
+		Fields:  fields,
+	}
+}
+
+func (v *Validator) message(fe validator.FieldError) string {
+	message, ok := v.messages[fe.Tag()]
+	if !ok {
+		return fmt.Sprintf("failed on the %s rule", fe.Tag())
+	}
+	if strings.Contains(message, "%s") {
+		return fmt.Sprintf(message, fe.Param())
+	}
+	return message
+}
+
+// fieldPath drops the struct name from the namespace, e.g. Promotion.discount_tiers[0].value
+func fieldPath(fe validator.FieldError) string {
+	namespace := fe.Namespace()
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return namespace
+}
+
+// BindAndValidate binds the request into i and validates it.
+// The returned error is ready to be returned by the handler.
+func BindAndValidate(c echo.Context, i interface{}) error {
+	if err := c.Bind(i); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+	if err := c.Validate(i); err != nil {
+		return ValidationHTTPError(err)
+	}
+	return nil
+}
+
+// ValidationHTTPError turns a validation error into a 422 response listing every failing field
+func ValidationHTTPError(err error) error {
+	var e *exception.ValidationError
+	if errors.As(err, &e) {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, ValidationResponse{
+			Message: e.Message,
+			Errors:  e.Fields,
+		})
+	}
+	return echo.NewHTTPError(http.StatusInternalServerError, "Failed to validate request")
+}
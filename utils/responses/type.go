@@ -1 +1,234 @@
 package responses
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"smkdevid/echocommercehub/utils/exception"
+	"smkdevid/echocommercehub/utils/requests"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Machine readable codes of Error
+const (
+	CodeBadRequest           = "bad_request"
+	CodeUnauthorized         = "unauthorized"
+	CodeForbidden            = "forbidden"
+	CodeNotFound             = "not_found"
+	CodeMethodNotAllowed     = "method_not_allowed"
+	CodeConflict             = "conflict"
+	CodePreconditionFailed   = "precondition_failed"
+	CodePayloadTooLarge      = "payload_too_large"
+	CodeUnsupportedMediaType = "unsupported_media_type"
+	CodeValidationFailed     = "validation_failed"
+	CodeRateLimited          = "rate_limited"
+	CodeInternalError        = "internal_error"
+	CodeUpstreamError        = "upstream_error"
+)
+
+// Response is the envelope of every JSON response. Data is set on success and Errors on failure.
+type Response struct {
+	Data      interface{} `json:"data"`
+	Meta      *Meta       `json:"meta,omitempty"`
+	Errors    []Error     `json:"errors,omitempty"`
+	RequestID string      `json:"request_id,omitempty"`
+}
+
+// Meta holds the information about Data, such as its pagination
+type Meta struct {
+	Pagination *Pagination `json:"pagination,omitempty"`
+}
+
+// Pagination describes the page returned in Data
+type Pagination struct {
+	Total      int64  `json:"total"`
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// Error is a single failure of the request. Field is set when the failure comes from a payload field.
+type Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Field   string `json:"field,omitempty"`
+}
+
+// JSON writes data in the envelope
+func JSON(c echo.Context, status int, data interface{}) error {
+	return c.JSON(status, Response{
+		Data:      data,
+		RequestID: requestID(c),
+	})
+}
+
+// Paginated writes a page of data in the envelope together with its pagination
+func Paginated(c echo.Context, data interface{}, pagination Pagination) error {
+	return c.JSON(http.StatusOK, Response{
+		Data:      data,
+		Meta:      &Meta{Pagination: &pagination},
+		RequestID: requestID(c),
+	})
+}
+
+// Fail writes the errors in the envelope
+func Fail(c echo.Context, status int, errs ...Error) error {
+	return c.JSON(status, Response{
+		Errors:    errs,
+		RequestID: requestID(c),
+	})
+}
+
+// HTTPErrorHandler writes every error returned by handlers and middlewares in the envelope.
+// It replaces the default echo.HTTPErrorHandler.
+func HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	var rateLimited *exception.RateLimitedError
+	if errors.As(err, &rateLimited) && rateLimited.RetryAfter > 0 {
+		c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(rateLimited.RetryAfter.Seconds()))))
+	}
+
+	status, errs := errorsOf(err)
+	if status >= http.StatusInternalServerError {
+		c.Logger().Error(err)
+	}
+
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(status)
+	} else {
+		err = Fail(c, status, errs...)
+	}
+	if err != nil {
+		c.Logger().Error(err)
+	}
+}
+
+func errorsOf(err error) (int, []Error) {
+	var he *echo.HTTPError
+	if !errors.As(err, &he) {
+		return domainErrorsOf(err)
+	}
+	if he.Internal != nil {
+		var internal *echo.HTTPError
+		if errors.As(he.Internal, &internal) {
+			he = internal
+		}
+	}
+
+	code := CodeForStatus(he.Code)
+	switch message := he.Message.(type) {
+	case requests.ValidationResponse:
+		return he.Code, FieldErrors(code, message.Errors)
+	case string:
+		return he.Code, []Error{{Code: code, Message: message}}
+	case error:
+		return he.Code, []Error{{Code: code, Message: message.Error()}}
+	}
+	return he.Code, []Error{{Code: code, Message: http.StatusText(he.Code)}}
+}
+
+// domainErrorsOf maps the errors of the exception catalogue, anything else is an internal error
+// whose message is not shown to the client
+func domainErrorsOf(err error) (int, []Error) {
+	var domainErr exception.DomainError
+	if !errors.As(err, &domainErr) {
+		return http.StatusInternalServerError, []Error{{
+			Code:    CodeInternalError,
+			Message: http.StatusText(http.StatusInternalServerError),
+		}}
+	}
+
+	var validationErr *exception.ValidationError
+	if errors.As(err, &validationErr) && len(validationErr.Fields) > 0 {
+		return StatusOf(err), FieldErrors(validationErr.Code(), validationErr.Fields)
+	}
+	return StatusOf(err), []Error{{Code: domainErr.Code(), Message: domainErr.Error()}}
+}
+
+// StatusOf is the HTTP status of the kind of a domain error
+func StatusOf(err error) int {
+	switch {
+	case errors.Is(err, exception.ErrInvalidRequest):
+		return http.StatusBadRequest
+	case errors.Is(err, exception.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, exception.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, exception.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, exception.ErrPreconditionFailed):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, exception.ErrPayloadTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, exception.ErrUnsupportedMedia):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, exception.ErrValidation):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, exception.ErrRateLimited):
+		return http.StatusTooManyRequests
+	case errors.Is(err, exception.ErrUpstream):
+		return http.StatusBadGateway
+	}
+	return http.StatusInternalServerError
+}
+
+// FieldErrors reports every invalid field as an Error of the given code
+func FieldErrors(code string, fields []exception.FieldError) []Error {
+	errs := make([]Error, 0, len(fields))
+	for _, field := range fields {
+		errs = append(errs, Error{
+			Code:    code,
+			Message: field.Message,
+			Field:   field.Field,
+		})
+	}
+	return errs
+}
+
+// CodeForStatus is the error code used for an HTTP status
+func CodeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusPreconditionFailed:
+		return CodePreconditionFailed
+	case http.StatusRequestEntityTooLarge:
+		return CodePayloadTooLarge
+	case http.StatusUnsupportedMediaType:
+		return CodeUnsupportedMediaType
+	case http.StatusUnprocessableEntity:
+		return CodeValidationFailed
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return CodeUpstreamError
+	}
+	if status >= http.StatusInternalServerError {
+		return CodeInternalError
+	}
+	return CodeBadRequest
+}
+
+// requestID is the ID given to the request by the RequestID middleware
+func requestID(c echo.Context) string {
+	if id := c.Response().Header().Get(echo.HeaderXRequestID); id != "" {
+		return id
+	}
+	return c.Request().Header.Get(echo.HeaderXRequestID)
+}